@@ -22,13 +22,17 @@ var cmdResetState = &cobra.Command{
 	Short: "Reset StateStages (5,6,7,8,9,10) and buckets",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 		if err := db.View(ctx, func(tx kv.Tx) error { return printStages(tx, allSnapshots(db)) }); err != nil {
 			return err
 		}
 
-		err := reset2.ResetState(db, ctx, chain)
+		err = reset2.ResetState(db, ctx, chain)
 		if err != nil {
 			log.Error(err.Error())
 			return err
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"fmt"
 	"path/filepath"
 
 	"github.com/c2h5oh/datasize"
@@ -9,6 +10,7 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/erigon/internal/debug"
 	"github.com/ledgerwatch/erigon/migrations"
+	"github.com/ledgerwatch/erigon/node/nodecfg/datadir"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/cobra"
 	"github.com/torquem-ch/mdbx-go/mdbx"
@@ -27,9 +29,34 @@ var rootCmd = &cobra.Command{
 	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
 		defer utils.StopDebug()
+		if datadirLock != nil {
+			if err := datadirLock.Unlock(); err != nil {
+				log.Warn("Can't release datadir lock", "err", err)
+			}
+		}
 	},
 }
 
+// datadirLock is the lease taken on datadirCli by the first opened DB, it's held until the command exits
+var datadirLock *datadir.Lock
+
+// lockDatadir makes sure the command doesn't modify a datadir used by a running node (or read it while
+// another tool modifies it)
+func lockDatadir(mode datadir.AccessMode) error {
+	if datadirLock != nil {
+		if datadirLock.Mode() < mode {
+			return fmt.Errorf("datadir already locked in %s mode, %s access requested", datadirLock.Mode(), mode)
+		}
+		return nil
+	}
+	l, err := datadir.TryLock(datadir.New(datadirCli), mode)
+	if err != nil {
+		return err
+	}
+	datadirLock = l
+	return nil
+}
+
 func RootCommand() *cobra.Command {
 	utils.CobraFlags(rootCmd, append(debug.Flags, utils.MetricFlags...))
 	return rootCmd
@@ -46,7 +73,18 @@ func dbCfg(label kv.Label, path string) kv2.MdbxOpts {
 	return opts
 }
 
-func openDB(opts kv2.MdbxOpts, applyMigrations bool) kv.RwDB {
+// openReadonlyDB opens the DB in read-only mode, which is safe while Erigon is running on the same datadir
+func openReadonlyDB(opts kv2.MdbxOpts) (kv.RwDB, error) {
+	if err := lockDatadir(datadir.ReadOnly); err != nil {
+		return nil, err
+	}
+	return opts.Readonly().Flags(func(f uint) uint { return f | mdbx.Accede }).MustOpen(), nil
+}
+
+func openDB(opts kv2.MdbxOpts, applyMigrations bool) (kv.RwDB, error) {
+	if err := lockDatadir(datadir.ReadWrite); err != nil {
+		return nil, err
+	}
 	// integration tool don't intent to create db, then easiest way to open db - it's pass mdbx.Accede flag, which allow
 	// to read all options from DB, instead of overriding them
 	opts = opts.Flags(func(f uint) uint { return f | mdbx.Accede })
@@ -68,5 +106,5 @@ func openDB(opts kv2.MdbxOpts, applyMigrations bool) kv.RwDB {
 			db = opts.MustOpen()
 		}
 	}
-	return db
+	return db, nil
 }
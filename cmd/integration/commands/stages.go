@@ -53,7 +53,11 @@ var cmdStageHeaders = &cobra.Command{
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common2.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 
 		if err := stageHeaders(db, ctx); err != nil {
@@ -69,7 +73,11 @@ var cmdStageBodies = &cobra.Command{
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common2.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 
 		if err := stageBodies(db, ctx); err != nil {
@@ -85,7 +93,11 @@ var cmdStageSenders = &cobra.Command{
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common2.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 
 		if err := stageSenders(db, ctx); err != nil {
@@ -101,7 +113,11 @@ var cmdStageExec = &cobra.Command{
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common2.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 
 		if err := stageExec(db, ctx); err != nil {
@@ -117,7 +133,11 @@ var cmdStageTrie = &cobra.Command{
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common2.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 
 		if err := stageTrie(db, ctx); err != nil {
@@ -133,7 +153,11 @@ var cmdStageHashState = &cobra.Command{
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common2.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 
 		if err := stageHashState(db, ctx); err != nil {
@@ -149,7 +173,11 @@ var cmdStageHistory = &cobra.Command{
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common2.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 
 		if err := stageHistory(db, ctx); err != nil {
@@ -165,7 +193,11 @@ var cmdLogIndex = &cobra.Command{
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common2.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 
 		if err := stageLogIndex(db, ctx); err != nil {
@@ -181,7 +213,11 @@ var cmdCallTraces = &cobra.Command{
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common2.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 
 		if err := stageCallTraces(db, ctx); err != nil {
@@ -197,7 +233,11 @@ var cmdStageTxLookup = &cobra.Command{
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common2.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 
 		if err := stageTxLookup(db, ctx); err != nil {
@@ -212,7 +252,11 @@ var cmdPrintStages = &cobra.Command{
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common2.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata).Readonly(), false)
+		db, err := openReadonlyDB(dbCfg(kv.ChainDB, chaindata))
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 
 		if err := printAllStages(db, ctx); err != nil {
@@ -228,7 +272,11 @@ var cmdPrintMigrations = &cobra.Command{
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common2.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata), false)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), false)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 		if err := printAppliedMigrations(db, ctx); err != nil {
 			log.Error("Error", "err", err)
@@ -243,7 +291,11 @@ var cmdRemoveMigration = &cobra.Command{
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, _ := common2.RootContext()
-		db := openDB(dbCfg(kv.ChainDB, chaindata), false)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), false)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 		if err := removeMigration(db, ctx); err != nil {
 			log.Error("Error", "err", err)
@@ -257,7 +309,11 @@ var cmdRunMigrations = &cobra.Command{
 	Use:   "run_migrations",
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 		// Nothing to do, migrations will be applied automatically
 		return nil
@@ -268,7 +324,11 @@ var cmdSetPrune = &cobra.Command{
 	Use:   "force_set_prune",
 	Short: "Override existing --prune flag value (if you know what you are doing)",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 		return overrideStorageMode(db)
 	},
@@ -278,7 +338,11 @@ var cmdSetSnap = &cobra.Command{
 	Use:   "force_set_snapshot",
 	Short: "Override existing --snapshots flag value (if you know what you are doing)",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 		snapshots := allSnapshots(db)
 		if err := db.Update(context.Background(), func(tx kv.RwTx) error {
@@ -294,7 +358,11 @@ var cmdForceSetHistoryV2 = &cobra.Command{
 	Use:   "force_set_history_v2",
 	Short: "Override existing --history.v2 flag value (if you know what you are doing)",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		db := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		db, err := openDB(dbCfg(kv.ChainDB, chaindata), true)
+		if err != nil {
+			log.Error("Opening DB", "error", err)
+			return err
+		}
 		defer db.Close()
 		return db.Update(context.Background(), func(tx kv.RwTx) error {
 			return rawdb.HistoryV2.ForceWrite(tx, _forceSetHistoryV2)
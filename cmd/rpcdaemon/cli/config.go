@@ -3,9 +3,11 @@ package cli
 import (
 	"context"
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math/big"
 	"net"
 	"net/http"
 	"os"
@@ -32,6 +34,7 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/health"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcservices"
+	"github.com/ledgerwatch/erigon/cmd/sentry/sentryadmin"
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
@@ -51,7 +54,7 @@ import (
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/semaphore"
 	"google.golang.org/grpc"
-	grpcHealth "google.golang.org/grpc/health"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
@@ -63,8 +66,8 @@ var rootCmd = &cobra.Command{
 func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	utils.CobraFlags(rootCmd, append(debug.Flags, utils.MetricFlags...))
 
-	cfg := &httpcfg.HttpCfg{Enabled: true, StateCache: kvcache.DefaultCoherentConfig}
-	rootCmd.PersistentFlags().StringVar(&cfg.PrivateApiAddr, "private.api.addr", "127.0.0.1:9090", "private api network address, for example: 127.0.0.1:9090")
+	cfg := &httpcfg.HttpCfg{Enabled: true, StateCache: kvcache.DefaultCoherentConfig, Gpo: ethconfig.Defaults.GPO}
+	rootCmd.PersistentFlags().StringVar(&cfg.PrivateApiAddr, "private.api.addr", "127.0.0.1:9090", "private api network address, for example: 127.0.0.1:9090. Comma separated addresses of several erigon instances are health-checked and used in order, failing over to the next healthy one")
 	rootCmd.PersistentFlags().StringVar(&cfg.DataDir, "datadir", "", "path to Erigon working directory")
 	rootCmd.PersistentFlags().StringVar(&cfg.HttpListenAddress, "http.addr", nodecfg.DefaultHTTPHost, "HTTP-RPC server listening interface")
 	rootCmd.PersistentFlags().StringVar(&cfg.TLSCertfile, "tls.cert", "", "certificate for client side TLS handshake")
@@ -73,19 +76,69 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().IntVar(&cfg.HttpPort, "http.port", nodecfg.DefaultHTTPPort, "HTTP-RPC server listening port")
 	rootCmd.PersistentFlags().StringSliceVar(&cfg.HttpCORSDomain, "http.corsdomain", []string{}, "Comma separated list of domains from which to accept cross origin requests (browser enforced)")
 	rootCmd.PersistentFlags().StringSliceVar(&cfg.HttpVirtualHost, "http.vhosts", nodecfg.DefaultConfig.HTTPVirtualHosts, "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.")
+	rootCmd.PersistentFlags().StringVar(&cfg.HttpVirtualHostRulesPath, utils.HTTPVirtualHostRulesFlag.Name, "", utils.HTTPVirtualHostRulesFlag.Usage)
 	rootCmd.PersistentFlags().BoolVar(&cfg.HttpCompression, "http.compression", true, "Disable http compression")
+	rootCmd.PersistentFlags().StringVar(&cfg.HttpJWTSecretPath, utils.HTTPJWTSecretFlag.Name, "", utils.HTTPJWTSecretFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.HttpTLSCertFile, utils.HTTPTLSCertFlag.Name, "", utils.HTTPTLSCertFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.HttpTLSKeyFile, utils.HTTPTLSKeyFlag.Name, "", utils.HTTPTLSKeyFlag.Usage)
 	rootCmd.PersistentFlags().StringSliceVar(&cfg.API, "http.api", []string{"eth", "erigon"}, "API's offered over the HTTP-RPC interface: eth,erigon,web3,net,debug,trace,txpool,db. Supported methods: https://github.com/ledgerwatch/erigon/tree/devel/cmd/rpcdaemon")
 	rootCmd.PersistentFlags().Uint64Var(&cfg.Gascap, "rpc.gascap", 50000000, "Sets a cap on gas that can be used in eth_call/estimateGas")
+	rootCmd.PersistentFlags().IntVar(&cfg.Gpo.Blocks, utils.GpoBlocksFlag.Name, utils.GpoBlocksFlag.Value, utils.GpoBlocksFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.Gpo.Percentile, utils.GpoPercentileFlag.Name, utils.GpoPercentileFlag.Value, utils.GpoPercentileFlag.Usage)
+	var gpoMaxPrice, gpoIgnorePrice int64
+	rootCmd.PersistentFlags().Int64Var(&gpoMaxPrice, utils.GpoMaxGasPriceFlag.Name, utils.GpoMaxGasPriceFlag.Value, utils.GpoMaxGasPriceFlag.Usage)
+	rootCmd.PersistentFlags().Int64Var(&gpoIgnorePrice, utils.GpoIgnoreGasPriceFlag.Name, utils.GpoIgnoreGasPriceFlag.Value, utils.GpoIgnoreGasPriceFlag.Usage)
 	rootCmd.PersistentFlags().Uint64Var(&cfg.MaxTraces, "trace.maxtraces", 200, "Sets a limit on traces that can be returned in trace_filter")
+	rootCmd.PersistentFlags().IntVar(&cfg.MaxGetProofRewindBlocks, utils.RpcMaxGetProofRewindBlockCountFlag.Name, utils.RpcMaxGetProofRewindBlockCountFlag.Value, utils.RpcMaxGetProofRewindBlockCountFlag.Usage)
+	rootCmd.PersistentFlags().Uint64Var(&cfg.GetLogsMaxBlockRange, utils.RpcGetLogsMaxBlockRangeFlag.Name, utils.RpcGetLogsMaxBlockRangeFlag.Value, utils.RpcGetLogsMaxBlockRangeFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.GetLogsMaxResults, utils.RpcGetLogsMaxResultsFlag.Name, utils.RpcGetLogsMaxResultsFlag.Value, utils.RpcGetLogsMaxResultsFlag.Usage)
+	rootCmd.PersistentFlags().DurationVar(&cfg.GetLogsTimeout, utils.RpcGetLogsTimeoutFlag.Name, utils.RpcGetLogsTimeoutFlag.Value, utils.RpcGetLogsTimeoutFlag.Usage)
 	rootCmd.PersistentFlags().BoolVar(&cfg.WebsocketEnabled, "ws", false, "Enable Websockets")
 	rootCmd.PersistentFlags().BoolVar(&cfg.WebsocketCompression, "ws.compression", false, "Enable Websocket compression (RFC 7692)")
+	rootCmd.PersistentFlags().IntVar(&cfg.WebsocketCompressionLevel, utils.WsCompressionLevelFlag.Name, utils.WsCompressionLevelFlag.Value, utils.WsCompressionLevelFlag.Usage)
+	rootCmd.PersistentFlags().Int64Var(&cfg.WebsocketMessageSizeLimit, utils.WsMessageSizeLimitFlag.Name, utils.WsMessageSizeLimitFlag.Value, utils.WsMessageSizeLimitFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.WebsocketConnLimits.MaxConns, utils.WsConnsLimitFlag.Name, 0, utils.WsConnsLimitFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.WebsocketConnLimits.MaxConnsPerIP, utils.WsConnsPerIPLimitFlag.Name, 0, utils.WsConnsPerIPLimitFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.WebsocketConnLimits.MaxSubscriptions, utils.WsSubscriptionsLimitFlag.Name, 0, utils.WsSubscriptionsLimitFlag.Usage)
+	rootCmd.PersistentFlags().DurationVar(&cfg.WebsocketConnLimits.IdleTimeout, utils.WsIdleTimeoutFlag.Name, 0, utils.WsIdleTimeoutFlag.Usage)
 	rootCmd.PersistentFlags().StringVar(&cfg.RpcAllowListFilePath, "rpc.accessList", "", "Specify granular (method-by-method) API allowlist")
+	rootCmd.PersistentFlags().StringVar(&cfg.RpcACLFilePath, utils.RpcACLFlag.Name, "", utils.RpcACLFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.RpcMethodLimitsFilePath, utils.RpcMethodLimitsFlag.Name, "", utils.RpcMethodLimitsFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.RpcMethodTimeouts, utils.RpcMethodTimeoutsFlag.Name, "", utils.RpcMethodTimeoutsFlag.Usage)
+	rootCmd.PersistentFlags().DurationVar(&cfg.RpcEvmTimeout, utils.RpcEvmTimeoutFlag.Name, utils.RpcEvmTimeoutFlag.Value, utils.RpcEvmTimeoutFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.RpcSigner, utils.RpcSignerFlag.Name, "", utils.RpcSignerFlag.Usage)
 	rootCmd.PersistentFlags().UintVar(&cfg.RpcBatchConcurrency, utils.RpcBatchConcurrencyFlag.Name, 2, utils.RpcBatchConcurrencyFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.RpcBatchLimit, utils.RpcBatchLimitFlag.Name, utils.RpcBatchLimitFlag.Value, utils.RpcBatchLimitFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.RpcBatchResponseMaxSize, utils.RpcBatchResponseMaxSizeFlag.Name, utils.RpcBatchResponseMaxSizeFlag.Value, utils.RpcBatchResponseMaxSizeFlag.Usage)
+	rootCmd.PersistentFlags().Int64Var(&cfg.ParseLimits.MaxBodySize, utils.RpcRequestMaxSizeFlag.Name, utils.RpcRequestMaxSizeFlag.Value, utils.RpcRequestMaxSizeFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.ParseLimits.MaxDepth, utils.RpcRequestMaxDepthFlag.Name, 0, utils.RpcRequestMaxDepthFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.ParseLimits.MaxStringLength, utils.RpcRequestMaxStringFlag.Name, 0, utils.RpcRequestMaxStringFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.ParseLimits.MaxArrayLength, utils.RpcRequestMaxArrayFlag.Name, 0, utils.RpcRequestMaxArrayFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.RpcResponseCacheSize, utils.RpcResponseCacheSizeFlag.Name, utils.RpcResponseCacheSizeFlag.Value, utils.RpcResponseCacheSizeFlag.Usage)
+	rootCmd.PersistentFlags().DurationVar(&cfg.RpcSlowThreshold, utils.RpcSlowThresholdFlag.Name, 0, utils.RpcSlowThresholdFlag.Usage)
+	rootCmd.PersistentFlags().Float64Var(&cfg.RpcSampleRate, utils.RpcSampleRateFlag.Name, 0, utils.RpcSampleRateFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.RpcSampleFile, utils.RpcSampleFileFlag.Name, "", utils.RpcSampleFileFlag.Usage)
 	rootCmd.PersistentFlags().BoolVar(&cfg.RpcStreamingDisable, utils.RpcStreamingDisableFlag.Name, false, utils.RpcStreamingDisableFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.Health.Path, utils.HealthPathFlag.Name, utils.HealthPathFlag.Value, utils.HealthPathFlag.Usage)
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.Health.ReadinessChecks, utils.HealthReadinessFlag.Name, strings.Split(utils.HealthReadinessFlag.Value, ","), utils.HealthReadinessFlag.Usage)
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.Health.LivenessChecks, utils.HealthLivenessFlag.Name, strings.Split(utils.HealthLivenessFlag.Value, ","), utils.HealthLivenessFlag.Usage)
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.Health.DefaultChecks, utils.HealthDefaultFlag.Name, nil, utils.HealthDefaultFlag.Usage)
+	rootCmd.PersistentFlags().DurationVar(&cfg.Health.DBMaxLatency, utils.HealthDBMaxLatencyFlag.Name, utils.HealthDBMaxLatencyFlag.Value, utils.HealthDBMaxLatencyFlag.Usage)
+	rootCmd.PersistentFlags().DurationVar(&cfg.Health.MaxHeadStall, utils.HealthHeadStallFlag.Name, utils.HealthHeadStallFlag.Value, utils.HealthHeadStallFlag.Usage)
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.Health.Upstreams, utils.HealthUpstreamsFlag.Name, nil, utils.HealthUpstreamsFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.Health.UpstreamQuorum, utils.HealthUpstreamsQuorumFlag.Name, utils.HealthUpstreamsQuorumFlag.Value, utils.HealthUpstreamsQuorumFlag.Usage)
+	rootCmd.PersistentFlags().DurationVar(&cfg.Health.CacheTTL, utils.HealthCacheTTLFlag.Name, utils.HealthCacheTTLFlag.Value, utils.HealthCacheTTLFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.Health.AuthSecret, utils.HealthAuthSecretFlag.Name, "", utils.HealthAuthSecretFlag.Usage)
+	var healthCallTo, healthCallData, healthCallResult, healthJWTSecretPath string
+	rootCmd.PersistentFlags().StringVar(&healthJWTSecretPath, utils.HealthAuthJWTSecretFlag.Name, "", utils.HealthAuthJWTSecretFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&healthCallTo, utils.HealthCallToFlag.Name, "", utils.HealthCallToFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&healthCallData, utils.HealthCallDataFlag.Name, "", utils.HealthCallDataFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&healthCallResult, utils.HealthCallResultFlag.Name, "", utils.HealthCallResultFlag.Usage)
 	rootCmd.PersistentFlags().IntVar(&cfg.DBReadConcurrency, utils.DBReadConcurrencyFlag.Name, utils.DBReadConcurrencyFlag.Value, utils.DBReadConcurrencyFlag.Usage)
 	rootCmd.PersistentFlags().BoolVar(&cfg.TraceCompatibility, "trace.compat", false, "Bug for bug compatibility with OE for trace_ routines")
 	rootCmd.PersistentFlags().StringVar(&cfg.TxPoolApiAddr, "txpool.api.addr", "", "txpool api network address, for example: 127.0.0.1:9090 (default: use value of --private.api.addr)")
 	rootCmd.PersistentFlags().BoolVar(&cfg.Sync.UseSnapshots, "snapshot", true, utils.SnapshotFlag.Usage)
+	rootCmd.PersistentFlags().BoolVar(&cfg.SnapshotOnly, "snapshot.only", false, "Serve the blocks and transactions of the snapshots of --datadir only, without an Erigon instance nor its database")
 	rootCmd.PersistentFlags().IntVar(&cfg.StateCache.KeysLimit, "state.cache", kvcache.DefaultCoherentConfig.KeysLimit, "Amount of keys to store in StateCache (enabled if no --datadir set). Set 0 to disable StateCache. 1_000_000 keys ~ equal to 2Gb RAM (maybe we will add RAM accounting in future versions).")
 	rootCmd.PersistentFlags().BoolVar(&cfg.GRPCServerEnabled, "grpc", false, "Enable GRPC server")
 	rootCmd.PersistentFlags().StringVar(&cfg.GRPCListenAddress, "grpc.addr", nodecfg.DefaultGRPCHost, "GRPC server listening interface")
@@ -113,10 +166,19 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 				cfg.DataDir = paths.DefaultDataDir()
 			}
 			cfg.Dirs = datadir.New(cfg.DataDir)
+			cfg.AggregatorDir = filepath.Join(cfg.DataDir, "erigon22")
 		}
 		if cfg.TxPoolApiAddr == "" {
 			cfg.TxPoolApiAddr = cfg.PrivateApiAddr
 		}
+		cfg.Gpo.MaxPrice, cfg.Gpo.IgnorePrice = big.NewInt(gpoMaxPrice), big.NewInt(gpoIgnorePrice)
+		var err error
+		if cfg.Health.Call, err = health.ParseCallCheck(healthCallTo, healthCallData, healthCallResult); err != nil {
+			return err
+		}
+		if cfg.Health.AuthJWTSecret, err = health.LoadJWTSecret(healthJWTSecretPath); err != nil {
+			return err
+		}
 		return nil
 	}
 	rootCmd.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
@@ -133,15 +195,32 @@ type StateChangesClient interface {
 	StateChanges(ctx context.Context, in *remote.StateChangeRequest, opts ...grpc.CallOption) (remote.KV_StateChangesClient, error)
 }
 
-func subscribeToStateChangesLoop(ctx context.Context, client StateChangesClient, cache kvcache.Cache) {
+// subscribeToStateChangesLoop feeds cache with the state changes of the backend. If switched isn't nil, it returns a
+// channel closed when the connection switches backend: the subscription is then renewed on the new backend, after the
+// cache is reset.
+func subscribeToStateChangesLoop(ctx context.Context, client StateChangesClient, cache kvcache.Cache, switched func() <-chan struct{}) {
 	go func() {
+		var backendSwitched <-chan struct{}
+		if switched != nil {
+			backendSwitched = switched()
+		}
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			default:
 			}
-			if err := subscribeToStateChanges(ctx, client, cache); err != nil {
+			err := subscribeToStateChanges(ctx, client, cache, backendSwitched)
+			select {
+			case <-backendSwitched:
+				backendSwitched = switched()
+				if failoverCache, ok := cache.(*rpcservices.FailoverCache); ok {
+					failoverCache.Reset()
+				}
+				continue
+			default:
+			}
+			if err != nil {
 				if grpcutil.IsRetryLater(err) || grpcutil.IsEndOfStream(err) {
 					time.Sleep(3 * time.Second)
 					continue
@@ -152,9 +231,16 @@ func subscribeToStateChangesLoop(ctx context.Context, client StateChangesClient,
 	}()
 }
 
-func subscribeToStateChanges(ctx context.Context, client StateChangesClient, cache kvcache.Cache) error {
+func subscribeToStateChanges(ctx context.Context, client StateChangesClient, cache kvcache.Cache, switched <-chan struct{}) error {
 	streamCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	go func() {
+		select {
+		case <-switched:
+			cancel()
+		case <-streamCtx.Done():
+		}
+	}()
 	stream, err := client.StateChanges(streamCtx, &remote.StateChangeRequest{WithStorage: true, WithTransactions: false}, grpc.WaitForReady(true))
 	if err != nil {
 		return err
@@ -212,6 +298,7 @@ func EmbeddedServices(ctx context.Context,
 	erigonDB kv.RoDB, stateCacheCfg kvcache.CoherentConfig,
 	blockReader services.FullBlockReader, snapshots *snapshotsync.RoSnapshots,
 	ethBackendServer remote.ETHBACKENDServer, txPoolServer txpool.TxpoolServer, miningServer txpool.MiningServer,
+	sentryAdminServer sentryadmin.SentryAdminServer,
 ) (eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient, stateCache kvcache.Cache, ff *rpchelper.Filters, txNums *exec22.TxNums, err error) {
 	if stateCacheCfg.KeysLimit > 0 {
 		stateCache = kvcache.NewDummy()
@@ -225,11 +312,11 @@ func EmbeddedServices(ctx context.Context,
 	}
 	kvRPC := remotedbserver.NewKvServer(ctx, erigonDB, snapshots)
 	stateDiffClient := direct.NewStateDiffClientDirect(kvRPC)
-	subscribeToStateChangesLoop(ctx, stateDiffClient, stateCache)
+	subscribeToStateChangesLoop(ctx, stateDiffClient, stateCache, nil)
 
 	directClient := direct.NewEthBackendClientDirect(ethBackendServer)
 
-	eth = rpcservices.NewRemoteBackend(directClient, erigonDB, blockReader)
+	eth = rpcservices.NewRemoteBackend(directClient, erigonDB, blockReader).WithSentryAdmin(sentryadmin.NewDirectClient(sentryAdminServer))
 	txPool = direct.NewTxPoolClient(txPoolServer)
 	mining = direct.NewMiningClient(miningServer)
 	ff = rpchelper.New(ctx, eth, txPool, mining, func() {})
@@ -266,6 +353,10 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 	agg *libstate.Aggregator22,
 	txNums *exec22.TxNums,
 	err error) {
+	if cfg.SnapshotOnly {
+		db, stateCache, blockReader, ff, err = snapshotOnlyServices(ctx, cfg, logger)
+		return db, nil, nil, nil, nil, stateCache, blockReader, ff, nil, nil, err
+	}
 	if !cfg.WithDatadir && cfg.PrivateApiAddr == "" {
 		return nil, nil, nil, nil, nil, nil, nil, ff, nil, nil, fmt.Errorf("either remote db or local db must be specified")
 	}
@@ -347,7 +438,7 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 	if err != nil {
 		return nil, nil, nil, nil, nil, nil, nil, ff, nil, nil, fmt.Errorf("open tls cert: %w", err)
 	}
-	conn, err := grpcutil.Connect(creds, cfg.PrivateApiAddr)
+	conn, err := connectPrivateApi(ctx, creds, cfg.PrivateApiAddr)
 	if err != nil {
 		return nil, nil, nil, nil, nil, nil, nil, ff, nil, nil, fmt.Errorf("could not connect to execution service privateApi: %w", err)
 	}
@@ -358,7 +449,12 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 		return nil, nil, nil, nil, nil, nil, nil, ff, nil, nil, fmt.Errorf("could not connect to remoteKv: %w", err)
 	}
 
-	subscribeToStateChangesLoop(ctx, kvClient, stateCache)
+	var switched func() <-chan struct{}
+	if failover, ok := conn.(*rpcservices.FailoverConn); ok {
+		stateCache = rpcservices.NewFailoverCache(stateCache, cfg.StateCache)
+		switched = failover.Switched
+	}
+	subscribeToStateChangesLoop(ctx, kvClient, stateCache, switched)
 
 	onNewSnapshot := func() {}
 	if cfg.WithDatadir {
@@ -394,12 +490,12 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 	if !cfg.WithDatadir {
 		blockReader = snapshotsync.NewRemoteBlockReader(remote.NewETHBACKENDClient(conn))
 	}
-	remoteEth := rpcservices.NewRemoteBackend(remote.NewETHBACKENDClient(conn), db, blockReader)
+	remoteEth := rpcservices.NewRemoteBackend(remote.NewETHBACKENDClient(conn), db, blockReader).WithSentryAdmin(sentryadmin.NewSentryAdminClient(conn))
 	blockReader = remoteEth
 
 	txpoolConn := conn
 	if cfg.TxPoolApiAddr != cfg.PrivateApiAddr {
-		txpoolConn, err = grpcutil.Connect(creds, cfg.TxPoolApiAddr)
+		txpoolConn, err = connectPrivateApi(ctx, creds, cfg.TxPoolApiAddr)
 		if err != nil {
 			return nil, nil, nil, nil, nil, nil, nil, ff, nil, nil, fmt.Errorf("could not connect to txpool api: %w", err)
 		}
@@ -430,16 +526,40 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 
 	ff = rpchelper.New(ctx, eth, txPool, mining, onNewSnapshot)
 	if cfg.WithDatadir {
-		e22Dir := filepath.Join(cfg.DataDir, "erigon22")
-		dir.MustExist(e22Dir)
-		if agg, err = libstate.NewAggregator22(e22Dir, ethconfig.HistoryV2AggregationStep); err != nil {
+		dir.MustExist(cfg.AggregatorDir)
+		if agg, err = libstate.NewAggregator22(cfg.AggregatorDir, ethconfig.HistoryV2AggregationStep); err != nil {
 			return nil, nil, nil, nil, nil, nil, nil, ff, nil, nil, fmt.Errorf("create aggregator: %w", err)
 		}
 	}
 	return db, borDb, eth, txPool, mining, stateCache, blockReader, ff, agg, txNums, err
 }
 
-func StartRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rpc.API, authAPI []rpc.API) error {
+// connectPrivateApi connects to the private API at addrs, with failover between the instances if it's a comma
+// separated list of addresses
+func connectPrivateApi(ctx context.Context, creds credentials.TransportCredentials, addrs string) (grpc.ClientConnInterface, error) {
+	var conns []*grpc.ClientConn
+	list := strings.Split(addrs, ",")
+	for i, addr := range list {
+		list[i] = strings.TrimSpace(addr)
+		conn, err := grpcutil.Connect(creds, list[i])
+		if err != nil {
+			for _, c := range conns {
+				c.Close()
+			}
+			return nil, fmt.Errorf("%s: %w", list[i], err)
+		}
+		conns = append(conns, conn)
+	}
+	if len(conns) == 1 {
+		return conns[0], nil
+	}
+	return rpcservices.NewFailoverConn(ctx, list, conns), nil
+}
+
+// StartRpcServer serves rpcAPI on the regular endpoints and authAPI on the engine endpoint. The response cache of the
+// regular endpoints, if enabled, keeps the responses cachePolicy finds immutable. The gRPC server, if enabled, serves
+// grpcServices next to the health check.
+func StartRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rpc.API, authAPI []rpc.API, cachePolicy rpc.ResponseCachePolicy, grpcServices ...func(grpc.ServiceRegistrar)) error {
 	if len(authAPI) > 0 {
 		engineInfo, err := startAuthenticatedRpcServer(cfg, authAPI)
 		if err != nil {
@@ -449,24 +569,62 @@ func StartRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rpc.API,
 	}
 
 	if cfg.Enabled {
-		return startRegularRpcServer(ctx, cfg, rpcAPI)
+		return startRegularRpcServer(ctx, cfg, rpcAPI, cachePolicy, grpcServices)
 	}
 
 	return nil
 }
 
-func startRegularRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rpc.API) error {
+func startRegularRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rpc.API, cachePolicy rpc.ResponseCachePolicy, grpcServices []func(grpc.ServiceRegistrar)) error {
 	// register apis and create handler stack
 	httpEndpoint := fmt.Sprintf("%s:%d", cfg.HttpListenAddress, cfg.HttpPort)
 
 	log.Trace("TraceRequests = %t\n", cfg.TraceRequests)
 	srv := rpc.NewServer(cfg.RpcBatchConcurrency, cfg.TraceRequests, cfg.RpcStreamingDisable)
+	srv.SetBatchLimits(cfg.RpcBatchLimit, cfg.RpcBatchResponseMaxSize)
+	if err := srv.SetParseLimits(cfg.ParseLimits); err != nil {
+		return err
+	}
+	srv.SetResponseCache(cfg.RpcResponseCacheSize, cachePolicy)
+	requestLog, err := requestLogConfig(cfg)
+	if err != nil {
+		return err
+	}
+	if err := srv.SetRequestLog(requestLog); err != nil {
+		return err
+	}
 
 	allowListForRPC, err := parseAllowListForRPC(cfg.RpcAllowListFilePath)
 	if err != nil {
 		return err
 	}
+	if cfg.SnapshotOnly {
+		allowListForRPC = snapshotOnlyAllowList(allowListForRPC)
+	}
 	srv.SetAllowList(allowListForRPC)
+	if err := watchACLForRPC(ctx, cfg.RpcACLFilePath, srv); err != nil {
+		return err
+	}
+	methodLimits, err := parseMethodLimitsForRPC(cfg.RpcMethodLimitsFilePath)
+	if err != nil {
+		return err
+	}
+	if err := srv.SetMethodLimits(methodLimits); err != nil {
+		return err
+	}
+	methodTimeouts, err := rpc.ParseMethodTimeouts(cfg.RpcMethodTimeouts)
+	if err != nil {
+		return err
+	}
+	if err := srv.SetMethodTimeouts(methodTimeouts); err != nil {
+		return err
+	}
+	if err := srv.SetWebsocketLimits(cfg.WebsocketCompressionLevel, cfg.WebsocketMessageSizeLimit); err != nil {
+		return err
+	}
+	if err := srv.SetWebsocketConnLimits(cfg.WebsocketConnLimits); err != nil {
+		return err
+	}
 
 	var defaultAPIList []rpc.API
 
@@ -487,26 +645,59 @@ func startRegularRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rp
 		return fmt.Errorf("could not start register RPC apis: %w", err)
 	}
 
+	// the JWTs are optional on the regular endpoints, with their own secret
+	var jwtSecret []byte
+	if cfg.HttpJWTSecretPath != "" {
+		if jwtSecret, err = obtainJWTSecret(cfg.HttpJWTSecretPath); err != nil {
+			return err
+		}
+	}
+
+	var tlsConfig *tls.Config
+	if cfg.HttpTLSCertFile != "" || cfg.HttpTLSKeyFile != "" {
+		certReloader, err := node.NewCertReloader(cfg.HttpTLSCertFile, cfg.HttpTLSKeyFile)
+		if err != nil {
+			return err
+		}
+		tlsConfig = certReloader.TLSConfig()
+	}
+
 	httpHandler := node.NewHTTPHandlerStack(srv, cfg.HttpCORSDomain, cfg.HttpVirtualHost, cfg.HttpCompression)
 	var wsHandler http.Handler
 	if cfg.WebsocketEnabled {
-		wsHandler = srv.WebsocketHandler([]string{"*"}, nil, cfg.WebsocketCompression)
+		wsHandler = srv.WebsocketHandler([]string{"*"}, jwtSecret, cfg.WebsocketCompression)
 	}
 
-	apiHandler, err := createHandler(cfg, defaultAPIList, httpHandler, wsHandler, nil)
+	if cfg.WebsocketEnabled {
+		cfg.Health.WSEndpoint = health.WebsocketEndpoint(cfg.HttpListenAddress, cfg.HttpPort, tlsConfig != nil)
+		cfg.Health.WSJWTSecret = jwtSecret
+	}
+	healthChecker := health.NewChecker(defaultAPIList, cfg.Health)
+	apiHandler, err := createHandler(cfg, healthChecker, httpHandler, wsHandler, jwtSecret)
 	if err != nil {
 		return err
 	}
+	vhostRules, err := parseVirtualHostRules(cfg.HttpVirtualHostRulesPath)
+	if err != nil {
+		return err
+	}
+	apiHandler, err = node.NewVirtualHostRouter(vhostRules, cfg.HttpVirtualHost, func(rule *node.VirtualHostRule) (http.Handler, error) {
+		return createHandler(cfg, healthChecker, node.NewVirtualHostHandlerStack(srv, rule, cfg.HttpCompression), wsHandler, jwtSecret)
+	}, apiHandler)
+	if err != nil {
+		return err
+	}
+	health.RegisterMetrics(defaultAPIList)
 
-	listener, _, err := node.StartHTTPEndpoint(httpEndpoint, cfg.HTTPTimeouts, apiHandler)
+	listener, _, err := node.StartHTTPSEndpoint(httpEndpoint, cfg.HTTPTimeouts, apiHandler, tlsConfig)
 	if err != nil {
 		return fmt.Errorf("could not start RPC api: %w", err)
 	}
 	info := []interface{}{"url", httpEndpoint, "ws", cfg.WebsocketEnabled,
-		"ws.compression", cfg.WebsocketCompression, "grpc", cfg.GRPCServerEnabled}
+		"ws.compression", cfg.WebsocketCompression, "grpc", cfg.GRPCServerEnabled, "jwt", jwtSecret != nil, "tls", tlsConfig != nil}
 
 	var (
-		healthServer *grpcHealth.Server
+		healthServer *health.GRPCServer
 		grpcServer   *grpc.Server
 		grpcListener net.Listener
 		grpcEndpoint string
@@ -516,11 +707,18 @@ func startRegularRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rp
 		if grpcListener, err = net.Listen("tcp", grpcEndpoint); err != nil {
 			return fmt.Errorf("could not start GRPC listener: %w", err)
 		}
-		grpcServer = grpc.NewServer()
+		var grpcOptions []grpc.ServerOption
+		if jwtSecret != nil {
+			grpcOptions = grpcJWTOptions(jwtSecret)
+		}
+		grpcServer = grpc.NewServer(grpcOptions...)
 		if cfg.GRPCHealthCheckEnabled {
-			healthServer = grpcHealth.NewServer()
+			healthServer = health.NewGRPCServer(healthChecker)
 			grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
 		}
+		for _, register := range grpcServices {
+			register(grpcServer)
+		}
 		go grpcServer.Serve(grpcListener)
 		info = append(info, "grpc.port", cfg.GRPCPort)
 	}
@@ -558,6 +756,10 @@ type engineInfo struct {
 func startAuthenticatedRpcServer(cfg httpcfg.HttpCfg, rpcAPI []rpc.API) (*engineInfo, error) {
 	log.Trace("TraceRequests = %t\n", cfg.TraceRequests)
 	srv := rpc.NewServer(cfg.RpcBatchConcurrency, cfg.TraceRequests, cfg.RpcStreamingDisable)
+	srv.SetBatchLimits(cfg.RpcBatchLimit, cfg.RpcBatchResponseMaxSize)
+	if err := srv.SetParseLimits(cfg.ParseLimits); err != nil {
+		return nil, err
+	}
 
 	engineListener, engineSrv, engineHttpEndpoint, err := createEngineListener(cfg, rpcAPI)
 	if err != nil {
@@ -590,39 +792,39 @@ func isWebsocket(r *http.Request) bool {
 		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
 }
 
-// obtainJWTSecret loads the jwt-secret, either from the provided config,
+// obtainJWTSecret loads the jwt-secret, either from the provided path,
 // or from the default location. If neither of those are present, it generates
 // a new secret and stores to the default location.
-func obtainJWTSecret(cfg httpcfg.HttpCfg) ([]byte, error) {
+func obtainJWTSecret(jwtSecretPath string) ([]byte, error) {
 	// try reading from file
-	log.Info("Reading JWT secret", "path", cfg.JWTSecretPath)
+	log.Info("Reading JWT secret", "path", jwtSecretPath)
 	// If we run the rpcdaemon and datadir is not specified we just use jwt.hex in current directory.
-	if len(cfg.JWTSecretPath) == 0 {
-		cfg.JWTSecretPath = "jwt.hex"
+	if len(jwtSecretPath) == 0 {
+		jwtSecretPath = "jwt.hex"
 	}
-	if data, err := os.ReadFile(cfg.JWTSecretPath); err == nil {
+	if data, err := os.ReadFile(jwtSecretPath); err == nil {
 		jwtSecret := common.FromHex(strings.TrimSpace(string(data)))
 		if len(jwtSecret) == 32 {
 			return jwtSecret, nil
 		}
-		log.Error("Invalid JWT secret", "path", cfg.JWTSecretPath, "length", len(jwtSecret))
+		log.Error("Invalid JWT secret", "path", jwtSecretPath, "length", len(jwtSecret))
 		return nil, errors.New("invalid JWT secret")
 	}
 	// Need to generate one
 	jwtSecret := make([]byte, 32)
 	rand.Read(jwtSecret)
 
-	if err := os.WriteFile(cfg.JWTSecretPath, []byte(hexutil.Encode(jwtSecret)), 0600); err != nil {
+	if err := os.WriteFile(jwtSecretPath, []byte(hexutil.Encode(jwtSecret)), 0600); err != nil {
 		return nil, err
 	}
-	log.Info("Generated JWT secret", "path", cfg.JWTSecretPath)
+	log.Info("Generated JWT secret", "path", jwtSecretPath)
 	return jwtSecret, nil
 }
 
-func createHandler(cfg httpcfg.HttpCfg, apiList []rpc.API, httpHandler http.Handler, wsHandler http.Handler, jwtSecret []byte) (http.Handler, error) {
+func createHandler(cfg httpcfg.HttpCfg, healthChecker *health.Checker, httpHandler http.Handler, wsHandler http.Handler, jwtSecret []byte) (http.Handler, error) {
 	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// adding a healthcheck here
-		if health.ProcessHealthcheckIfNeeded(w, r, apiList) {
+		if healthChecker.ProcessHealthcheckIfNeeded(w, r) {
 			return
 		}
 		if cfg.WebsocketEnabled && wsHandler != nil && isWebsocket(r) {
@@ -649,16 +851,19 @@ func createEngineListener(cfg httpcfg.HttpCfg, engineApi []rpc.API) (*http.Serve
 		return nil, nil, "", fmt.Errorf("could not start register RPC engine api: %w", err)
 	}
 
-	jwtSecret, err := obtainJWTSecret(cfg)
+	jwtSecret, err := obtainJWTSecret(cfg.JWTSecretPath)
 	if err != nil {
 		return nil, nil, "", err
 	}
 
+	if err := engineSrv.SetWebsocketLimits(cfg.WebsocketCompressionLevel, cfg.WebsocketMessageSizeLimit); err != nil {
+		return nil, nil, "", err
+	}
 	wsHandler := engineSrv.WebsocketHandler([]string{"*"}, jwtSecret, cfg.WebsocketCompression)
 
 	engineHttpHandler := node.NewHTTPHandlerStack(engineSrv, nil /* authCors */, cfg.AuthRpcVirtualHost, cfg.HttpCompression)
 
-	engineApiHandler, err := createHandler(cfg, engineApi, engineHttpHandler, wsHandler, jwtSecret)
+	engineApiHandler, err := createHandler(cfg, health.NewChecker(engineApi, cfg.Health), engineHttpHandler, wsHandler, jwtSecret)
 	if err != nil {
 		return nil, nil, "", err
 	}
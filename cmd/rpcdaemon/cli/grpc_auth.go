@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcJWTOptions return the options of a gRPC server rejecting the calls without a JWT signed with jwtSecret in their
+// authorization metadata, "Bearer <token>" like on HTTP. The health checks keep their own authentication, like on HTTP.
+func grpcJWTOptions(jwtSecret []byte) []grpc.ServerOption {
+	authorize := func(ctx context.Context, fullMethod string) error {
+		if strings.HasPrefix(fullMethod, "/"+grpc_health_v1.Health_ServiceDesc.ServiceName+"/") {
+			return nil
+		}
+		var token string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if auth := md.Get("authorization"); len(auth) > 0 && strings.HasPrefix(auth[0], "Bearer ") {
+				token = strings.TrimPrefix(auth[0], "Bearer ")
+			}
+		}
+		if err := rpc.CheckJwtToken(token, jwtSecret); err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := authorize(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := authorize(ss.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
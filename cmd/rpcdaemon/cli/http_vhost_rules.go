@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/node"
+)
+
+// parseVirtualHostRules reads the rules of the virtual hosts from a JSON file mapping the host names to their rule,
+// for example {"rpc.example.com": {"corsDomains": ["*"], "api": ["eth", "net"]}}
+func parseVirtualHostRules(path string) (map[string]*node.VirtualHostRule, error) {
+	path = strings.TrimSpace(path)
+	if path == "" { // no file is provided
+		return nil, nil
+	}
+
+	fileContents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules map[string]*node.VirtualHostRule
+	if err = json.Unmarshal(fileContents, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
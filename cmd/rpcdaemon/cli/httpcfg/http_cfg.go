@@ -1,50 +1,82 @@
 package httpcfg
 
 import (
+	"time"
+
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/health"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/eth/gasprice"
 	"github.com/ledgerwatch/erigon/node/nodecfg/datadir"
+	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/rpc/rpccfg"
 )
 
 type HttpCfg struct {
-	Enabled                  bool
-	PrivateApiAddr           string
-	WithDatadir              bool // Erigon's database can be read by separated processes on same machine - in read-only mode - with full support of transactions. It will share same "OS PageCache" with Erigon process.
-	DataDir                  string
-	Dirs                     datadir.Dirs
-	HttpListenAddress        string
-	AuthRpcHTTPListenAddress string
-	TLSCertfile              string
-	TLSCACert                string
-	TLSKeyFile               string
-	HttpPort                 int
-	AuthRpcPort              int
-	HttpCORSDomain           []string
-	HttpVirtualHost          []string
-	AuthRpcVirtualHost       []string
-	HttpCompression          bool
-	API                      []string
-	Gascap                   uint64
-	MaxTraces                uint64
-	WebsocketEnabled         bool
-	WebsocketCompression     bool
-	RpcAllowListFilePath     string
-	RpcBatchConcurrency      uint
-	RpcStreamingDisable      bool
-	DBReadConcurrency        int
-	TraceCompatibility       bool // Bug for bug compatibility for trace_ routines with OpenEthereum
-	TxPoolApiAddr            string
-	StateCache               kvcache.CoherentConfig
-	Snap                     ethconfig.Snapshot
-	Sync                     ethconfig.Sync
-	GRPCServerEnabled        bool
-	GRPCListenAddress        string
-	GRPCPort                 int
-	GRPCHealthCheckEnabled   bool
-	StarknetGRPCAddress      string
-	JWTSecretPath            string // Engine API Authentication
-	TraceRequests            bool   // Always trace requests in INFO level
-	HTTPTimeouts             rpccfg.HTTPTimeouts
-	AuthRpcTimeouts          rpccfg.HTTPTimeouts
+	Enabled                   bool
+	PrivateApiAddr            string
+	WithDatadir               bool // Erigon's database can be read by separated processes on same machine - in read-only mode - with full support of transactions. It will share same "OS PageCache" with Erigon process.
+	SnapshotOnly              bool // serve the blocks of the snapshot files only, without the database of Erigon
+	DataDir                   string
+	Dirs                      datadir.Dirs
+	AggregatorDir             string // the files of the history of state, to open the private copies of the aggregator
+	HttpListenAddress         string
+	AuthRpcHTTPListenAddress  string
+	TLSCertfile               string
+	TLSCACert                 string
+	TLSKeyFile                string
+	HttpPort                  int
+	AuthRpcPort               int
+	HttpCORSDomain            []string
+	HttpVirtualHost           []string
+	HttpVirtualHostRulesPath  string
+	AuthRpcVirtualHost        []string
+	HttpCompression           bool
+	API                       []string
+	Gascap                    uint64
+	Gpo                       gasprice.Config // gas price oracle of eth_gasPrice, eth_maxPriorityFeePerGas and eth_feeHistory
+	MaxTraces                 uint64
+	MaxGetProofRewindBlocks   int           // how far eth_getProof can rewind the state, in blocks
+	GetLogsMaxBlockRange      uint64        // blocks in the range of an eth_getLogs call, 0 is no limit
+	GetLogsMaxResults         int           // logs returned by an eth_getLogs call, 0 is no limit
+	GetLogsTimeout            time.Duration // time an eth_getLogs call can spend reading logs, 0 is no limit
+	WebsocketEnabled          bool
+	WebsocketCompression      bool
+	WebsocketCompressionLevel int
+	WebsocketMessageSizeLimit int64
+	WebsocketConnLimits       rpc.WebsocketConnLimits
+	RpcAllowListFilePath      string
+	RpcACLFilePath            string
+	RpcMethodLimitsFilePath   string
+	RpcMethodTimeouts         string        // comma separated method=duration
+	RpcEvmTimeout             time.Duration // time limit of the EVM execution of a call, 0 is no limit
+	RpcSigner                 string        // URL of the external signer of eth_sendTransaction and eth_signTransaction
+	RpcBatchConcurrency       uint
+	RpcBatchLimit             int
+	RpcBatchResponseMaxSize   int
+	ParseLimits               rpc.ParseLimits
+	RpcResponseCacheSize      int           // bytes of immutable responses kept in memory, 0 disables the cache
+	RpcSlowThreshold          time.Duration // the calls taking longer are logged, 0 disables it
+	RpcSampleRate             float64       // fraction of all the calls logged
+	RpcSampleFile             string        // JSON log of the sampled calls, the main log if empty
+	RpcStreamingDisable       bool
+	DBReadConcurrency         int
+	TraceCompatibility        bool // Bug for bug compatibility for trace_ routines with OpenEthereum
+	TxPoolApiAddr             string
+	StateCache                kvcache.CoherentConfig
+	Snap                      ethconfig.Snapshot
+	Sync                      ethconfig.Sync
+	GRPCServerEnabled         bool
+	GRPCListenAddress         string
+	GRPCPort                  int
+	GRPCHealthCheckEnabled    bool
+	StarknetGRPCAddress       string
+	JWTSecretPath             string // Engine API Authentication
+	HttpJWTSecretPath         string // Regular API Authentication, optional
+	HttpTLSCertFile           string // TLS of the regular endpoints, optional, reloaded on change
+	HttpTLSKeyFile            string // Key of HttpTLSCertFile
+	TraceRequests             bool   // Always trace requests in INFO level
+	HTTPTimeouts              rpccfg.HTTPTimeouts
+	Health                    health.Config
+	AuthRpcTimeouts           rpccfg.HTTPTimeouts
 }
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// aclReloadInterval is how often the ACL file is checked for changes
+const aclReloadInterval = 5 * time.Second
+
+// watchACLForRPC applies the ACL file to srv, then reloads it whenever it changes until ctx is done. The ACL of a
+// changed file which can't be read or parsed is kept until the file is fixed.
+func watchACLForRPC(ctx context.Context, path string, srv *rpc.Server) error {
+	path = strings.TrimSpace(path)
+	if path == "" { // no file is provided
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	acl, err := readACL(path)
+	if err != nil {
+		return err
+	}
+	srv.SetACL(acl)
+
+	go func() {
+		ticker := time.NewTicker(aclReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			newInfo, err := os.Stat(path)
+			if err != nil {
+				log.Warn("Cannot read the RPC ACL file", "path", path, "err", err)
+				continue
+			}
+			if newInfo.ModTime().Equal(info.ModTime()) && newInfo.Size() == info.Size() {
+				continue
+			}
+			info = newInfo
+			acl, err := readACL(path)
+			if err != nil {
+				log.Warn("Invalid RPC ACL file, keeping the previous ACL", "path", path, "err", err)
+				continue
+			}
+			srv.SetACL(acl)
+			log.Info("RPC ACL reloaded", "path", path, "rules", len(acl.Rules))
+		}
+	}()
+	return nil
+}
+
+func readACL(path string) (*rpc.ACL, error) {
+	fileContents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return rpc.ParseACL(fileContents)
+}
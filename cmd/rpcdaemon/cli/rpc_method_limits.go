@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// parseMethodLimitsForRPC reads the limits of the methods from a JSON file mapping the method names to their
+// limits, for example {"debug_traceBlockByNumber": {"rate": 2, "concurrency": 4}}
+func parseMethodLimitsForRPC(path string) (rpc.MethodLimits, error) {
+	path = strings.TrimSpace(path)
+	if path == "" { // no file is provided
+		return nil, nil
+	}
+
+	fileContents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var limits rpc.MethodLimits
+	if err = json.Unmarshal(fileContents, &limits); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// requestLogConfig returns the logging of the slow and sampled calls, the sampled calls are logged as JSON lines to
+// cfg.RpcSampleFile if it's set
+func requestLogConfig(cfg httpcfg.HttpCfg) (rpc.RequestLogConfig, error) {
+	requestLog := rpc.RequestLogConfig{SlowThreshold: cfg.RpcSlowThreshold, SampleRate: cfg.RpcSampleRate}
+	if cfg.RpcSampleFile != "" && cfg.RpcSampleRate > 0 {
+		handler, err := log.FileHandler(cfg.RpcSampleFile, log.JsonFormat())
+		if err != nil {
+			return requestLog, err
+		}
+		requestLog.Samples = log.New()
+		requestLog.Samples.SetHandler(handler)
+	}
+	return requestLog, nil
+}
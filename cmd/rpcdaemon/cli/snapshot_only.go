@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/etl"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	kv2 "github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/services"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/log/v3"
+)
+
+const (
+	// snapshotsRefreshInterval is the time between the checks for new snapshot files in snapshot-only mode
+	snapshotsRefreshInterval = time.Minute
+	// snapshotIndexDir is the database of the index of the snapshots in snapshot-only mode, in the datadir
+	snapshotIndexDir = "snapshotindex"
+)
+
+// snapshotOnlyMethods are the methods served in snapshot-only mode, the ones reading only the blocks and their
+// transactions. The receipts, logs and traces need the state history, which isn't in the snapshots.
+var snapshotOnlyMethods = rpc.AllowList{
+	"eth_blockNumber":                            {},
+	"eth_chainId":                                {},
+	"eth_getBlockByNumber":                       {},
+	"eth_getBlockByHash":                         {},
+	"eth_getBlockTransactionCountByNumber":       {},
+	"eth_getBlockTransactionCountByHash":         {},
+	"eth_getTransactionByHash":                   {},
+	"eth_getTransactionByBlockHashAndIndex":      {},
+	"eth_getTransactionByBlockNumberAndIndex":    {},
+	"eth_getRawTransactionByHash":                {},
+	"eth_getRawTransactionByBlockHashAndIndex":   {},
+	"eth_getRawTransactionByBlockNumberAndIndex": {},
+	"eth_getUncleByBlockNumberAndIndex":          {},
+	"eth_getUncleByBlockHashAndIndex":            {},
+	"eth_getUncleCountByBlockNumber":             {},
+	"eth_getUncleCountByBlockHash":               {},
+	"erigon_getHeaderByNumber":                   {},
+	"erigon_getHeaderByHash":                     {},
+	"erigon_getHeadersByRange":                   {},
+	"erigon_getBlockByTimestamp":                 {},
+	"web3_clientVersion":                         {},
+	"web3_sha3":                                  {},
+	"rpc_modules":                                {},
+}
+
+// snapshotOnlyAllowList restricts allowList, which allows every method if it's empty, to the methods served in
+// snapshot-only mode
+func snapshotOnlyAllowList(allowList rpc.AllowList) rpc.AllowList {
+	restricted := make(rpc.AllowList, len(snapshotOnlyMethods))
+	for method := range snapshotOnlyMethods {
+		if _, ok := allowList[method]; ok || len(allowList) == 0 {
+			restricted[method] = struct{}{}
+		}
+	}
+	return restricted
+}
+
+// snapshotOnlyServices serves the blocks of the snapshot files of the datadir, without an erigon instance nor its
+// database. The canonical hashes, total difficulties and block numbers of the hashes, which the snapshots don't
+// have, are indexed into the database of snapshotIndexDir, which is resumed at startup, and the new snapshot files are
+// picked up every minute.
+func snapshotOnlyServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger) (db kv.RoDB, stateCache kvcache.Cache, blockReader services.FullBlockReader, ff *rpchelper.Filters, err error) {
+	if !cfg.WithDatadir {
+		return nil, nil, nil, nil, fmt.Errorf("snapshot-only mode reads the snapshots of --datadir, which isn't set")
+	}
+	snapCfg := cfg.Snap
+	snapCfg.Enabled, snapCfg.NoDownloader = true, true
+	allSnapshots := snapshotsync.NewRoSnapshots(snapCfg, cfg.Dirs.Snap)
+	if err = allSnapshots.ReopenFolder(); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if allSnapshots.BlocksAvailable() == 0 {
+		return nil, nil, nil, nil, fmt.Errorf("no indexed snapshots in %s", cfg.Dirs.Snap)
+	}
+	allSnapshots.LogStat()
+	blockReader = snapshotsync.NewBlockReaderWithSnapshots(allSnapshots)
+
+	indexDb, err := kv2.NewMDBX(logger).Path(filepath.Join(cfg.DataDir, snapshotIndexDir)).Open()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	next, err := snapshotIndexProgress(ctx, indexDb, blockReader)
+	if err == nil && allSnapshots.BlocksAvailable() >= next {
+		_, err = indexSnapshots(ctx, indexDb, allSnapshots, cfg.Dirs.Tmp, next)
+	}
+	if err != nil {
+		indexDb.Close()
+		return nil, nil, nil, nil, err
+	}
+	go func() {
+		ticker := time.NewTicker(snapshotsRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := allSnapshots.ReopenFolder(); err != nil {
+				log.Warn("[snapshots] reopen", "err", err)
+				continue
+			}
+			next, err := snapshotIndexProgress(ctx, indexDb, blockReader)
+			if err != nil {
+				log.Error("[snapshots] index", "err", err)
+				continue
+			}
+			if allSnapshots.BlocksAvailable() < next {
+				continue
+			}
+			allSnapshots.LogStat()
+			if _, err := indexSnapshots(ctx, indexDb, allSnapshots, cfg.Dirs.Tmp, next); err != nil {
+				log.Error("[snapshots] index", "err", err)
+			}
+		}
+	}()
+
+	ff = rpchelper.New(ctx, nil, nil, nil, func() {})
+	return indexDb, kvcache.NewDummy(), blockReader, ff, nil
+}
+
+// snapshotIndexProgress returns the first block of the snapshots which isn't in the index of db. The index is cleared
+// if its last block isn't the one of the snapshots anymore, like when the files were replaced by those of another
+// chain.
+func snapshotIndexProgress(ctx context.Context, db kv.RwDB, blockReader services.FullBlockReader) (uint64, error) {
+	tx, err := db.BeginRw(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	last, err := stages.GetStageProgress(tx, stages.Headers)
+	if err != nil {
+		return 0, err
+	}
+	hash, err := rawdb.ReadCanonicalHash(tx, last)
+	if err != nil {
+		return 0, err
+	}
+	if hash == (common.Hash{}) { // the index is empty
+		return 0, nil
+	}
+	header, err := blockReader.HeaderByNumber(ctx, tx, last)
+	if err != nil {
+		return 0, err
+	}
+	if header != nil && header.Hash() == hash {
+		return last + 1, nil
+	}
+	log.Warn("[snapshots] The index doesn't match the snapshots, rebuilding it", "block", last)
+	for _, table := range []string{kv.HeaderCanonical, kv.HeaderTD, kv.HeaderNumber, kv.HeadHeaderKey, kv.LastForkchoice, kv.SyncStageProgress, kv.ConfigTable} {
+		if err = tx.ClearBucket(table); err != nil {
+			return 0, err
+		}
+	}
+	return 0, tx.Commit()
+}
+
+// indexSnapshots writes the canonical hashes, total difficulties and numbers of the hashes of the blocks of the
+// snapshots from block from, and the progress of the stages the rpc methods use to find the latest block. The chain
+// config is found by the hash of the genesis. It returns the last indexed block.
+func indexSnapshots(ctx context.Context, db kv.RwDB, snapshots *snapshotsync.RoSnapshots, tmpdir string, from uint64) (uint64, error) {
+	tx, err := db.BeginRw(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	td := big.NewInt(0)
+	if from > 0 {
+		hash, err := rawdb.ReadCanonicalHash(tx, from-1)
+		if err != nil {
+			return 0, err
+		}
+		if td, err = rawdb.ReadTd(tx, hash, from-1); err != nil {
+			return 0, err
+		}
+		if td == nil {
+			return 0, fmt.Errorf("total difficulty of block %d not found", from-1)
+		}
+	}
+
+	logEvery := time.NewTicker(30 * time.Second)
+	defer logEvery.Stop()
+	h2n := etl.NewCollector("Snapshots", tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
+	defer h2n.Close()
+	h2n.LogLvl(log.LvlDebug)
+
+	last, lastHash := from, common.Hash{}
+	if err := snapshotsync.ForEachHeaderFrom(ctx, snapshots, from, func(header *types.Header) error {
+		blockNum, blockHash := header.Number.Uint64(), header.Hash()
+		if blockNum < from {
+			return nil
+		}
+		if blockNum == 0 {
+			chainConfig := params.ChainConfigByGenesisHash(blockHash)
+			if chainConfig == nil {
+				return fmt.Errorf("unknown chain of genesis %x", blockHash)
+			}
+			if err := rawdb.WriteChainConfig(tx, blockHash, chainConfig); err != nil {
+				return err
+			}
+		}
+		td.Add(td, header.Difficulty)
+		if err := rawdb.WriteTd(tx, blockHash, blockNum, td); err != nil {
+			return err
+		}
+		if err := rawdb.WriteCanonicalHash(tx, blockHash, blockNum); err != nil {
+			return err
+		}
+		if err := h2n.Collect(blockHash[:], dbutils.EncodeBlockNumber(blockNum)); err != nil {
+			return err
+		}
+		last, lastHash = blockNum, blockHash
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-logEvery.C:
+			log.Info("[snapshots] Indexing blocks", "block_num", blockNum)
+		default:
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	if err := h2n.Load(tx, kv.HeaderNumber, etl.IdentityLoadFunc, etl.TransformArgs{}); err != nil {
+		return 0, err
+	}
+	if lastHash == (common.Hash{}) {
+		return 0, fmt.Errorf("no headers from block %d in the snapshots", from)
+	}
+
+	// the blocks of the snapshots are final
+	if err := rawdb.WriteHeadHeaderHash(tx, lastHash); err != nil {
+		return 0, err
+	}
+	rawdb.WriteForkchoiceHead(tx, lastHash)
+	rawdb.WriteForkchoiceSafe(tx, lastHash)
+	rawdb.WriteForkchoiceFinalized(tx, lastHash)
+	for _, stage := range []stages.SyncStage{stages.Headers, stages.BlockHashes, stages.Bodies, stages.Senders, stages.Finish} {
+		if err := stages.SaveStageProgress(tx, stage, last); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	log.Info("[snapshots] Indexed blocks", "from", from, "to", last)
+	return last, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
 
@@ -17,6 +18,31 @@ type AdminAPI interface {
 	// Peers returns information about the connected remote nodes.
 	// https://geth.ethereum.org/docs/rpc/ns-admin#admin_peers
 	Peers(ctx context.Context) ([]*p2p.PeerInfo, error)
+
+	// AddPeer adds a static peer to the sentries, which is dialed and reconnected whenever it disconnects.
+	// https://geth.ethereum.org/docs/rpc/ns-admin#admin_addpeer
+	AddPeer(ctx context.Context, url string) (bool, error)
+
+	// RemovePeer removes a static peer from the sentries, and disconnects it.
+	// https://geth.ethereum.org/docs/rpc/ns-admin#admin_removepeer
+	RemovePeer(ctx context.Context, url string) (bool, error)
+
+	// AddTrustedPeer adds a trusted peer to the sentries, which is always allowed to connect, even above the
+	// maximum number of peers.
+	// https://geth.ethereum.org/docs/rpc/ns-admin#admin_addtrustedpeer
+	AddTrustedPeer(ctx context.Context, url string) (bool, error)
+
+	// RemoveTrustedPeer removes a trusted peer from the sentries, without disconnecting it.
+	// https://geth.ethereum.org/docs/rpc/ns-admin#admin_removetrustedpeer
+	RemoveTrustedPeer(ctx context.Context, url string) (bool, error)
+}
+
+// peersManager is implemented by the backends which can manage the peers of the sentries
+type peersManager interface {
+	AddPeer(ctx context.Context, url string) error
+	RemovePeer(ctx context.Context, url string) error
+	AddTrustedPeer(ctx context.Context, url string) error
+	RemoveTrustedPeer(ctx context.Context, url string) error
 }
 
 // AdminAPIImpl data structure to store things needed for admin_* commands.
@@ -47,3 +73,33 @@ func (api *AdminAPIImpl) NodeInfo(ctx context.Context) (*p2p.NodeInfo, error) {
 func (api *AdminAPIImpl) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
 	return api.ethBackend.Peers(ctx)
 }
+
+func (api *AdminAPIImpl) AddPeer(ctx context.Context, url string) (bool, error) {
+	return api.managePeers(ctx, url, peersManager.AddPeer)
+}
+
+func (api *AdminAPIImpl) RemovePeer(ctx context.Context, url string) (bool, error) {
+	return api.managePeers(ctx, url, peersManager.RemovePeer)
+}
+
+func (api *AdminAPIImpl) AddTrustedPeer(ctx context.Context, url string) (bool, error) {
+	return api.managePeers(ctx, url, peersManager.AddTrustedPeer)
+}
+
+func (api *AdminAPIImpl) RemoveTrustedPeer(ctx context.Context, url string) (bool, error) {
+	return api.managePeers(ctx, url, peersManager.RemoveTrustedPeer)
+}
+
+func (api *AdminAPIImpl) managePeers(ctx context.Context, url string, f func(peersManager, context.Context, string) error) (bool, error) {
+	manager, ok := api.ethBackend.(peersManager)
+	if !ok {
+		return false, rpchelper.ErrPeerManagementUnavailable
+	}
+	if _, err := enode.Parse(enode.ValidSchemes, url); err != nil {
+		return false, fmt.Errorf("invalid enode: %w", err)
+	}
+	if err := f(manager, ctx, url); err != nil {
+		return false, err
+	}
+	return true, nil
+}
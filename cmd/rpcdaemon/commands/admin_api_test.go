@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/stretchr/testify/require"
+)
+
+// testPeersManager records the calls managing the peers
+type testPeersManager struct {
+	rpchelper.ApiBackend
+	calls []string
+}
+
+func (m *testPeersManager) AddPeer(_ context.Context, url string) error {
+	m.calls = append(m.calls, "add "+url)
+	return nil
+}
+
+func (m *testPeersManager) RemovePeer(_ context.Context, url string) error {
+	m.calls = append(m.calls, "remove "+url)
+	return nil
+}
+
+func (m *testPeersManager) AddTrustedPeer(_ context.Context, url string) error {
+	m.calls = append(m.calls, "addTrusted "+url)
+	return nil
+}
+
+func (m *testPeersManager) RemoveTrustedPeer(_ context.Context, url string) error {
+	m.calls = append(m.calls, "removeTrusted "+url)
+	return nil
+}
+
+func TestAdminManagePeers(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	url := enode.NewV4(&key.PublicKey, net.IP{127, 0, 0, 1}, 30303, 30303).URLv4()
+
+	manager := &testPeersManager{}
+	api := NewAdminAPI(manager)
+	for _, f := range []func(context.Context, string) (bool, error){api.AddPeer, api.AddTrustedPeer, api.RemoveTrustedPeer, api.RemovePeer} {
+		ok, err := f(ctx, url)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	require.Equal(t, []string{"add " + url, "addTrusted " + url, "removeTrusted " + url, "remove " + url}, manager.calls)
+
+	// the invalid URLs aren't forwarded
+	ok, err := api.AddPeer(ctx, "enode://invalid")
+	require.Error(t, err)
+	require.False(t, ok)
+	require.Len(t, manager.calls, 4)
+
+	// nor are the calls to a backend which can't manage the peers
+	ok, err = NewAdminAPI(nil).AddPeer(ctx, url)
+	require.Error(t, err)
+	require.False(t, ok)
+}
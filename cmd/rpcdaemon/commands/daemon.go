@@ -18,8 +18,15 @@ func APIList(db kv.RoDB, borDb kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.
 	blockReader services.FullBlockReader, agg *libstate.Aggregator22, txNums *exec22.TxNums, cfg httpcfg.HttpCfg) (list []rpc.API) {
 
 	base := NewBaseApi(filters, stateCache, blockReader, agg, txNums, cfg.WithDatadir)
-	ethImpl := NewEthAPI(base, db, eth, txPool, mining, cfg.Gascap)
-	erigonImpl := NewErigonAPI(base, db, eth)
+	base.EvmCallTimeout = cfg.RpcEvmTimeout
+	base.AggregatorDir = cfg.AggregatorDir
+	ethImpl := NewEthAPI(base, db, eth, txPool, mining, cfg.Gascap).WithGasPriceOracle(cfg.Gpo)
+	ethImpl.MaxGetProofRewindBlocks = cfg.MaxGetProofRewindBlocks
+	ethImpl.GetLogsLimits = GetLogsLimits{MaxBlockRange: cfg.GetLogsMaxBlockRange, MaxResults: cfg.GetLogsMaxResults, Timeout: cfg.GetLogsTimeout}
+	if cfg.RpcSigner != "" {
+		ethImpl.Signer = NewExternalSigner(cfg.RpcSigner)
+	}
+	erigonImpl := NewErigonAPI(base, db, eth, txPool)
 	txpoolImpl := NewTxPoolAPI(base, db, txPool)
 	netImpl := NewNetAPIImpl(eth)
 	debugImpl := NewPrivateDebugAPI(base, db, cfg.Gascap)
@@ -29,6 +36,7 @@ func APIList(db kv.RoDB, borDb kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.
 	adminImpl := NewAdminAPI(eth)
 	parityImpl := NewParityAPIImpl(db)
 	borImpl := NewBorAPI(base, db, borDb) // bor (consensus) specific
+	otsImpl := NewOtterscanAPI(base, db)
 
 	for _, enabledAPI := range cfg.API {
 		switch enabledAPI {
@@ -38,6 +46,11 @@ func APIList(db kv.RoDB, borDb kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.
 				Public:    true,
 				Service:   EthAPI(ethImpl),
 				Version:   "1.0",
+			}, rpc.API{
+				Namespace: "eth",
+				Public:    true,
+				Service:   EthSyncingAPI(NewEthSyncingAPI(db)),
+				Version:   "1.0",
 			})
 		case "debug":
 			list = append(list, rpc.API{
@@ -95,6 +108,13 @@ func APIList(db kv.RoDB, borDb kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.
 				Service:   BorAPI(borImpl),
 				Version:   "1.0",
 			})
+		case "ots":
+			list = append(list, rpc.API{
+				Namespace: "ots",
+				Public:    true,
+				Service:   OtterscanAPI(otsImpl),
+				Version:   "1.0",
+			})
 		case "admin":
 			list = append(list, rpc.API{
 				Namespace: "admin",
@@ -119,8 +139,14 @@ func AuthAPIList(db kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClien
 	filters *rpchelper.Filters, stateCache kvcache.Cache, blockReader services.FullBlockReader,
 	cfg httpcfg.HttpCfg) (list []rpc.API) {
 	base := NewBaseApi(filters, stateCache, blockReader, nil, nil, cfg.WithDatadir)
+	base.EvmCallTimeout = cfg.RpcEvmTimeout
 
-	ethImpl := NewEthAPI(base, db, eth, txPool, mining, cfg.Gascap)
+	ethImpl := NewEthAPI(base, db, eth, txPool, mining, cfg.Gascap).WithGasPriceOracle(cfg.Gpo)
+	ethImpl.MaxGetProofRewindBlocks = cfg.MaxGetProofRewindBlocks
+	ethImpl.GetLogsLimits = GetLogsLimits{MaxBlockRange: cfg.GetLogsMaxBlockRange, MaxResults: cfg.GetLogsMaxResults, Timeout: cfg.GetLogsTimeout}
+	if cfg.RpcSigner != "" {
+		ethImpl.Signer = NewExternalSigner(cfg.RpcSigner)
+	}
 	engineImpl := NewEngineAPI(base, db, eth)
 
 	list = append(list, rpc.API{
@@ -7,13 +7,22 @@ import (
 	"testing"
 
 	jsoniter "github.com/json-iterator/go"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/tracers"
 	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
 )
 
 var debugTraceTransactionTests = []struct {
@@ -183,3 +192,236 @@ func TestTraceTransactionNoRefund(t *testing.T) {
 		}
 	}
 }
+
+func TestAccountRange(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	base := NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false)
+	api := NewPrivateDebugAPI(base, db, 0)
+	ethApi := NewEthAPI(base, db, nil, nil, nil, 5000000)
+	ethApi.MaxGetProofRewindBlocks = 10
+	ctx := context.Background()
+
+	for _, number := range []rpc.BlockNumber{4, rpc.LatestBlockNumber} {
+		blockNrOrHash := rpc.BlockNumberOrHashWithNumber(number)
+		all, err := api.AccountRange(ctx, blockNrOrHash, nil, 0, true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		require.Nil(t, all.Next)
+		require.NotEmpty(t, all.Accounts)
+
+		// the accounts are paged through with the next keys
+		paged := map[common.Address]state.DumpAccount{}
+		var start []byte
+		for {
+			page, err := api.AccountRange(ctx, blockNrOrHash, start, 2, true, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			require.LessOrEqual(t, len(page.Accounts), 2)
+			for address, account := range page.Accounts {
+				paged[address] = account
+			}
+			if page.Next == nil {
+				break
+			}
+			start = page.Next
+		}
+		require.Equal(t, all.Accounts, paged)
+
+		for address, account := range all.Accounts {
+			balance, err := ethApi.GetBalance(ctx, address, blockNrOrHash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			require.Equal(t, balance.ToInt().String(), account.Balance, "balance of %x", address)
+			proof, err := ethApi.GetProof(ctx, address, nil, blockNrOrHash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			require.Equal(t, proof.StorageHash.Bytes(), []byte(account.Root), "storage root of %x", address)
+		}
+	}
+
+	if _, err := api.AccountRange(ctx, rpc.BlockNumberOrHashWithNumber(20), nil, 0, true, true); err == nil {
+		t.Error("expected an error for a block not executed yet")
+	}
+}
+
+func TestStorageRangeAt(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewPrivateDebugAPI(
+		NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false),
+		db, 0)
+	ctx := context.Background()
+
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockHash, err := rawdb.ReadCanonicalHash(tx, 5)
+	tx.Rollback()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the token deployed in block 3 and minted in block 4 has its total supply, the balance of the holder and the minter
+	token := crypto.CreateAddress(common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7"), 2)
+	all, err := api.StorageRangeAt(ctx, blockHash, 0, token, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	require.Len(t, all.Storage, 3)
+	require.Nil(t, all.NextKey)
+
+	paged := StorageMap{}
+	var start hexutil.Bytes
+	for {
+		page, err := api.StorageRangeAt(ctx, blockHash, 0, token, start, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		require.Len(t, page.Storage, 1)
+		for seckey, entry := range page.Storage {
+			paged[seckey] = entry
+		}
+		if page.NextKey == nil {
+			break
+		}
+		start = page.NextKey.Bytes()
+	}
+	require.Equal(t, all.Storage, paged)
+
+	if _, err = api.StorageRangeAt(ctx, common.Hash{1}, 0, token, nil, 0); err == nil {
+		t.Error("expected an error for an unknown block")
+	}
+}
+
+func TestGetModifiedAccountsWithStorage(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	baseApi := NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false)
+	ethApi := NewEthAPI(baseApi, db, nil, nil, nil, 5000000)
+	api := NewPrivateDebugAPI(baseApi, db, 0)
+	ctx := context.Background()
+	token := crypto.CreateAddress(common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7"), 2)
+	opts := &ModifiedAccountsOptions{Storage: true, Code: true}
+
+	addresses, err := api.GetModifiedAccountsByNumber(ctx, 4, nil, nil)
+	require.NoError(t, err)
+	require.Contains(t, addresses, token)
+
+	// the token minted in block 4 has its total supply and the balance of the holder changed
+	res, err := api.GetModifiedAccountsByNumber(ctx, 4, nil, opts)
+	require.NoError(t, err)
+	var minted *ModifiedAccount
+	for _, account := range res.([]*ModifiedAccount) {
+		if account.Address == token {
+			minted = account
+		}
+	}
+	require.NotNil(t, minted)
+	require.NotNil(t, minted.CodeChanged)
+	require.False(t, *minted.CodeChanged)
+	require.Len(t, minted.Storage, 2)
+	for slot, diff := range minted.Storage {
+		from, err := ethApi.GetStorageAt(ctx, token, slot.Hex(), rpc.BlockNumberOrHashWithNumber(3))
+		require.NoError(t, err)
+		to, err := ethApi.GetStorageAt(ctx, token, slot.Hex(), rpc.BlockNumberOrHashWithNumber(4))
+		require.NoError(t, err)
+		require.Equal(t, common.HexToHash(from), diff.From)
+		require.Equal(t, common.HexToHash(to), diff.To)
+		require.NotEqual(t, diff.From, diff.To)
+	}
+
+	// the token is deployed in block 3
+	res, err = api.GetModifiedAccountsByNumber(ctx, 3, nil, &ModifiedAccountsOptions{Code: true})
+	require.NoError(t, err)
+	var deployed *ModifiedAccount
+	for _, account := range res.([]*ModifiedAccount) {
+		if account.Address == token {
+			deployed = account
+		}
+	}
+	require.NotNil(t, deployed)
+	require.True(t, *deployed.CodeChanged)
+	require.Nil(t, deployed.Storage)
+}
+
+// rawReceipts are consensus encoded receipts, for their root
+type rawReceipts []hexutil.Bytes
+
+func (rs rawReceipts) Len() int                           { return len(rs) }
+func (rs rawReceipts) EncodeIndex(i int, w *bytes.Buffer) { w.Write(rs[i]) }
+
+func TestGetRawBlockHeaderReceipts(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewPrivateDebugAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false), db, 0)
+	ctx := context.Background()
+
+	// the blocks are read first, the test db allows a single read transaction at a time
+	var blocks []*types.Block
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		for number := uint64(0); number <= 10; number++ {
+			block, err := rawdb.ReadBlockByNumber(tx, number)
+			if err != nil {
+				return err
+			}
+			blocks = append(blocks, block)
+		}
+		return nil
+	}))
+	for number, block := range blocks {
+		for _, blockNrOrHash := range []rpc.BlockNumberOrHash{rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number)), rpc.BlockNumberOrHashWithHash(block.Hash(), true)} {
+			rawHeader, err := api.GetRawHeader(ctx, blockNrOrHash)
+			require.NoError(t, err)
+			var header types.Header
+			require.NoError(t, rlp.DecodeBytes(rawHeader, &header))
+			require.Equal(t, block.Hash(), header.Hash())
+
+			rawBlock, err := api.GetRawBlock(ctx, blockNrOrHash)
+			require.NoError(t, err)
+			var decoded types.Block
+			require.NoError(t, rlp.DecodeBytes(rawBlock, &decoded))
+			require.Equal(t, block.Hash(), decoded.Hash())
+			require.Equal(t, block.Transactions().Len(), decoded.Transactions().Len())
+
+			receipts, err := api.GetRawReceipts(ctx, blockNrOrHash)
+			require.NoError(t, err)
+			require.Len(t, receipts, block.Transactions().Len())
+			require.Equal(t, block.ReceiptHash(), types.DeriveSha(rawReceipts(receipts)))
+		}
+	}
+
+	_, err := api.GetRawBlock(ctx, rpc.BlockNumberOrHashWithHash(common.Hash{1}, false))
+	require.Error(t, err)
+}
+
+func TestGetBadBlocks(t *testing.T) {
+	m, chain, _ := newLogsTestChain(t)
+	api := NewPrivateDebugAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false), m.DB, 0)
+	ctx := context.Background()
+
+	badBlocks, err := api.GetBadBlocks(ctx)
+	require.NoError(t, err)
+	require.Empty(t, badBlocks)
+
+	// a child of the head with a wrong state root
+	next, err := core.GenerateChain(m.ChainConfig, chain.TopBlock, m.Engine, m.DB, 1, func(i int, block *core.BlockGen) {}, false /* intermediateHashes */)
+	require.NoError(t, err)
+	header := types.CopyHeader(next.Headers[0])
+	header.Root = common.Hash{1}
+	badBlock := next.Blocks[0].WithSeal(header)
+	require.Error(t, m.InsertChain(&core.ChainPack{Headers: []*types.Header{header}, Blocks: []*types.Block{badBlock}, TopBlock: badBlock}))
+
+	badBlocks, err = api.GetBadBlocks(ctx)
+	require.NoError(t, err)
+	require.Len(t, badBlocks, 1)
+	require.Equal(t, badBlock.Hash(), badBlocks[0].Hash)
+	require.Equal(t, (*hexutil.Big)(badBlock.Number()), badBlocks[0].Block["number"])
+	require.Contains(t, badBlocks[0].Reason, "wrong trie root")
+	var decoded types.Block
+	require.NoError(t, rlp.DecodeBytes(badBlocks[0].RLP, &decoded))
+	require.Equal(t, badBlock.Hash(), decoded.Hash())
+}
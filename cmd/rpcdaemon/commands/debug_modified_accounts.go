@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/changeset"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+)
+
+// ModifiedAccountsOptions are the optional settings of debug_getModifiedAccountsBy*
+type ModifiedAccountsOptions struct {
+	// Storage adds the storage slots changed by the blocks to every account
+	Storage bool `json:"storage"`
+	// Code adds whether the blocks changed the code of every account
+	Code bool `json:"code"`
+}
+
+// ModifiedAccount is an account modified by a range of blocks
+type ModifiedAccount struct {
+	Address     common.Address               `json:"address"`
+	Storage     map[common.Hash]*StorageDiff `json:"storage,omitempty"`
+	CodeChanged *bool                        `json:"codeChanged,omitempty"`
+}
+
+// StorageDiff is the value of a storage slot before and after a range of blocks
+type StorageDiff struct {
+	From common.Hash `json:"from"`
+	To   common.Hash `json:"to"`
+}
+
+// modifiedAccounts returns the accounts modified by the blocks from startNum to endNum (excluded), read from the change
+// sets: their addresses, or their ModifiedAccount if opts asks for more
+func (api *PrivateDebugAPIImpl) modifiedAccounts(tx kv.Tx, startNum, endNum uint64, opts *ModifiedAccountsOptions) (interface{}, error) {
+	if opts == nil || (!opts.Storage && !opts.Code) {
+		return changeset.GetModifiedAccounts(tx, startNum, endNum)
+	}
+	if api.historyV2(tx) {
+		return nil, fmt.Errorf("the storage and code changes need the change sets, which history v2 doesn't keep")
+	}
+
+	modified := make(map[common.Address]*ModifiedAccount)
+	if err := changeset.ForRange(tx, kv.AccountChangeSet, startNum, endNum, func(_ uint64, k, _ []byte) error {
+		address := common.BytesToAddress(k)
+		if _, ok := modified[address]; !ok {
+			modified[address] = &ModifiedAccount{Address: address}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if opts.Storage {
+		// the first value of a slot in the change sets is the one before the range
+		if err := changeset.ForRange(tx, kv.StorageChangeSet, startNum, endNum, func(_ uint64, k, v []byte) error {
+			address := common.BytesToAddress(k[:common.AddressLength])
+			slot := common.BytesToHash(k[common.AddressLength+common.IncarnationLength:])
+			account, ok := modified[address]
+			if !ok {
+				account = &ModifiedAccount{Address: address}
+				modified[address] = account
+			}
+			if account.Storage == nil {
+				account.Storage = make(map[common.Hash]*StorageDiff)
+			}
+			if _, ok := account.Storage[slot]; !ok {
+				account.Storage[slot] = &StorageDiff{From: common.BytesToHash(v)}
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	readerBefore, readerAfter := state.NewPlainState(tx, startNum), state.NewPlainState(tx, endNum)
+	codeHash := func(acc *accounts.Account) common.Hash {
+		if acc == nil || acc.IsEmptyCodeHash() {
+			return common.Hash{}
+		}
+		return acc.CodeHash
+	}
+	result := make([]*ModifiedAccount, 0, len(modified))
+	for address, account := range modified {
+		after, err := readerAfter.ReadAccountData(address)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Code {
+			before, err := readerBefore.ReadAccountData(address)
+			if err != nil {
+				return nil, err
+			}
+			codeChanged := codeHash(before) != codeHash(after)
+			account.CodeChanged = &codeChanged
+		}
+		if after != nil {
+			for slot, diff := range account.Storage {
+				slot := slot
+				v, err := readerAfter.ReadAccountStorage(address, after.Incarnation, &slot)
+				if err != nil {
+					return nil, err
+				}
+				diff.To = common.BytesToHash(v)
+			}
+		}
+		result = append(result, account)
+	}
+	sort.Slice(result, func(i, j int) bool { return bytes.Compare(result[i].Address[:], result[j].Address[:]) < 0 })
+	return result, nil
+}
@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/eth/tracers"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// traceChainBufferedBlocks is the number of traced blocks a traceChain subscription keeps ahead of the ones sent,
+// the tracing waits while the client doesn't read them
+const traceChainBufferedBlocks = 4
+
+// TraceChainResult is a block traced by a traceChain subscription, Traces being the result of
+// debug_traceBlockByHash. Error is set instead if the block couldn't be traced, and ends the subscription.
+type TraceChainResult struct {
+	Block  hexutil.Uint64  `json:"block"`
+	Hash   common.Hash     `json:"hash"`
+	Traces json.RawMessage `json:"traces,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// TraceChain implements debug_subscribe("traceChain", start, end, config). Traces the blocks from start to end one
+// after the other, and sends the traces of each block as soon as it's traced, the blocks being traced only a few
+// blocks ahead of the ones the client read. Nothing is sent after the end block.
+func (api *PrivateDebugAPIImpl) TraceChain(ctx context.Context, start, end rpc.BlockNumber, config *tracers.TraceConfig) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	from, to, err := api.traceChainRange(ctx, start, end)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer debug.LogPanic()
+		traceCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		results := make(chan *TraceChainResult, traceChainBufferedBlocks)
+		go api.traceChain(traceCtx, from, to, config, results)
+
+		for {
+			select {
+			case res, ok := <-results:
+				if !ok {
+					return
+				}
+				if err := notifier.Notify(rpcSub.ID, res); err != nil {
+					log.Warn("error while notifying subscription", "err", err)
+					return
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// traceChainRange returns the blocks of start and end, which have to be executed
+func (api *PrivateDebugAPIImpl) traceChainRange(ctx context.Context, start, end rpc.BlockNumber) (uint64, uint64, error) {
+	if start == rpc.PendingBlockNumber || end == rpc.PendingBlockNumber {
+		return 0, 0, fmt.Errorf("pending block isn't supported by traceChain")
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+	from, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(start), tx, api.filters)
+	if err != nil {
+		return 0, 0, err
+	}
+	to, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(end), tx, api.filters)
+	if err != nil {
+		return 0, 0, err
+	}
+	if from > to {
+		return 0, 0, fmt.Errorf("start block %d is after end block %d", from, to)
+	}
+	latest, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestExecutedBlockNumber), tx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	if to > latest {
+		return 0, 0, fmt.Errorf("end block %d is after the latest executed block %d", to, latest)
+	}
+	return from, to, nil
+}
+
+// traceChain sends the traces of the blocks from to to, the channel is closed after the last block or the first
+// one which couldn't be traced
+func (api *PrivateDebugAPIImpl) traceChain(ctx context.Context, from, to uint64, config *tracers.TraceConfig, out chan<- *TraceChainResult) {
+	defer close(out)
+	for number := from; number <= to; number++ {
+		res := api.traceChainBlock(ctx, number, config)
+		select {
+		case out <- res:
+		case <-ctx.Done():
+			return
+		}
+		if res.Error != "" {
+			return
+		}
+	}
+}
+
+func (api *PrivateDebugAPIImpl) traceChainBlock(ctx context.Context, number uint64, config *tracers.TraceConfig) *TraceChainResult {
+	res := &TraceChainResult{Block: hexutil.Uint64(number)}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Hash, err = api._blockReader.CanonicalHash(ctx, tx, number)
+	tx.Rollback()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	var buf bytes.Buffer
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+	if err = api.traceBlock(ctx, rpc.BlockNumberOrHashWithHash(res.Hash, true), config, stream); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Traces = buf.Bytes()
+	return res
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceChain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db := rpcdaemontest.CreateTestKV(t)
+	baseApi := NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false)
+	api := NewPrivateDebugAPI(baseApi, db, 0)
+
+	server := rpc.NewServer(50, false /* traceRequests */, true)
+	require.NoError(t, server.RegisterName("debug", api))
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	_, err := client.Subscribe(ctx, "debug", make(chan TraceChainResult), "traceChain", 3, 2, nil)
+	require.Error(t, err, "the start block is after the end block")
+	_, err = client.Subscribe(ctx, "debug", make(chan TraceChainResult), "traceChain", 3, 1000, nil)
+	require.Error(t, err, "the end block isn't executed")
+
+	results := make(chan TraceChainResult)
+	sub, err := client.Subscribe(ctx, "debug", results, "traceChain", 1, 4, nil)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	for number := uint64(1); number <= 4; number++ {
+		select {
+		case res := <-results:
+			require.Equal(t, number, uint64(res.Block))
+			require.Empty(t, res.Error)
+			var buf bytes.Buffer
+			stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+			require.NoError(t, api.TraceBlockByHash(ctx, res.Hash, nil, stream))
+			require.JSONEq(t, buf.String(), string(res.Traces))
+		case err := <-sub.Err():
+			t.Fatal(err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout waiting for the traces of a block")
+		}
+	}
+	select {
+	case res := <-results:
+		t.Fatalf("unexpected traces of block %d", res.Block)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/log/v3"
 )
@@ -58,12 +59,25 @@ type TransitionConfiguration struct {
 	TerminalBlockNumber     *hexutil.Big `json:"terminalBlockNumber"     gencodec:"required"`
 }
 
+// ExecutionPayloadBodyV1 represents the body of an execution payload. The withdrawals are always null, they
+// aren't supported yet.
+type ExecutionPayloadBodyV1 struct {
+	Transactions []hexutil.Bytes `json:"transactions" gencodec:"required"`
+	Withdrawals  []interface{}   `json:"withdrawals"  gencodec:"required"`
+}
+
+// maxPayloadBodiesRequest is the maximum number of bodies of a request of engine_getPayloadBodiesByHashV1 or
+// engine_getPayloadBodiesByRangeV1
+const maxPayloadBodiesRequest = 1024
+
 // EngineAPI Beacon chain communication endpoint
 type EngineAPI interface {
 	ForkchoiceUpdatedV1(ctx context.Context, forkChoiceState *ForkChoiceState, payloadAttributes *PayloadAttributes) (map[string]interface{}, error)
 	NewPayloadV1(context.Context, *ExecutionPayload) (map[string]interface{}, error)
 	GetPayloadV1(ctx context.Context, payloadID hexutil.Bytes) (*ExecutionPayload, error)
 	ExchangeTransitionConfigurationV1(ctx context.Context, transitionConfiguration TransitionConfiguration) (TransitionConfiguration, error)
+	GetPayloadBodiesByHashV1(ctx context.Context, hashes []common.Hash) ([]*ExecutionPayloadBodyV1, error)
+	GetPayloadBodiesByRangeV1(ctx context.Context, start, count hexutil.Uint64) ([]*ExecutionPayloadBodyV1, error)
 }
 
 // EngineImpl is implementation of the EngineAPI interface
@@ -286,6 +300,87 @@ func (e *EngineImpl) ExchangeTransitionConfigurationV1(ctx context.Context, beac
 	}, nil
 }
 
+// GetPayloadBodiesByHashV1 returns the bodies of the blocks of hashes, null for the unknown blocks.
+// See https://github.com/ethereum/execution-apis/blob/main/src/engine/shanghai.md#engine_getpayloadbodiesbyhashv1
+func (e *EngineImpl) GetPayloadBodiesByHashV1(ctx context.Context, hashes []common.Hash) ([]*ExecutionPayloadBodyV1, error) {
+	if len(hashes) > maxPayloadBodiesRequest {
+		return nil, &rpc.CustomError{Code: -38004, Message: fmt.Sprintf("too large request: %d hashes, the maximum is %d", len(hashes), maxPayloadBodiesRequest)}
+	}
+	tx, err := e.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	bodies := make([]*ExecutionPayloadBodyV1, len(hashes))
+	for i, hash := range hashes {
+		block, err := e.blockByHashWithSenders(tx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			continue
+		}
+		if bodies[i], err = newExecutionPayloadBodyV1(block); err != nil {
+			return nil, err
+		}
+	}
+	return bodies, nil
+}
+
+// GetPayloadBodiesByRangeV1 returns the bodies of the count canonical blocks from start, null for the missing
+// blocks. The result stops at the head block.
+// See https://github.com/ethereum/execution-apis/blob/main/src/engine/shanghai.md#engine_getpayloadbodiesbyrangev1
+func (e *EngineImpl) GetPayloadBodiesByRangeV1(ctx context.Context, start, count hexutil.Uint64) ([]*ExecutionPayloadBodyV1, error) {
+	if start == 0 || count == 0 {
+		return nil, &rpc.CustomError{Code: -32602, Message: fmt.Sprintf("invalid start %d or count %d", start, count)}
+	}
+	if count > maxPayloadBodiesRequest {
+		return nil, &rpc.CustomError{Code: -38004, Message: fmt.Sprintf("too large request: %d blocks, the maximum is %d", count, maxPayloadBodiesRequest)}
+	}
+	tx, err := e.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	head := rawdb.ReadCurrentBlockNumber(tx)
+	if head == nil || uint64(start) > *head {
+		return []*ExecutionPayloadBodyV1{}, nil
+	}
+	end := uint64(start) + uint64(count) - 1
+	if end > *head {
+		end = *head
+	}
+	bodies := make([]*ExecutionPayloadBodyV1, 0, end-uint64(start)+1)
+	for number := uint64(start); number <= end; number++ {
+		block, err := e.blockByNumberWithSenders(tx, number)
+		if err != nil {
+			return nil, err
+		}
+		var body *ExecutionPayloadBodyV1
+		if block != nil {
+			if body, err = newExecutionPayloadBodyV1(block); err != nil {
+				return nil, err
+			}
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies, nil
+}
+
+func newExecutionPayloadBodyV1(block *types.Block) (*ExecutionPayloadBodyV1, error) {
+	encodedTransactions, err := types.MarshalTransactionsBinary(block.Transactions())
+	if err != nil {
+		return nil, err
+	}
+	transactions := make([]hexutil.Bytes, len(encodedTransactions))
+	for i, transaction := range encodedTransactions {
+		transactions[i] = transaction
+	}
+	return &ExecutionPayloadBodyV1{Transactions: transactions}, nil
+}
+
 // NewEngineAPI returns EngineImpl instance
 func NewEngineAPI(base *BaseAPI, db kv.RoDB, api rpchelper.ApiBackend) *EngineImpl {
 	return &EngineImpl{
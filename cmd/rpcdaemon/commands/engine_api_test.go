@@ -1,12 +1,21 @@
 package commands
 
 import (
+	"context"
 	"testing"
 
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Test case for https://github.com/ethereum/execution-apis/pull/217 responses
@@ -16,3 +25,59 @@ func TestZeroLatestValidHash(t *testing.T) {
 	assert.Equal(t, "INVALID", json["status"])
 	assert.Equal(t, common.Hash{}, json["latestValidHash"])
 }
+
+func TestGetPayloadBodies(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewEngineAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false), db, nil)
+	ctx := context.Background()
+
+	// the blocks are read first, the test db allows a single read transaction at a time
+	var blocks []*types.Block
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		for number := uint64(0); number <= 10; number++ {
+			block, err := rawdb.ReadBlockByNumber(tx, number)
+			if err != nil {
+				return err
+			}
+			blocks = append(blocks, block)
+		}
+		return nil
+	}))
+	requireBody := func(block *types.Block, body *ExecutionPayloadBodyV1) {
+		t.Helper()
+		require.NotNil(t, body)
+		require.Nil(t, body.Withdrawals)
+		require.Len(t, body.Transactions, block.Transactions().Len())
+		for i, encoded := range body.Transactions {
+			txn, err := types.UnmarshalTransactionFromBinary(encoded)
+			require.NoError(t, err)
+			require.Equal(t, block.Transactions()[i].Hash(), txn.Hash())
+		}
+	}
+
+	bodies, err := api.GetPayloadBodiesByHashV1(ctx, []common.Hash{blocks[3].Hash(), {1}, blocks[7].Hash()})
+	require.NoError(t, err)
+	require.Len(t, bodies, 3)
+	requireBody(blocks[3], bodies[0])
+	require.Nil(t, bodies[1])
+	requireBody(blocks[7], bodies[2])
+
+	// the range stops at the head block
+	bodies, err = api.GetPayloadBodiesByRangeV1(ctx, 8, 5)
+	require.NoError(t, err)
+	require.Len(t, bodies, 3)
+	for i, body := range bodies {
+		requireBody(blocks[8+i], body)
+	}
+	bodies, err = api.GetPayloadBodiesByRangeV1(ctx, 11, 5)
+	require.NoError(t, err)
+	require.Empty(t, bodies)
+
+	var engineErr *rpc.CustomError
+	_, err = api.GetPayloadBodiesByRangeV1(ctx, 0, 5)
+	require.ErrorAs(t, err, &engineErr)
+	require.Equal(t, -32602, engineErr.ErrorCode())
+	_, err = api.GetPayloadBodiesByRangeV1(ctx, 1, maxPayloadBodiesRequest+1)
+	require.ErrorAs(t, err, &engineErr)
+	require.Equal(t, -38004, engineErr.ErrorCode())
+}
@@ -0,0 +1,279 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/changeset"
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// accountChangesMaxRewindBlocks is the maximum age of the blocks whose storage roots are sent by the accountChanges
+// subscriptions, the changes of older blocks are sent without them
+const accountChangesMaxRewindBlocks = 64
+
+// AccountChange is the state of a watched account after a block changed it. StorageRoot is only set if the block
+// changed its storage.
+type AccountChange struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Address     common.Address `json:"address"`
+	Balance     *hexutil.Big   `json:"balance"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	StorageRoot *common.Hash   `json:"storageRoot,omitempty"`
+}
+
+// AccountChanges implements erigon_subscribe("accountChanges"). Sends the balance, nonce and storage root of the
+// watched accounts changed by each new block, read from the change sets. The blocks replaced by a reorg are sent
+// again from the new head.
+func (api *ErigonImpl) AccountChanges(ctx context.Context, addresses []common.Address) (*rpc.Subscription, error) {
+	if api.filters == nil {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if len(addresses) == 0 {
+		return &rpc.Subscription{}, fmt.Errorf("no addresses to watch")
+	}
+	watched := make(map[common.Address]struct{}, len(addresses))
+	for _, address := range addresses {
+		watched[address] = struct{}{}
+	}
+
+	// the new heads are subscribed to before the latest block is read, not to miss the blocks executed meanwhile
+	headers := make(chan *types.Header, 1)
+	id := api.filters.SubscribeNewHeads(headers)
+	latest, err := api.latestExecutedForAccountChanges(ctx)
+	if err != nil {
+		api.filters.UnsubscribeHeads(id)
+		return &rpc.Subscription{}, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	// the new heads are drained at once, the filters wait for each subscription to take them: they are merged until
+	// the worker reading the changes is done with the previous ones
+	heads := &pendingHeads{wake: make(chan struct{}, 1)}
+	drained, worked := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer debug.LogPanic()
+		defer api.filters.UnsubscribeHeads(id)
+		defer close(drained)
+		for {
+			select {
+			case h, ok := <-headers:
+				if h != nil {
+					heads.add(h.Number.Uint64())
+				}
+				if !ok {
+					log.Warn("new heads channel was closed")
+					return
+				}
+			case <-rpcSub.Err():
+				return
+			case <-worked:
+				return
+			}
+		}
+	}()
+	go func() {
+		defer debug.LogPanic()
+		defer close(worked)
+
+		// the next block whose changes are sent
+		next := latest + 1
+		for {
+			select {
+			case <-heads.wake:
+			case <-drained:
+				return
+			}
+			lowest, number, ok := heads.take()
+			if !ok {
+				continue
+			}
+			if lowest < next {
+				next = lowest
+			}
+			changes, err := api.readAccountChanges(context.Background(), watched, next, number)
+			if err != nil {
+				log.Warn("error while reading account changes", "err", err)
+				return
+			}
+			for _, change := range changes {
+				if err := notifier.Notify(rpcSub.ID, change); err != nil {
+					log.Warn("error while notifying subscription", "err", err)
+					return
+				}
+			}
+			next = number + 1
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// pendingHeads are the new heads received by an accountChanges subscription while its changes are read
+type pendingHeads struct {
+	mu      sync.Mutex
+	lowest  uint64 // the lowest head, a reorg goes back to it
+	latest  uint64
+	pending bool
+	wake    chan struct{}
+}
+
+func (p *pendingHeads) add(number uint64) {
+	p.mu.Lock()
+	if !p.pending || number < p.lowest {
+		p.lowest = number
+	}
+	p.latest = number
+	p.pending = true
+	p.mu.Unlock()
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the lowest and the latest of the heads added since the last call, ok is false if there's none
+func (p *pendingHeads) take() (lowest, latest uint64, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	lowest, latest, ok = p.lowest, p.latest, p.pending
+	p.pending = false
+	return lowest, latest, ok
+}
+
+// latestExecutedForAccountChanges returns the latest executed block, the accountChanges subscriptions send the
+// changes of the following ones
+func (api *ErigonImpl) latestExecutedForAccountChanges(ctx context.Context) (uint64, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if api.historyV2(tx) {
+		return 0, fmt.Errorf("accountChanges subscriptions need the change sets, which history v2 doesn't keep")
+	}
+	latest, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestExecutedBlockNumber), tx, nil)
+	return latest, err
+}
+
+// readAccountChanges returns the changes of the watched accounts by the blocks from to to, in block order
+func (api *ErigonImpl) readAccountChanges(ctx context.Context, watched map[common.Address]struct{}, from, to uint64) ([]*AccountChange, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// the watched accounts changed by each block, true if their storage changed
+	changed := make(map[uint64]map[common.Address]bool)
+	mark := func(blockN uint64, address common.Address, storage bool) {
+		if _, ok := watched[address]; !ok {
+			return
+		}
+		if changed[blockN] == nil {
+			changed[blockN] = make(map[common.Address]bool)
+		}
+		changed[blockN][address] = changed[blockN][address] || storage
+	}
+	if err = changeset.ForRange(tx, kv.AccountChangeSet, from, to+1, func(blockN uint64, k, _ []byte) error {
+		mark(blockN, common.BytesToAddress(k), false)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if err = changeset.ForRange(tx, kv.StorageChangeSet, from, to+1, func(blockN uint64, k, _ []byte) error {
+		mark(blockN, common.BytesToAddress(k[:common.AddressLength]), true)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	latest, err := stages.GetStageProgress(tx, stages.IntermediateHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	blockNums := make([]uint64, 0, len(changed))
+	for blockN := range changed {
+		blockNums = append(blockNums, blockN)
+	}
+	sort.Slice(blockNums, func(i, j int) bool { return blockNums[i] < blockNums[j] })
+	var changes []*AccountChange
+	for _, blockN := range blockNums {
+		blockHash, err := rawdb.ReadCanonicalHash(tx, blockN)
+		if err != nil {
+			return nil, err
+		}
+		// the storage roots are computed in one go for the accounts whose storage changed
+		var tr *trie.Trie
+		storageChanged := make(map[common.Address][]common.Hash)
+		for address, storage := range changed[blockN] {
+			if storage {
+				storageChanged[address] = nil
+			}
+		}
+		if len(storageChanged) > 0 && blockN <= latest && latest-blockN <= accountChangesMaxRewindBlocks {
+			if tr, err = api.stateTrie(ctx, tx, blockN, accountChangesMaxRewindBlocks, storageChanged, "erigon_accountChanges"); err != nil {
+				return nil, err
+			}
+		}
+
+		reader := state.NewPlainState(tx, blockN+1)
+		addresses := make([]common.Address, 0, len(changed[blockN]))
+		for address := range changed[blockN] {
+			addresses = append(addresses, address)
+		}
+		sort.Slice(addresses, func(i, j int) bool { return bytes.Compare(addresses[i][:], addresses[j][:]) < 0 })
+		for _, address := range addresses {
+			change := &AccountChange{
+				BlockNumber: hexutil.Uint64(blockN),
+				BlockHash:   blockHash,
+				Address:     address,
+				Balance:     new(hexutil.Big),
+			}
+			acc, err := reader.ReadAccountData(address)
+			if err != nil {
+				return nil, err
+			}
+			if acc != nil {
+				change.Balance = (*hexutil.Big)(acc.Balance.ToBig())
+				change.Nonce = hexutil.Uint64(acc.Nonce)
+			}
+			if _, ok := storageChanged[address]; ok && tr != nil {
+				root := trie.EmptyRoot
+				addrHash, err := common.HashData(address[:])
+				if err != nil {
+					return nil, err
+				}
+				if account, ok := tr.GetAccount(addrHash[:]); ok && account != nil {
+					root = account.Root
+				}
+				change.StorageRoot = &root
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
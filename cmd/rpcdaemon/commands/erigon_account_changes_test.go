@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountChangesSubscription(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db := rpcdaemontest.CreateTestKV(t)
+	ff := rpchelper.New(ctx, nil, nil, nil, func() {})
+	base := NewBaseApi(ff, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false)
+	ethApi := NewEthAPI(base, db, nil, nil, nil, 5000000)
+	ethApi.MaxGetProofRewindBlocks = accountChangesMaxRewindBlocks
+
+	sender := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	key1, _ := crypto.HexToECDSA("49a7b37aa6f6645917e7b807e9d1c00d4fa71f18343b0d4122a4d2df64dd6fee")
+	minter := crypto.PubkeyToAddress(key1.PublicKey)
+	// the token deployed by the sender in block 3, which the minter mints in block 4
+	token := crypto.CreateAddress(sender, 2)
+
+	block4 := rpc.BlockNumberOrHashWithNumber(4)
+	minterBalance, err := ethApi.GetBalance(ctx, minter, block4)
+	require.NoError(t, err)
+	minterNonce, err := ethApi.GetTransactionCount(ctx, minter, block4)
+	require.NoError(t, err)
+	tokenProof, err := ethApi.GetProof(ctx, token, nil, block4)
+	require.NoError(t, err)
+	var header4 []byte
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		header4, err = rlp.EncodeToBytes(rawdb.ReadHeaderByNumber(tx, 4))
+		return err
+	}))
+
+	server := rpc.NewServer(50, false /* traceRequests */, true)
+	require.NoError(t, server.RegisterName("erigon", NewErigonAPI(base, db, nil, nil)))
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	changes := make(chan AccountChange)
+	sub, err := client.Subscribe(ctx, "erigon", changes, "accountChanges", []common.Address{token, minter, sender})
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	// a head behind the latest block is a reorg, its block is sent again
+	ff.OnNewEvent(&remote.SubscribeReply{Type: remote.Event_HEADER, Data: header4})
+	received := make(map[common.Address]AccountChange)
+	for i := 0; i < 2; i++ {
+		select {
+		case change := <-changes:
+			received[change.Address] = change
+		case err := <-sub.Err():
+			t.Fatal(err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout waiting for an account change")
+		}
+	}
+	require.Len(t, received, 2)
+
+	minterChange := received[minter]
+	require.Equal(t, uint64(4), uint64(minterChange.BlockNumber))
+	require.Equal(t, minterBalance.ToInt(), minterChange.Balance.ToInt())
+	require.Equal(t, *minterNonce, minterChange.Nonce)
+	require.Nil(t, minterChange.StorageRoot, "the storage of the minter is unchanged")
+
+	tokenChange := received[token]
+	require.Equal(t, minterChange.BlockHash, tokenChange.BlockHash)
+	require.NotNil(t, tokenChange.StorageRoot)
+	require.Equal(t, tokenProof.StorageHash, *tokenChange.StorageRoot)
+
+	select {
+	case change := <-changes:
+		t.Fatalf("unexpected change of %x", change.Address)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPendingHeads(t *testing.T) {
+	heads := &pendingHeads{wake: make(chan struct{}, 1)}
+	_, _, ok := heads.take()
+	require.False(t, ok)
+
+	// the heads added meanwhile are merged, back to the lowest one after a reorg
+	heads.add(10)
+	heads.add(8)
+	heads.add(9)
+	require.Len(t, heads.wake, 1)
+	lowest, latest, ok := heads.take()
+	require.True(t, ok)
+	require.Equal(t, uint64(8), lowest)
+	require.Equal(t, uint64(9), latest)
+
+	heads.add(11)
+	lowest, latest, ok = heads.take()
+	require.True(t, ok)
+	require.Equal(t, uint64(11), lowest)
+	require.Equal(t, uint64(11), latest)
+	_, _, ok = heads.take()
+	require.False(t, ok)
+}
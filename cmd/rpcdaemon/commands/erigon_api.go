@@ -5,10 +5,12 @@ import (
 
 	ethFilters "github.com/ledgerwatch/erigon/eth/filters"
 
+	proto_txpool "github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
@@ -18,10 +20,12 @@ import (
 type ErigonAPI interface {
 	// System related (see ./erigon_system.go)
 	Forks(ctx context.Context) (Forks, error)
+	StagesProgress(ctx context.Context) (map[stages.SyncStage]hexutil.Uint64, error)
 
 	// Blocks related (see ./erigon_blocks.go)
 	GetHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error)
 	GetHeaderByHash(_ context.Context, hash common.Hash) (*types.Header, error)
+	GetHeadersByRange(ctx context.Context, fromBlock, toBlock rpc.BlockNumber, limit uint64) ([]*types.Header, error)
 	GetBlockByTimestamp(ctx context.Context, timeStamp rpc.Timestamp, fullTx bool) (map[string]interface{}, error)
 	GetBalanceChangesInBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (map[common.Address]*hexutil.Big, error)
 
@@ -29,6 +33,9 @@ type ErigonAPI interface {
 	GetLogsByHash(ctx context.Context, hash common.Hash) ([][]*types.Log, error)
 	//GetLogsByNumber(ctx context.Context, number rpc.BlockNumber) ([][]*types.Log, error)
 	GetLogs(ctx context.Context, crit ethFilters.FilterCriteria) (types.ErigonLogs, error)
+	GetLogsWithCoverage(ctx context.Context, crit ethFilters.FilterCriteria) (*LogsWithCoverage, error)
+	GetLatestLogs(ctx context.Context, crit ethFilters.FilterCriteria, limit uint64) (types.ErigonLogs, error)
+	GetLogsByTimeRange(ctx context.Context, crit ethFilters.FilterCriteria, fromTimestamp, toTimestamp rpc.Timestamp) (types.ErigonLogs, error)
 
 	// WatchTheBurn / reward related (see ./erigon_issuance.go)
 	WatchTheBurn(ctx context.Context, blockNr rpc.BlockNumber) (Issuance, error)
@@ -36,6 +43,9 @@ type ErigonAPI interface {
 	// CumulativeChainTraffic / related to chain traffic (see ./erigon_cumulative_index.go)
 	CumulativeChainTraffic(ctx context.Context, blockNr rpc.BlockNumber) (ChainTraffic, error)
 
+	// ChainStats / daily or per-epoch activity rollups (see ./erigon_chain_stats.go)
+	ChainStats(ctx context.Context, fromBlock, toBlock rpc.BlockNumber, period string) ([]*ChainStats, error)
+
 	// NodeInfo returns a collection of metadata known about the host.
 	NodeInfo(ctx context.Context) ([]p2p.NodeInfo, error)
 }
@@ -45,13 +55,18 @@ type ErigonImpl struct {
 	*BaseAPI
 	db         kv.RoDB
 	ethBackend rpchelper.ApiBackend
+	txPool     proto_txpool.TxpoolClient
+
+	txpoolEvents *txpoolEventsFeed
 }
 
 // NewErigonAPI returns ErigonImpl instance
-func NewErigonAPI(base *BaseAPI, db kv.RoDB, eth rpchelper.ApiBackend) *ErigonImpl {
+func NewErigonAPI(base *BaseAPI, db kv.RoDB, eth rpchelper.ApiBackend, txPool proto_txpool.TxpoolClient) *ErigonImpl {
 	return &ErigonImpl{
-		BaseAPI:    base,
-		db:         db,
-		ethBackend: eth,
+		BaseAPI:      base,
+		db:           db,
+		ethBackend:   eth,
+		txPool:       txPool,
+		txpoolEvents: newTxpoolEventsFeed(txPool, db, base.filters, base.chainConfig),
 	}
 }
@@ -74,6 +74,65 @@ func (api *ErigonImpl) GetHeaderByHash(ctx context.Context, hash common.Hash) (*
 	return header, nil
 }
 
+// headersByRangeMaxLimit is the maximum number of headers returned by erigon_getHeadersByRange
+const headersByRangeMaxLimit = 1024
+
+// GetHeadersByRange implements erigon_getHeadersByRange. Returns the canonical headers from fromBlock to toBlock, at
+// most limit of them (and at most headersByRangeMaxLimit), all read in the same transaction. The range stops at the
+// latest block, a caller walking a longer range continues after the last header returned.
+func (api *ErigonImpl) GetHeadersByRange(ctx context.Context, fromBlock, toBlock rpc.BlockNumber, limit uint64) ([]*types.Header, error) {
+	if limit == 0 {
+		return nil, fmt.Errorf("limit must be greater than 0")
+	}
+	if limit > headersByRangeMaxLimit {
+		limit = headersByRangeMaxLimit
+	}
+	if fromBlock == rpc.PendingBlockNumber || toBlock == rpc.PendingBlockNumber {
+		return nil, fmt.Errorf("pending block isn't supported by erigon_getHeadersByRange")
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	from, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(fromBlock), tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	to, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(toBlock), tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("fromBlock %d is after toBlock %d", from, to)
+	}
+	latest, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	if to > latest {
+		to = latest
+	}
+	if to-from >= limit {
+		to = from + limit - 1
+	}
+
+	headers := make([]*types.Header, 0, to-from+1)
+	for number := from; number <= to; number++ {
+		header, err := api._blockReader.HeaderByNumber(ctx, tx, number)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("block header not found: %d", number)
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
 func (api *ErigonImpl) GetBlockByTimestamp(ctx context.Context, timeStamp rpc.Timestamp, fullTx bool) (map[string]interface{}, error) {
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
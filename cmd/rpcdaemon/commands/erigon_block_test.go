@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHeadersByRange(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil)
+
+	headers, err := api.GetHeadersByRange(ctx, 2, 6, 100)
+	require.NoError(t, err)
+	require.Len(t, headers, 5)
+	for i, header := range headers {
+		expected, err := api.GetHeaderByNumber(ctx, rpc.BlockNumber(2+i))
+		require.NoError(t, err)
+		require.Equal(t, expected.Hash(), header.Hash())
+		if i > 0 {
+			require.Equal(t, headers[i-1].Hash(), header.ParentHash)
+		}
+	}
+
+	// the range is cut by the limit and the latest block
+	headers, err = api.GetHeadersByRange(ctx, 2, 6, 2)
+	require.NoError(t, err)
+	require.Len(t, headers, 2)
+	require.Equal(t, uint64(3), headers[1].Number.Uint64())
+	headers, err = api.GetHeadersByRange(ctx, 8, 1000, 100)
+	require.NoError(t, err)
+	require.Len(t, headers, 3)
+	require.Equal(t, uint64(10), headers[2].Number.Uint64())
+
+	_, err = api.GetHeadersByRange(ctx, 6, 2, 100)
+	require.Error(t, err)
+	_, err = api.GetHeadersByRange(ctx, 2, 6, 0)
+	require.Error(t, err)
+}
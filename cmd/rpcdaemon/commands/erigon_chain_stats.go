@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+const (
+	ChainStatsPeriodDay   = "day"   // UTC calendar days, by block timestamp
+	ChainStatsPeriodEpoch = "epoch" // fixed windows of stagedsync.ChainStatsEpochLength blocks
+
+	chainStatsMaxPeriods = 1024
+	// chainStatsMaxScannedBlocks bounds the blocks read by a call for the periods without rollups: the periods in
+	// progress, cut by the requested range, or all of them without the ChainStats stage
+	chainStatsMaxScannedBlocks = 50_000
+)
+
+// ChainStats is a rollup of chain activity over one period
+type ChainStats struct {
+	FromBlock     hexutil.Uint64 `json:"fromBlock"`
+	ToBlock       hexutil.Uint64 `json:"toBlock"`
+	FromTimestamp hexutil.Uint64 `json:"fromTimestamp"`
+	ToTimestamp   hexutil.Uint64 `json:"toTimestamp"`
+	TxCount       hexutil.Uint64 `json:"txCount"`
+	GasUsed       hexutil.Uint64 `json:"gasUsed"`
+	UniqueSenders hexutil.Uint64 `json:"uniqueSenders"`
+	AvgBaseFee    *hexutil.Big   `json:"avgBaseFee,omitempty"`
+	Complete      bool           `json:"complete"` // false if the period is cut by the requested range or the chain head
+}
+
+// ChainStats implements erigon_chainStats. Returns per-period rollups (transaction count, gas used, unique senders
+// and average base fee) of the canonical blocks in [fromBlock, toBlock]. Period is either "day" or "epoch".
+// The complete periods are read from the rollups written by the ChainStats stage (--chain-stats), the others are
+// computed from the blocks, up to chainStatsMaxScannedBlocks of them per call.
+func (api *ErigonImpl) ChainStats(ctx context.Context, fromBlock, toBlock rpc.BlockNumber, period string) ([]*ChainStats, error) {
+	var code byte
+	switch period {
+	case ChainStatsPeriodDay:
+		code = stagedsync.ChainStatsDay
+	case ChainStatsPeriodEpoch:
+		code = stagedsync.ChainStatsEpoch
+	default:
+		return nil, fmt.Errorf("unknown period %q, expected %q or %q", period, ChainStatsPeriodDay, ChainStatsPeriodEpoch)
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	from, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(fromBlock), tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	to, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(toBlock), tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("fromBlock %d is after toBlock %d", from, to)
+	}
+	head := rawdb.ReadCurrentBlockNumber(tx)
+	if head == nil {
+		return nil, fmt.Errorf("current block not found")
+	}
+	if to > *head {
+		to = *head
+	}
+
+	var result []*ChainStats
+	var scanned uint64
+	for start := from; start <= to; {
+		if len(result) >= chainStatsMaxPeriods {
+			return nil, fmt.Errorf("range spans more than %d periods, narrow it down", chainStatsMaxPeriods)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end, complete, err := stagedsync.ChainStatsPeriodEnd(ctx, tx, api._blockReader, code, start, *head)
+		if err != nil {
+			return nil, err
+		}
+		if end > to {
+			end, complete = to, false
+		}
+		var stats *rawdb.ChainStats
+		if complete {
+			if stats, err = rawdb.ReadChainStats(tx, code, start); err != nil {
+				return nil, err
+			}
+		}
+		if stats == nil || stats.ToBlock != end {
+			if scanned += end - start + 1; scanned > chainStatsMaxScannedBlocks {
+				return nil, fmt.Errorf("range needs reading more than %d blocks without rollups, narrow it down or enable the ChainStats stage with --chain-stats", chainStatsMaxScannedBlocks)
+			}
+			if stats, err = stagedsync.ComputeChainStats(ctx, tx, api._blockReader, start, end); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, newChainStats(stats, complete))
+		start = end + 1
+	}
+	return result, nil
+}
+
+func newChainStats(stats *rawdb.ChainStats, complete bool) *ChainStats {
+	res := &ChainStats{
+		FromBlock:     hexutil.Uint64(stats.FromBlock),
+		ToBlock:       hexutil.Uint64(stats.ToBlock),
+		FromTimestamp: hexutil.Uint64(stats.FromTimestamp),
+		ToTimestamp:   hexutil.Uint64(stats.ToTimestamp),
+		TxCount:       hexutil.Uint64(stats.TxCount),
+		GasUsed:       hexutil.Uint64(stats.GasUsed),
+		UniqueSenders: hexutil.Uint64(stats.UniqueSenders),
+		Complete:      complete,
+	}
+	if stats.BaseFeeBlocks > 0 {
+		avg := new(big.Int).Div(stats.BaseFeeSum, new(big.Int).SetUint64(stats.BaseFeeBlocks))
+		res.AvgBaseFee = (*hexutil.Big)(avg)
+	}
+	return res
+}
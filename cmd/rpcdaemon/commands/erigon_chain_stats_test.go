@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainStats(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil)
+
+	var head uint64
+	var txCount, gasUsed uint64
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	head = *rawdb.ReadCurrentBlockNumber(tx)
+	for n := uint64(0); n <= head; n++ {
+		block, err := rawdb.ReadBlockByNumber(tx, n)
+		require.NoError(t, err)
+		txCount += uint64(len(block.Transactions()))
+		gasUsed += block.GasUsed()
+	}
+	tx.Rollback()
+
+	for _, period := range []string{ChainStatsPeriodEpoch, ChainStatsPeriodDay} {
+		stats, err := api.ChainStats(ctx, 0, rpc.LatestBlockNumber, period)
+		require.NoError(t, err)
+		require.NotEmpty(t, stats)
+		require.Equal(t, uint64(0), uint64(stats[0].FromBlock))
+		require.Equal(t, head, uint64(stats[len(stats)-1].ToBlock))
+		var gotTxs, gotGas uint64
+		for i, s := range stats {
+			if i > 0 {
+				require.Equal(t, uint64(stats[i-1].ToBlock)+1, uint64(s.FromBlock))
+			}
+			gotTxs += uint64(s.TxCount)
+			gotGas += uint64(s.GasUsed)
+		}
+		require.Equal(t, txCount, gotTxs, period)
+		require.Equal(t, gasUsed, gotGas, period)
+	}
+
+	_, err = api.ChainStats(ctx, 0, rpc.LatestBlockNumber, "week")
+	require.Error(t, err)
+}
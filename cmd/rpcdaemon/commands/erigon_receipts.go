@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"math"
+	"sort"
 
 	"github.com/RoaringBitmap/roaring"
 	"github.com/ledgerwatch/erigon-lib/kv"
@@ -13,7 +15,6 @@ import (
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/filters"
-	"github.com/ledgerwatch/erigon/ethdb/bitmapdb"
 	"github.com/ledgerwatch/erigon/ethdb/cbor"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
@@ -99,34 +100,10 @@ func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria)
 	if end > roaring.MaxUint32 {
 		return nil, fmt.Errorf("end (%d) > MaxUint32", end)
 	}
-	blockNumbers := roaring.New()
-	blockNumbers.AddRange(begin, end+1) // [min,max)
-
-	topicsBitmap, err := getTopicsBitmap(tx, crit.Topics, uint32(begin), uint32(end))
+	blockNumbers, _, err := logsBlockNumbers(tx, begin, end, crit)
 	if err != nil {
 		return nil, err
 	}
-	if topicsBitmap != nil {
-		blockNumbers.And(topicsBitmap)
-	}
-
-	var addrBitmap *roaring.Bitmap
-	for _, addr := range crit.Addresses {
-		m, err := bitmapdb.Get(tx, kv.LogAddressIndex, addr[:], uint32(begin), uint32(end))
-		if err != nil {
-			return nil, err
-		}
-		if addrBitmap == nil {
-			addrBitmap = m
-			continue
-		}
-		addrBitmap = roaring.Or(addrBitmap, m)
-	}
-
-	if addrBitmap != nil {
-		blockNumbers.And(addrBitmap)
-	}
-
 	if blockNumbers.GetCardinality() == 0 {
 		return erigonLogs, nil
 	}
@@ -203,6 +180,217 @@ func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria)
 	return erigonLogs, nil
 }
 
+// LogsWithCoverage are the logs matching a filter object with how much of its range the log indices covered
+type LogsWithCoverage struct {
+	Logs          types.Logs         `json:"logs"`
+	IndexCoverage *LogsIndexCoverage `json:"indexCoverage"`
+}
+
+// GetLogsWithCoverage implements erigon_getLogsWithCoverage. Returns the logs matching a given filter object, like
+// eth_getLogs, with how much of the range the log indices covered: the blocks not indexed yet are read whatever the
+// addresses and topics of the filter, which is slow on wide ranges.
+func (api *ErigonImpl) GetLogsWithCoverage(ctx context.Context, crit filters.FilterCriteria) (*LogsWithCoverage, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	begin, end, err := api.logsRange(ctx, tx, crit)
+	if err != nil {
+		return nil, err
+	}
+	logs, coverage, err := api.getLogsInRange(ctx, api.db, tx, begin, end, crit, GetLogsLimits{})
+	if err != nil {
+		return nil, err
+	}
+	return &LogsWithCoverage{Logs: logs, IndexCoverage: coverage}, nil
+}
+
+// latestLogsMaxLimit is the maximum number of logs returned by erigon_getLatestLogs, greater limits are lowered to it
+const latestLogsMaxLimit = 10_000
+
+// GetLatestLogs implements erigon_getLatestLogs. Returns the limit most recent logs matching a given filter object,
+// most recent first, latestLogsMaxLimit at most. The blocks are searched backwards from crit.ToBlock, or from the
+// head if it's not set, down to crit.FromBlock, or to the genesis if it's not set.
+func (api *ErigonImpl) GetLatestLogs(ctx context.Context, crit filters.FilterCriteria, limit uint64) (types.ErigonLogs, error) {
+	if limit == 0 {
+		return nil, fmt.Errorf("limit must be greater than 0")
+	}
+	if limit > latestLogsMaxLimit {
+		limit = latestLogsMaxLimit
+	}
+	if crit.BlockHash != nil {
+		return nil, fmt.Errorf("blockHash is not supported, use erigon_getLogs")
+	}
+	erigonLogs := types.ErigonLogs{}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return erigonLogs, err
+	}
+	defer tx.Rollback()
+
+	latest, err := rpchelper.GetLatestBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+	var begin uint64
+	if crit.FromBlock != nil {
+		if crit.FromBlock.Sign() >= 0 {
+			begin = crit.FromBlock.Uint64()
+		} else if !crit.FromBlock.IsInt64() || crit.FromBlock.Int64() != int64(rpc.LatestBlockNumber) {
+			return nil, fmt.Errorf("negative value for FromBlock: %v", crit.FromBlock)
+		} else {
+			begin = latest
+		}
+	}
+	end := latest
+	if crit.ToBlock != nil {
+		if crit.ToBlock.Sign() >= 0 {
+			end = crit.ToBlock.Uint64()
+		} else if !crit.ToBlock.IsInt64() || crit.ToBlock.Int64() != int64(rpc.LatestBlockNumber) {
+			return nil, fmt.Errorf("negative value for ToBlock: %v", crit.ToBlock)
+		}
+	}
+	if end < begin {
+		return nil, fmt.Errorf("end (%d) < begin (%d)", end, begin)
+	}
+	if end > roaring.MaxUint32 {
+		return nil, fmt.Errorf("end (%d) > MaxUint32", end)
+	}
+
+	blockNumbers, _, err := logsBlockNumbers(tx, begin, end, crit)
+	if err != nil {
+		return nil, err
+	}
+	iter := blockNumbers.ReverseIterator()
+	for iter.HasNext() && uint64(len(erigonLogs)) < limit {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		blockNumber := uint64(iter.Next())
+		blockLogs, err := api.getBlockLogs(ctx, tx, blockNumber, crit)
+		if err != nil {
+			return nil, err
+		}
+		if len(blockLogs) == 0 {
+			continue
+		}
+		header, err := api._blockReader.HeaderByNumber(ctx, tx, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("block header not found: %d", blockNumber)
+		}
+		for i := len(blockLogs) - 1; i >= 0 && uint64(len(erigonLogs)) < limit; i-- {
+			erigonLogs = append(erigonLogs, &types.ErigonLog{Log: *blockLogs[i], Timestamp: header.Time})
+		}
+	}
+
+	return erigonLogs, nil
+}
+
+// GetLogsByTimeRange implements erigon_getLogsByTimeRange. Returns the logs matching the addresses and topics of a
+// filter object in the blocks with a timestamp between fromTimestamp and toTimestamp, both inclusive.
+func (api *ErigonImpl) GetLogsByTimeRange(ctx context.Context, crit filters.FilterCriteria, fromTimestamp, toTimestamp rpc.Timestamp) (types.ErigonLogs, error) {
+	if crit.BlockHash != nil || crit.FromBlock != nil || crit.ToBlock != nil {
+		return nil, fmt.Errorf("blockHash, fromBlock and toBlock are not supported, the blocks are given by the time range")
+	}
+	if toTimestamp < fromTimestamp {
+		return nil, fmt.Errorf("toTimestamp (%d) < fromTimestamp (%d)", toTimestamp, fromTimestamp)
+	}
+	erigonLogs := types.ErigonLogs{}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return erigonLogs, err
+	}
+	defer tx.Rollback()
+
+	latest, err := rpchelper.GetLatestBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+	begin, err := api.blockNumberByTime(ctx, tx, fromTimestamp.TurnIntoUint64(), latest)
+	if err != nil {
+		return nil, err
+	}
+	// the last block of the range is the one before the first block after it
+	end := latest + 1
+	if to := toTimestamp.TurnIntoUint64(); to < math.MaxUint64 {
+		if end, err = api.blockNumberByTime(ctx, tx, to+1, latest); err != nil {
+			return nil, err
+		}
+	}
+	if end <= begin {
+		return erigonLogs, nil
+	}
+	end--
+	if end > roaring.MaxUint32 {
+		return nil, fmt.Errorf("end (%d) > MaxUint32", end)
+	}
+
+	blockNumbers, _, err := logsBlockNumbers(tx, begin, end, crit)
+	if err != nil {
+		return nil, err
+	}
+	iter := blockNumbers.Iterator()
+	for iter.HasNext() {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		blockNumber := uint64(iter.Next())
+		blockLogs, err := api.getBlockLogs(ctx, tx, blockNumber, crit)
+		if err != nil {
+			return nil, err
+		}
+		if len(blockLogs) == 0 {
+			continue
+		}
+		header, err := api._blockReader.HeaderByNumber(ctx, tx, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("block header not found: %d", blockNumber)
+		}
+		for _, log := range blockLogs {
+			erigonLogs = append(erigonLogs, &types.ErigonLog{Log: *log, Timestamp: header.Time})
+		}
+	}
+
+	return erigonLogs, nil
+}
+
+// blockNumberByTime returns the number of the first block with a timestamp at or after the given one, or latest+1 if
+// there is none. The timestamps of the canonical headers increase with their numbers, so they are binary searched.
+func (api *ErigonImpl) blockNumberByTime(ctx context.Context, tx kv.Tx, timestamp uint64, latest uint64) (uint64, error) {
+	var searchErr error
+	n := sort.Search(int(latest)+1, func(i int) bool {
+		if searchErr != nil {
+			return true
+		}
+		header, err := api._blockReader.HeaderByNumber(ctx, tx, uint64(i))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		if header == nil {
+			searchErr = fmt.Errorf("block header not found: %d", i)
+			return true
+		}
+		return header.Time >= timestamp
+	})
+	if searchErr != nil {
+		return 0, searchErr
+	}
+	return uint64(n), nil
+}
+
 // GetLogsByNumber implements erigon_getLogsByHash. Returns all the logs that appear in a block given the block's hash.
 // func (api *ErigonImpl) GetLogsByNumber(ctx context.Context, number rpc.BlockNumber) ([][]*types.Log, error) {
 // 	tx, err := api.db.Begin(ctx, false)
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLatestLogs(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	base := NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false)
+	api := NewErigonAPI(base, db, nil, nil)
+
+	all, err := NewEthAPI(base, db, nil, nil, nil, 5000000).GetLogs(ctx, filters.FilterCriteria{FromBlock: common.Big0})
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+
+	for _, limit := range []int{1, len(all), len(all) + 1} {
+		latest, err := api.GetLatestLogs(ctx, filters.FilterCriteria{}, uint64(limit))
+		require.NoError(t, err)
+		if limit > len(all) {
+			limit = len(all)
+		}
+		require.Len(t, latest, limit)
+		for i, lg := range latest {
+			want := all[len(all)-1-i]
+			require.Equal(t, want.BlockNumber, lg.Log.BlockNumber)
+			require.Equal(t, want.TxHash, lg.Log.TxHash)
+			require.Equal(t, want.Index, lg.Log.Index)
+			require.NotZero(t, lg.Timestamp)
+		}
+	}
+
+	// the search stops at ToBlock
+	last := all[len(all)-1].BlockNumber
+	latest, err := api.GetLatestLogs(ctx, filters.FilterCriteria{ToBlock: new(big.Int).SetUint64(last - 1)}, uint64(len(all)))
+	require.NoError(t, err)
+	var before int
+	for _, lg := range all {
+		if lg.BlockNumber < last {
+			before++
+		}
+	}
+	require.Len(t, latest, before)
+
+	// the limit is lowered to the maximum
+	latest, err = api.GetLatestLogs(ctx, filters.FilterCriteria{}, math.MaxUint64)
+	require.NoError(t, err)
+	require.Len(t, latest, len(all))
+
+	_, err = api.GetLatestLogs(ctx, filters.FilterCriteria{}, 0)
+	require.Error(t, err)
+}
+
+func TestGetLogsByTimeRange(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	base := NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false)
+	api := NewErigonAPI(base, db, nil, nil)
+
+	all, err := NewEthAPI(base, db, nil, nil, nil, 5000000).GetLogs(ctx, filters.FilterCriteria{FromBlock: common.Big0})
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+
+	timeOf := func(number uint64) rpc.Timestamp {
+		header, err := api.GetHeaderByNumber(ctx, rpc.BlockNumber(number))
+		require.NoError(t, err)
+		return rpc.Timestamp(header.Time)
+	}
+	last := all[len(all)-1].BlockNumber
+	require.Less(t, timeOf(last-1), timeOf(last)-1)
+	for _, tt := range []struct {
+		from, to rpc.Timestamp
+		begin    uint64 // the first block of the range
+		end      uint64 // the last block of the range
+	}{
+		{0, math.MaxUint64, 0, last},
+		{timeOf(last), timeOf(last), last, last},
+		{timeOf(last - 1), timeOf(last), last - 1, last},
+		{0, timeOf(last) - 1, 0, last - 1},
+		{timeOf(last) + 1, math.MaxUint64, last + 1, last + 1},
+		// between two blocks
+		{timeOf(last-1) + 1, timeOf(last) - 1, last, last - 1},
+	} {
+		logs, err := api.GetLogsByTimeRange(ctx, filters.FilterCriteria{}, tt.from, tt.to)
+		require.NoError(t, err)
+		var want []*types.Log
+		for _, lg := range all {
+			if lg.BlockNumber >= tt.begin && lg.BlockNumber <= tt.end {
+				want = append(want, lg)
+			}
+		}
+		require.Len(t, logs, len(want), "from %d to %d", tt.from, tt.to)
+		for i, lg := range logs {
+			require.Equal(t, want[i].TxHash, lg.Log.TxHash)
+			require.Equal(t, want[i].Index, lg.Log.Index)
+			require.Equal(t, uint64(timeOf(lg.Log.BlockNumber)), lg.Timestamp)
+		}
+	}
+
+	// the logs are filtered by address
+	logs, err := api.GetLogsByTimeRange(ctx, filters.FilterCriteria{Addresses: []common.Address{{1}}}, 0, math.MaxUint64)
+	require.NoError(t, err)
+	require.Empty(t, logs)
+
+	_, err = api.GetLogsByTimeRange(ctx, filters.FilterCriteria{}, 2, 1)
+	require.Error(t, err)
+	_, err = api.GetLogsByTimeRange(ctx, filters.FilterCriteria{FromBlock: common.Big0}, 0, 1)
+	require.Error(t, err)
+}
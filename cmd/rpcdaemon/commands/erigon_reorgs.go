@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// reorgsMaxDepth is the number of canonical blocks remembered by the reorgs subscriptions, the reorgs replacing
+// older blocks aren't sent
+const reorgsMaxDepth = 128
+
+// ReorgBlock is a block of a ReorgEvent
+type ReorgBlock struct {
+	Number hexutil.Uint64 `json:"number"`
+	Hash   common.Hash    `json:"hash"`
+}
+
+// ReorgEvent is a reorganization of the canonical chain: the blocks after the common ancestor were replaced, the
+// removed and added hashes are in block order
+type ReorgEvent struct {
+	CommonAncestor ReorgBlock    `json:"commonAncestor"`
+	Removed        []common.Hash `json:"removed"`
+	Added          []common.Hash `json:"added"`
+}
+
+// Reorgs implements erigon_subscribe("reorgs"). Sends an event each time a new head isn't a descendant of the
+// previous one, with the blocks removed from and added to the canonical chain up to the new head.
+func (api *ErigonImpl) Reorgs(ctx context.Context) (*rpc.Subscription, error) {
+	if api.filters == nil {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	// the new heads are subscribed to before the canonical chain is read, not to miss the blocks added meanwhile
+	headers := make(chan *types.Header, 1)
+	id := api.filters.SubscribeNewHeads(headers)
+	chain, err := api.readReorgsChain(ctx)
+	if err != nil {
+		api.filters.UnsubscribeHeads(id)
+		return &rpc.Subscription{}, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer debug.LogPanic()
+		defer api.filters.UnsubscribeHeads(id)
+
+		for {
+			select {
+			case h, ok := <-headers:
+				if h != nil {
+					event, err := api.newHeadForReorgs(chain, h)
+					if err != nil {
+						log.Warn("error while looking for a reorg", "err", err)
+						return
+					}
+					if event != nil {
+						if err := notifier.Notify(rpcSub.ID, event); err != nil {
+							log.Warn("error while notifying subscription", "err", err)
+							return
+						}
+					}
+				}
+				if !ok {
+					log.Warn("new heads channel was closed")
+					return
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// readReorgsChain returns the last canonical blocks, up to reorgsMaxDepth of them
+func (api *ErigonImpl) readReorgsChain(ctx context.Context) (*reorgsChain, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	latest, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), tx, nil)
+	if err != nil {
+		return nil, err
+	}
+	chain := newReorgsChain()
+	for i := uint64(0); i < reorgsMaxDepth && i <= latest; i++ {
+		hash, err := api._blockReader.CanonicalHash(ctx, tx, latest-i)
+		if err != nil {
+			return nil, err
+		}
+		if hash == (common.Hash{}) {
+			break
+		}
+		chain.hashes[latest-i] = hash
+	}
+	chain.head = latest
+	return chain, nil
+}
+
+// newHeadForReorgs adds the new head h to chain, and returns the reorg it made if any
+func (api *ErigonImpl) newHeadForReorgs(chain *reorgsChain, h *types.Header) (*ReorgEvent, error) {
+	var tx kv.Tx
+	defer func() {
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+	// the parents of h are read until one of the chain is found
+	parent := func(hash common.Hash, number uint64) (common.Hash, bool, error) {
+		if tx == nil {
+			var err error
+			if tx, err = api.db.BeginRo(context.Background()); err != nil {
+				return common.Hash{}, false, err
+			}
+		}
+		header, err := api._blockReader.Header(context.Background(), tx, hash, number)
+		if err != nil || header == nil {
+			return common.Hash{}, false, err
+		}
+		return header.ParentHash, true, nil
+	}
+	return chain.add(h.Hash(), h.Number.Uint64(), h.ParentHash, parent)
+}
+
+// reorgsChain is the canonical chain seen by a reorgs subscription, its last blocks by number
+type reorgsChain struct {
+	hashes map[uint64]common.Hash
+	head   uint64
+}
+
+func newReorgsChain() *reorgsChain {
+	return &reorgsChain{hashes: make(map[uint64]common.Hash)}
+}
+
+// add makes the block hash the head of the chain, and returns the reorg it made if any. parent returns the parent
+// hash of a block, false if the block is unknown. When no common ancestor is found within reorgsMaxDepth blocks, the
+// chain restarts from the block without any event.
+func (c *reorgsChain) add(hash common.Hash, number uint64, parentHash common.Hash,
+	parent func(hash common.Hash, number uint64) (common.Hash, bool, error)) (*ReorgEvent, error) {
+	// the new blocks after the common ancestor, most recent first
+	added := []common.Hash{}
+	ancestor, found := number, c.hashes[number] == hash
+	for cur, curNumber := hash, number; !found && len(c.hashes) > 0 && curNumber > 0 && len(added) < reorgsMaxDepth; {
+		added = append(added, cur)
+		if c.hashes[curNumber-1] == parentHash {
+			ancestor, found = curNumber-1, true
+			break
+		}
+		var ok bool
+		var err error
+		cur, curNumber = parentHash, curNumber-1
+		if parentHash, ok, err = parent(cur, curNumber); err != nil {
+			return nil, err
+		} else if !ok {
+			break
+		}
+	}
+	if !found {
+		if len(c.hashes) > 0 {
+			log.Warn("no common ancestor found for the new head, reorgs subscription restarted", "number", number)
+		}
+		c.hashes = map[uint64]common.Hash{number: hash}
+		c.head = number
+		return nil, nil
+	}
+	for i, j := 0, len(added)-1; i < j; i, j = i+1, j-1 {
+		added[i], added[j] = added[j], added[i]
+	}
+
+	var event *ReorgEvent
+	if ancestor < c.head {
+		event = &ReorgEvent{CommonAncestor: ReorgBlock{Number: hexutil.Uint64(ancestor), Hash: c.hashes[ancestor]}, Added: added}
+		for n := ancestor + 1; n <= c.head; n++ {
+			event.Removed = append(event.Removed, c.hashes[n])
+			delete(c.hashes, n)
+		}
+	}
+	for i, hash := range added {
+		c.hashes[ancestor+1+uint64(i)] = hash
+	}
+	c.head = ancestor + uint64(len(added))
+	for n := range c.hashes {
+		if n+reorgsMaxDepth <= c.head {
+			delete(c.hashes, n)
+		}
+	}
+	return event, nil
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReorgsSubscription(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db := rpcdaemontest.CreateTestKV(t)
+	ff := rpchelper.New(ctx, nil, nil, nil, func() {})
+	base := NewBaseApi(ff, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false)
+
+	canonical := make(map[uint64]common.Hash)
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		for number := uint64(7); number <= 10; number++ {
+			hash, err := rawdb.ReadCanonicalHash(tx, number)
+			if err != nil {
+				return err
+			}
+			canonical[number] = hash
+		}
+		return nil
+	}))
+
+	server := rpc.NewServer(50, false /* traceRequests */, true)
+	require.NoError(t, server.RegisterName("erigon", NewErigonAPI(base, db, nil, nil)))
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	events := make(chan ReorgEvent)
+	sub, err := client.Subscribe(ctx, "erigon", events, "reorgs")
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	newHead := func(number uint64, parent common.Hash) *types.Header {
+		h := &types.Header{ParentHash: parent, Number: new(big.Int).SetUint64(number), Difficulty: big.NewInt(1), Extra: []byte("fork")}
+		data, err := rlp.EncodeToBytes(h)
+		require.NoError(t, err)
+		ff.OnNewEvent(&remote.SubscribeReply{Type: remote.Event_HEADER, Data: data})
+		return h
+	}
+
+	// a new block 8 replaces the blocks 8 to 10, then the fork is extended without reorg
+	fork8 := newHead(8, canonical[7])
+	select {
+	case event := <-events:
+		require.Equal(t, uint64(7), uint64(event.CommonAncestor.Number))
+		require.Equal(t, canonical[7], event.CommonAncestor.Hash)
+		require.Equal(t, []common.Hash{canonical[8], canonical[9], canonical[10]}, event.Removed)
+		require.Equal(t, []common.Hash{fork8.Hash()}, event.Added)
+	case err := <-sub.Err():
+		t.Fatal(err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for a reorg")
+	}
+	newHead(9, fork8.Hash())
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected reorg from %d", event.CommonAncestor.Number)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestReorgsChain(t *testing.T) {
+	hash := func(fork byte, number uint64) common.Hash {
+		return common.Hash{fork, byte(number)}
+	}
+	// the blocks 0 to 5 of the fork 1, 0 to 2 being shared with the fork 2
+	chain := newReorgsChain()
+	for number := uint64(0); number <= 5; number++ {
+		chain.hashes[number] = hash(1, number)
+	}
+	chain.head = 5
+	parent := func(h common.Hash, number uint64) (common.Hash, bool, error) {
+		if number <= 3 {
+			return hash(1, number-1), true, nil
+		}
+		return hash(h[0], number-1), true, nil
+	}
+
+	// the fork 2 up to the block 5 replaces the blocks 3 to 5, its parents being read
+	event, err := chain.add(hash(2, 5), 5, hash(2, 4), parent)
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	require.Equal(t, hash(1, 2), event.CommonAncestor.Hash)
+	require.Equal(t, []common.Hash{hash(1, 3), hash(1, 4), hash(1, 5)}, event.Removed)
+	require.Equal(t, []common.Hash{hash(2, 3), hash(2, 4), hash(2, 5)}, event.Added)
+
+	// skipped blocks aren't a reorg
+	event, err = chain.add(hash(2, 7), 7, hash(2, 6), parent)
+	require.NoError(t, err)
+	require.Nil(t, event)
+	require.Equal(t, uint64(7), chain.head)
+
+	// going back to a block of the chain removes the following ones
+	event, err = chain.add(hash(2, 6), 6, hash(2, 5), parent)
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	require.Equal(t, hash(2, 6), event.CommonAncestor.Hash)
+	require.Equal(t, []common.Hash{hash(2, 7)}, event.Removed)
+	require.Empty(t, event.Added)
+}
@@ -0,0 +1,237 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/log/v3"
+)
+
+const (
+	// logsReplayBatchSize is the number of blocks whose historical logs are read at once
+	logsReplayBatchSize = 1000
+	// logsReplayReorgDepth is the number of replayed blocks remembered to drop the live logs duplicating them
+	logsReplayReorgDepth = 128
+	// logsReplayMaxQueued is the maximum number of live logs queued while the history is sent, the subscription
+	// ends with an error beyond
+	logsReplayMaxQueued = 10_000
+)
+
+// LogsSubscriptionError is the last notification of a logs subscription which can't go on
+type LogsSubscriptionError struct {
+	Error string `json:"error"`
+}
+
+// Logs implements erigon_subscribe("logs"). Like the logs subscription of eth_subscribe, the logs matching crit are
+// sent as they appear. If crit.FromBlock is set, the matching logs from this block up to the head are sent first,
+// then the subscription switches to the new logs without missing nor repeating any. The subscription ends with a
+// LogsSubscriptionError if the history can't be read, or if more than logsReplayMaxQueued live logs arrive meanwhile.
+func (api *ErigonImpl) Logs(ctx context.Context, crit filters.FilterCriteria) (*rpc.Subscription, error) {
+	if api.filters == nil {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if crit.BlockHash != nil || crit.ToBlock != nil {
+		return &rpc.Subscription{}, fmt.Errorf("blockHash and toBlock are not supported by logs subscriptions")
+	}
+	replay := crit.FromBlock != nil && crit.FromBlock.Sign() >= 0
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer debug.LogPanic()
+		// the live logs are subscribed to before the history is read, the ones of the blocks read meanwhile are
+		// dropped by the handoff
+		logs := make(chan *types.Log, 1)
+		id := api.filters.SubscribeLogs(logs, crit)
+		defer api.filters.UnsubscribeLogs(id)
+
+		replayCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		history := make(chan logsBatch)
+		if replay {
+			go api.replayLogs(replayCtx, crit, crit.FromBlock.Uint64(), history)
+		} else {
+			close(history)
+		}
+
+		handoff := &logsHandoff{replaying: true, sent: make(map[common.Hash]uint64), maxQueued: logsReplayMaxQueued}
+		fail := func(err error) {
+			if err := notifier.Notify(rpcSub.ID, &LogsSubscriptionError{Error: err.Error()}); err != nil {
+				log.Warn("error while notifying subscription", "err", err)
+			}
+		}
+		notify := func(lgs []*types.Log) bool {
+			for _, lg := range lgs {
+				if err := notifier.Notify(rpcSub.ID, lg); err != nil {
+					log.Warn("error while notifying subscription", "err", err)
+					return false
+				}
+			}
+			return true
+		}
+		for {
+			select {
+			case batch, ok := <-history:
+				if !ok {
+					history = nil
+					if !notify(handoff.finish()) {
+						return
+					}
+					continue
+				}
+				if batch.err != nil {
+					log.Warn("error while reading historical logs", "err", batch.err)
+					fail(batch.err)
+					return
+				}
+				handoff.replayed(batch)
+				if !notify(batch.logs) {
+					return
+				}
+			case lg, ok := <-logs:
+				if lg != nil {
+					lgs, err := handoff.live(lg)
+					if err != nil {
+						fail(err)
+						return
+					}
+					if !notify(lgs) {
+						return
+					}
+				}
+				if !ok {
+					log.Warn("log channel was closed")
+					return
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// logsBatch are the historical logs of the blocks up to to
+type logsBatch struct {
+	logs types.Logs
+	to   uint64
+	err  error
+}
+
+// replayLogs sends the logs matching crit from the block from to the head, by batches. The channel is closed once
+// the head is reached.
+func (api *ErigonImpl) replayLogs(ctx context.Context, crit filters.FilterCriteria, from uint64, out chan<- logsBatch) {
+	defer close(out)
+	for {
+		batch, done := api.readLogsBatch(ctx, crit, from)
+		if done {
+			return
+		}
+		select {
+		case out <- batch:
+		case <-ctx.Done():
+			return
+		}
+		if batch.err != nil {
+			return
+		}
+		from = batch.to + 1
+	}
+}
+
+// readLogsBatch reads the logs of the next batch of blocks starting at from, done is true if from is after the head
+func (api *ErigonImpl) readLogsBatch(ctx context.Context, crit filters.FilterCriteria, from uint64) (batch logsBatch, done bool) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return logsBatch{err: err}, false
+	}
+	defer tx.Rollback()
+
+	latest, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestExecutedBlockNumber), tx, nil)
+	if err != nil {
+		return logsBatch{err: err}, false
+	}
+	if from > latest {
+		return logsBatch{}, true
+	}
+	to := from + logsReplayBatchSize - 1
+	if to > latest {
+		to = latest
+	}
+	logs, _, err := api.getLogsInRange(ctx, api.db, tx, from, to, crit, GetLogsLimits{})
+	return logsBatch{logs: logs, to: to, err: err}, false
+}
+
+// logsHandoff drops the live logs of a subscription duplicating the historical ones sent before. The live logs
+// received while the history is sent are queued until it's done, maxQueued at most.
+type logsHandoff struct {
+	replaying bool
+	head      uint64                 // the last block of the history
+	sent      map[common.Hash]uint64 // the blocks of the history with logs, the most recent ones only
+	queue     []*types.Log
+	maxQueued int
+}
+
+func (h *logsHandoff) replayed(batch logsBatch) {
+	h.head = batch.to
+	for _, lg := range batch.logs {
+		h.sent[lg.BlockHash] = lg.BlockNumber
+	}
+	for hash, number := range h.sent {
+		if number+logsReplayReorgDepth <= h.head {
+			delete(h.sent, hash)
+		}
+	}
+}
+
+// live returns the logs to send for a new live log, or an error if too many are queued during the replay
+func (h *logsHandoff) live(lg *types.Log) ([]*types.Log, error) {
+	if h.replaying {
+		if len(h.queue) >= h.maxQueued {
+			return nil, fmt.Errorf("more than %d new logs arrived while the history was sent, subscribe from a later block", h.maxQueued)
+		}
+		h.queue = append(h.queue, lg)
+		return nil, nil
+	}
+	if h.duplicate(lg) {
+		return nil, nil
+	}
+	return []*types.Log{lg}, nil
+}
+
+// finish returns the queued live logs to send once the history is sent
+func (h *logsHandoff) finish() []*types.Log {
+	h.replaying = false
+	var logs []*types.Log
+	for _, lg := range h.queue {
+		if !h.duplicate(lg) {
+			logs = append(logs, lg)
+		}
+	}
+	h.queue = nil
+	return logs
+}
+
+// duplicate returns true if the history already reflects lg: it sent the logs of its block, or didn't send the
+// logs it removes
+func (h *logsHandoff) duplicate(lg *types.Log) bool {
+	if lg.BlockNumber > h.head {
+		return false
+	}
+	_, sent := h.sent[lg.BlockHash]
+	if lg.Removed {
+		return !sent
+	}
+	return sent
+}
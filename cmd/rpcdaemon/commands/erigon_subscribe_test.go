@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogsHandoff(t *testing.T) {
+	sentHash, otherHash := common.Hash{1}, common.Hash{2}
+	h := &logsHandoff{replaying: true, sent: make(map[common.Hash]uint64), maxQueued: 4}
+
+	h.replayed(logsBatch{logs: types.Logs{{BlockNumber: 5, BlockHash: sentHash}}, to: 6})
+	var (
+		duplicate = &types.Log{BlockNumber: 5, BlockHash: sentHash}
+		next      = &types.Log{BlockNumber: 7, BlockHash: otherHash}
+		unsent    = &types.Log{BlockNumber: 5, BlockHash: otherHash, Removed: true}
+		removed   = &types.Log{BlockNumber: 5, BlockHash: sentHash, Removed: true}
+	)
+	for _, lg := range []*types.Log{duplicate, next, unsent, removed} {
+		lgs, err := h.live(lg)
+		require.NoError(t, err)
+		require.Empty(t, lgs, "the live logs are queued during the replay")
+	}
+	_, err := h.live(next)
+	require.Error(t, err, "the queue is full")
+	require.Equal(t, []*types.Log{next, removed}, h.finish())
+
+	lgs, err := h.live(duplicate)
+	require.NoError(t, err)
+	require.Empty(t, lgs)
+	reorged := &types.Log{BlockNumber: 6, BlockHash: otherHash}
+	lgs, err = h.live(reorged)
+	require.NoError(t, err)
+	require.Equal(t, []*types.Log{reorged}, lgs)
+
+	// the replayed blocks are forgotten past the reorg depth
+	h.replayed(logsBatch{to: 5 + logsReplayReorgDepth})
+	require.Empty(t, h.sent)
+}
+
+func TestErigonLogsSubscription(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	ff := rpchelper.New(ctx, nil, nil, nil, func() {})
+	base := NewBaseApi(ff, stateCache, snapshotsync.NewBlockReader(), nil, nil, false)
+
+	history, err := NewEthAPI(base, db, nil, nil, nil, 5000000).GetLogs(ctx, filters.FilterCriteria{FromBlock: common.Big0})
+	require.NoError(t, err)
+	require.NotEmpty(t, history)
+
+	server := rpc.NewServer(50, false /* traceRequests */, true)
+	require.NoError(t, server.RegisterName("erigon", NewErigonAPI(base, db, nil, nil)))
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	logs := make(chan types.Log)
+	sub, err := client.Subscribe(ctx, "erigon", logs, "logs", map[string]interface{}{"fromBlock": "0x0"})
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	receive := func() types.Log {
+		select {
+		case lg := <-logs:
+			return lg
+		case err := <-sub.Err():
+			t.Fatal(err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout waiting for a log")
+		}
+		return types.Log{}
+	}
+	for _, want := range history {
+		lg := receive()
+		require.Equal(t, want.TxHash, lg.TxHash)
+		require.Equal(t, want.Index, lg.Index)
+	}
+
+	// the live logs already sent by the history are dropped
+	last := history[len(history)-1]
+	live := func(lg *types.Log) *remote.SubscribeLogsReply {
+		var topics []*types2.H256
+		for _, topic := range lg.Topics {
+			topics = append(topics, gointerfaces.ConvertHashToH256(topic))
+		}
+		return &remote.SubscribeLogsReply{
+			Address:         gointerfaces.ConvertAddressToH160(lg.Address),
+			Topics:          topics,
+			BlockHash:       gointerfaces.ConvertHashToH256(lg.BlockHash),
+			BlockNumber:     lg.BlockNumber,
+			Data:            lg.Data,
+			LogIndex:        uint64(lg.Index),
+			TransactionHash: gointerfaces.ConvertHashToH256(lg.TxHash),
+		}
+	}
+	ff.OnNewLogs(live(last))
+	ff.OnNewLogs(live(&types.Log{Address: last.Address, Topics: last.Topics, BlockNumber: last.BlockNumber + 100, TxHash: common.Hash{1}}))
+	lg := receive()
+	require.Equal(t, last.BlockNumber+100, lg.BlockNumber)
+	require.Equal(t, common.Hash{1}, lg.TxHash)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/forkid"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 )
 
 // Forks is a data type to record a list of forks passed by this node
@@ -29,3 +31,22 @@ func (api *ErigonImpl) Forks(ctx context.Context) (Forks, error) {
 
 	return Forks{genesis.Hash(), forksBlocks}, nil
 }
+
+// StagesProgress implements erigon_stagesProgress. Returns the block number reached by each stage of the staged sync
+func (api *ErigonImpl) StagesProgress(ctx context.Context) (map[stages.SyncStage]hexutil.Uint64, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	progress := make(map[stages.SyncStage]hexutil.Uint64, len(stages.AllStages))
+	for _, stage := range stages.AllStages {
+		n, err := stages.GetStageProgress(tx, stage)
+		if err != nil {
+			return nil, err
+		}
+		progress[stage] = hexutil.Uint64(n)
+	}
+	return progress, nil
+}
@@ -0,0 +1,385 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	proto_txpool "github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// txpoolEventsInterval is how often the transaction pool is compared to its previous content, while there are
+// subscribers to its events
+const txpoolEventsInterval = time.Second
+
+// txpoolTransientsLimit is the maximum number of transactions received from the stream of the pool between two
+// comparisons, the others are only seen by the comparisons
+const txpoolTransientsLimit = 10_000
+
+// The types of the txpool events
+const (
+	TxpoolEventAdd     = "add"
+	TxpoolEventDrop    = "drop"
+	TxpoolEventReplace = "replace"
+)
+
+// The reasons of the txpool drop events. The pool doesn't tell why it discards its transactions, only the ones
+// whose nonce is used in the state are known.
+const (
+	// TxpoolDropIncluded is the reason of the transactions whose nonce was used by an included transaction
+	TxpoolDropIncluded = "included"
+	// TxpoolDropUnknown is the reason of the other transactions: underpriced, evicted, invalidated by a reorg...
+	TxpoolDropUnknown = "unknown"
+)
+
+// TxpoolEvent is a change of the transaction pool sent by erigon_subscribe("txpoolEvents")
+type TxpoolEvent struct {
+	Type       string         `json:"type"`
+	Hash       common.Hash    `json:"hash"`
+	From       common.Address `json:"from"`
+	Nonce      hexutil.Uint64 `json:"nonce"`
+	SubPool    string         `json:"subPool,omitempty"`    // the sub-pool the transaction is in, or was in last when dropped
+	Reason     string         `json:"reason,omitempty"`     // only for the drops
+	ReplacedBy *common.Hash   `json:"replacedBy,omitempty"` // only for the replacements
+}
+
+// TxpoolEvents implements erigon_subscribe("txpoolEvents"). Sends the transactions added to, dropped from and replaced
+// in the transaction pool, with the reasons of the drops.
+func (api *ErigonImpl) TxpoolEvents(ctx context.Context) (*rpc.Subscription, error) {
+	if api.txPool == nil {
+		return &rpc.Subscription{}, fmt.Errorf("the transaction pool is not available")
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer debug.LogPanic()
+		events := make(chan []*TxpoolEvent, 16)
+		api.txpoolEvents.subscribe(events)
+		defer api.txpoolEvents.unsubscribe(events)
+
+		for {
+			select {
+			case batch := <-events:
+				for _, event := range batch {
+					if err := notifier.Notify(rpcSub.ID, event); err != nil {
+						log.Warn("error while notifying subscription", "err", err)
+						return
+					}
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// txpoolEntry is a transaction of a snapshot of the pool
+type txpoolEntry struct {
+	hash    common.Hash
+	from    common.Address
+	nonce   uint64
+	subPool string
+}
+
+type senderNonce struct {
+	from  common.Address
+	nonce uint64
+}
+
+// txpoolEventsFeed derives the events of the transaction pool from its snapshots, compared every txpoolEventsInterval
+// while there are subscribers. The transactions streamed by the pool when they become executable are also added to
+// the comparison, so that the ones gone before it are seen. The queued transactions added and removed between two
+// snapshots aren't.
+type txpoolEventsFeed struct {
+	pool        proto_txpool.TxpoolClient
+	db          kv.RoDB
+	filters     *rpchelper.Filters
+	chainConfig func(kv.Tx) (*params.ChainConfig, error)
+
+	mu   sync.Mutex
+	subs map[chan []*TxpoolEvent]struct{}
+	stop context.CancelFunc
+}
+
+func newTxpoolEventsFeed(pool proto_txpool.TxpoolClient, db kv.RoDB, filters *rpchelper.Filters, chainConfig func(kv.Tx) (*params.ChainConfig, error)) *txpoolEventsFeed {
+	return &txpoolEventsFeed{pool: pool, db: db, filters: filters, chainConfig: chainConfig, subs: make(map[chan []*TxpoolEvent]struct{})}
+}
+
+func (f *txpoolEventsFeed) subscribe(ch chan []*TxpoolEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs[ch] = struct{}{}
+	if len(f.subs) == 1 {
+		ctx, cancel := context.WithCancel(context.Background())
+		f.stop = cancel
+		go f.run(ctx)
+	}
+}
+
+func (f *txpoolEventsFeed) unsubscribe(ch chan []*TxpoolEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subs, ch)
+	if len(f.subs) == 0 && f.stop != nil {
+		f.stop()
+		f.stop = nil
+	}
+}
+
+func (f *txpoolEventsFeed) send(events []*TxpoolEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subs {
+		select {
+		case ch <- events:
+		default:
+			log.Warn("txpool events subscriber is too slow, dropping events", "count", len(events))
+		}
+	}
+}
+
+func (f *txpoolEventsFeed) run(ctx context.Context) {
+	defer debug.LogPanic()
+	ticker := time.NewTicker(txpoolEventsInterval)
+	defer ticker.Stop()
+
+	// the stream is drained at once, the filters wait for each subscriber to take the transactions
+	var (
+		streamedMu sync.Mutex
+		streamed   = make(map[common.Hash]types.Transaction)
+	)
+	if f.filters != nil {
+		txs := make(chan []types.Transaction, 1)
+		id := f.filters.SubscribePendingTxs(txs)
+		defer f.filters.UnsubscribePendingTxs(id)
+		go func() {
+			defer debug.LogPanic()
+			for batch := range txs {
+				streamedMu.Lock()
+				for _, txn := range batch {
+					if txn != nil && len(streamed) < txpoolTransientsLimit {
+						streamed[txn.Hash()] = txn
+					}
+				}
+				streamedMu.Unlock()
+			}
+		}()
+	}
+
+	var prev map[common.Hash]*txpoolEntry
+	for {
+		// the transactions streamed before the snapshot, the ones which aren't in it are gone already
+		streamedMu.Lock()
+		transients := streamed
+		streamed = make(map[common.Hash]types.Transaction)
+		streamedMu.Unlock()
+
+		next, err := f.snapshot(ctx, prev)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Warn("txpool events: reading the transaction pool", "err", err)
+		} else {
+			if prev != nil {
+				events, err := f.diff(ctx, prev, next, transients)
+				if err != nil {
+					log.Warn("txpool events: comparing the transaction pool", "err", err)
+				} else if len(events) > 0 {
+					f.send(events)
+				}
+			}
+			prev = next
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// snapshot returns the transactions of the pool by the hash of their RLP, only the ones not in prev are decoded
+func (f *txpoolEventsFeed) snapshot(ctx context.Context, prev map[common.Hash]*txpoolEntry) (map[common.Hash]*txpoolEntry, error) {
+	reply, err := f.pool.All(ctx, &proto_txpool.AllRequest{})
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[common.Hash]*txpoolEntry, len(reply.Txs))
+	for _, tx := range reply.Txs {
+		var subPool string
+		switch tx.TxnType {
+		case proto_txpool.AllReply_PENDING:
+			subPool = "pending"
+		case proto_txpool.AllReply_BASE_FEE:
+			subPool = "baseFee"
+		case proto_txpool.AllReply_QUEUED:
+			subPool = "queued"
+		default:
+			continue
+		}
+		key := crypto.Keccak256Hash(tx.RlpTx)
+		if entry, ok := prev[key]; ok {
+			entries[key] = &txpoolEntry{hash: entry.hash, from: entry.from, nonce: entry.nonce, subPool: subPool}
+			continue
+		}
+		txn, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(tx.RlpTx), 0))
+		if err != nil {
+			return nil, err
+		}
+		entries[key] = &txpoolEntry{
+			hash:    txn.Hash(),
+			from:    gointerfaces.ConvertH160toAddress(tx.Sender),
+			nonce:   txn.GetNonce(),
+			subPool: subPool,
+		}
+	}
+	return entries, nil
+}
+
+// diff returns the events turning the snapshot prev into next, with the streamed transactions in neither of them
+func (f *txpoolEventsFeed) diff(ctx context.Context, prev, next map[common.Hash]*txpoolEntry, streamed map[common.Hash]types.Transaction) ([]*TxpoolEvent, error) {
+	tx, err := f.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var transients []*txpoolEntry
+	if len(streamed) > 0 {
+		known := make(map[common.Hash]struct{}, len(prev)+len(next))
+		for _, entries := range []map[common.Hash]*txpoolEntry{prev, next} {
+			for _, entry := range entries {
+				known[entry.hash] = struct{}{}
+			}
+		}
+		cc, err := f.chainConfig(tx)
+		if err != nil {
+			return nil, err
+		}
+		signer := types.LatestSignerForChainID(cc.ChainID)
+		for hash, txn := range streamed {
+			if _, ok := known[hash]; ok {
+				continue
+			}
+			from, err := txn.Sender(*signer)
+			if err != nil {
+				return nil, err
+			}
+			transients = append(transients, &txpoolEntry{hash: hash, from: from, nonce: txn.GetNonce()})
+		}
+	}
+
+	reader := state.NewPlainStateReader(tx)
+	return diffTxpool(prev, next, transients, func(addr common.Address) (uint64, error) {
+		account, err := reader.ReadAccountData(addr)
+		if err != nil || account == nil {
+			return 0, err
+		}
+		return account.Nonce, nil
+	})
+}
+
+// diffTxpool returns the events turning the snapshot prev of the pool into next, ordered by sender and nonce. The
+// transients, in neither snapshot, were added and removed in between. A transaction removed while another one with
+// the same sender and nonce is added is replaced, the others are dropped with a reason given by the nonce of the
+// sender in the state.
+func diffTxpool(prev, next map[common.Hash]*txpoolEntry, transients []*txpoolEntry, stateNonce func(common.Address) (uint64, error)) ([]*TxpoolEvent, error) {
+	// the events of a sender and nonce are sent in the order of their step: the removals of prev, the additions and
+	// removals of the transients, then the additions of next
+	type stepEvent struct {
+		*TxpoolEvent
+		step int
+	}
+	var events []stepEvent
+	addEvent := func(entry *txpoolEntry, step int) {
+		events = append(events, stepEvent{&TxpoolEvent{Type: TxpoolEventAdd, Hash: entry.hash, From: entry.from, Nonce: hexutil.Uint64(entry.nonce), SubPool: entry.subPool}, step})
+	}
+	added := make(map[senderNonce]*txpoolEntry)
+	for key, entry := range next {
+		if _, ok := prev[key]; ok {
+			continue
+		}
+		added[senderNonce{entry.from, entry.nonce}] = entry
+		addEvent(entry, 3)
+	}
+	type removal struct {
+		entry *txpoolEntry
+		step  int
+	}
+	var removed []removal
+	for key, entry := range prev {
+		if _, ok := next[key]; !ok {
+			removed = append(removed, removal{entry, 0})
+		}
+	}
+	for _, entry := range transients {
+		addEvent(entry, 1)
+		removed = append(removed, removal{entry, 2})
+	}
+	nonces := make(map[common.Address]uint64)
+	for _, r := range removed {
+		entry := r.entry
+		event := &TxpoolEvent{Hash: entry.hash, From: entry.from, Nonce: hexutil.Uint64(entry.nonce), SubPool: entry.subPool}
+		if replacement, ok := added[senderNonce{entry.from, entry.nonce}]; ok {
+			event.Type = TxpoolEventReplace
+			event.ReplacedBy = &replacement.hash
+			events = append(events, stepEvent{event, r.step})
+			continue
+		}
+		nonce, ok := nonces[entry.from]
+		if !ok {
+			var err error
+			if nonce, err = stateNonce(entry.from); err != nil {
+				return nil, err
+			}
+			nonces[entry.from] = nonce
+		}
+		event.Type = TxpoolEventDrop
+		event.Reason = TxpoolDropUnknown
+		if nonce > entry.nonce {
+			event.Reason = TxpoolDropIncluded
+		}
+		events = append(events, stepEvent{event, r.step})
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if c := bytes.Compare(events[i].From[:], events[j].From[:]); c != 0 {
+			return c < 0
+		}
+		if events[i].Nonce != events[j].Nonce {
+			return events[i].Nonce < events[j].Nonce
+		}
+		if events[i].step != events[j].step {
+			return events[i].step < events[j].step
+		}
+		return bytes.Compare(events[i].Hash[:], events[j].Hash[:]) < 0
+	})
+	result := make([]*TxpoolEvent, len(events))
+	for i, event := range events {
+		result[i] = event.TxpoolEvent
+	}
+	return result, nil
+}
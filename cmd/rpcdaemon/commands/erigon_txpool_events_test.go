@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffTxpool(t *testing.T) {
+	sender, other := common.Address{1}, common.Address{2}
+	var (
+		kept        = &txpoolEntry{hash: common.Hash{1}, from: sender, nonce: 3, subPool: "pending"}
+		replaced    = &txpoolEntry{hash: common.Hash{2}, from: sender, nonce: 4, subPool: "pending"}
+		replacement = &txpoolEntry{hash: common.Hash{3}, from: sender, nonce: 4, subPool: "pending"}
+		included    = &txpoolEntry{hash: common.Hash{4}, from: other, nonce: 1, subPool: "pending"}
+		underpriced = &txpoolEntry{hash: common.Hash{5}, from: other, nonce: 2, subPool: "baseFee"}
+		evicted     = &txpoolEntry{hash: common.Hash{7}, from: sender, nonce: 5, subPool: "pending"}
+		added       = &txpoolEntry{hash: common.Hash{8}, from: other, nonce: 6, subPool: "queued"}
+		// streamed after prev and gone before next, replaced by replacement, then mined
+		transient = &txpoolEntry{hash: common.Hash{9}, from: sender, nonce: 4}
+		mined     = &txpoolEntry{hash: common.Hash{10}, from: sender, nonce: 2}
+	)
+	prev := map[common.Hash]*txpoolEntry{{1}: kept, {2}: replaced, {4}: included, {5}: underpriced, {7}: evicted}
+	next := map[common.Hash]*txpoolEntry{{1}: kept, {3}: replacement, {8}: added}
+	nonces := map[common.Address]uint64{sender: 3, other: 2}
+
+	events, err := diffTxpool(prev, next, []*txpoolEntry{transient, mined}, func(addr common.Address) (uint64, error) { return nonces[addr], nil })
+	require.NoError(t, err)
+	replacementHash := replacement.hash
+	require.Equal(t, []*TxpoolEvent{
+		{Type: TxpoolEventAdd, Hash: mined.hash, From: sender, Nonce: 2},
+		{Type: TxpoolEventDrop, Hash: mined.hash, From: sender, Nonce: 2, Reason: TxpoolDropIncluded},
+		{Type: TxpoolEventReplace, Hash: replaced.hash, From: sender, Nonce: 4, SubPool: "pending", ReplacedBy: &replacementHash},
+		{Type: TxpoolEventAdd, Hash: transient.hash, From: sender, Nonce: 4},
+		{Type: TxpoolEventReplace, Hash: transient.hash, From: sender, Nonce: 4, ReplacedBy: &replacementHash},
+		{Type: TxpoolEventAdd, Hash: replacement.hash, From: sender, Nonce: 4, SubPool: "pending"},
+		{Type: TxpoolEventDrop, Hash: evicted.hash, From: sender, Nonce: 5, SubPool: "pending", Reason: TxpoolDropUnknown},
+		{Type: TxpoolEventDrop, Hash: included.hash, From: other, Nonce: 1, SubPool: "pending", Reason: TxpoolDropIncluded},
+		{Type: TxpoolEventDrop, Hash: underpriced.hash, From: other, Nonce: 2, SubPool: "baseFee", Reason: TxpoolDropUnknown},
+		{Type: TxpoolEventAdd, Hash: added.hash, From: other, Nonce: 6, SubPool: "queued"},
+	}, events)
+}
+
+func TestTxpoolEventsSubscription(t *testing.T) {
+	m := stages.MockWithTxPool(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	txPool := txpool.NewTxpoolClient(conn)
+	base := NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false)
+
+	server := rpc.NewServer(50, false /* traceRequests */, true)
+	require.NoError(t, server.RegisterName("erigon", NewErigonAPI(base, m.DB, nil, txPool)))
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	events := make(chan TxpoolEvent)
+	sub, err := client.Subscribe(ctx, "erigon", events, "txpoolEvents")
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	receive := func() TxpoolEvent {
+		select {
+		case event := <-events:
+			return event
+		case err := <-sub.Err():
+			t.Fatal(err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout waiting for a txpool event")
+		}
+		return TxpoolEvent{}
+	}
+	// the first snapshot of the pool is taken before the transactions are added
+	time.Sleep(2 * txpoolEventsInterval)
+
+	add := func(tip uint64) types.Transaction {
+		txn, err := types.SignTx(types.NewTransaction(0, common.Address{1}, uint256.NewInt(1), params.TxGas, uint256.NewInt(tip*params.GWei), nil), *types.LatestSignerForChainID(m.ChainConfig.ChainID), m.Key)
+		require.NoError(t, err)
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, txn.MarshalBinary(buf))
+		reply, err := txPool.Add(ctx, &txpool.AddRequest{RlpTxs: [][]byte{buf.Bytes()}})
+		require.NoError(t, err)
+		require.Equal(t, txpool.ImportResult_SUCCESS, reply.Imported[0], reply.Errors)
+		return txn
+	}
+	first := add(10)
+	event := receive()
+	require.Equal(t, TxpoolEventAdd, event.Type)
+	require.Equal(t, first.Hash(), event.Hash)
+	require.Equal(t, m.Address, event.From)
+	require.Equal(t, hexutil.Uint64(0), event.Nonce)
+
+	second := add(20)
+	event = receive()
+	require.Equal(t, TxpoolEventReplace, event.Type)
+	require.Equal(t, first.Hash(), event.Hash)
+	require.Equal(t, second.Hash(), *event.ReplacedBy)
+	event = receive()
+	require.Equal(t, TxpoolEventAdd, event.Type)
+	require.Equal(t, second.Hash(), event.Hash)
+}
@@ -8,3 +8,6 @@ const NotAvailableChainData = "the function %s is not available, please use --pr
 
 // NotAvailableDeprecated x
 const NotAvailableDeprecated = "the method has been deprecated: %s"
+
+// NoExternalSigner is the error of the methods signing with the external signer, when it isn't set
+const NoExternalSigner = "the method %s needs an external signer, please use the --rpc.signer option"
@@ -13,6 +13,7 @@ import (
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/trie"
 )
 
 // GetBalance implements eth_getBalance. Returns the balance of an account for a given address.
@@ -119,3 +120,59 @@ func (api *APIImpl) GetStorageAt(ctx context.Context, address common.Address, in
 	}
 	return hexutil.Encode(common.LeftPadBytes(res, 32)), err
 }
+
+// AccountInfo is the result of eth_getAccount
+type AccountInfo struct {
+	Balance     *hexutil.Big   `json:"balance"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	CodeHash    common.Hash    `json:"codeHash"`
+	StorageRoot common.Hash    `json:"storageRoot"`
+}
+
+// GetAccount implements eth_getAccount. Returns the balance, nonce, code hash and storage root of an account at a given
+// block in one call. The storage roots of the contracts are computed from the intermediate hashes like eth_getProof,
+// so they're limited to the same recent blocks, those of the other accounts are always empty.
+func (api *APIImpl) GetAccount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*AccountInfo, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getAccount cannot open tx: %w", err)
+	}
+	defer tx.Rollback()
+	reader, err := rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, api.filters, api.stateCache)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := reader.ReadAccountData(address)
+	if err != nil {
+		return nil, fmt.Errorf("cant get account %x: %w", address, err)
+	}
+	info := &AccountInfo{Balance: (*hexutil.Big)(big.NewInt(0)), CodeHash: trie.EmptyCodeHash, StorageRoot: trie.EmptyRoot}
+	if acc == nil {
+		// Special case - non-existent account is assumed to be empty
+		return info, nil
+	}
+	info.Balance = (*hexutil.Big)(acc.Balance.ToBig())
+	info.Nonce = hexutil.Uint64(acc.Nonce)
+	info.CodeHash = acc.CodeHash
+	if acc.Incarnation == 0 {
+		// only the contracts have storage
+		return info, nil
+	}
+
+	blockNr, _, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := api.stateTrie(ctx, tx, blockNr, uint64(api.MaxGetProofRewindBlocks), map[common.Address][]common.Hash{address: nil}, "eth_getAccount")
+	if err != nil {
+		return nil, err
+	}
+	addrHash, err := common.HashData(address[:])
+	if err != nil {
+		return nil, err
+	}
+	if account, ok := tr.GetAccount(addrHash[:]); ok && account != nil {
+		info.StorageRoot = account.Root
+	}
+	return info, nil
+}
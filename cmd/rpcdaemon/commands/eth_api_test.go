@@ -8,9 +8,11 @@ import (
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/internal/ethapi"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/trie"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
@@ -24,7 +26,7 @@ func TestGetBalanceChangesInBlock(t *testing.T) {
 
 	db := rpcdaemontest.CreateTestKV(t)
 	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
-	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil)
+	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil)
 	balances, err := api.GetBalanceChangesInBlock(context.Background(), myBlockNum)
 	if err != nil {
 		t.Errorf("calling GetBalanceChangesInBlock resulted in an error: %v", err)
@@ -61,6 +63,43 @@ func TestGetTransactionReceiptUnprotected(t *testing.T) {
 	}
 }
 
+func TestGetBlockReceipts(t *testing.T) {
+	assert := assert.New(t)
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	ctx := context.Background()
+
+	block, err := api.GetBlockByNumber(ctx, 1, false)
+	if err != nil {
+		t.Fatalf("calling GetBlockByNumber: %v", err)
+	}
+	txns := block["transactions"].([]interface{})
+	assert.NotEmpty(txns)
+
+	byNumber, err := api.GetBlockReceipts(ctx, rpc.BlockNumberOrHashWithNumber(1))
+	if err != nil {
+		t.Fatalf("calling GetBlockReceipts by number: %v", err)
+	}
+	byHash, err := api.GetBlockReceipts(ctx, rpc.BlockNumberOrHashWithHash(block["hash"].(common.Hash), true))
+	if err != nil {
+		t.Fatalf("calling GetBlockReceipts by hash: %v", err)
+	}
+	assert.Equal(byNumber, byHash)
+	assert.Equal(len(txns), len(byNumber))
+	for i, txn := range txns {
+		receipt, err := api.GetTransactionReceipt(ctx, txn.(common.Hash))
+		if err != nil {
+			t.Fatalf("calling GetTransactionReceipt: %v", err)
+		}
+		assert.Equal(receipt, byNumber[i])
+	}
+
+	missing, err := api.GetBlockReceipts(ctx, rpc.BlockNumberOrHashWithHash(common.Hash{1}, false))
+	assert.Error(err)
+	assert.Nil(missing)
+}
+
 // EIP-1898 test cases
 
 func TestGetStorageAt_ByBlockNumber_WithRequireCanonicalDefault(t *testing.T) {
@@ -209,7 +248,7 @@ func TestCall_ByBlockHash_WithRequireCanonicalDefault_NonCanonicalBlock(t *testi
 	if _, err := api.Call(context.Background(), ethapi.CallArgs{
 		From: &from,
 		To:   &to,
-	}, rpc.BlockNumberOrHashWithHash(orphanedBlock.Hash(), false), nil); err != nil {
+	}, rpc.BlockNumberOrHashWithHash(orphanedBlock.Hash(), false), nil, nil); err != nil {
 		if fmt.Sprintf("%v", err) != fmt.Sprintf("hash %s is not currently canonical", orphanedBlock.Hash().String()[2:]) {
 			/* Not sure. Here https://github.com/ethereum/EIPs/blob/master/EIPS/eip-1898.md it is not explicitly said that
 			   eth_call should only work with canonical blocks.
@@ -234,7 +273,7 @@ func TestCall_ByBlockHash_WithRequireCanonicalTrue_NonCanonicalBlock(t *testing.
 	if _, err := api.Call(context.Background(), ethapi.CallArgs{
 		From: &from,
 		To:   &to,
-	}, rpc.BlockNumberOrHashWithHash(orphanedBlock.Hash(), true), nil); err != nil {
+	}, rpc.BlockNumberOrHashWithHash(orphanedBlock.Hash(), true), nil, nil); err != nil {
 		if fmt.Sprintf("%v", err) != fmt.Sprintf("hash %s is not currently canonical", orphanedBlock.Hash().String()[2:]) {
 			t.Errorf("wrong error: %v", err)
 		}
@@ -242,3 +281,29 @@ func TestCall_ByBlockHash_WithRequireCanonicalTrue_NonCanonicalBlock(t *testing.
 		t.Error("error expected")
 	}
 }
+
+func TestGetAccount(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewEthAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	api.MaxGetProofRewindBlocks = 10
+
+	sender := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	// the token deployed by the sender in block 3, which has storage from block 4
+	token := crypto.CreateAddress(sender, 2)
+	for _, address := range []common.Address{sender, token, common.HexToAddress("0xdeadbeef")} {
+		for _, block := range []rpc.BlockNumberOrHash{rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), rpc.BlockNumberOrHashWithNumber(4)} {
+			proof, err := api.GetProof(ctx, address, nil, block)
+			assert.NoError(t, err)
+			account, err := api.GetAccount(ctx, address, block)
+			assert.NoError(t, err)
+			assert.Equal(t, proof.Balance.ToInt(), account.Balance.ToInt())
+			assert.Equal(t, proof.Nonce, account.Nonce)
+			assert.Equal(t, proof.CodeHash, account.CodeHash)
+			assert.Equal(t, proof.StorageHash, account.StorageRoot)
+			if address == token {
+				assert.NotEqual(t, trie.EmptyRoot, account.StorageRoot)
+			}
+		}
+	}
+}
@@ -2,34 +2,48 @@ package commands
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
+	"os"
+	"strings"
 
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	txpool_proto "github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	libstate "github.com/ledgerwatch/erigon-lib/state"
 	"github.com/ledgerwatch/log/v3"
 	"google.golang.org/grpc"
 
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/eth/tracers/logger"
 	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/node/nodecfg/datadir"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/shards"
 	"github.com/ledgerwatch/erigon/turbo/transactions"
+	"github.com/ledgerwatch/erigon/turbo/trie"
 )
 
 // Call implements eth_call. Executes a new message call immediately without creating a transaction on the block chain.
-func (api *APIImpl) Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi.StateOverrides) (hexutil.Bytes, error) {
+// The state and the header fields of the block can be overridden to simulate hypothetical conditions.
+func (api *APIImpl) Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi.StateOverrides, blockOverrides *ethapi.BlockOverrides) (hexutil.Bytes, error) {
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
@@ -45,7 +59,7 @@ func (api *APIImpl) Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHas
 		args.Gas = (*hexutil.Uint64)(&api.GasCap)
 	}
 
-	blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters) // DoCall cannot be executed on non-canonical blocks
+	blockNumber, hash, latest, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters) // DoCall cannot be executed on non-canonical blocks
 	if err != nil {
 		return nil, err
 	}
@@ -56,8 +70,12 @@ func (api *APIImpl) Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHas
 	if block == nil {
 		return nil, nil
 	}
+	stateReader, err := api.callStateReader(ctx, tx, blockNumber, latest)
+	if err != nil {
+		return nil, err
+	}
 
-	result, err := transactions.DoCall(ctx, args, tx, blockNrOrHash, block, overrides, api.GasCap, chainConfig, api.filters, api.stateCache, api._blockReader)
+	result, err := transactions.DoCall(ctx, args, tx, blockNrOrHash, block, stateReader, overrides, blockOverrides, api.GasCap, chainConfig, api._blockReader, api.EvmCallTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +103,8 @@ func headerByNumberOrHash(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.Block
 }
 
 // EstimateGas implements eth_estimateGas. Returns an estimate of how much gas is necessary to allow the transaction to complete. The transaction will not be added to the blockchain.
-func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+// The state can be overridden like in eth_call, e.g. to estimate for an account with a modified balance or code.
+func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash, overrides *ethapi.StateOverrides) (hexutil.Uint64, error) {
 	var args ethapi.CallArgs
 	// if we actually get CallArgs here, we use them
 	if argsOrNil != nil {
@@ -152,17 +171,40 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi.CallArgs,
 	} else {
 		feeCap = common.Big0
 	}
+	// The estimation executes on the state after the block, the latest one if it's pending. The reads of the
+	// iterations of the binary search are cached.
+	numOrHash := bNrOrHash
+	if number, ok := numOrHash.Number(); ok && number == rpc.PendingBlockNumber {
+		numOrHash = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	}
+	blockNumber, hash, latest, err := rpchelper.GetCanonicalBlockNumber(numOrHash, dbtx, api.filters) // DoCall cannot be executed on non-canonical blocks
+	if err != nil {
+		return 0, err
+	}
+	block, err := api.BaseAPI.blockWithSenders(dbtx, hash, blockNumber)
+	if err != nil {
+		return 0, err
+	}
+	if block == nil {
+		return 0, nil
+	}
+	historyReader, err := api.callStateReader(ctx, dbtx, blockNumber, latest)
+	if err != nil {
+		return 0, err
+	}
+	stateReader := state.NewCachedReader(historyReader, shards.NewStateCache(32, 0 /* no limit */))
+
 	// Recap the highest gas limit with account's available balance.
 	if feeCap.Sign() != 0 {
-		cacheView, err := api.stateCache.View(ctx, dbtx)
-		if err != nil {
-			return 0, err
-		}
-		stateReader := state.NewCachedReader2(cacheView, dbtx)
 		state := state.New(stateReader)
 		if state == nil {
 			return 0, fmt.Errorf("can't get the current state")
 		}
+		if overrides != nil {
+			if err := overrides.Override(state); err != nil {
+				return 0, err
+			}
+		}
 
 		balance := state.GetBalance(*args.From) // from can't be nil
 		available := balance.ToBig()
@@ -192,7 +234,6 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi.CallArgs,
 		hi = api.GasCap
 	}
 	cap = hi
-	var lastBlockNum = rpc.LatestBlockNumber
 
 	chainConfig, err := api.chainConfig(dbtx)
 	if err != nil {
@@ -203,21 +244,8 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi.CallArgs,
 	executable := func(gas uint64) (bool, *core.ExecutionResult, error) {
 		args.Gas = (*hexutil.Uint64)(&gas)
 
-		numOrHash := rpc.BlockNumberOrHash{BlockNumber: &lastBlockNum}
-		blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(numOrHash, dbtx, api.filters) // DoCall cannot be executed on non-canonical blocks
-		if err != nil {
-			return false, nil, err
-		}
-		block, err := api.BaseAPI.blockWithSenders(dbtx, hash, blockNumber)
-		if err != nil {
-			return false, nil, err
-		}
-		if block == nil {
-			return false, nil, nil
-		}
-
-		result, err := transactions.DoCall(ctx, args, dbtx, numOrHash, block, nil,
-			api.GasCap, chainConfig, api.filters, api.stateCache, api._blockReader)
+		result, err := transactions.DoCall(ctx, args, dbtx, numOrHash, block, stateReader, overrides, nil,
+			api.GasCap, chainConfig, api._blockReader, api.EvmCallTimeout)
 		if err != nil {
 			if errors.Is(err, core.ErrIntrinsicGas) {
 				// Special case, raise gas limit
@@ -266,10 +294,201 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi.CallArgs,
 	return hexutil.Uint64(hi), nil
 }
 
-// GetProof not implemented
-func (api *APIImpl) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*interface{}, error) {
-	var stub interface{}
-	return &stub, fmt.Errorf(NotImplemented, "eth_getProof")
+// GetProof implements eth_getProof. Returns the account and storage values of an address at a block, with their
+// merkle proofs against the state root of the block. The state of the older blocks is rewound from the head, by
+// MaxGetProofRewindBlocks blocks at most.
+func (api *APIImpl) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNrOrHash rpc.BlockNumberOrHash) (*ethapi.AccountResult, error) {
+	keys := make([]common.Hash, len(storageKeys))
+	for i, key := range storageKeys {
+		var err error
+		if keys[i], err = decodeStorageKey(key); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	blockNr, _, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := api.stateTrie(ctx, tx, blockNr, uint64(api.MaxGetProofRewindBlocks), map[common.Address][]common.Hash{address: keys}, "eth_getProof")
+	if err != nil {
+		return nil, err
+	}
+	addrHash, err := common.HashData(address[:])
+	if err != nil {
+		return nil, err
+	}
+
+	accountProof, err := tr.Prove(addrHash[:], 0, false)
+	if err != nil {
+		return nil, err
+	}
+	result := &ethapi.AccountResult{
+		Address:      address,
+		AccountProof: proofToHex(accountProof),
+		Balance:      (*hexutil.Big)(new(big.Int)),
+		CodeHash:     trie.EmptyCodeHash,
+		StorageHash:  trie.EmptyRoot,
+		StorageProof: make([]ethapi.StorageResult, len(keys)),
+	}
+	if account, ok := tr.GetAccount(addrHash[:]); ok && account != nil {
+		result.Balance = (*hexutil.Big)(account.Balance.ToBig())
+		result.CodeHash = account.CodeHash
+		result.Nonce = hexutil.Uint64(account.Nonce)
+		result.StorageHash = account.Root
+	}
+	for i, key := range keys {
+		keyHash, err := common.HashData(key[:])
+		if err != nil {
+			return nil, err
+		}
+		trieKey := append(addrHash[:], keyHash[:]...)
+		proof, err := tr.Prove(trieKey, 64, true)
+		if err != nil {
+			return nil, err
+		}
+		value := new(big.Int)
+		if v, ok := tr.Get(trieKey); ok {
+			value.SetBytes(v)
+		}
+		result.StorageProof[i] = ethapi.StorageResult{Key: storageKeys[i], Value: (*hexutil.Big)(value), Proof: proofToHex(proof)}
+	}
+	return result, nil
+}
+
+// stateTrie returns the trie of the state after block blockNr, with the nodes proving the accounts and their storage
+// keys. The intermediate hashes are rewound from the head by maxRewind blocks at most.
+func (api *BaseAPI) stateTrie(ctx context.Context, tx kv.Tx, blockNr uint64, maxRewind uint64, storageKeys map[common.Address][]common.Hash, logPrefix string) (*trie.Trie, error) {
+	header, err := api._blockReader.HeaderByNumber(ctx, tx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %d not found", blockNr)
+	}
+	// the hashed state and the intermediate hashes are those of the last block whose state root was computed
+	latest, err := stages.GetStageProgress(tx, stages.IntermediateHashes)
+	if err != nil {
+		return nil, err
+	}
+	if blockNr > latest {
+		return nil, fmt.Errorf("block %d is ahead of the state root computation, at block %d", blockNr, latest)
+	}
+	if latest-blockNr > maxRewind {
+		return nil, fmt.Errorf("block %d is too old, the state can be rewound by %d blocks at most", blockNr, maxRewind)
+	}
+
+	batch := memdb.NewMemoryBatch(tx)
+	defer batch.Rollback()
+	rl := trie.NewRetainList(0)
+	var loader *trie.FlatDBTrieLoader
+	if blockNr < latest {
+		// the unwind sets the transaction of the aggregator, so it gets its own copy rather than the shared one
+		historyV2 := api.historyV2(tx)
+		var agg *libstate.Aggregator22
+		if historyV2 {
+			if agg, err = api.privateAggregator(); err != nil {
+				return nil, err
+			}
+			defer agg.Close()
+		}
+		hashStateCfg := stagedsync.StageHashStateCfg(nil, datadir.Dirs{Tmp: os.TempDir()}, historyV2, api._txNums, agg)
+		trieCfg := stagedsync.StageTrieCfg(nil, false, false, false, os.TempDir(), api._blockReader, nil, historyV2, api._txNums, agg)
+		u := &stagedsync.UnwindState{ID: stages.IntermediateHashes, UnwindPoint: blockNr}
+		s := &stagedsync.StageState{ID: stages.IntermediateHashes, BlockNumber: latest}
+		if loader, err = stagedsync.UnwindIntermediateHashesForTrieLoader(logPrefix, rl, u, s, batch, hashStateCfg, trieCfg, ctx.Done()); err != nil {
+			return nil, err
+		}
+	} else {
+		loader = trie.NewFlatDBTrieLoader(logPrefix)
+		if err = loader.Reset(rl, nil, nil, false); err != nil {
+			return nil, err
+		}
+	}
+
+	proofKeys := trie.NewRetainList(0)
+	for address, keys := range storageKeys {
+		// the storage keys are retained with the incarnation the account had at the block
+		addrHash, err := common.HashData(address[:])
+		if err != nil {
+			return nil, err
+		}
+		rl.AddKey(addrHash[:])
+		proofKeys.AddKey(addrHash[:])
+		enc, err := batch.GetOne(kv.HashedAccounts, addrHash[:])
+		if err != nil {
+			return nil, err
+		}
+		var acc accounts.Account
+		if len(enc) > 0 {
+			if err = acc.DecodeForStorage(enc); err != nil {
+				return nil, err
+			}
+		}
+		if acc.Incarnation > 0 {
+			for _, key := range keys {
+				keyHash, err := common.HashData(key[:])
+				if err != nil {
+					return nil, err
+				}
+				storageKey := dbutils.GenerateCompositeStorageKey(addrHash, acc.Incarnation, keyHash)
+				rl.AddKey(storageKey)
+				proofKeys.AddKey(storageKey)
+			}
+		}
+	}
+	loader.RetainNodes(proofKeys)
+	root, err := loader.CalcTrieRoot(batch, []byte{}, ctx.Done())
+	if err != nil {
+		return nil, err
+	}
+	if root != header.Root {
+		return nil, fmt.Errorf("state root mismatch at block %d: computed %x, expected %x", blockNr, root, header.Root)
+	}
+	tr := trie.New(root)
+	if err = tr.HookSubTries(loader.Result(), [][]byte{nil}); err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// privateAggregator opens a copy of the aggregator of the history of state, for the calls which set its transaction:
+// the shared one is used concurrently by the other calls and, in the embedded rpcdaemon, by the sync
+func (api *BaseAPI) privateAggregator() (*libstate.Aggregator22, error) {
+	if api.AggregatorDir == "" || api._txNums == nil {
+		return nil, fmt.Errorf("the history of state isn't available")
+	}
+	return libstate.NewAggregator22(api.AggregatorDir, ethconfig.HistoryV2AggregationStep)
+}
+
+// decodeStorageKey parses a storage slot given as a hex string of 32 bytes at most
+func decodeStorageKey(s string) (common.Hash, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid storage key %q: %w", s, err)
+	}
+	if len(b) > common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid storage key %q: longer than %d bytes", s, common.HashLength)
+	}
+	return common.BytesToHash(b), nil
+}
+
+func proofToHex(proof [][]byte) []string {
+	hexes := make([]string, len(proof))
+	for i, node := range proof {
+		hexes[i] = hexutil.Encode(node)
+	}
+	return hexes
 }
 
 // accessListResult returns an optional accesslist
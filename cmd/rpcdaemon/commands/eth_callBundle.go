@@ -0,0 +1,331 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/log/v3"
+)
+
+const (
+	// callBundleTimeout is the default time limit of eth_callBundle
+	callBundleTimeout = 5 * time.Second
+	// callBundleBlockInterval is the default time between the state block and the simulated block, in seconds
+	callBundleBlockInterval = 12
+)
+
+// CallBundleArgs are the parameters of eth_callBundle, with the names and the encodings of the Flashbots relays.
+// The bundle is executed in a block with the number BlockNumber on top of the state after StateBlockNumber, the
+// latest block by default. The timeout is in seconds. A JSON array instead of an object is a list of hashes of
+// mined transactions, the bundle of the former eth_callBundle.
+type CallBundleArgs struct {
+	TxHashes         []common.Hash          `json:"-"`
+	Txs              []hexutil.Bytes        `json:"txs"`
+	BlockNumber      rpc.BlockNumber        `json:"blockNumber"`
+	StateBlockNumber *rpc.BlockNumberOrHash `json:"stateBlockNumber"`
+	Coinbase         *common.Address        `json:"coinbase"`
+	Timestamp        *uint64                `json:"timestamp"`
+	Timeout          *int64                 `json:"timeout"`
+	GasLimit         *uint64                `json:"gasLimit"`
+	Difficulty       *big.Int               `json:"difficulty"`
+	BaseFee          *big.Int               `json:"baseFee"`
+}
+
+func (args *CallBundleArgs) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, &args.TxHashes)
+	}
+	type callBundleArgs CallBundleArgs
+	return json.Unmarshal(data, (*callBundleArgs)(args))
+}
+
+// CallBundleTxResult is the result of a transaction of a bundle. The amounts are decimal strings of wei, the value
+// is the returned data of the successful transactions and the revert reason is the one of the reverted ones.
+type CallBundleTxResult struct {
+	TxHash            common.Hash     `json:"txHash"`
+	GasUsed           uint64          `json:"gasUsed"`
+	FromAddress       common.Address  `json:"fromAddress"`
+	ToAddress         *common.Address `json:"toAddress"`
+	GasPrice          string          `json:"gasPrice"`
+	GasFees           string          `json:"gasFees"`
+	EthSentToCoinbase string          `json:"ethSentToCoinbase"`
+	CoinbaseDiff      string          `json:"coinbaseDiff"`
+	Value             hexutil.Bytes   `json:"value,omitempty"`
+	Error             string          `json:"error,omitempty"`
+	Revert            string          `json:"revert,omitempty"`
+}
+
+// CallBundleResult is the result of eth_callBundle. The coinbase payments are split between the gas fees and the
+// direct transfers, the bundle gas price is the payment per unit of gas.
+type CallBundleResult struct {
+	BundleHash        common.Hash          `json:"bundleHash"`
+	BundleGasPrice    string               `json:"bundleGasPrice"`
+	CoinbaseDiff      string               `json:"coinbaseDiff"`
+	EthSentToCoinbase string               `json:"ethSentToCoinbase"`
+	GasFees           string               `json:"gasFees"`
+	Results           []CallBundleTxResult `json:"results"`
+	StateBlockNumber  uint64               `json:"stateBlockNumber"`
+	TotalGasUsed      uint64               `json:"totalGasUsed"`
+}
+
+// CallBundle implements eth_callBundle. Executes signed transactions one after the other on top of the state of a
+// block, in a block with the given number, timestamp and coinbase, and returns their gas, their results and what
+// they pay to the coinbase. A transaction which can't be included, like one with a wrong nonce, fails the bundle.
+// The state block and the timeout in milliseconds of the former eth_callBundle follow the hashes of its bundle, it
+// returns null if one of the transactions isn't found.
+func (api *APIImpl) CallBundle(ctx context.Context, args CallBundleArgs, stateBlockNumberOrHash *rpc.BlockNumberOrHash, timeoutMilliSecondsPtr *int64) (*CallBundleResult, error) {
+	stateBlockNumber := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	timeout := callBundleTimeout
+	switch {
+	case args.TxHashes != nil:
+		if stateBlockNumberOrHash != nil {
+			stateBlockNumber = *stateBlockNumberOrHash
+		}
+		if timeoutMilliSecondsPtr != nil {
+			timeout = time.Duration(*timeoutMilliSecondsPtr) * time.Millisecond
+		}
+	case args.StateBlockNumber != nil:
+		stateBlockNumber = *args.StateBlockNumber
+		fallthrough
+	default:
+		if args.Timeout != nil {
+			timeout = time.Duration(*args.Timeout) * time.Second
+		}
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	txs, err := api.bundleTransactions(ctx, tx, &args)
+	if err != nil || txs == nil {
+		return nil, err
+	}
+
+	parentNumber, parentHash, _, err := rpchelper.GetBlockNumber(stateBlockNumber, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := api._blockReader.Header(ctx, tx, parentHash, parentNumber)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("block %d(%x) not found", parentNumber, parentHash)
+	}
+	stateReader, err := rpchelper.CreateStateReader(ctx, tx, stateBlockNumber, api.filters, api.stateCache)
+	if err != nil {
+		return nil, err
+	}
+	ibs := state.New(stateReader)
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   parent.Coinbase,
+		Difficulty: new(big.Int).Set(parent.Difficulty),
+		Number:     new(big.Int).SetUint64(parentNumber + 1),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + callBundleBlockInterval,
+		MixDigest:  parent.MixDigest,
+	}
+	if args.BlockNumber > 0 {
+		header.Number.SetInt64(args.BlockNumber.Int64())
+	}
+	if args.Coinbase != nil {
+		header.Coinbase = *args.Coinbase
+	}
+	if args.Timestamp != nil {
+		header.Time = *args.Timestamp
+	}
+	if args.GasLimit != nil {
+		header.GasLimit = *args.GasLimit
+	}
+	if args.Difficulty != nil {
+		header.Difficulty = new(big.Int).Set(args.Difficulty)
+	}
+	if chainConfig.IsLondon(header.Number.Uint64()) {
+		header.Eip1559 = true
+		if args.BaseFee != nil {
+			header.BaseFee = new(big.Int).Set(args.BaseFee)
+		} else {
+			header.BaseFee = misc.CalcBaseFee(chainConfig, parent)
+		}
+	}
+	var baseFee *uint256.Int
+	if header.Eip1559 {
+		var overflow bool
+		if baseFee, overflow = uint256.FromBig(header.BaseFee); overflow {
+			return nil, fmt.Errorf("base fee higher than 2^256-1")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	defer func(start time.Time) { log.Trace("Executing EVM callBundle finished", "runtime", time.Since(start)) }(time.Now())
+
+	getHash := func(n uint64) common.Hash {
+		if n > parentNumber {
+			return common.Hash{}
+		}
+		hash, err := rawdb.ReadCanonicalHash(tx, n)
+		if err != nil {
+			log.Debug("Can't get block hash by number", "number", n, "only-canonical", true)
+		}
+		return hash
+	}
+	evm := vm.NewEVM(core.NewEVMBlockContext(header, getHash, nil, &header.Coinbase), vm.TxContext{}, ibs, chainConfig, vm.Config{})
+	bundleDone := make(chan struct{})
+	defer close(bundleDone)
+	go func() {
+		select {
+		case <-ctx.Done():
+			evm.Cancel()
+		case <-bundleDone:
+		}
+	}()
+
+	var (
+		signer       = types.MakeSigner(chainConfig, header.Number.Uint64())
+		rules        = chainConfig.Rules(header.Number.Uint64())
+		gp           = new(core.GasPool).AddGas(header.GasLimit)
+		coinbaseDiff = new(big.Int)
+		gasFees      = new(big.Int)
+		txHashes     = make([]byte, 0, len(txs)*common.HashLength)
+		result       = &CallBundleResult{Results: make([]CallBundleTxResult, 0, len(txs)), StateBlockNumber: parentNumber}
+	)
+	for i, txn := range txs {
+		msg, err := txn.AsMessage(*signer, header.BaseFee, rules)
+		if err != nil {
+			return nil, fmt.Errorf("err: %w; txhash %x", err, txn.Hash())
+		}
+		coinbaseBefore := ibs.GetBalance(header.Coinbase).ToBig()
+		ibs.Prepare(txn.Hash(), common.Hash{}, i)
+		evm.Reset(core.NewEVMTxContext(msg), ibs)
+		execResult, err := core.ApplyMessage(evm, msg, gp, true /* refunds */, false /* gasBailout */)
+		if err != nil {
+			return nil, fmt.Errorf("err: %w; txhash %x", err, txn.Hash())
+		}
+		if evm.Cancelled() {
+			return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+		}
+		if err = ibs.FinalizeTx(rules, state.NewNoopWriter()); err != nil {
+			return nil, err
+		}
+
+		gasPrice := txn.GetPrice()
+		if baseFee != nil {
+			gasPrice = txn.GetEffectiveGasTip(baseFee)
+		}
+		// the coinbase may pay for the transactions, so its balance may decrease
+		txGasFees := new(big.Int).Mul(gasPrice.ToBig(), new(big.Int).SetUint64(execResult.UsedGas))
+		txCoinbaseDiff := new(big.Int).Sub(ibs.GetBalance(header.Coinbase).ToBig(), coinbaseBefore)
+		txResult := CallBundleTxResult{
+			TxHash:            txn.Hash(),
+			GasUsed:           execResult.UsedGas,
+			FromAddress:       msg.From(),
+			ToAddress:         txn.GetTo(),
+			GasPrice:          gasPrice.ToBig().String(),
+			GasFees:           txGasFees.String(),
+			EthSentToCoinbase: new(big.Int).Sub(txCoinbaseDiff, txGasFees).String(),
+			CoinbaseDiff:      txCoinbaseDiff.String(),
+		}
+		if execResult.Err != nil {
+			txResult.Error = execResult.Err.Error()
+			if revert := execResult.Revert(); len(revert) > 0 {
+				if reason, err := abi.UnpackRevert(revert); err == nil {
+					txResult.Revert = reason
+				} else {
+					txResult.Revert = hexutil.Encode(revert)
+				}
+			}
+		} else {
+			txResult.Value = execResult.Return()
+		}
+		result.Results = append(result.Results, txResult)
+		result.TotalGasUsed += execResult.UsedGas
+		coinbaseDiff.Add(coinbaseDiff, txCoinbaseDiff)
+		gasFees.Add(gasFees, txGasFees)
+		hash := txn.Hash()
+		txHashes = append(txHashes, hash[:]...)
+	}
+	result.BundleHash = crypto.Keccak256Hash(txHashes)
+	result.CoinbaseDiff = coinbaseDiff.String()
+	result.GasFees = gasFees.String()
+	result.EthSentToCoinbase = new(big.Int).Sub(coinbaseDiff, gasFees).String()
+	bundleGasPrice := new(big.Int)
+	if result.TotalGasUsed > 0 {
+		bundleGasPrice.Quo(coinbaseDiff, new(big.Int).SetUint64(result.TotalGasUsed))
+	}
+	result.BundleGasPrice = bundleGasPrice.String()
+	return result, nil
+}
+
+// bundleTransactions returns the signed transactions of a bundle, or the mined ones of its hashes. The result is nil
+// if one of the hashes isn't found.
+func (api *APIImpl) bundleTransactions(ctx context.Context, tx kv.Tx, args *CallBundleArgs) (types.Transactions, error) {
+	if args.TxHashes == nil {
+		if len(args.Txs) == 0 {
+			return nil, fmt.Errorf("bundle missing txs")
+		}
+		txs := make(types.Transactions, len(args.Txs))
+		for i, encoded := range args.Txs {
+			txn, err := types.UnmarshalTransactionFromBinary(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("transaction %d: %w", i, err)
+			}
+			txs[i] = txn
+		}
+		return txs, nil
+	}
+	if len(args.TxHashes) == 0 {
+		return nil, nil
+	}
+	txs := make(types.Transactions, 0, len(args.TxHashes))
+	for _, txHash := range args.TxHashes {
+		blockNum, ok, err := api.txnLookup(ctx, tx, txHash)
+		if err != nil || !ok {
+			return nil, err
+		}
+		block, err := api.blockByNumberWithSenders(tx, blockNum)
+		if err != nil || block == nil {
+			return nil, err
+		}
+		var txn types.Transaction
+		for _, transaction := range block.Transactions() {
+			if transaction.Hash() == txHash {
+				txn = transaction
+				break
+			}
+		}
+		if txn == nil {
+			return nil, nil // not error, see https://github.com/ledgerwatch/turbo-geth/issues/1645
+		}
+		txs = append(txs, txn)
+	}
+	return txs, nil
+}
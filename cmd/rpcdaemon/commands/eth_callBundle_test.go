@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallBundle(t *testing.T) {
+	config := *params.AllEthashProtocolChanges
+	config.LondonBlock = big.NewInt(0)
+	var (
+		signer      = types.LatestSignerForChainID(nil)
+		bankKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		bankAddress = crypto.PubkeyToAddress(bankKey.PublicKey)
+		gspec       = &core.Genesis{
+			Config: &config,
+			Alloc:  core.GenesisAlloc{bankAddress: {Balance: big.NewInt(1e18)}},
+		}
+		coinbase = common.Address{0xc0}
+		gasPrice = uint256.NewInt(2e9)
+		// reverts with Error("x")
+		revertCode = common.FromHex("0x6064600c60003960646000fd" +
+			"08c379a0" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"7800000000000000000000000000000000000000000000000000000000000000")
+	)
+	m := stages.MockWithGenesis(t, gspec, bankKey, false)
+	api := NewEthAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false), m.DB, nil, nil, nil, 5000000)
+	ctx := context.Background()
+
+	encode := func(txn types.Transaction) hexutil.Bytes {
+		var buf bytes.Buffer
+		require.NoError(t, txn.MarshalBinary(&buf))
+		return buf.Bytes()
+	}
+	payment, err := types.SignTx(types.NewTransaction(0, coinbase, uint256.NewInt(1000), params.TxGas, gasPrice, nil), *signer, bankKey)
+	require.NoError(t, err)
+	reverted, err := types.SignTx(types.NewContractCreation(1, new(uint256.Int), 1e5, gasPrice, revertCode), *signer, bankKey)
+	require.NoError(t, err)
+
+	var args CallBundleArgs
+	require.NoError(t, json.Unmarshal([]byte(`{"txs":["`+hexutil.Encode(encode(payment))+`","`+hexutil.Encode(encode(reverted))+`"],
+		"blockNumber":"0x1","stateBlockNumber":"latest","coinbase":"`+coinbase.Hex()+`","timestamp":1000}`), &args))
+	require.Nil(t, args.TxHashes)
+	require.Len(t, args.Txs, 2)
+	result, err := api.CallBundle(ctx, args, nil, nil)
+	require.NoError(t, err)
+
+	var genesis *types.Header
+	require.NoError(t, m.DB.View(ctx, func(tx kv.Tx) error {
+		genesis = rawdb.ReadHeaderByNumber(tx, 0)
+		return nil
+	}))
+	tip := new(big.Int).Sub(gasPrice.ToBig(), misc.CalcBaseFee(m.ChainConfig, genesis))
+	gasFees := func(gasUsed uint64) *big.Int { return new(big.Int).Mul(tip, new(big.Int).SetUint64(gasUsed)) }
+
+	require.Len(t, result.Results, 2)
+	paymentResult, revertedResult := result.Results[0], result.Results[1]
+	require.Equal(t, payment.Hash(), paymentResult.TxHash)
+	require.Equal(t, params.TxGas, paymentResult.GasUsed)
+	require.Equal(t, bankAddress, paymentResult.FromAddress)
+	require.Equal(t, &coinbase, paymentResult.ToAddress)
+	require.Equal(t, tip.String(), paymentResult.GasPrice)
+	require.Equal(t, gasFees(params.TxGas).String(), paymentResult.GasFees)
+	require.Equal(t, "1000", paymentResult.EthSentToCoinbase)
+	require.Equal(t, new(big.Int).Add(gasFees(params.TxGas), big.NewInt(1000)).String(), paymentResult.CoinbaseDiff)
+	require.Empty(t, paymentResult.Error)
+
+	require.Equal(t, "execution reverted", revertedResult.Error)
+	require.Equal(t, "x", revertedResult.Revert)
+	require.Nil(t, revertedResult.ToAddress)
+	require.Equal(t, "0", revertedResult.EthSentToCoinbase)
+
+	totalGasUsed := paymentResult.GasUsed + revertedResult.GasUsed
+	coinbaseDiff := new(big.Int).Add(gasFees(totalGasUsed), big.NewInt(1000))
+	require.Equal(t, totalGasUsed, result.TotalGasUsed)
+	require.Equal(t, gasFees(totalGasUsed).String(), result.GasFees)
+	require.Equal(t, "1000", result.EthSentToCoinbase)
+	require.Equal(t, coinbaseDiff.String(), result.CoinbaseDiff)
+	require.Equal(t, new(big.Int).Quo(coinbaseDiff, new(big.Int).SetUint64(totalGasUsed)).String(), result.BundleGasPrice)
+	require.Equal(t, crypto.Keccak256Hash(payment.Hash().Bytes(), reverted.Hash().Bytes()), result.BundleHash)
+	require.Equal(t, uint64(0), result.StateBlockNumber)
+
+	// a transaction which can't be included fails the bundle
+	_, err = api.CallBundle(ctx, CallBundleArgs{Txs: []hexutil.Bytes{encode(reverted)}}, nil, nil)
+	require.ErrorIs(t, err, core.ErrNonceTooHigh)
+
+	// the hashes of mined transactions, null when they aren't found
+	require.NoError(t, json.Unmarshal([]byte(`["`+payment.Hash().Hex()+`"]`), &args))
+	require.Equal(t, []common.Hash{payment.Hash()}, args.TxHashes)
+	result, err = api.CallBundle(ctx, args, nil, nil)
+	require.NoError(t, err)
+	require.Nil(t, result)
+}
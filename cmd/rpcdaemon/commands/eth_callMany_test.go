@@ -12,6 +12,7 @@ import (
 	"github.com/ledgerwatch/erigon/accounts/abi/bind"
 	"github.com/ledgerwatch/erigon/accounts/abi/bind/backends"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands/contracts"
+	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/crypto"
@@ -162,4 +163,24 @@ func TestCallMany(t *testing.T) {
 	if addr1Balance != 100 || addr2Balance != 0 {
 		t.Errorf("eth_callMany: %s", "balanceUnmatch")
 	}
+
+	// the state overrides of a bundle apply to it and to the following bundles
+	probeAddr, emptyAddr := common.HexToAddress("0x1234"), common.HexToAddress("0x5678")
+	probeCode := hexutil.Bytes(common.FromHex("0x73" + emptyAddr.Hex()[2:] + "3160005260206000f3")) // returns the balance of emptyAddr
+	newBalance := (*hexutil.Big)(big.NewInt(1234))
+	callProbe := ethapi.CallArgs{From: &address, To: &probeAddr}
+	res, err = api.CallMany(ctx, []Bundle{
+		{Transactions: []ethapi.CallArgs{callProbe}, StateOverride: &ethapi.StateOverrides{probeAddr: {Code: &probeCode}}},
+		{Transactions: []ethapi.CallArgs{callProbe}, StateOverride: &ethapi.StateOverrides{emptyAddr: {Balance: &newBalance}}},
+		{Transactions: []ethapi.CallArgs{callProbe}},
+	}, StateContext{BlockNumber: rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), TransactionIndex: &txIndex}, nil, &timeout)
+	if err != nil {
+		t.Fatalf("eth_callMany: %v", err)
+	}
+	for i, expected := range []int64{0, 1234, 1234} {
+		balance, ok := new(big.Int).SetString(fmt.Sprintf("%v", res[i][0]["value"]), 16)
+		if !ok || balance.Int64() != expected {
+			t.Errorf("eth_callMany: bundle %d returned %v, expected balance %d", i, res[i][0], expected)
+		}
+	}
 }
@@ -1,9 +1,13 @@
 package commands
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"math/big"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -28,6 +32,7 @@ import (
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/ledgerwatch/erigon/turbo/stages"
+	"github.com/ledgerwatch/erigon/turbo/trie"
 )
 
 func TestEstimateGas(t *testing.T) {
@@ -42,11 +47,40 @@ func TestEstimateGas(t *testing.T) {
 	if _, err := api.EstimateGas(context.Background(), &ethapi.CallArgs{
 		From: &from,
 		To:   &to,
-	}, nil); err != nil {
+	}, nil, nil); err != nil {
 		t.Errorf("calling EstimateGas: %v", err)
 	}
 }
 
+func TestEstimateGasStateOverrides(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, stages.Mock(t))
+	mining := txpool.NewMiningClient(conn)
+	ff := rpchelper.New(ctx, nil, nil, mining, func() {})
+	api := NewEthAPI(NewBaseApi(ff, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	from := common.HexToAddress("0xdead")
+	to := common.HexToAddress("0x1234")
+	args := &ethapi.CallArgs{From: &from, To: &to, GasPrice: (*hexutil.Big)(big.NewInt(1)), Value: (*hexutil.Big)(big.NewInt(1000))}
+
+	if _, err := api.EstimateGas(context.Background(), args, nil, nil); err == nil || err.Error() != "insufficient funds for transfer" {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+
+	balance := (*hexutil.Big)(big.NewInt(1e18))
+	code := hexutil.Bytes(common.FromHex("0x6001600055")) // stores 1 at slot 0
+	gas, err := api.EstimateGas(context.Background(), args, nil, &ethapi.StateOverrides{
+		from: {Balance: &balance},
+		to:   {Code: &code},
+	})
+	if err != nil {
+		t.Fatalf("calling EstimateGas: %v", err)
+	}
+	if gas <= hexutil.Uint64(params.TxGas+params.SstoreSetGasEIP2200) {
+		t.Errorf("expected the estimate to include the storage write, got %d", gas)
+	}
+}
+
 func TestEthCallNonCanonical(t *testing.T) {
 	db := rpcdaemontest.CreateTestKV(t)
 	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
@@ -56,7 +90,7 @@ func TestEthCallNonCanonical(t *testing.T) {
 	if _, err := api.Call(context.Background(), ethapi.CallArgs{
 		From: &from,
 		To:   &to,
-	}, rpc.BlockNumberOrHashWithHash(common.HexToHash("0x3fcb7c0d4569fddc89cbea54b42f163e0c789351d98810a513895ab44b47020b"), true), nil); err != nil {
+	}, rpc.BlockNumberOrHashWithHash(common.HexToHash("0x3fcb7c0d4569fddc89cbea54b42f163e0c789351d98810a513895ab44b47020b"), true), nil, nil); err != nil {
 		if fmt.Sprintf("%v", err) != "hash 3fcb7c0d4569fddc89cbea54b42f163e0c789351d98810a513895ab44b47020b is not currently canonical" {
 			t.Errorf("wrong error: %v", err)
 		}
@@ -81,11 +115,295 @@ func TestEthCallToPrunedBlock(t *testing.T) {
 		From: &bankAddress,
 		To:   &contractAddress,
 		Data: &callDataBytes,
-	}, rpc.BlockNumberOrHashWithNumber(ethCallBlockNumber), nil); err != nil {
+	}, rpc.BlockNumberOrHashWithNumber(ethCallBlockNumber), nil, nil); err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
 
+func TestEthCallBlockOverrides(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	from := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	to := common.HexToAddress("0x1234")
+
+	number, timestamp, gasLimit := hexutil.Uint64(100000), hexutil.Uint64(1700000000), hexutil.Uint(12345678)
+	coinbase := common.HexToAddress("0xc0ffee")
+	prevRandao := common.HexToHash("0xabcdef")
+	overrides := &ethapi.BlockOverrides{BlockNumber: &number, Timestamp: &timestamp, GasLimit: &gasLimit, Coinbase: &coinbase, PrevRandao: &prevRandao}
+
+	for _, tt := range []struct {
+		name     string
+		opcode   byte
+		expected common.Hash
+	}{
+		{"coinbase", 0x41, coinbase.Hash()},
+		{"timestamp", 0x42, common.BigToHash(big.NewInt(int64(timestamp)))},
+		{"number", 0x43, common.BigToHash(big.NewInt(int64(number)))},
+		{"prevRandao", 0x44, prevRandao},
+		{"gasLimit", 0x45, common.BigToHash(big.NewInt(int64(gasLimit)))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// returns the value pushed by the opcode
+			code := hexutil.Bytes(append([]byte{tt.opcode}, common.FromHex("0x60005260206000f3")...))
+			res, err := api.Call(context.Background(), ethapi.CallArgs{From: &from, To: &to},
+				rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), &ethapi.StateOverrides{to: {Code: &code}}, overrides)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if common.BytesToHash(res) != tt.expected {
+				t.Errorf("expected %x, got %x", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestEthCallHistorical(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	key2, _ := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	holder := crypto.PubkeyToAddress(key2.PublicKey)
+	token := crypto.CreateAddress(common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7"), 2)
+
+	// the token is deployed in block 3, 10 are minted to the holder in block 4, who transfers 3 in block 5
+	balanceOf := hexutil.Bytes(append(common.FromHex("0x70a08231"), common.LeftPadBytes(holder[:], 32)...))
+	for blockNum, want := range map[rpc.BlockNumber]int64{3: 0, 4: 10, 5: 7, rpc.LatestBlockNumber: 7} {
+		res, err := api.Call(context.Background(), ethapi.CallArgs{To: &token, Data: &balanceOf}, rpc.BlockNumberOrHashWithNumber(blockNum), nil, nil)
+		if err != nil {
+			t.Fatalf("calling balanceOf at block %d: %v", blockNum, err)
+		}
+		if got := new(big.Int).SetBytes(res); got.Int64() != want {
+			t.Errorf("wrong balance at block %d: got %d, want %d", blockNum, got, want)
+		}
+	}
+}
+
+// eth_estimateGas executes on the state after the requested block, not on the latest one
+func TestEstimateGasHistorical(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	key2, _ := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	holder := crypto.PubkeyToAddress(key2.PublicKey)
+	token := crypto.CreateAddress(common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7"), 2)
+
+	// the transfer can only be estimated once the tokens are minted, in block 4
+	transfer := hexutil.Bytes(append(common.FromHex("0xa9059cbb"), append(common.LeftPadBytes(common.HexToAddress("0x1234").Bytes(), 32), common.LeftPadBytes([]byte{5}, 32)...)...))
+	args := &ethapi.CallArgs{From: &holder, To: &token, Data: &transfer}
+	block3, block4 := rpc.BlockNumberOrHashWithNumber(3), rpc.BlockNumberOrHashWithNumber(4)
+	if _, err := api.EstimateGas(context.Background(), args, &block3, nil); err == nil {
+		t.Error("expected the transfer to fail at block 3")
+	}
+	if _, err := api.EstimateGas(context.Background(), args, &block4, nil); err != nil {
+		t.Errorf("estimating the transfer at block 4: %v", err)
+	}
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if _, err := api.EstimateGas(context.Background(), args, &latest, nil); err != nil {
+		t.Errorf("estimating the transfer at the latest block: %v", err)
+	}
+}
+
+func TestEthCallTimeout(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 1_000_000_000)
+	from := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	to := common.HexToAddress("0x1234")
+	code := hexutil.Bytes(common.FromHex("0x5b600056")) // loops until it runs out of gas
+	call := func(ctx context.Context) error {
+		_, err := api.Call(ctx, ethapi.CallArgs{From: &from, To: &to},
+			rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), &ethapi.StateOverrides{to: {Code: &code}}, nil)
+		return err
+	}
+
+	api.EvmCallTimeout = 10 * time.Millisecond
+	start := time.Now()
+	if err := call(context.Background()); err == nil || !strings.Contains(err.Error(), "execution aborted") {
+		t.Fatalf("expected the execution to be aborted, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("the execution wasn't aborted in time, it took %v", elapsed)
+	}
+
+	// the deadline of the method takes precedence
+	api.EvmCallTimeout = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if err := call(ctx); err == nil {
+		t.Fatal("expected the execution to be aborted")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("the execution wasn't aborted in time, it took %v", elapsed)
+	}
+}
+
+func TestEthCallMovePrecompile(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	from := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	sha256Addr, moved := common.BytesToAddress([]byte{2}), common.HexToAddress("0x1234")
+	code := hexutil.Bytes(common.FromHex("0x602a60005260206000f3")) // returns 42
+	input := hexutil.Bytes("abc")
+	call := func(to common.Address, overrides ethapi.StateOverrides) (hexutil.Bytes, error) {
+		return api.Call(context.Background(), ethapi.CallArgs{From: &from, To: &to, Data: &input},
+			rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), &overrides, nil)
+	}
+
+	overrides := ethapi.StateOverrides{sha256Addr: {Code: &code, MovePrecompileTo: &moved}}
+	res, err := call(sha256Addr, overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(res, common.LeftPadBytes([]byte{42}, 32)) {
+		t.Fatalf("the code of the overridden precompile wasn't run, got %x", res)
+	}
+	res, err = call(moved, overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sha256.Sum256(input); !bytes.Equal(res, want[:]) {
+		t.Fatalf("wrong result of the moved precompile: got %x, want %x", res, want)
+	}
+
+	ecrecoverAddr := common.BytesToAddress([]byte{1})
+	for name, overrides := range map[string]ethapi.StateOverrides{
+		"not a precompile":    {moved: {MovePrecompileTo: &sha256Addr}},
+		"onto a precompile":   {sha256Addr: {MovePrecompileTo: &ecrecoverAddr}},
+		"to the same address": {sha256Addr: {MovePrecompileTo: &moved}, ecrecoverAddr: {MovePrecompileTo: &moved}},
+	} {
+		if _, err := call(moved, overrides); err == nil {
+			t.Errorf("no error when moving %s", name)
+		}
+	}
+}
+
+func TestGetProof(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	api.MaxGetProofRewindBlocks = 5
+	ctx := context.Background()
+
+	sender := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	// the token deployed by the sender in block 3, minted in block 4
+	token := crypto.CreateAddress(sender, 2)
+	storageKeys := []string{"0x0", "0x2", "0x05"}
+	for _, number := range []rpc.BlockNumber{10, 7, 5} {
+		blockNrOrHash := rpc.BlockNumberOrHashWithNumber(number)
+		block, err := api.GetBlockByNumber(ctx, number, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root := block["stateRoot"].(common.Hash)
+		for _, address := range []common.Address{sender, token, common.HexToAddress("0xdead")} {
+			result, err := api.GetProof(ctx, address, storageKeys, blockNrOrHash)
+			if err != nil {
+				t.Fatalf("block %d, address %x: %v", number, address, err)
+			}
+			checkProof(t, root, result.AccountProof)
+			balance, err := api.GetBalance(ctx, address, blockNrOrHash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, balance.ToInt().String(), result.Balance.ToInt().String())
+			nonce, err := api.GetTransactionCount(ctx, address, blockNrOrHash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, *nonce, result.Nonce)
+			for i, storage := range result.StorageProof {
+				assert.Equal(t, storageKeys[i], storage.Key)
+				if address == token {
+					checkProof(t, result.StorageHash, storage.Proof)
+				}
+				value, err := api.GetStorageAt(ctx, address, storageKeys[i], blockNrOrHash)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, common.HexToHash(value).Big().String(), storage.Value.ToInt().String())
+			}
+		}
+	}
+	result, err := api.GetProof(ctx, token, storageKeys, rpc.BlockNumberOrHashWithNumber(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "10", result.StorageProof[0].Value.ToInt().String())
+	assert.NotEqual(t, trie.EmptyRoot, result.StorageHash)
+
+	// the state can't be rewound further than MaxGetProofRewindBlocks, nor proved ahead of the head
+	if _, err = api.GetProof(ctx, sender, nil, rpc.BlockNumberOrHashWithNumber(4)); err == nil {
+		t.Error("expected an error for a block too old")
+	}
+	if _, err = api.GetProof(ctx, sender, nil, rpc.BlockNumberOrHashWithNumber(11)); err == nil {
+		t.Error("expected an error for a block ahead of the head")
+	}
+	if _, err = api.GetProof(ctx, sender, []string{"0x" + strings.Repeat("00", 33)}, rpc.BlockNumberOrHashWithNumber(10)); err == nil {
+		t.Error("expected an error for a storage key longer than 32 bytes")
+	}
+}
+
+// This test checks that concurrent eth_getProof calls rewinding the state don't interfere, run it with -race.
+func TestGetProofConcurrent(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	api.MaxGetProofRewindBlocks = 5
+	ctx := context.Background()
+
+	token := crypto.CreateAddress(common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7"), 2)
+	numbers := []rpc.BlockNumber{5, 6, 7, 8, 9, 10}
+	results := make([]*ethapi.AccountResult, 4*len(numbers))
+	errs := make([]error, len(results))
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = api.GetProof(ctx, token, []string{"0x0"}, rpc.BlockNumberOrHashWithNumber(numbers[i%len(numbers)]))
+		}(i)
+	}
+	wg.Wait()
+	for i, result := range results {
+		if errs[i] != nil {
+			t.Fatalf("block %d: %v", numbers[i%len(numbers)], errs[i])
+		}
+		block, err := api.GetBlockByNumber(ctx, numbers[i%len(numbers)], false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkProof(t, block["stateRoot"].(common.Hash), result.AccountProof)
+		checkProof(t, result.StorageHash, result.StorageProof[0].Proof)
+	}
+
+	// with the history of state, the rewind needs a private copy of the aggregator
+	historyV2 := true
+	api._historyV2 = &historyV2
+	if _, err := api.GetProof(ctx, token, nil, rpc.BlockNumberOrHashWithNumber(7)); err == nil {
+		t.Error("expected an error without the files of the history of state")
+	}
+}
+
+// checkProof checks that the proof nodes chain from the root, each node referencing the hash of the next one
+func checkProof(t *testing.T, root common.Hash, proof []string) {
+	t.Helper()
+	if len(proof) == 0 {
+		t.Fatal("empty proof")
+	}
+	hash := root.Bytes()
+	for i, hexNode := range proof {
+		node := hexutil.MustDecode(hexNode)
+		if i == 0 {
+			assert.Equal(t, hash, crypto.Keccak256(node), "root node")
+		} else if len(node) >= 32 {
+			assert.True(t, bytes.Contains(hexutil.MustDecode(proof[i-1]), crypto.Keccak256(node)), "node %d not referenced by its parent", i)
+		}
+	}
+}
+
 func TestGetBlockByTimestampLatestTime(t *testing.T) {
 	ctx := context.Background()
 	db := rpcdaemontest.CreateTestKV(t)
@@ -97,7 +415,7 @@ func TestGetBlockByTimestampLatestTime(t *testing.T) {
 	defer tx.Rollback()
 
 	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
-	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil)
+	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil)
 
 	latestBlock := rawdb.ReadCurrentBlock(tx)
 	response, err := ethapi.RPCMarshalBlock(latestBlock, true, false)
@@ -134,7 +452,7 @@ func TestGetBlockByTimestampOldestTime(t *testing.T) {
 	defer tx.Rollback()
 
 	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
-	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil)
+	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil)
 
 	oldestBlock, err := rawdb.ReadBlockByNumber(tx, 0)
 	if err != nil {
@@ -175,7 +493,7 @@ func TestGetBlockByTimeHigherThanLatestBlock(t *testing.T) {
 	defer tx.Rollback()
 
 	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
-	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil)
+	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil)
 
 	latestBlock := rawdb.ReadCurrentBlock(tx)
 
@@ -213,7 +531,7 @@ func TestGetBlockByTimeMiddle(t *testing.T) {
 	defer tx.Rollback()
 
 	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
-	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil)
+	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil)
 
 	currentHeader := rawdb.ReadCurrentHeader(tx)
 	oldestHeader, err := api._blockReader.HeaderByNumber(ctx, tx, 0)
@@ -264,7 +582,7 @@ func TestGetBlockByTimestamp(t *testing.T) {
 	defer tx.Rollback()
 
 	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
-	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil)
+	api := NewErigonAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil)
 
 	highestBlockNumber := rawdb.ReadCurrentHeader(tx).Number
 	pickedBlock, err := rawdb.ReadBlockByNumber(tx, highestBlockNumber.Uint64()/3)
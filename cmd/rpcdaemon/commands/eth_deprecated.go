@@ -8,9 +8,12 @@ import (
 	"github.com/ledgerwatch/erigon/common/hexutil"
 )
 
-// Accounts implements eth_accounts. Returns a list of addresses owned by the client.
+// Accounts implements eth_accounts. Returns a list of addresses owned by the client, the ones of the external signer.
 // Deprecated: This function will be removed in the future.
 func (api *APIImpl) Accounts(ctx context.Context) ([]common.Address, error) {
+	if api.Signer != nil {
+		return api.Signer.Accounts(ctx)
+	}
 	return []common.Address{}, fmt.Errorf(NotAvailableDeprecated, "eth_accounts")
 }
 
@@ -19,8 +22,3 @@ func (api *APIImpl) Accounts(ctx context.Context) ([]common.Address, error) {
 func (api *APIImpl) Sign(ctx context.Context, _ common.Address, _ hexutil.Bytes) (hexutil.Bytes, error) {
 	return hexutil.Bytes(""), fmt.Errorf(NotAvailableDeprecated, "eth_sign")
 }
-
-// SignTransaction deprecated
-func (api *APIImpl) SignTransaction(_ context.Context, txObject interface{}) (common.Hash, error) {
-	return common.Hash{0}, fmt.Errorf(NotAvailableDeprecated, "eth_signTransaction")
-}
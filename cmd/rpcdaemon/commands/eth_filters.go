@@ -2,11 +2,17 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
+	"math/big"
 
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/misc"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/log/v3"
@@ -118,8 +124,14 @@ func (api *APIImpl) GetFilterChanges(_ context.Context, index string) ([]interfa
 	return stub, nil
 }
 
+// NewHeadsOptions are the optional settings of the newHeads subscription
+type NewHeadsOptions struct {
+	// IncludeFeeStats adds baseFeeDelta, nextBaseFee, gasUsedRatio and gasTargetUtilization to every notification
+	IncludeFeeStats bool `json:"includeFeeStats"`
+}
+
 // NewHeads send a notification each time a new (header) block is appended to the chain.
-func (api *APIImpl) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+func (api *APIImpl) NewHeads(ctx context.Context, opts *NewHeadsOptions) (*rpc.Subscription, error) {
 	if api.filters == nil {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
@@ -136,11 +148,38 @@ func (api *APIImpl) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 		id := api.filters.SubscribeNewHeads(headers)
 		defer api.filters.UnsubscribeHeads(id)
 
+		var (
+			parent *types.Header
+			cc     *params.ChainConfig // read with the first head, for the fee stats
+		)
 		for {
 			select {
 			case h, ok := <-headers:
 				if h != nil {
-					err := notifier.Notify(rpcSub.ID, h)
+					var notification interface{} = h
+					if opts != nil && opts.IncludeFeeStats {
+						if parent == nil || parent.Hash() != h.ParentHash {
+							parent = nil
+							if h.Number.Sign() > 0 {
+								parent = api.headerForFeeStats(h.ParentHash, h.Number.Uint64()-1)
+							}
+						}
+						var enriched map[string]json.RawMessage
+						var err error
+						if cc == nil {
+							cc, err = api.chainConfigForFeeStats()
+						}
+						if err == nil {
+							enriched, err = headerWithFeeStats(cc, h, parent)
+						}
+						if err != nil {
+							log.Warn("error while enriching new head", "err", err)
+						} else {
+							notification = enriched
+						}
+						parent = h
+					}
+					err := notifier.Notify(rpcSub.ID, notification)
 					if err != nil {
 						log.Warn("error while notifying subscription", "err", err)
 						return
@@ -159,6 +198,63 @@ func (api *APIImpl) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	return rpcSub, nil
 }
 
+// headerForFeeStats reads the parent of a new head, nil if it's unknown
+func (api *APIImpl) headerForFeeStats(hash common.Hash, number uint64) *types.Header {
+	tx, err := api.db.BeginRo(context.Background())
+	if err != nil {
+		return nil
+	}
+	defer tx.Rollback()
+	h, err := api._blockReader.Header(context.Background(), tx, hash, number)
+	if err != nil {
+		return nil
+	}
+	return h
+}
+
+// chainConfigForFeeStats reads the chain config, once per newHeads subscription with the fee stats
+func (api *APIImpl) chainConfigForFeeStats() (cc *params.ChainConfig, err error) {
+	err = api.db.View(context.Background(), func(tx kv.Tx) error {
+		cc, err = api.chainConfig(tx)
+		return err
+	})
+	return cc, err
+}
+
+// headerWithFeeStats returns the JSON representation of h extended with EIP-1559 fee statistics, so fee
+// trackers don't need a follow-up request per block
+func headerWithFeeStats(cc *params.ChainConfig, h, parent *types.Header) (map[string]json.RawMessage, error) {
+	enc, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err = json.Unmarshal(enc, &fields); err != nil {
+		return nil, err
+	}
+	stats := map[string]interface{}{}
+	if h.GasLimit > 0 {
+		stats["gasUsedRatio"] = float64(h.GasUsed) / float64(h.GasLimit)
+		if target := h.GasLimit / params.ElasticityMultiplier; target > 0 {
+			stats["gasTargetUtilization"] = float64(h.GasUsed) / float64(target)
+		}
+	}
+	if h.BaseFee != nil {
+		if parent != nil && parent.BaseFee != nil {
+			stats["baseFeeDelta"] = (*hexutil.Big)(new(big.Int).Sub(h.BaseFee, parent.BaseFee))
+		}
+		if cc != nil && cc.IsLondon(h.Number.Uint64()+1) {
+			stats["nextBaseFee"] = (*hexutil.Big)(misc.CalcBaseFee(cc, h))
+		}
+	}
+	for k, v := range stats {
+		if fields[k], err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
 // NewPendingTransactions send a notification each time a new (header) block is appended to the chain.
 func (api *APIImpl) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
 	if api.filters == nil {
@@ -203,7 +299,9 @@ func (api *APIImpl) NewPendingTransactions(ctx context.Context) (*rpc.Subscripti
 }
 
 // Logs send a notification each time a new log appears.
-func (api *APIImpl) Logs(ctx context.Context, crit filters.FilterCriteria) (*rpc.Subscription, error) {
+// The optional expression is evaluated against every matching log and only logs satisfying it are sent,
+// see rpchelper.LogsExpression for the syntax.
+func (api *APIImpl) Logs(ctx context.Context, crit filters.FilterCriteria, expression *string) (*rpc.Subscription, error) {
 	if api.filters == nil {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
@@ -211,6 +309,13 @@ func (api *APIImpl) Logs(ctx context.Context, crit filters.FilterCriteria) (*rpc
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	var expr *rpchelper.LogsExpression
+	if expression != nil {
+		var err error
+		if expr, err = rpchelper.ParseLogsExpression(*expression); err != nil {
+			return &rpc.Subscription{}, err
+		}
+	}
 
 	rpcSub := notifier.CreateSubscription()
 
@@ -222,7 +327,7 @@ func (api *APIImpl) Logs(ctx context.Context, crit filters.FilterCriteria) (*rpc
 		for {
 			select {
 			case h, ok := <-logs:
-				if h != nil {
+				if h != nil && expr.Match(h) {
 					err := notifier.Notify(rpcSub.ID, h)
 					if err != nil {
 						log.Warn("error while notifying subscription", "err", err)
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"math/big"
 	"math/rand"
 	"sync"
 	"testing"
@@ -14,6 +15,7 @@ import (
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/ledgerwatch/erigon/turbo/stages"
@@ -96,3 +98,21 @@ func TestLogsSubscribeAndUnsubscribe_WithoutConcurrentMapIssue(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestHeaderWithFeeStats(t *testing.T) {
+	assert := assert.New(t)
+	parent := &types.Header{Number: big.NewInt(14_999_999), GasLimit: 30_000_000, BaseFee: big.NewInt(1000)}
+	h := &types.Header{Number: big.NewInt(15_000_000), GasLimit: 30_000_000, GasUsed: 22_500_000, BaseFee: big.NewInt(1100), Eip1559: true}
+
+	fields, err := headerWithFeeStats(params.MainnetChainConfig, h, parent)
+	assert.NoError(err)
+	assert.Equal(`0.75`, string(fields["gasUsedRatio"]))
+	assert.Equal(`1.5`, string(fields["gasTargetUtilization"]))
+	assert.Equal(`"0x64"`, string(fields["baseFeeDelta"]))
+	assert.Equal(`"0x490"`, string(fields["nextBaseFee"]))
+	assert.Contains(fields, "parentHash")
+
+	fields, err = headerWithFeeStats(params.MainnetChainConfig, h, nil)
+	assert.NoError(err)
+	assert.NotContains(fields, "baseFeeDelta")
+}
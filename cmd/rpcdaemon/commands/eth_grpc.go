@@ -0,0 +1,397 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/grpcapi"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// EthGrpcServer serves the core read methods of the eth API over gRPC, without their JSON encoding. The calls are
+// limited by the timeouts of their JSON-RPC counterparts, and Logs by GetLogsLimits like eth_getLogs.
+type EthGrpcServer struct {
+	grpcapi.UnimplementedETHServer
+	*BaseAPI
+	db             kv.RoDB
+	GetLogsLimits  GetLogsLimits
+	MethodTimeouts rpc.MethodTimeouts
+}
+
+// NewEthGrpcServer returns EthGrpcServer instance
+func NewEthGrpcServer(base *BaseAPI, db kv.RoDB) *EthGrpcServer {
+	return &EthGrpcServer{BaseAPI: base, db: db}
+}
+
+// Register registers the service on a gRPC server
+func (s *EthGrpcServer) Register(server grpc.ServiceRegistrar) {
+	grpcapi.RegisterETHServer(server, s)
+}
+
+// grpcError returns the gRPC status of err, the statuses being kept
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var limitErr *getLogsLimitError
+	switch {
+	case errors.As(err, &limitErr) && limitErr.limit == "timeout", errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.As(err, &limitErr):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// blockNumberOrHash returns the block of a request, the latest one if it's unset
+func blockNumberOrHash(ref *grpcapi.BlockRef) (rpc.BlockNumberOrHash, error) {
+	if ref == nil {
+		return rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil
+	}
+	if hash := ref.GetBlockHash(); len(hash) > 0 {
+		if len(hash) != common.HashLength {
+			return rpc.BlockNumberOrHash{}, status.Errorf(codes.InvalidArgument, "invalid block hash of %d bytes", len(hash))
+		}
+		return rpc.BlockNumberOrHashWithHash(common.BytesToHash(hash), false), nil
+	}
+	return rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(ref.GetBlockNumber())), nil
+}
+
+// blockNumber returns the number and the hash of the block of a request, NotFound if it's unknown
+func (s *EthGrpcServer) blockNumber(tx kv.Tx, ref *grpcapi.BlockRef) (uint64, common.Hash, error) {
+	blockNrOrHash, err := blockNumberOrHash(ref)
+	if err != nil {
+		return 0, common.Hash{}, err
+	}
+	if hash, ok := blockNrOrHash.Hash(); ok && rawdb.ReadHeaderNumber(tx, hash) == nil {
+		return 0, common.Hash{}, status.Errorf(codes.NotFound, "block %x not found", hash)
+	}
+	blockNumber, hash, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, s.filters)
+	if err != nil {
+		return 0, common.Hash{}, err
+	}
+	if hash == (common.Hash{}) {
+		return 0, common.Hash{}, status.Errorf(codes.NotFound, "block %d not found", blockNumber)
+	}
+	return blockNumber, hash, nil
+}
+
+// grpcAddress returns the address of a request, InvalidArgument if it isn't 20 bytes long
+func grpcAddress(b []byte) (common.Address, error) {
+	if len(b) != common.AddressLength {
+		return common.Address{}, status.Errorf(codes.InvalidArgument, "invalid address of %d bytes", len(b))
+	}
+	return common.BytesToAddress(b), nil
+}
+
+func (s *EthGrpcServer) BlockNumber(ctx context.Context, _ *emptypb.Empty) (_ *grpcapi.BlockNumberReply, err error) {
+	defer func() { err = grpcError(err) }()
+	ctx, cancel := s.MethodTimeouts.WithTimeout(ctx, "eth_blockNumber")
+	defer cancel()
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	blockNumber, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestExecutedBlockNumber), tx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &grpcapi.BlockNumberReply{BlockNumber: blockNumber}, nil
+}
+
+// block returns the block of a request with the senders of its transactions
+func (s *EthGrpcServer) block(tx kv.Tx, ref *grpcapi.BlockRef) (*types.Block, error) {
+	blockNumber, hash, err := s.blockNumber(tx, ref)
+	if err != nil {
+		return nil, err
+	}
+	block, err := s.blockWithSenders(tx, hash, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, status.Errorf(codes.NotFound, "block %d not found", blockNumber)
+	}
+	return block, nil
+}
+
+func (s *EthGrpcServer) Block(ctx context.Context, req *grpcapi.BlockRequest) (_ *grpcapi.BlockReply, err error) {
+	defer func() { err = grpcError(err) }()
+	ctx, cancel := s.MethodTimeouts.WithTimeout(ctx, "eth_getBlockByNumber")
+	defer cancel()
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	block, err := s.block(tx, req.GetBlock())
+	if err != nil {
+		return nil, err
+	}
+	blockRlp, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return nil, err
+	}
+	senders := block.Body().SendersFromTxs()
+	reply := &grpcapi.BlockReply{BlockRlp: blockRlp, Senders: make([][]byte, len(senders))}
+	for i, sender := range senders {
+		reply.Senders[i] = sender.Bytes()
+	}
+	return reply, nil
+}
+
+func (s *EthGrpcServer) Receipts(ctx context.Context, req *grpcapi.BlockRequest) (_ *grpcapi.ReceiptsReply, err error) {
+	defer func() { err = grpcError(err) }()
+	ctx, cancel := s.MethodTimeouts.WithTimeout(ctx, "eth_getBlockReceipts")
+	defer cancel()
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	block, err := s.block(tx, req.GetBlock())
+	if err != nil {
+		return nil, err
+	}
+	chainConfig, err := s.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := s.getReceipts(ctx, tx, chainConfig, block, block.Body().SendersFromTxs())
+	if err != nil {
+		return nil, fmt.Errorf("getReceipts error: %w", err)
+	}
+	reply := &grpcapi.ReceiptsReply{Receipts: make([]*grpcapi.Receipt, len(receipts))}
+	for i, receipt := range receipts {
+		r := &grpcapi.Receipt{
+			TransactionHash:   receipt.TxHash.Bytes(),
+			TransactionIndex:  uint64(receipt.TransactionIndex),
+			Type:              uint32(receipt.Type),
+			Status:            receipt.Status,
+			CumulativeGasUsed: receipt.CumulativeGasUsed,
+			GasUsed:           receipt.GasUsed,
+			Logs:              make([]*grpcapi.Log, len(receipt.Logs)),
+		}
+		if receipt.ContractAddress != (common.Address{}) {
+			r.ContractAddress = receipt.ContractAddress.Bytes()
+		}
+		for j, lg := range receipt.Logs {
+			r.Logs[j] = grpcLog(lg)
+		}
+		reply.Receipts[i] = r
+	}
+	return reply, nil
+}
+
+func grpcLog(lg *types.Log) *grpcapi.Log {
+	topics := make([][]byte, len(lg.Topics))
+	for i, topic := range lg.Topics {
+		topics[i] = topic.Bytes()
+	}
+	return &grpcapi.Log{
+		Address:          lg.Address.Bytes(),
+		Topics:           topics,
+		Data:             lg.Data,
+		BlockNumber:      lg.BlockNumber,
+		BlockHash:        lg.BlockHash.Bytes(),
+		TransactionHash:  lg.TxHash.Bytes(),
+		TransactionIndex: uint64(lg.TxIndex),
+		LogIndex:         uint64(lg.Index),
+	}
+}
+
+// Logs sends the logs of the blocks from req.FromBlock to req.ToBlock, or the latest block if it's 0, by batches of
+// blocks, each read with its own transaction. GetLogsLimits apply to the whole stream.
+func (s *EthGrpcServer) Logs(req *grpcapi.LogsRequest, stream grpcapi.ETH_LogsServer) (err error) {
+	defer func() { err = grpcError(err) }()
+	ctx, cancel := s.MethodTimeouts.WithTimeout(stream.Context(), "eth_getLogs")
+	defer cancel()
+	if s.GetLogsLimits.Timeout > 0 {
+		var cancelLogs context.CancelFunc
+		ctx, cancelLogs = context.WithTimeout(ctx, s.GetLogsLimits.Timeout)
+		defer cancelLogs()
+	}
+
+	var crit filters.FilterCriteria
+	for _, address := range req.GetAddresses() {
+		crit.Addresses = append(crit.Addresses, common.BytesToAddress(address))
+	}
+	for _, position := range req.GetTopics() {
+		var topics []common.Hash
+		for _, topic := range position.GetTopics() {
+			topics = append(topics, common.BytesToHash(topic))
+		}
+		crit.Topics = append(crit.Topics, topics)
+	}
+	begin, end, err := s.logsBlocks(ctx, req)
+	if err != nil || begin > end {
+		return err
+	}
+	if max := s.GetLogsLimits.MaxBlockRange; max > 0 && end-begin >= max {
+		return status.Errorf(codes.InvalidArgument, "block range of %d blocks exceeds the limit of %d blocks", end-begin+1, max)
+	}
+	sent := 0
+	for from := begin; ; {
+		logs, to, done, err := s.readLogs(ctx, crit, from, end)
+		if err != nil {
+			return err
+		}
+		if max := s.GetLogsLimits.MaxResults; max > 0 && sent+len(logs) > max {
+			return status.Errorf(codes.ResourceExhausted, "more than %d logs in the blocks %d to %d", max, begin, to)
+		}
+		for _, lg := range logs {
+			if err = stream.Send(grpcLog(lg)); err != nil {
+				return err
+			}
+		}
+		sent += len(logs)
+		if done {
+			return nil
+		}
+		from = to + 1
+	}
+}
+
+// logsBlocks returns the first and the last block of a Logs request, the last one being capped by the latest
+// executed block
+func (s *EthGrpcServer) logsBlocks(ctx context.Context, req *grpcapi.LogsRequest) (uint64, uint64, error) {
+	if req.GetToBlock() != 0 && req.GetFromBlock() > req.GetToBlock() {
+		return 0, 0, status.Errorf(codes.InvalidArgument, "from block %d is after to block %d", req.GetFromBlock(), req.GetToBlock())
+	}
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+	latest, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestExecutedBlockNumber), tx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	end := req.GetToBlock()
+	if end == 0 || end > latest {
+		end = latest
+	}
+	return req.GetFromBlock(), end, nil
+}
+
+// readLogs reads the logs of the next batch of blocks from from, done is true if it's the last one
+func (s *EthGrpcServer) readLogs(ctx context.Context, crit filters.FilterCriteria, from, end uint64) (logs types.Logs, to uint64, done bool, err error) {
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer tx.Rollback()
+
+	to = from + logsReplayBatchSize - 1
+	if to >= end {
+		to, done = end, true
+	}
+	// the limits of the stream are enforced by Logs
+	logs, _, err = s.getLogsInRange(ctx, s.db, tx, from, to, crit, GetLogsLimits{})
+	return logs, to, done, err
+}
+
+func (s *EthGrpcServer) Account(ctx context.Context, req *grpcapi.AccountRequest) (_ *grpcapi.AccountReply, err error) {
+	defer func() { err = grpcError(err) }()
+	ctx, cancel := s.MethodTimeouts.WithTimeout(ctx, "eth_getBalance")
+	defer cancel()
+	address, err := grpcAddress(req.GetAddress())
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	reader, err := s.stateReader(ctx, tx, req.GetBlock())
+	if err != nil {
+		return nil, err
+	}
+	acc, err := reader.ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil {
+		return &grpcapi.AccountReply{}, nil
+	}
+	reply := &grpcapi.AccountReply{Exists: true, Balance: acc.Balance.Bytes(), Nonce: acc.Nonce, CodeHash: acc.CodeHash.Bytes()}
+	if req.GetWithCode() {
+		if reply.Code, err = reader.ReadAccountCode(address, acc.Incarnation, acc.CodeHash); err != nil {
+			return nil, err
+		}
+	}
+	return reply, nil
+}
+
+func (s *EthGrpcServer) Storage(ctx context.Context, req *grpcapi.StorageRequest) (_ *grpcapi.StorageReply, err error) {
+	defer func() { err = grpcError(err) }()
+	ctx, cancel := s.MethodTimeouts.WithTimeout(ctx, "eth_getStorageAt")
+	defer cancel()
+	address, err := grpcAddress(req.GetAddress())
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range req.GetKeys() {
+		if len(key) > common.HashLength {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid storage key of %d bytes", len(key))
+		}
+	}
+	tx, err := s.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	reader, err := s.stateReader(ctx, tx, req.GetBlock())
+	if err != nil {
+		return nil, err
+	}
+	acc, err := reader.ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	reply := &grpcapi.StorageReply{Values: make([][]byte, len(req.GetKeys()))}
+	for i, key := range req.GetKeys() {
+		var value []byte
+		if acc != nil {
+			location := common.BytesToHash(key)
+			if value, err = reader.ReadAccountStorage(address, acc.Incarnation, &location); err != nil {
+				return nil, err
+			}
+		}
+		reply.Values[i] = common.BytesToHash(value).Bytes()
+	}
+	return reply, nil
+}
+
+// stateReader returns the reader of the state after the block of a request, NotFound if it's unknown
+func (s *EthGrpcServer) stateReader(ctx context.Context, tx kv.Tx, ref *grpcapi.BlockRef) (state.StateReader, error) {
+	blockNumber, hash, err := s.blockNumber(tx, ref)
+	if err != nil {
+		return nil, err
+	}
+	blockNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNumber))
+	if ref != nil && len(ref.GetBlockHash()) > 0 {
+		blockNrOrHash = rpc.BlockNumberOrHashWithHash(hash, false)
+	}
+	return rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, s.filters, s.stateCache)
+}
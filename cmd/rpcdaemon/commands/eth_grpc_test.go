@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/grpcapi"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// startEthGrpcServer serves s over an in-memory listener and returns its client
+func startEthGrpcServer(t *testing.T, s *EthGrpcServer) grpcapi.ETHClient {
+	server := grpc.NewServer()
+	s.Register(server)
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener) //nolint:errcheck
+	t.Cleanup(server.Stop)
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return grpcapi.NewETHClient(conn)
+}
+
+func TestEthGrpcServer(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	base := NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false)
+	api := NewEthAPI(base, db, nil, nil, nil, 5000000)
+	client := startEthGrpcServer(t, NewEthGrpcServer(base, db))
+
+	number, err := client.BlockNumber(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), number.BlockNumber)
+
+	// the block minting the tokens
+	block4 := &grpcapi.BlockRef{BlockNumber: 4}
+	jsonBlock, err := api.GetBlockByNumber(ctx, 4, false)
+	require.NoError(t, err)
+	reply, err := client.Block(ctx, &grpcapi.BlockRequest{Block: block4})
+	require.NoError(t, err)
+	var block types.Block
+	require.NoError(t, rlp.DecodeBytes(reply.BlockRlp, &block))
+	require.Equal(t, jsonBlock["hash"], block.Hash())
+	require.Len(t, reply.Senders, block.Transactions().Len())
+	byHash, err := client.Block(ctx, &grpcapi.BlockRequest{Block: &grpcapi.BlockRef{BlockHash: block.Hash().Bytes()}})
+	require.NoError(t, err)
+	require.Equal(t, reply.BlockRlp, byHash.BlockRlp)
+	_, err = client.Block(ctx, &grpcapi.BlockRequest{Block: &grpcapi.BlockRef{BlockNumber: 100}})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.Block(ctx, &grpcapi.BlockRequest{Block: &grpcapi.BlockRef{BlockHash: common.HexToHash("0x1").Bytes()}})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.Block(ctx, &grpcapi.BlockRequest{Block: &grpcapi.BlockRef{BlockHash: []byte{1}}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	jsonReceipts, err := api.GetBlockReceipts(ctx, rpc.BlockNumberOrHashWithNumber(4))
+	require.NoError(t, err)
+	receipts, err := client.Receipts(ctx, &grpcapi.BlockRequest{Block: block4})
+	require.NoError(t, err)
+	require.Len(t, receipts.Receipts, len(jsonReceipts))
+	for i, receipt := range receipts.Receipts {
+		require.Equal(t, jsonReceipts[i]["transactionHash"], common.BytesToHash(receipt.TransactionHash))
+		require.Equal(t, jsonReceipts[i]["gasUsed"], hexutil.Uint64(receipt.GasUsed))
+		require.Len(t, jsonReceipts[i]["logs"], len(receipt.Logs))
+	}
+
+	jsonLogs, err := api.GetLogs(ctx, filters.FilterCriteria{FromBlock: common.Big0})
+	require.NoError(t, err)
+	require.NotEmpty(t, jsonLogs)
+	stream, err := client.Logs(ctx, &grpcapi.LogsRequest{})
+	require.NoError(t, err)
+	for _, want := range jsonLogs {
+		lg, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, want.TxHash, common.BytesToHash(lg.TransactionHash))
+		require.Equal(t, uint64(want.Index), lg.LogIndex)
+		require.Equal(t, want.Data, lg.Data)
+	}
+	_, err = stream.Recv()
+	require.Equal(t, io.EOF, err)
+
+	sender := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	token := crypto.CreateAddress(sender, 2)
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	balance, err := api.GetBalance(ctx, sender, latest)
+	require.NoError(t, err)
+	account, err := client.Account(ctx, &grpcapi.AccountRequest{Address: sender.Bytes()})
+	require.NoError(t, err)
+	require.True(t, account.Exists)
+	require.Equal(t, balance.ToInt().Bytes(), account.Balance)
+	require.Empty(t, account.Code)
+	code, err := api.GetCode(ctx, token, latest)
+	require.NoError(t, err)
+	account, err = client.Account(ctx, &grpcapi.AccountRequest{Address: token.Bytes(), WithCode: true})
+	require.NoError(t, err)
+	require.Equal(t, []byte(code), account.Code)
+	require.Equal(t, crypto.Keccak256(code), account.CodeHash)
+	account, err = client.Account(ctx, &grpcapi.AccountRequest{Address: common.HexToAddress("0xdeadbeef").Bytes()})
+	require.NoError(t, err)
+	require.False(t, account.Exists)
+	_, err = client.Account(ctx, &grpcapi.AccountRequest{Address: []byte{1}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.Account(ctx, &grpcapi.AccountRequest{Address: sender.Bytes(), Block: &grpcapi.BlockRef{BlockNumber: 100}})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	slot2, err := api.GetStorageAt(ctx, token, "0x2", latest)
+	require.NoError(t, err)
+	storage, err := client.Storage(ctx, &grpcapi.StorageRequest{Address: token.Bytes(), Keys: [][]byte{{2}, {0x10}}})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{common.HexToHash(slot2).Bytes(), common.Hash{}.Bytes()}, storage.Values)
+}
+
+func TestEthGrpcServerLogsLimits(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	base := NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false)
+	server := NewEthGrpcServer(base, db)
+	client := startEthGrpcServer(t, server)
+	api := NewEthAPI(base, db, nil, nil, nil, 5000000)
+	jsonLogs, err := api.GetLogs(ctx, filters.FilterCriteria{FromBlock: common.Big0})
+	require.NoError(t, err)
+	require.NotEmpty(t, jsonLogs)
+
+	// recv returns the error ending the stream of the logs of a request
+	recv := func(req *grpcapi.LogsRequest) error {
+		stream, err := client.Logs(ctx, req)
+		require.NoError(t, err)
+		for {
+			if _, err = stream.Recv(); err != nil {
+				return err
+			}
+		}
+	}
+	require.Equal(t, io.EOF, recv(&grpcapi.LogsRequest{}))
+	require.Equal(t, codes.InvalidArgument, status.Code(recv(&grpcapi.LogsRequest{FromBlock: 5, ToBlock: 4})))
+
+	server.GetLogsLimits = GetLogsLimits{MaxBlockRange: 5}
+	require.Equal(t, codes.InvalidArgument, status.Code(recv(&grpcapi.LogsRequest{})))
+	require.Equal(t, io.EOF, recv(&grpcapi.LogsRequest{FromBlock: 6}))
+
+	server.GetLogsLimits = GetLogsLimits{MaxResults: len(jsonLogs)}
+	require.Equal(t, io.EOF, recv(&grpcapi.LogsRequest{}))
+
+	// the limits of the scans of the blocks
+	require.Equal(t, codes.ResourceExhausted, status.Code(grpcError(&getLogsLimitError{limit: "results"})))
+	require.Equal(t, codes.DeadlineExceeded, status.Code(grpcError(&getLogsLimitError{limit: "timeout"})))
+	require.Equal(t, codes.DeadlineExceeded, status.Code(grpcError(fmt.Errorf("reading logs: %w", context.DeadlineExceeded))))
+}
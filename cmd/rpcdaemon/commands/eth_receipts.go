@@ -6,10 +6,13 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math/big"
+	"sync/atomic"
+	"time"
 
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/log/v3"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/RoaringBitmap/roaring"
 	"github.com/ledgerwatch/erigon/common"
@@ -22,6 +25,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/ethdb/bitmapdb"
 	"github.com/ledgerwatch/erigon/ethdb/cbor"
 	"github.com/ledgerwatch/erigon/params"
@@ -71,7 +75,6 @@ func (api *BaseAPI) getReceipts(ctx context.Context, tx kv.Tx, chainConfig *para
 
 // GetLogs implements eth_getLogs. Returns an array of logs matching a given filter object.
 func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (types.Logs, error) {
-	var begin, end uint64
 	logs := types.Logs{}
 
 	tx, beginErr := api.db.BeginRo(ctx)
@@ -80,13 +83,75 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 	}
 	defer tx.Rollback()
 
+	begin, end, err := api.logsRange(ctx, tx, crit)
+	if err != nil {
+		return nil, err
+	}
+	if max := api.GetLogsLimits.MaxBlockRange; max > 0 && end-begin >= max {
+		return nil, &getLogsLimitError{
+			limit:     "blockRange",
+			message:   fmt.Sprintf("block range of %d blocks exceeds the limit of %d blocks", end-begin+1, max),
+			fromBlock: begin,
+			toBlock:   begin + max - 1,
+		}
+	}
+	logs, _, err = api.getLogsInRange(ctx, api.db, tx, begin, end, crit, api.GetLogsLimits)
+	return logs, err
+}
+
+// GetLogsLimits bound the work of a single eth_getLogs call, the zero values are no limit
+type GetLogsLimits struct {
+	MaxBlockRange uint64        // blocks in the range of the call
+	MaxResults    int           // logs returned
+	Timeout       time.Duration // time spent reading the logs
+}
+
+// getLogsLimitError is returned by eth_getLogs when a limit of GetLogsLimits is exceeded, with a smaller range to
+// retry with and the block where the scan stopped
+type getLogsLimitError struct {
+	limit     string // blockRange, results or timeout
+	message   string
+	fromBlock uint64 // the suggested range
+	toBlock   uint64
+	stoppedAt *uint64 // nil when the scan didn't start
+}
+
+func (e *getLogsLimitError) Error() string  { return e.message }
+func (e *getLogsLimitError) ErrorCode() int { return -32005 }
+
+// ErrorData returns the limit exceeded, the suggested range and the block where the scan stopped
+func (e *getLogsLimitError) ErrorData() interface{} {
+	data := map[string]interface{}{
+		"limit":     e.limit,
+		"fromBlock": hexutil.Uint64(e.fromBlock),
+		"toBlock":   hexutil.Uint64(e.toBlock),
+	}
+	if e.stoppedAt != nil {
+		data["stoppedAtBlock"] = hexutil.Uint64(*e.stoppedAt)
+	}
+	return data
+}
+
+// newGetLogsStoppedError returns the error of a scan of the blocks from begin stopped by limit at the block
+// stoppedAt, suggesting the range of the blocks before it, or the block alone when it's the first one with logs
+func newGetLogsStoppedError(limit, message string, begin, stoppedAt uint64, first bool) *getLogsLimitError {
+	toBlock := stoppedAt
+	if !first && stoppedAt > begin {
+		toBlock = stoppedAt - 1
+	}
+	return &getLogsLimitError{limit: limit, message: message, fromBlock: begin, toBlock: toBlock, stoppedAt: &stoppedAt}
+}
+
+// logsRange returns the first and the last block of the range of crit, the latest executed block by default
+func (api *BaseAPI) logsRange(ctx context.Context, tx kv.Tx, crit filters.FilterCriteria) (uint64, uint64, error) {
+	var begin, end uint64
 	if crit.BlockHash != nil {
 		header, err := api._blockReader.HeaderByHash(ctx, tx, *crit.BlockHash)
 		if err != nil {
-			return nil, err
+			return 0, 0, err
 		}
 		if header == nil {
-			return nil, fmt.Errorf("block not found: %x", *crit.BlockHash)
+			return 0, 0, fmt.Errorf("block not found: %x", *crit.BlockHash)
 		}
 		begin = header.Number.Uint64()
 		end = header.Number.Uint64()
@@ -94,7 +159,7 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 		// Convert the RPC block numbers into internal representations
 		latest, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestExecutedBlockNumber), tx, nil)
 		if err != nil {
-			return nil, err
+			return 0, 0, err
 		}
 
 		begin = latest
@@ -102,7 +167,7 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 			if crit.FromBlock.Sign() >= 0 {
 				begin = crit.FromBlock.Uint64()
 			} else if !crit.FromBlock.IsInt64() || crit.FromBlock.Int64() != int64(rpc.LatestBlockNumber) {
-				return nil, fmt.Errorf("negative value for FromBlock: %v", crit.FromBlock)
+				return 0, 0, fmt.Errorf("negative value for FromBlock: %v", crit.FromBlock)
 			}
 		}
 		end = latest
@@ -110,34 +175,165 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 			if crit.ToBlock.Sign() >= 0 {
 				end = crit.ToBlock.Uint64()
 			} else if !crit.ToBlock.IsInt64() || crit.ToBlock.Int64() != int64(rpc.LatestBlockNumber) {
-				return nil, fmt.Errorf("negative value for ToBlock: %v", crit.ToBlock)
+				return 0, 0, fmt.Errorf("negative value for ToBlock: %v", crit.ToBlock)
 			}
 		}
 	}
 	if end < begin {
-		return nil, fmt.Errorf("end (%d) < begin (%d)", end, begin)
+		return 0, 0, fmt.Errorf("end (%d) < begin (%d)", end, begin)
 	}
 	if end > roaring.MaxUint32 {
 		latest, err := rpchelper.GetLatestBlockNumber(tx)
 		if err != nil {
-			return nil, err
+			return 0, 0, err
 		}
 		if begin > latest {
-			return nil, fmt.Errorf("begin (%d) > latest (%d)", begin, latest)
+			return 0, 0, fmt.Errorf("begin (%d) > latest (%d)", begin, latest)
 		}
 		end = latest
 	}
+	return begin, end, nil
+}
 
-	blockNumbers := roaring.New()
-	blockNumbers.AddRange(begin, end+1) // [min,max)
-	topicsBitmap, err := getTopicsBitmap(tx, crit.Topics, uint32(begin), uint32(end))
+// getLogsWorkers is how many blocks of a range getLogsInRange reads at the same time, each with its own db transaction
+const getLogsWorkers = 4
+
+// getLogsInRange returns the logs matching the addresses and topics of crit in the blocks from begin to end,
+// inclusive, and how much of the range the log indices covered. The blocks are read with tx and, if db isn't nil, with
+// up to getLogsWorkers-1 other transactions of db. The scan stops with a getLogsLimitError once the logs found or the
+// time spent exceed the MaxResults and Timeout of limits.
+func (api *BaseAPI) getLogsInRange(ctx context.Context, db kv.RoDB, tx kv.Tx, begin, end uint64, crit filters.FilterCriteria, limits GetLogsLimits) (types.Logs, *LogsIndexCoverage, error) {
+	logs := types.Logs{}
+	blockNumbers, coverage, err := logsBlockNumbers(tx, begin, end, crit)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if blockNumbers.GetCardinality() == 0 {
+		return logs, coverage, nil
+	}
+
+	limitCtx := ctx
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		limitCtx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+	numbers := blockNumbers.ToArray()
+	blockLogs := make([]types.Logs, len(numbers))
+	read := make([]bool, len(numbers)) // the blocks whose logs were all read
+	workers := getLogsWorkers
+	if db == nil {
+		workers = 1
+	}
+	if workers > len(numbers) {
+		workers = len(numbers)
+	}
+	g, gctx := errgroup.WithContext(limitCtx)
+	// the transactions not opened yet once all the blocks are taken aren't needed anymore
+	openCtx, stopOpening := context.WithCancel(gctx)
+	defer stopOpening()
+	next, found := int64(-1), int64(0)
+	readBlocks := func(tx kv.Tx) error {
+		for {
+			// the blocks are taken in order, the ones taken before the limit of results is exceeded hold enough logs
+			if limits.MaxResults > 0 && atomic.LoadInt64(&found) > int64(limits.MaxResults) {
+				stopOpening()
+				return nil
+			}
+			i := atomic.AddInt64(&next, 1)
+			if i >= int64(len(numbers)) {
+				stopOpening()
+				return nil
+			}
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			var err error
+			if blockLogs[i], err = api.getBlockLogs(gctx, tx, uint64(numbers[i]), crit); err != nil {
+				return err
+			}
+			read[i] = true
+			atomic.AddInt64(&found, int64(len(blockLogs[i])))
+		}
 	}
+	// tx reads the blocks even when no other transaction can be opened
+	g.Go(func() error { return readBlocks(tx) })
+	for w := 1; w < workers; w++ {
+		g.Go(func() error {
+			workerTx, err := db.BeginRo(openCtx)
+			if err != nil {
+				if openCtx.Err() != nil && gctx.Err() == nil {
+					return nil
+				}
+				return err
+			}
+			defer workerTx.Rollback()
+			return readBlocks(workerTx)
+		})
+	}
+	if err := g.Wait(); err != nil && (limitCtx.Err() != context.DeadlineExceeded || ctx.Err() != nil) {
+		return nil, nil, err
+	}
+	for i, l := range blockLogs {
+		if !read[i] {
+			// the scan timed out at the first block not read
+			return nil, nil, newGetLogsStoppedError("timeout", fmt.Sprintf("logs not read within %s", limits.Timeout), begin, uint64(numbers[i]), i == 0)
+		}
+		if limits.MaxResults > 0 && len(logs)+len(l) > limits.MaxResults {
+			return nil, nil, newGetLogsStoppedError("results", fmt.Sprintf("more than %d logs found", limits.MaxResults), begin, uint64(numbers[i]), i == 0)
+		}
+		logs = append(logs, l...)
+	}
+	return logs, coverage, nil
+}
 
-	if topicsBitmap != nil {
-		blockNumbers.And(topicsBitmap)
+// LogsIndexCoverage tells how much of the range of a logs query the log indices covered. The blocks after
+// IndexedToBlock aren't indexed yet, all of them are read whatever the addresses and topics of the query.
+type LogsIndexCoverage struct {
+	FromBlock       hexutil.Uint64  `json:"fromBlock"`
+	ToBlock         hexutil.Uint64  `json:"toBlock"`
+	IndexedToBlock  *hexutil.Uint64 `json:"indexedToBlock"`  // nil when no block of the range is indexed
+	UnindexedBlocks hexutil.Uint64  `json:"unindexedBlocks"` // the blocks read without the indices
+	ReadBlocks      hexutil.Uint64  `json:"readBlocks"`      // all the blocks whose logs were read
+}
+
+// logsBlockNumbers returns the numbers of the blocks from begin to end which may have logs matching crit, according
+// to the log indices, and how much of the range the indices covered. The bitmaps of all the addresses and topics are
+// intersected before any log is read, the blocks not indexed yet are all returned.
+func logsBlockNumbers(tx kv.Tx, begin, end uint64, crit filters.FilterCriteria) (*roaring.Bitmap, *LogsIndexCoverage, error) {
+	indexed, err := stages.GetStageProgress(tx, stages.LogIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	coverage := &LogsIndexCoverage{FromBlock: hexutil.Uint64(begin), ToBlock: hexutil.Uint64(end)}
+	blockNumbers := roaring.New()
+	if begin <= indexed {
+		indexedEnd := end
+		if indexedEnd > indexed {
+			indexedEnd = indexed
+		}
+		coverage.IndexedToBlock = (*hexutil.Uint64)(&indexedEnd)
+		if blockNumbers, err = indexedLogsBlockNumbers(tx, begin, indexedEnd, crit); err != nil {
+			return nil, nil, err
+		}
+	}
+	if end > indexed {
+		unindexedBegin := begin
+		if unindexedBegin <= indexed {
+			unindexedBegin = indexed + 1
+		}
+		blockNumbers.AddRange(unindexedBegin, end+1) // [min,max)
+		coverage.UnindexedBlocks = hexutil.Uint64(end - unindexedBegin + 1)
 	}
+	coverage.ReadBlocks = hexutil.Uint64(blockNumbers.GetCardinality())
+	return blockNumbers, coverage, nil
+}
+
+// indexedLogsBlockNumbers returns the numbers of the indexed blocks from begin to end which have logs of the addresses
+// and with the topics of crit
+func indexedLogsBlockNumbers(tx kv.Tx, begin, end uint64, crit filters.FilterCriteria) (*roaring.Bitmap, error) {
+	blockNumbers := roaring.New()
+	blockNumbers.AddRange(begin, end+1) // [min,max)
 
 	var addrBitmap *roaring.Bitmap
 	for _, addr := range crit.Addresses {
@@ -149,76 +345,77 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 			addrBitmap = m
 			continue
 		}
-		addrBitmap = roaring.Or(addrBitmap, m)
+		addrBitmap.Or(m)
 	}
-
 	if addrBitmap != nil {
 		blockNumbers.And(addrBitmap)
+		// the topics can't narrow the blocks anymore
+		if blockNumbers.IsEmpty() {
+			return blockNumbers, nil
+		}
 	}
 
-	if blockNumbers.GetCardinality() == 0 {
-		return logs, nil
+	topicsBitmap, err := getTopicsBitmap(tx, crit.Topics, uint32(begin), uint32(end))
+	if err != nil {
+		return nil, err
 	}
+	if topicsBitmap != nil {
+		blockNumbers.And(topicsBitmap)
+	}
+	return blockNumbers, nil
+}
 
-	iter := blockNumbers.Iterator()
-	for iter.HasNext() {
-		if err = ctx.Err(); err != nil {
-			return nil, err
-		}
-
-		blockNumber := uint64(iter.Next())
-		var logIndex uint
-		var txIndex uint
-		var blockLogs []*types.Log
-		err := tx.ForPrefix(kv.Log, dbutils.EncodeBlockNumber(blockNumber), func(k, v []byte) error {
-			var logs types.Logs
-			if err := cbor.Unmarshal(&logs, bytes.NewReader(v)); err != nil {
-				return fmt.Errorf("receipt unmarshal failed:  %w", err)
-			}
-			for _, log := range logs {
-				log.Index = logIndex
-				logIndex++
-			}
-			filtered := filterLogs(logs, crit.Addresses, crit.Topics)
-			if len(filtered) == 0 {
-				return nil
-			}
-			txIndex = uint(binary.BigEndian.Uint32(k[8:]))
-			for _, log := range filtered {
-				log.TxIndex = txIndex
-			}
-			blockLogs = append(blockLogs, filtered...)
-
+// getBlockLogs returns the logs of a block matching the addresses and topics of crit
+func (api *BaseAPI) getBlockLogs(ctx context.Context, tx kv.Tx, blockNumber uint64, crit filters.FilterCriteria) (types.Logs, error) {
+	var logIndex uint
+	var txIndex uint
+	var blockLogs []*types.Log
+	err := tx.ForPrefix(kv.Log, dbutils.EncodeBlockNumber(blockNumber), func(k, v []byte) error {
+		var logs types.Logs
+		if err := cbor.Unmarshal(&logs, bytes.NewReader(v)); err != nil {
+			return fmt.Errorf("receipt unmarshal failed:  %w", err)
+		}
+		for _, log := range logs {
+			log.Index = logIndex
+			logIndex++
+		}
+		filtered := filterLogs(logs, crit.Addresses, crit.Topics)
+		if len(filtered) == 0 {
 			return nil
-		})
-		if err != nil {
-			return logs, err
 		}
-		if len(blockLogs) == 0 {
-			continue
+		txIndex = uint(binary.BigEndian.Uint32(k[8:]))
+		for _, log := range filtered {
+			log.TxIndex = txIndex
 		}
+		blockLogs = append(blockLogs, filtered...)
 
-		blockHash, err := rawdb.ReadCanonicalHash(tx, blockNumber)
-		if err != nil {
-			return nil, err
-		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(blockLogs) == 0 {
+		return nil, nil
+	}
 
-		body, err := api._blockReader.BodyWithTransactions(ctx, tx, blockHash, blockNumber)
-		if err != nil {
-			return nil, err
-		}
-		if body == nil {
-			return nil, fmt.Errorf("block not found %d", blockNumber)
-		}
-		for _, log := range blockLogs {
-			log.BlockNumber = blockNumber
-			log.BlockHash = blockHash
-			log.TxHash = body.Transactions[log.TxIndex].Hash()
-		}
-		logs = append(logs, blockLogs...)
+	blockHash, err := rawdb.ReadCanonicalHash(tx, blockNumber)
+	if err != nil {
+		return nil, err
 	}
 
-	return logs, nil
+	body, err := api._blockReader.BodyWithTransactions(ctx, tx, blockHash, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return nil, fmt.Errorf("block not found %d", blockNumber)
+	}
+	for _, log := range blockLogs {
+		log.BlockNumber = blockNumber
+		log.BlockHash = blockHash
+		log.TxHash = body.Transactions[log.TxIndex].Hash()
+	}
+	return blockLogs, nil
 }
 
 // The Topic list restricts matches to particular event topics. Each event has a list
@@ -342,16 +539,15 @@ func (api *APIImpl) GetTransactionReceipt(ctx context.Context, txnHash common.Ha
 	return marshalReceipt(receipts[txnIndex], block.Transactions()[txnIndex], cc, block, txnHash, true), nil
 }
 
-// GetBlockReceipts - receipts for individual block
-// func (api *APIImpl) GetBlockReceipts(ctx context.Context, number rpc.BlockNumber) ([]map[string]interface{}, error) {
-func (api *APIImpl) GetBlockReceipts(ctx context.Context, number rpc.BlockNumber) ([]map[string]interface{}, error) {
+// GetBlockReceipts - receipts of all transactions of a block, read in a single db transaction
+func (api *APIImpl) GetBlockReceipts(ctx context.Context, numberOrHash rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	blockNum, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(number), tx, api.filters)
+	blockNum, _, _, err := rpchelper.GetBlockNumber(numberOrHash, tx, api.filters)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/bitmapdb"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	stages2 "github.com/ledgerwatch/erigon/turbo/stages"
+	"github.com/stretchr/testify/require"
+)
+
+const logsTestBlocks, logsTestTxsPerBlock = 20, 3
+
+// newLogsTestChain returns a chain of logsTestBlocks blocks, each with logsTestTxsPerBlock contract creations emitting
+// a log, and the address of their sender
+func newLogsTestChain(t *testing.T) (*stages2.MockSentry, *core.ChainPack, common.Address) {
+	var (
+		signer      = types.LatestSignerForChainID(nil)
+		bankKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		bankAddress = crypto.PubkeyToAddress(bankKey.PublicKey)
+		gspec       = &core.Genesis{
+			Config: params.AllEthashProtocolChanges,
+			Alloc:  core.GenesisAlloc{bankAddress: {Balance: big.NewInt(1e18)}},
+		}
+		// emits a log with the single byte of data 0x01
+		initCode = common.FromHex("0x600160005360016000a000")
+	)
+	m := stages2.MockWithGenesis(t, gspec, bankKey, false)
+
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, logsTestBlocks, func(i int, block *core.BlockGen) {
+		for j := 0; j < logsTestTxsPerBlock; j++ {
+			txn, err := types.SignTx(types.NewContractCreation(block.TxNonce(bankAddress), new(uint256.Int), 1e5, new(uint256.Int), initCode), *signer, bankKey)
+			require.NoError(t, err)
+			block.AddTx(txn)
+		}
+	}, false /* intermediateHashes */)
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+	return m, chain, bankAddress
+}
+
+func TestGetLogsOrder(t *testing.T) {
+	const blocks, txsPerBlock = logsTestBlocks, logsTestTxsPerBlock
+	m, chain, bankAddress := newLogsTestChain(t)
+	api := NewEthAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false), m.DB, nil, nil, nil, 5000000)
+	logs, err := api.GetLogs(context.Background(), filters.FilterCriteria{FromBlock: big.NewInt(0)})
+	require.NoError(t, err)
+	require.Len(t, logs, blocks*txsPerBlock)
+	for i, log := range logs {
+		// the contracts are created in the order of the logs
+		require.Equal(t, crypto.CreateAddress(bankAddress, uint64(i)), log.Address)
+		require.Equal(t, uint64(i/txsPerBlock+1), log.BlockNumber)
+		require.Equal(t, uint(i%txsPerBlock), log.TxIndex)
+		require.Equal(t, uint(i%txsPerBlock), log.Index)
+		require.Equal(t, chain.Blocks[i/txsPerBlock].Transactions()[i%txsPerBlock].Hash(), log.TxHash)
+		require.Equal(t, []byte{1}, log.Data)
+	}
+
+	// the blocks without the addresses aren't read
+	addresses := []common.Address{crypto.CreateAddress(bankAddress, 40), crypto.CreateAddress(bankAddress, 4)}
+	logs, err = api.GetLogs(context.Background(), filters.FilterCriteria{FromBlock: big.NewInt(0), Addresses: addresses})
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	require.Equal(t, addresses[1], logs[0].Address)
+	require.Equal(t, addresses[0], logs[1].Address)
+}
+
+func TestGetLogsIndexCoverage(t *testing.T) {
+	m, _, bankAddress := newLogsTestChain(t)
+	api := NewErigonAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false), m.DB, nil, nil)
+	ctx := context.Background()
+
+	// the addresses of the logs in blocks 5 and 15
+	addresses := []common.Address{crypto.CreateAddress(bankAddress, 12), crypto.CreateAddress(bankAddress, 42)}
+	crit := filters.FilterCriteria{FromBlock: big.NewInt(0), Addresses: addresses}
+	result, err := api.GetLogsWithCoverage(ctx, crit)
+	require.NoError(t, err)
+	require.Len(t, result.Logs, 2)
+	indexedTo := hexutil.Uint64(logsTestBlocks)
+	require.Equal(t, &LogsIndexCoverage{FromBlock: 0, ToBlock: logsTestBlocks, IndexedToBlock: &indexedTo, ReadBlocks: 2}, result.IndexCoverage)
+
+	// the log indices are behind the execution from block 11
+	require.NoError(t, m.DB.Update(ctx, func(tx kv.RwTx) error {
+		for _, addr := range addresses {
+			if err := bitmapdb.TruncateRange(tx, kv.LogAddressIndex, addr[:], 11); err != nil {
+				return err
+			}
+		}
+		return stages.SaveStageProgress(tx, stages.LogIndex, 10)
+	}))
+	result, err = api.GetLogsWithCoverage(ctx, crit)
+	require.NoError(t, err)
+	require.Len(t, result.Logs, 2)
+	require.Equal(t, addresses[0], result.Logs[0].Address)
+	require.Equal(t, addresses[1], result.Logs[1].Address)
+	require.Equal(t, uint64(15), result.Logs[1].BlockNumber)
+	indexedTo = 10
+	require.Equal(t, &LogsIndexCoverage{FromBlock: 0, ToBlock: logsTestBlocks, IndexedToBlock: &indexedTo, UnindexedBlocks: 10, ReadBlocks: 11}, result.IndexCoverage)
+
+	// no block of the range is indexed
+	crit.FromBlock = big.NewInt(16)
+	result, err = api.GetLogsWithCoverage(ctx, crit)
+	require.NoError(t, err)
+	require.Empty(t, result.Logs)
+	require.Equal(t, &LogsIndexCoverage{FromBlock: 16, ToBlock: logsTestBlocks, UnindexedBlocks: 5, ReadBlocks: 5}, result.IndexCoverage)
+}
+
+func TestGetLogsLimits(t *testing.T) {
+	m, _, _ := newLogsTestChain(t)
+	api := NewEthAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false), m.DB, nil, nil, nil, 5000000)
+	ctx := context.Background()
+	crit := filters.FilterCriteria{FromBlock: big.NewInt(1)}
+
+	requireLimitError := func(err error, data map[string]interface{}) {
+		t.Helper()
+		var limitErr *getLogsLimitError
+		require.ErrorAs(t, err, &limitErr)
+		require.Equal(t, -32005, limitErr.ErrorCode())
+		require.Equal(t, data, limitErr.ErrorData())
+	}
+
+	api.GetLogsLimits = GetLogsLimits{MaxBlockRange: 5}
+	_, err := api.GetLogs(ctx, crit)
+	requireLimitError(err, map[string]interface{}{"limit": "blockRange", "fromBlock": hexutil.Uint64(1), "toBlock": hexutil.Uint64(5)})
+
+	// the suggested range is within the limits
+	api.GetLogsLimits = GetLogsLimits{MaxResults: 10}
+	_, err = api.GetLogs(ctx, crit)
+	requireLimitError(err, map[string]interface{}{"limit": "results", "fromBlock": hexutil.Uint64(1), "toBlock": hexutil.Uint64(3), "stoppedAtBlock": hexutil.Uint64(4)})
+	logs, err := api.GetLogs(ctx, filters.FilterCriteria{FromBlock: big.NewInt(1), ToBlock: big.NewInt(3)})
+	require.NoError(t, err)
+	require.Len(t, logs, 9)
+
+	api.GetLogsLimits = GetLogsLimits{Timeout: time.Nanosecond}
+	_, err = api.GetLogs(ctx, crit)
+	requireLimitError(err, map[string]interface{}{"limit": "timeout", "fromBlock": hexutil.Uint64(1), "toBlock": hexutil.Uint64(1), "stoppedAtBlock": hexutil.Uint64(1)})
+
+	api.GetLogsLimits = GetLogsLimits{MaxBlockRange: 20, MaxResults: 60, Timeout: time.Minute}
+	logs, err = api.GetLogs(ctx, crit)
+	require.NoError(t, err)
+	require.Len(t, logs, logsTestBlocks*logsTestTxsPerBlock)
+}
@@ -0,0 +1,523 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/crypto"
+	rpcapi "github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/transactions"
+	"github.com/ledgerwatch/log/v3"
+)
+
+const (
+	// maxSimulateBlocks is the number of blocks eth_simulateV1 can simulate, including the ones filling the gaps
+	// between the requested block numbers
+	maxSimulateBlocks = 256
+	// simulateBlockInterval is the default time between the simulated blocks, in seconds
+	simulateBlockInterval = 12
+)
+
+var (
+	// transferLogAddress is the address of the logs of the ether transfers traced by eth_simulateV1
+	transferLogAddress = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+	// transferLogTopic is the topic of the ERC20 Transfer(address,address,uint256) event, used by the transfer logs
+	transferLogTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+)
+
+// SimulationOpts are the parameters of eth_simulateV1: the blocks to simulate and how.
+type SimulationOpts struct {
+	BlockStateCalls        []SimulatedBlock `json:"blockStateCalls"`
+	TraceTransfers         bool             `json:"traceTransfers"`         // ether transfers returned as logs
+	Validation             bool             `json:"validation"`             // nonces, balances and base fee checked
+	ReturnFullTransactions bool             `json:"returnFullTransactions"` // transaction objects in the blocks
+}
+
+// SimulatedBlock is a block of calls, executed after its overrides are applied. The state overrides persist in the
+// following blocks.
+type SimulatedBlock struct {
+	BlockOverrides *SimulatedBlockOverrides `json:"blockOverrides"`
+	StateOverrides *rpcapi.StateOverrides   `json:"stateOverrides"`
+	Calls          []rpcapi.CallArgs        `json:"calls"`
+}
+
+// SimulatedBlockOverrides replace the fields of a simulated block header, which follow the previous block by
+// default.
+type SimulatedBlockOverrides struct {
+	Number        *hexutil.Big    `json:"number"`
+	Time          *hexutil.Uint64 `json:"time"`
+	GasLimit      *hexutil.Uint64 `json:"gasLimit"`
+	FeeRecipient  *common.Address `json:"feeRecipient"`
+	PrevRandao    *common.Hash    `json:"prevRandao"`
+	BaseFeePerGas *hexutil.Big    `json:"baseFeePerGas"`
+}
+
+// SimulatedCallResult is the outcome of a simulated call.
+type SimulatedCallResult struct {
+	ReturnData hexutil.Bytes       `json:"returnData"`
+	Logs       []*types.Log        `json:"logs"`
+	GasUsed    hexutil.Uint64      `json:"gasUsed"`
+	Status     hexutil.Uint64      `json:"status"`
+	Error      *SimulatedCallError `json:"error,omitempty"`
+}
+
+// SimulatedCallError is the error of a failed call: 3 for a revert, with the revert data, -32015 for the other
+// EVM errors.
+type SimulatedCallError struct {
+	Code    int           `json:"code"`
+	Message string        `json:"message"`
+	Data    hexutil.Bytes `json:"data,omitempty"`
+}
+
+// SimulateV1 implements eth_simulateV1. Executes blocks of calls on top of the given block (latest by default),
+// each call seeing the state left by the previous ones, and returns the resulting blocks with the results of
+// their calls.
+func (api *APIImpl) SimulateV1(ctx context.Context, opts SimulationOpts, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, &rpc.CustomError{Code: -32602, Message: "empty input"}
+	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(*blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	base, err := api.blockWithSenders(tx, hash, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		return nil, fmt.Errorf("block %d(%x) not found", blockNumber, hash)
+	}
+	blocks, err := fillSimulatedBlocks(base.Header(), opts.BlockStateCalls)
+	if err != nil {
+		return nil, err
+	}
+
+	stateReader, err := rpchelper.CreateStateReader(ctx, tx, *blockNrOrHash, api.filters, api.stateCache)
+	if err != nil {
+		return nil, err
+	}
+	ibs := state.New(stateReader)
+
+	// the deadline of the method or --rpc.evmtimeout limits the execution of all the blocks
+	ctx, cancel, timeout := transactions.WithEVMTimeout(ctx, api.EvmCallTimeout)
+	defer cancel()
+	defer func(start time.Time) { log.Trace("Executing EVM simulateV1 finished", "runtime", time.Since(start)) }(time.Now())
+
+	// BLOCKHASH sees the simulated blocks on top of the canonical ones
+	simulatedHashes := make(map[uint64]common.Hash, len(blocks))
+	getHash := func(n uint64) common.Hash {
+		if hash, ok := simulatedHashes[n]; ok {
+			return hash
+		}
+		if n > blockNumber {
+			return common.Hash{}
+		}
+		hash, err := rawdb.ReadCanonicalHash(tx, n)
+		if err != nil {
+			log.Debug("Can't get block hash by number", "number", n, "only-canonical", true)
+		}
+		return hash
+	}
+
+	sim := &simulator{
+		api:         api,
+		opts:        opts,
+		chainConfig: chainConfig,
+		ibs:         ibs,
+		getHash:     getHash,
+		timeout:     timeout,
+	}
+	results := make([]map[string]interface{}, 0, len(blocks))
+	parent := base.Header()
+	for _, simulated := range blocks {
+		block, calls, err := sim.simulateBlock(ctx, parent, simulated)
+		if err != nil {
+			return nil, err
+		}
+		simulatedHashes[block.NumberU64()] = block.Hash()
+		fields, err := ethapi.RPCMarshalBlock(block, true, opts.ReturnFullTransactions, map[string]interface{}{"calls": calls})
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, fields)
+		parent = block.Header()
+	}
+	return results, nil
+}
+
+// fillSimulatedBlocks checks that the simulated blocks follow each other, and inserts empty blocks in the gaps
+// between their numbers
+func fillSimulatedBlocks(base *types.Header, blocks []SimulatedBlock) ([]SimulatedBlock, error) {
+	filled := make([]SimulatedBlock, 0, len(blocks))
+	prevNumber, prevTime := base.Number.Uint64(), base.Time
+	for _, block := range blocks {
+		if block.BlockOverrides == nil {
+			block.BlockOverrides = &SimulatedBlockOverrides{}
+		}
+		overrides := block.BlockOverrides
+		number := prevNumber + 1
+		if overrides.Number != nil {
+			if !overrides.Number.ToInt().IsUint64() || overrides.Number.ToInt().Uint64() <= prevNumber {
+				return nil, &rpc.CustomError{Code: -38020, Message: fmt.Sprintf("block numbers must be in order: %v <= %d", overrides.Number, prevNumber)}
+			}
+			number = overrides.Number.ToInt().Uint64()
+		}
+		if number-prevNumber+uint64(len(filled)) > maxSimulateBlocks {
+			return nil, &rpc.CustomError{Code: -38026, Message: fmt.Sprintf("too many blocks, at most %d can be simulated", maxSimulateBlocks)}
+		}
+		for gap := prevNumber + 1; gap < number; gap++ {
+			prevTime += simulateBlockInterval
+			gapNumber, gapTime := hexutil.Big(*new(big.Int).SetUint64(gap)), hexutil.Uint64(prevTime)
+			filled = append(filled, SimulatedBlock{BlockOverrides: &SimulatedBlockOverrides{Number: &gapNumber, Time: &gapTime}})
+		}
+		timestamp := prevTime + simulateBlockInterval
+		if overrides.Time != nil {
+			if uint64(*overrides.Time) <= prevTime {
+				return nil, &rpc.CustomError{Code: -38021, Message: fmt.Sprintf("block timestamps must be in order: %d <= %d", uint64(*overrides.Time), prevTime)}
+			}
+			timestamp = uint64(*overrides.Time)
+		}
+		numberOverride, timeOverride := hexutil.Big(*new(big.Int).SetUint64(number)), hexutil.Uint64(timestamp)
+		withDefaults := *overrides
+		withDefaults.Number, withDefaults.Time = &numberOverride, &timeOverride
+		block.BlockOverrides = &withDefaults
+		filled = append(filled, block)
+		prevNumber, prevTime = number, timestamp
+	}
+	return filled, nil
+}
+
+// simulator executes the simulated blocks one after the other on the same state
+type simulator struct {
+	api         *APIImpl
+	opts        SimulationOpts
+	chainConfig *params.ChainConfig
+	ibs         *state.IntraBlockState
+	getHash     func(uint64) common.Hash
+	timeout     time.Duration                             // the effective limit of the execution, reported when it's reached
+	precompiles map[common.Address]vm.PrecompiledContract // the ones moved by the previous blocks, nil if none is
+}
+
+// makeHeader returns the header of a simulated block following parent, before its calls are executed
+func (s *simulator) makeHeader(parent *types.Header, overrides *SimulatedBlockOverrides) (*types.Header, error) {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  types.EmptyUncleHash,
+		Coinbase:   parent.Coinbase,
+		Difficulty: new(big.Int).Set(parent.Difficulty),
+		Number:     overrides.Number.ToInt(),
+		GasLimit:   parent.GasLimit,
+		Time:       uint64(*overrides.Time),
+		MixDigest:  parent.MixDigest,
+	}
+	if overrides.GasLimit != nil {
+		header.GasLimit = uint64(*overrides.GasLimit)
+	}
+	if overrides.FeeRecipient != nil {
+		header.Coinbase = *overrides.FeeRecipient
+	}
+	if overrides.PrevRandao != nil {
+		header.MixDigest = *overrides.PrevRandao
+	}
+	if s.chainConfig.IsLondon(header.Number.Uint64()) {
+		header.Eip1559 = true
+		switch {
+		case overrides.BaseFeePerGas != nil:
+			header.BaseFee = new(big.Int).Set(overrides.BaseFeePerGas.ToInt())
+		case s.opts.Validation:
+			header.BaseFee = misc.CalcBaseFee(s.chainConfig, parent)
+		default:
+			// without validation the calls are free by default
+			header.BaseFee = new(big.Int)
+		}
+		if header.BaseFee.BitLen() > 256 {
+			return nil, fmt.Errorf("base fee higher than 2^256-1")
+		}
+	}
+	return header, nil
+}
+
+// simulateBlock executes the calls of a block and returns it with their results
+func (s *simulator) simulateBlock(ctx context.Context, parent *types.Header, simulated SimulatedBlock) (*types.Block, []SimulatedCallResult, error) {
+	chainConfig := s.chainConfig
+	header, err := s.makeHeader(parent, simulated.BlockOverrides)
+	if err != nil {
+		return nil, nil, err
+	}
+	if simulated.StateOverrides != nil {
+		if err := simulated.StateOverrides.Override(s.ibs); err != nil {
+			return nil, nil, err
+		}
+	}
+	var baseFee *uint256.Int
+	if header.Eip1559 {
+		baseFee, _ = uint256.FromBig(header.BaseFee)
+	}
+	chainID, _ := uint256.FromBig(chainConfig.ChainID)
+	rules := chainConfig.Rules(header.Number.Uint64())
+
+	var tracer *transferTracer
+	vmConfig := vm.Config{NoBaseFee: !s.opts.Validation}
+	// the precompiles moved by a block stay moved in the following ones, like the other state overrides
+	if s.precompiles, err = simulated.StateOverrides.Precompiles(rules, s.precompiles); err != nil {
+		return nil, nil, err
+	}
+	vmConfig.Precompiles = s.precompiles
+	if s.opts.TraceTransfers {
+		tracer = &transferTracer{}
+		vmConfig.Debug, vmConfig.Tracer = true, tracer
+	}
+	evm := vm.NewEVM(core.NewEVMBlockContext(header, s.getHash, nil, &header.Coinbase), vm.TxContext{}, s.ibs, chainConfig, vmConfig)
+	blockDone := make(chan struct{})
+	defer close(blockDone)
+	go func() {
+		select {
+		case <-ctx.Done():
+			evm.Cancel()
+		case <-blockDone:
+		}
+	}()
+
+	var (
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+		txs      = make(types.Transactions, 0, len(simulated.Calls))
+		receipts = make(types.Receipts, 0, len(simulated.Calls))
+		results  = make([]SimulatedCallResult, 0, len(simulated.Calls))
+		logs     []*types.Log
+	)
+	for i, args := range simulated.Calls {
+		var from common.Address
+		if args.From != nil {
+			from = *args.From
+		}
+		nonce := s.ibs.GetNonce(from)
+		if args.Nonce != nil {
+			nonce = uint64(*args.Nonce)
+		}
+		if args.Gas == nil {
+			remaining := hexutil.Uint64(gp.Gas())
+			args.Gas = &remaining
+		}
+		if uint64(*args.Gas) > gp.Gas() {
+			return nil, nil, &rpc.CustomError{Code: -38015, Message: fmt.Sprintf("block gas limit reached: call %d needs %d gas, %d left", i, uint64(*args.Gas), gp.Gas())}
+		}
+		callMsg, err := args.ToMessage(s.api.GasCap, baseFee)
+		if err != nil {
+			return nil, nil, err
+		}
+		msg := types.NewMessage(from, callMsg.To(), nonce, callMsg.Value(), callMsg.Gas(), callMsg.GasPrice(), callMsg.FeeCap(), callMsg.Tip(), callMsg.Data(), callMsg.AccessList(), s.opts.Validation)
+		txn := simulatedTransaction(msg, chainID, header.Eip1559)
+
+		s.ibs.Prepare(txn.Hash(), common.Hash{}, i)
+		logsBefore := len(s.ibs.GetLogs(txn.Hash()))
+		if tracer != nil {
+			tracer.reset(s.ibs, txn.Hash(), logsBefore)
+		}
+		evm.Reset(core.NewEVMTxContext(msg), s.ibs)
+		result, err := core.ApplyMessage(evm, msg, gp, true /* refunds */, !s.opts.Validation /* gasBailout */)
+		if err != nil {
+			return nil, nil, callValidationError(header.Number.Uint64(), i, err)
+		}
+		if evm.Cancelled() {
+			return nil, nil, fmt.Errorf("execution aborted (timeout = %v)", s.timeout)
+		}
+		if err = s.ibs.FinalizeTx(rules, state.NewNoopWriter()); err != nil {
+			return nil, nil, err
+		}
+
+		callLogs := s.ibs.GetLogs(txn.Hash())[logsBefore:]
+		if tracer != nil {
+			callLogs = tracer.merge(callLogs)
+		}
+		callResult := SimulatedCallResult{ReturnData: result.Return(), Logs: callLogs, GasUsed: hexutil.Uint64(result.UsedGas), Status: hexutil.Uint64(types.ReceiptStatusSuccessful)}
+		if result.Err != nil {
+			callResult.Status = hexutil.Uint64(types.ReceiptStatusFailed)
+			if errors.Is(result.Err, vm.ErrExecutionReverted) {
+				callResult.Error = &SimulatedCallError{Code: 3, Message: "execution reverted", Data: result.Revert()}
+			} else {
+				callResult.Error = &SimulatedCallError{Code: -32015, Message: result.Err.Error()}
+			}
+		}
+		if callResult.Logs == nil {
+			callResult.Logs = []*types.Log{}
+		}
+		header.GasUsed += result.UsedGas
+		receipt := &types.Receipt{
+			Type:              txn.Type(),
+			Status:            uint64(callResult.Status),
+			CumulativeGasUsed: header.GasUsed,
+			Logs:              callResult.Logs,
+			TxHash:            txn.Hash(),
+			GasUsed:           result.UsedGas,
+			TransactionIndex:  uint(i),
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+		txs = append(txs, txn)
+		receipts = append(receipts, receipt)
+		results = append(results, callResult)
+		logs = append(logs, callResult.Logs...)
+	}
+
+	block := types.NewBlock(header, txs, nil, receipts)
+	for i, lg := range logs {
+		lg.BlockNumber = block.NumberU64()
+		lg.BlockHash = block.Hash()
+		lg.Index = uint(i)
+	}
+	return block, results, nil
+}
+
+// simulatedTransaction returns the unsigned transaction of a simulated call, with its sender set
+func simulatedTransaction(msg types.Message, chainID *uint256.Int, eip1559 bool) types.Transaction {
+	commonTx := types.CommonTx{
+		ChainID: chainID,
+		Nonce:   msg.Nonce(),
+		Gas:     msg.Gas(),
+		To:      msg.To(),
+		Value:   msg.Value(),
+		Data:    msg.Data(),
+	}
+	var txn types.Transaction
+	if eip1559 {
+		txn = &types.DynamicFeeTransaction{CommonTx: commonTx, Tip: msg.Tip(), FeeCap: msg.FeeCap(), AccessList: msg.AccessList()}
+	} else {
+		txn = &types.LegacyTx{CommonTx: commonTx, GasPrice: msg.GasPrice()}
+	}
+	txn.SetSender(msg.From())
+	return txn
+}
+
+// callValidationError returns the error of a call which couldn't be executed, because of a wrong nonce or
+// insufficient funds for example
+func callValidationError(blockNumber uint64, index int, err error) error {
+	message := fmt.Sprintf("block %d, call %d: %v", blockNumber, index, err)
+	switch {
+	case errors.Is(err, core.ErrNonceTooLow):
+		return &rpc.CustomError{Code: -38010, Message: message}
+	case errors.Is(err, core.ErrNonceTooHigh):
+		return &rpc.CustomError{Code: -38011, Message: message}
+	case errors.Is(err, core.ErrFeeCapTooLow):
+		return &rpc.CustomError{Code: -38012, Message: message}
+	case errors.Is(err, core.ErrInsufficientFunds):
+		return &rpc.CustomError{Code: -38014, Message: message}
+	case errors.Is(err, core.ErrGasLimitReached):
+		return &rpc.CustomError{Code: -38015, Message: message}
+	case errors.Is(err, core.ErrIntrinsicGas):
+		return &rpc.CustomError{Code: -38013, Message: message}
+	}
+	return errors.New(message)
+}
+
+// transferTracer records the ether transfers of a call as logs, dropping the transfers of the reverted frames
+type transferTracer struct {
+	ibs        *state.IntraBlockState
+	txHash     common.Hash
+	logsBefore int
+	transfers  []tracedTransfer
+	frames     []int // number of transfers when each of the running frames started
+}
+
+type tracedTransfer struct {
+	log      *types.Log
+	position int // number of logs of the call emitted before the transfer
+}
+
+func (t *transferTracer) reset(ibs *state.IntraBlockState, txHash common.Hash, logsBefore int) {
+	t.ibs, t.txHash, t.logsBefore = ibs, txHash, logsBefore
+	t.transfers, t.frames = nil, nil
+}
+
+func (t *transferTracer) addTransfer(from, to common.Address, value *big.Int) {
+	amount, _ := uint256.FromBig(value)
+	data := amount.Bytes32()
+	t.transfers = append(t.transfers, tracedTransfer{
+		log: &types.Log{
+			Address: transferLogAddress,
+			Topics:  []common.Hash{transferLogTopic, from.Hash(), to.Hash()},
+			Data:    data[:],
+			TxHash:  t.txHash,
+			TxIndex: uint(t.ibs.TxIndex()),
+		},
+		position: len(t.ibs.GetLogs(t.txHash)) - t.logsBefore,
+	})
+}
+
+// merge inserts the transfers among the logs of the call, in the order they happened
+func (t *transferTracer) merge(logs []*types.Log) []*types.Log {
+	merged := make([]*types.Log, 0, len(logs)+len(t.transfers))
+	transfers := t.transfers
+	for i, lg := range logs {
+		for len(transfers) > 0 && transfers[0].position <= i {
+			merged = append(merged, transfers[0].log)
+			transfers = transfers[1:]
+		}
+		merged = append(merged, lg)
+	}
+	for _, transfer := range transfers {
+		merged = append(merged, transfer.log)
+	}
+	return merged
+}
+
+func (t *transferTracer) CaptureStart(env *vm.EVM, depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) {
+	t.frames = append(t.frames, len(t.transfers))
+	// the value of CALLCODE stays with the caller, DELEGATECALL and STATICCALL have negative values
+	if callType != vm.CALLCODET && value != nil && value.Sign() > 0 {
+		t.addTransfer(from, to, value)
+	}
+}
+
+func (t *transferTracer) CaptureEnd(depth int, output []byte, startGas, endGas uint64, d time.Duration, err error) {
+	start := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	if err != nil {
+		t.transfers = t.transfers[:start]
+	}
+}
+
+func (t *transferTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+	if value != nil && value.Sign() > 0 {
+		t.addTransfer(from, to, value)
+	}
+}
+
+func (t *transferTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (t *transferTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *transferTracer) CaptureAccountRead(account common.Address) error  { return nil }
+func (t *transferTracer) CaptureAccountWrite(account common.Address) error { return nil }
@@ -0,0 +1,177 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateV1(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	ctx := context.Background()
+
+	var (
+		sender   = common.HexToAddress("0xdead")
+		receiver = common.HexToAddress("0xbeef")
+		reader   = common.HexToAddress("0x1234")
+		reverter = common.HexToAddress("0x5678")
+		balance  = (*hexutil.Big)(big.NewInt(1e18))
+		// returns the balance of the receiver
+		readerCode   = hexutil.Bytes(append(append([]byte{0x73}, receiver.Bytes()...), common.FromHex("0x3160005260206000f3")...))
+		reverterCode = hexutil.Bytes(common.FromHex("0x60006000fd"))
+		amount       = (*hexutil.Big)(big.NewInt(1000))
+	)
+	latest, err := api.BlockNumber(ctx)
+	require.NoError(t, err)
+	lastNumber := hexutil.Big(*new(big.Int).SetUint64(uint64(latest) + 3))
+
+	results, err := api.SimulateV1(ctx, SimulationOpts{
+		TraceTransfers: true,
+		BlockStateCalls: []SimulatedBlock{
+			{
+				StateOverrides: &ethapi.StateOverrides{
+					sender:   {Balance: &balance},
+					reader:   {Code: &readerCode},
+					reverter: {Code: &reverterCode},
+				},
+				Calls: []ethapi.CallArgs{
+					{From: &sender, To: &receiver, Value: amount},
+					{From: &sender, To: &reverter, Value: amount},
+				},
+			},
+			{
+				BlockOverrides: &SimulatedBlockOverrides{Number: &lastNumber},
+				Calls:          []ethapi.CallArgs{{From: &sender, To: &reader}},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	// the gap between the two blocks is filled with an empty one
+	require.Len(t, results, 3)
+	for i, block := range results {
+		require.Equal(t, uint64(latest)+uint64(i)+1, block["number"].(*hexutil.Big).ToInt().Uint64())
+		if i > 0 {
+			require.Equal(t, results[i-1]["hash"], block["parentHash"])
+		}
+	}
+	require.Empty(t, results[1]["calls"])
+
+	calls := results[0]["calls"].([]SimulatedCallResult)
+	require.Len(t, calls, 2)
+	require.Equal(t, hexutil.Uint64(1), calls[0].Status)
+	require.Len(t, calls[0].Logs, 1)
+	transfer := calls[0].Logs[0]
+	require.Equal(t, transferLogAddress, transfer.Address)
+	require.Equal(t, []common.Hash{transferLogTopic, sender.Hash(), receiver.Hash()}, transfer.Topics)
+	require.Equal(t, common.BigToHash(amount.ToInt()).Bytes(), transfer.Data)
+	require.Equal(t, results[0]["hash"], transfer.BlockHash)
+
+	// the transfers of the reverted calls aren't traced
+	require.Equal(t, hexutil.Uint64(0), calls[1].Status)
+	require.Equal(t, 3, calls[1].Error.Code)
+	require.Empty(t, calls[1].Logs)
+
+	// the state is carried over to the following blocks
+	calls = results[2]["calls"].([]SimulatedCallResult)
+	require.Len(t, calls, 1)
+	require.Equal(t, common.BigToHash(amount.ToInt()).Bytes(), []byte(calls[0].ReturnData))
+
+	// the block numbers must increase
+	_, err = api.SimulateV1(ctx, SimulationOpts{
+		BlockStateCalls: []SimulatedBlock{
+			{BlockOverrides: &SimulatedBlockOverrides{Number: &lastNumber}},
+			{BlockOverrides: &SimulatedBlockOverrides{Number: &lastNumber}},
+		},
+	}, nil)
+	require.Error(t, err)
+	require.Equal(t, -38020, err.(*rpc.CustomError).ErrorCode())
+
+	// the nonces are checked with the validation
+	nonce := hexutil.Uint64(5)
+	_, err = api.SimulateV1(ctx, SimulationOpts{
+		Validation: true,
+		BlockStateCalls: []SimulatedBlock{
+			{Calls: []ethapi.CallArgs{{From: &sender, To: &receiver, Nonce: &nonce}}},
+		},
+	}, nil)
+	require.Error(t, err)
+	require.Equal(t, -38011, err.(*rpc.CustomError).ErrorCode())
+}
+
+func TestSimulateV1Timeout(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 1_000_000_000)
+	from := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	to := common.HexToAddress("0x1234")
+	code := hexutil.Bytes(common.FromHex("0x5b600056")) // loops until it runs out of gas
+	gas := hexutil.Uint64(1_000_000_000)
+	simulate := func(ctx context.Context) error {
+		_, err := api.SimulateV1(ctx, SimulationOpts{
+			BlockStateCalls: []SimulatedBlock{{
+				BlockOverrides: &SimulatedBlockOverrides{GasLimit: &gas},
+				StateOverrides: &ethapi.StateOverrides{to: {Code: &code}},
+				Calls:          []ethapi.CallArgs{{From: &from, To: &to, Gas: &gas}},
+			}},
+		}, nil)
+		return err
+	}
+
+	// --rpc.evmtimeout limits the execution
+	api.EvmCallTimeout = 10 * time.Millisecond
+	require.EqualError(t, simulate(context.Background()), "execution aborted (timeout = 10ms)")
+
+	// the deadline of the method takes precedence
+	api.EvmCallTimeout = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := simulate(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "execution aborted")
+	require.NotContains(t, err.Error(), "1h0m0s")
+}
+
+func TestSimulateV1MovePrecompile(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	from := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	sha256Addr, moved := common.BytesToAddress([]byte{2}), common.HexToAddress("0x1234")
+	code := hexutil.Bytes(common.FromHex("0x602a60005260206000f3")) // returns 42
+	input := hexutil.Bytes("abc")
+	calls := []ethapi.CallArgs{{From: &from, To: &sha256Addr, Data: &input}, {From: &from, To: &moved, Data: &input}}
+
+	results, err := api.SimulateV1(context.Background(), SimulationOpts{
+		BlockStateCalls: []SimulatedBlock{
+			{
+				StateOverrides: &ethapi.StateOverrides{sha256Addr: {Code: &code, MovePrecompileTo: &moved}},
+				Calls:          calls,
+			},
+			{Calls: calls},
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	// the precompile stays moved in the following blocks
+	want := sha256.Sum256(input)
+	for i, result := range results {
+		calls := result["calls"].([]SimulatedCallResult)
+		require.Len(t, calls, 2)
+		require.Equal(t, common.LeftPadBytes([]byte{42}, 32), []byte(calls[0].ReturnData), "block %d", i)
+		require.Equal(t, want[:], []byte(calls[1].ReturnData), "block %d", i)
+	}
+}
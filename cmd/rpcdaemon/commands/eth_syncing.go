@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// syncingInterval is how often the progress of the staged sync is read for the syncing subscriptions
+const syncingInterval = time.Second
+
+// EthSyncingAPI is the syncing subscription of the eth namespace. It's a service of its own as its name is already
+// taken by eth_syncing in EthAPI.
+type EthSyncingAPI interface {
+	Syncing(ctx context.Context) (*rpc.Subscription, error)
+}
+
+// SyncingNotification is sent by eth_subscribe("syncing") when the sync starts and when it progresses, the end of the
+// sync is notified with false
+type SyncingNotification struct {
+	Syncing bool           `json:"syncing"`
+	Status  *SyncingStatus `json:"status"`
+}
+
+type EthSyncingImpl struct {
+	db       kv.RoDB
+	interval time.Duration
+}
+
+func NewEthSyncingAPI(db kv.RoDB) *EthSyncingImpl {
+	return &EthSyncingImpl{db: db, interval: syncingInterval}
+}
+
+// Syncing implements eth_subscribe("syncing"). Sends the progress of the staged sync when the node starts syncing
+// (right away if it's syncing already) and when the progress of a stage changes, then false once it's synced.
+func (api *EthSyncingImpl) Syncing(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer debug.LogPanic()
+		ticker := time.NewTicker(api.interval)
+		defer ticker.Stop()
+
+		var prev *SyncingStatus
+		for {
+			next, err := api.readStatus()
+			if err != nil {
+				log.Warn("syncing subscription: reading the sync progress", "err", err)
+			} else if notification, changed := syncingNotification(prev, next); changed {
+				if err := notifier.Notify(rpcSub.ID, notification); err != nil {
+					log.Warn("error while notifying subscription", "err", err)
+					return
+				}
+				prev = next
+			}
+			select {
+			case <-ticker.C:
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+func (api *EthSyncingImpl) readStatus() (*SyncingStatus, error) {
+	tx, err := api.db.BeginRo(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	return readSyncingStatus(tx)
+}
+
+// syncingNotification returns the notification of the change of the sync status from prev to next, both nil when
+// synced. The starting block of prev is kept in next while the sync goes on.
+func syncingNotification(prev, next *SyncingStatus) (interface{}, bool) {
+	switch {
+	case next == nil:
+		return false, prev != nil
+	case prev == nil:
+		starting := next.CurrentBlock
+		next.StartingBlock = &starting
+	default:
+		next.StartingBlock = prev.StartingBlock
+		if reflect.DeepEqual(prev, next) {
+			return nil, false
+		}
+	}
+	return &SyncingNotification{Syncing: true, Status: next}, true
+}
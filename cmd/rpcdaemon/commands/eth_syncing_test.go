@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncingSubscription(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewEthSyncingAPI(db)
+	api.interval = 10 * time.Millisecond
+
+	server := rpc.NewServer(50, false /* traceRequests */, true)
+	require.NoError(t, server.RegisterName("eth", EthSyncingAPI(api)))
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	notifications := make(chan json.RawMessage)
+	sub, err := client.Subscribe(ctx, "eth", notifications, "syncing")
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	receive := func() json.RawMessage {
+		select {
+		case n := <-notifications:
+			return n
+		case err := <-sub.Err():
+			t.Fatal(err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout waiting for a notification")
+		}
+		return nil
+	}
+	progress := func(stage stages.SyncStage, block uint64) {
+		require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+			return stages.SaveStageProgress(tx, stage, block)
+		}))
+	}
+	status := func(n json.RawMessage) *SyncingStatus {
+		var notification SyncingNotification
+		require.NoError(t, json.Unmarshal(n, &notification))
+		require.True(t, notification.Syncing)
+		return notification.Status
+	}
+
+	// the node re-enters the sync on new headers
+	progress(stages.Headers, 15)
+	started := status(receive())
+	require.Equal(t, hexutil.Uint64(10), *started.StartingBlock)
+	require.Equal(t, hexutil.Uint64(10), started.CurrentBlock)
+	require.Equal(t, hexutil.Uint64(15), started.HighestBlock)
+
+	progress(stages.Execution, 12)
+	progressed := status(receive())
+	require.Equal(t, hexutil.Uint64(10), *progressed.StartingBlock)
+	require.Equal(t, hexutil.Uint64(10), progressed.CurrentBlock)
+	for _, stage := range progressed.Stages {
+		if stage.StageName == string(stages.Execution) {
+			require.Equal(t, hexutil.Uint64(12), stage.BlockNumber)
+		}
+	}
+
+	progress(stages.Finish, 15)
+	require.Equal(t, "false", string(receive()))
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
-	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/eth/gasprice"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/params"
@@ -38,39 +37,61 @@ func (api *APIImpl) Syncing(ctx context.Context) (interface{}, error) {
 		return nil, err
 	}
 	defer tx.Rollback()
-	highestBlock, err := stages.GetStageProgress(tx, stages.Headers)
+	status, err := readSyncingStatus(tx)
 	if err != nil {
 		return false, err
 	}
+	if status == nil {
+		return false, nil
+	}
+	return status, nil
+}
+
+// StageProgress is the last block processed by a stage of the staged sync
+type StageProgress struct {
+	StageName   string         `json:"stage_name"`
+	BlockNumber hexutil.Uint64 `json:"block_number"`
+}
+
+// SyncingStatus is the progress of the staged sync returned by eth_syncing and sent by eth_subscribe("syncing")
+type SyncingStatus struct {
+	StartingBlock *hexutil.Uint64 `json:"startingBlock,omitempty"` // the current block when the sync started, only in the notifications
+	CurrentBlock  hexutil.Uint64  `json:"currentBlock"`
+	HighestBlock  hexutil.Uint64  `json:"highestBlock"`
+	Stages        []StageProgress `json:"stages"`
+}
+
+// readSyncingStatus returns the progress of the staged sync, nil if the synchronisation is completed
+func readSyncingStatus(tx kv.Tx) (*SyncingStatus, error) {
+	highestBlock, err := stages.GetStageProgress(tx, stages.Headers)
+	if err != nil {
+		return nil, err
+	}
 
 	currentBlock, err := stages.GetStageProgress(tx, stages.Finish)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	if currentBlock > 0 && currentBlock >= highestBlock { // Return not syncing if the synchronisation already completed
-		return false, nil
+		return nil, nil
 	}
 
 	// Otherwise gather the block sync stats
-	type S struct {
-		StageName   string         `json:"stage_name"`
-		BlockNumber hexutil.Uint64 `json:"block_number"`
-	}
-	stagesMap := make([]S, len(stages.AllStages))
+	stagesProgress := make([]StageProgress, len(stages.AllStages))
 	for i, stage := range stages.AllStages {
 		progress, err := stages.GetStageProgress(tx, stage)
 		if err != nil {
 			return nil, err
 		}
-		stagesMap[i].StageName = string(stage)
-		stagesMap[i].BlockNumber = hexutil.Uint64(progress)
+		stagesProgress[i].StageName = string(stage)
+		stagesProgress[i].BlockNumber = hexutil.Uint64(progress)
 	}
 
-	return map[string]interface{}{
-		"currentBlock": hexutil.Uint64(currentBlock),
-		"highestBlock": hexutil.Uint64(highestBlock),
-		"stages":       stagesMap,
+	return &SyncingStatus{
+		CurrentBlock: hexutil.Uint64(currentBlock),
+		HighestBlock: hexutil.Uint64(highestBlock),
+		Stages:       stagesProgress,
 	}, nil
 }
 
@@ -114,7 +135,7 @@ func (api *APIImpl) GasPrice(ctx context.Context) (*hexutil.Big, error) {
 	if err != nil {
 		return nil, err
 	}
-	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, cc, api.BaseAPI), ethconfig.Defaults.GPO)
+	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, cc, api.BaseAPI), api.gpoConfig, api.gpoCache)
 	tipcap, err := oracle.SuggestTipCap(ctx)
 	gasResult := big.NewInt(0)
 
@@ -139,7 +160,7 @@ func (api *APIImpl) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, err
 	if err != nil {
 		return nil, err
 	}
-	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, cc, api.BaseAPI), ethconfig.Defaults.GPO)
+	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, cc, api.BaseAPI), api.gpoConfig, api.gpoCache)
 	tipcap, err := oracle.SuggestTipCap(ctx)
 	if err != nil {
 		return nil, err
@@ -164,7 +185,7 @@ func (api *APIImpl) FeeHistory(ctx context.Context, blockCount rpc.DecimalOrHex,
 	if err != nil {
 		return nil, err
 	}
-	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, cc, api.BaseAPI), ethconfig.Defaults.GPO)
+	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, cc, api.BaseAPI), api.gpoConfig, api.gpoCache)
 
 	oldest, reward, baseFee, gasUsed, err := oracle.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles)
 	if err != nil {
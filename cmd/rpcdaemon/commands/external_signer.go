@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// TransactionArgs are the arguments of eth_sendTransaction and eth_signTransaction, which are sent to the external
+// signer once their defaults are filled in.
+type TransactionArgs struct {
+	From                 *common.Address   `json:"from"`
+	To                   *common.Address   `json:"to"`
+	Gas                  *hexutil.Uint64   `json:"gas"`
+	GasPrice             *hexutil.Big      `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big      `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big      `json:"maxPriorityFeePerGas,omitempty"`
+	Value                *hexutil.Big      `json:"value"`
+	Nonce                *hexutil.Uint64   `json:"nonce"`
+	Data                 *hexutil.Bytes    `json:"data,omitempty"`
+	Input                *hexutil.Bytes    `json:"input,omitempty"` // the newer name of data, both are accepted
+	AccessList           *types.AccessList `json:"accessList,omitempty"`
+	ChainID              *hexutil.Big      `json:"chainId,omitempty"`
+}
+
+// SignTransactionResult is the result of eth_signTransaction, the signed transaction and its RLP encoding
+type SignTransactionResult struct {
+	Raw hexutil.Bytes     `json:"raw"`
+	Tx  types.Transaction `json:"tx"`
+}
+
+// ExternalSigner signs the transactions of eth_sendTransaction and eth_signTransaction with an external signer
+// like clef, over the account_* methods of its API, so that the keys are never held by erigon. The signer is dialed
+// on the first use, and redialed after a failed dial.
+type ExternalSigner struct {
+	url string
+
+	lock   sync.Mutex
+	client *rpc.Client
+}
+
+// NewExternalSigner returns the signer at url, http(s) or ws(s)
+func NewExternalSigner(url string) *ExternalSigner {
+	return &ExternalSigner{url: url}
+}
+
+func (s *ExternalSigner) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	s.lock.Lock()
+	if s.client == nil {
+		client, err := rpc.DialContext(ctx, s.url)
+		if err != nil {
+			s.lock.Unlock()
+			return err
+		}
+		s.client = client
+	}
+	client := s.client
+	s.lock.Unlock()
+	return client.CallContext(ctx, result, method, args...)
+}
+
+// Accounts returns the accounts of the signer
+func (s *ExternalSigner) Accounts(ctx context.Context) ([]common.Address, error) {
+	var accounts []common.Address
+	if err := s.call(ctx, &accounts, "account_list"); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// SignTransaction returns the RLP encoding of the transaction of args, signed by the signer. The signer may have
+// changed the transaction, it has to be decoded.
+func (s *ExternalSigner) SignTransaction(ctx context.Context, args *TransactionArgs) (hexutil.Bytes, error) {
+	var res struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := s.call(ctx, &res, "account_signTransaction", args); err != nil {
+		return nil, err
+	}
+	return res.Raw, nil
+}
+
+// Close closes the connection to the signer
+func (s *ExternalSigner) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.client != nil {
+		s.client.Close()
+		s.client = nil
+	}
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"strings"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+)
+
+// testSigner is the account_* API of an external signer holding key
+type testSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s *testSigner) List() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(s.key.PublicKey)}
+}
+
+func (s *testSigner) SignTransaction(args TransactionArgs) (map[string]hexutil.Bytes, error) {
+	u256 := func(b *hexutil.Big) *uint256.Int {
+		v, _ := uint256.FromBig(b.ToInt())
+		return v
+	}
+	var txn types.Transaction
+	if args.GasPrice != nil {
+		txn = types.NewTransaction(uint64(*args.Nonce), *args.To, u256(args.Value), uint64(*args.Gas), u256(args.GasPrice), nil)
+	} else {
+		txn = &types.DynamicFeeTransaction{
+			CommonTx: types.CommonTx{ChainID: u256(args.ChainID), Nonce: uint64(*args.Nonce), Gas: uint64(*args.Gas), To: args.To, Value: u256(args.Value)},
+			Tip:      u256(args.MaxPriorityFeePerGas),
+			FeeCap:   u256(args.MaxFeePerGas),
+		}
+	}
+	signed, err := types.SignTx(txn, *types.LatestSignerForChainID(args.ChainID.ToInt()), s.key)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := signed.MarshalBinary(&buf); err != nil {
+		return nil, err
+	}
+	return map[string]hexutil.Bytes{"raw": buf.Bytes()}, nil
+}
+
+func TestExternalSigner(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	ff := rpchelper.New(ctx, nil, nil, nil, func() {})
+	api := NewEthAPI(NewBaseApi(ff, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	from := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	to := common.HexToAddress("0x1234")
+	nonce := hexutil.Uint64(10)
+	args := TransactionArgs{From: &from, To: &to, Value: (*hexutil.Big)(uint256.NewInt(params.GWei).ToBig()), Nonce: &nonce}
+
+	if _, err := api.SignTransaction(ctx, args); err == nil || !strings.Contains(err.Error(), "external signer") {
+		t.Fatalf("expected an error without a signer, got %v", err)
+	}
+
+	useSigner := func(key string) {
+		privateKey, err := crypto.HexToECDSA(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := rpc.NewServer(50, false /* traceRequests */, true)
+		if err := server.RegisterName("account", &testSigner{key: privateKey}); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(server.Stop)
+		api.Signer = &ExternalSigner{client: rpc.DialInProc(server)}
+		t.Cleanup(api.Signer.Close)
+	}
+
+	useSigner("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	accounts, err := api.Accounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 1 || accounts[0] != from {
+		t.Fatalf("wrong accounts: %x", accounts)
+	}
+	res, err := api.SignTransaction(ctx, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID, err := api.ChainId(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := res.Tx.Sender(*types.LatestSignerForChainID(uint256.NewInt(uint64(chainID)).ToBig()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender != from {
+		t.Errorf("wrong sender %x", sender)
+	}
+	if res.Tx.GetNonce() != uint64(nonce) || res.Tx.GetGas() != params.TxGas {
+		t.Errorf("wrong defaults: nonce %d, gas %d", res.Tx.GetNonce(), res.Tx.GetGas())
+	}
+
+	// the signer holds the key of another account
+	useSigner("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	if _, err := api.SignTransaction(ctx, args); err == nil || !strings.Contains(err.Error(), "signed by") {
+		t.Fatalf("expected an error for a transaction of another sender, got %v", err)
+	}
+}
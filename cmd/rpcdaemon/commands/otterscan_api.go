@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/transactions"
+)
+
+// OtterscanAPI the routines used by the Otterscan block explorer, see https://github.com/otterscan/otterscan
+type OtterscanAPI interface {
+	// Transaction related (see ./otterscan_trace.go)
+	GetInternalOperations(ctx context.Context, hash common.Hash) ([]*InternalOperation, error)
+	TraceTransaction(ctx context.Context, hash common.Hash) ([]*TraceEntry, error)
+	GetTransactionBySenderAndNonce(ctx context.Context, addr common.Address, nonce uint64) (*common.Hash, error)
+
+	// Address history related (see ./otterscan_search.go)
+	SearchTransactionsBefore(ctx context.Context, addr common.Address, blockNum uint64, pageSize uint16) (*TransactionsWithReceipts, error)
+	SearchTransactionsAfter(ctx context.Context, addr common.Address, blockNum uint64, pageSize uint16) (*TransactionsWithReceipts, error)
+
+	// Block related (see ./otterscan_block_details.go)
+	GetBlockDetails(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error)
+}
+
+// OtterscanAPIImpl is implementation of the OtterscanAPI interface
+type OtterscanAPIImpl struct {
+	*BaseAPI
+	db              kv.RoDB
+	searchMaxBlocks int
+}
+
+// NewOtterscanAPI returns OtterscanAPIImpl instance
+func NewOtterscanAPI(base *BaseAPI, db kv.RoDB) *OtterscanAPIImpl {
+	return &OtterscanAPIImpl{
+		BaseAPI:         base,
+		db:              db,
+		searchMaxBlocks: otterscanSearchMaxBlocks,
+	}
+}
+
+// runTracer executes the transaction hash with tracer. nil is returned if there is no such transaction.
+func (api *OtterscanAPIImpl) runTracer(ctx context.Context, tx kv.Tx, hash common.Hash, tracer vm.Tracer) (*core.ExecutionResult, error) {
+	blockNum, ok, err := api.txnLookup(ctx, tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	block, err := api.blockByNumberWithSenders(tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, nil
+	}
+	var txnIndex uint64
+	var txn types.Transaction
+	for i, transaction := range block.Transactions() {
+		if transaction.Hash() == hash {
+			txnIndex = uint64(i)
+			txn = transaction
+			break
+		}
+	}
+	if txn == nil {
+		return nil, fmt.Errorf("transaction %#x not found", hash)
+	}
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	getHeader := func(hash common.Hash, number uint64) *types.Header {
+		return rawdb.ReadHeader(tx, hash, number)
+	}
+	msg, blockCtx, txCtx, ibs, _, err := transactions.ComputeTxEnv(ctx, block, chainConfig, getHeader, ethash.NewFaker(), tx, block.Hash(), txnIndex)
+	if err != nil {
+		return nil, err
+	}
+	vmenv := vm.NewEVM(blockCtx, txCtx, ibs, chainConfig, vm.Config{Debug: true, Tracer: tracer})
+	return core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()), true /* refunds */, false /* gasBailout */)
+}
+
+// DefaultTracer is a vm.Tracer ignoring everything, the tracers of the ots_ methods embed it and implement the
+// hooks they need only
+type DefaultTracer struct{}
+
+func (t *DefaultTracer) CaptureStart(env *vm.EVM, depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) {
+}
+
+func (t *DefaultTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (t *DefaultTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *DefaultTracer) CaptureEnd(depth int, output []byte, startGas, endGas uint64, d time.Duration, err error) {
+}
+
+func (t *DefaultTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+}
+
+func (t *DefaultTracer) CaptureAccountRead(account common.Address) error {
+	return nil
+}
+
+func (t *DefaultTracer) CaptureAccountWrite(account common.Address) error {
+	return nil
+}
+
+// copyValue returns the value of a call, or nil for the delegate and static calls which are reported with
+// negative values
+func copyValue(value *big.Int) *hexutil.Big {
+	if value == nil || value.Sign() < 0 {
+		return nil
+	}
+	return (*hexutil.Big)(new(big.Int).Set(value))
+}
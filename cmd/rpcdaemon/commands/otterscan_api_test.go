@@ -0,0 +1,232 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+)
+
+// the account sending most of the transactions of the test chain
+var otsTestAddr = func() common.Address {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	return crypto.PubkeyToAddress(key.PublicKey)
+}()
+
+func TestOtsSearchTransactions(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewOtterscanAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db)
+
+	// the transactions sent by or to the address, most recent first
+	var want []common.Hash
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	head := *rawdb.ReadCurrentBlockNumber(tx)
+	for n := head; n > 0; n-- {
+		block, err := rawdb.ReadBlockByNumber(tx, n)
+		require.NoError(t, err)
+		senders, err := rawdb.ReadSenders(tx, block.Hash(), n)
+		require.NoError(t, err)
+		for i := len(block.Transactions()) - 1; i >= 0; i-- {
+			txn := block.Transactions()[i]
+			if senders[i] == otsTestAddr || (txn.GetTo() != nil && *txn.GetTo() == otsTestAddr) {
+				want = append(want, txn.Hash())
+			}
+		}
+	}
+	tx.Rollback()
+	require.NotEmpty(t, want)
+
+	hashes := func(res *TransactionsWithReceipts) []common.Hash {
+		require.Equal(t, len(res.Txs), len(res.Receipts))
+		var h []common.Hash
+		for i, txn := range res.Txs {
+			require.Equal(t, txn.Hash, res.Receipts[i]["transactionHash"])
+			h = append(h, txn.Hash)
+		}
+		return h
+	}
+
+	all, err := api.SearchTransactionsBefore(ctx, otsTestAddr, 0, 1000)
+	require.NoError(t, err)
+	require.Equal(t, want, hashes(all))
+	require.True(t, all.FirstPage)
+	require.True(t, all.LastPage)
+
+	all, err = api.SearchTransactionsAfter(ctx, otsTestAddr, 0, 1000)
+	require.NoError(t, err)
+	require.Equal(t, want, hashes(all))
+	require.True(t, all.FirstPage)
+	require.True(t, all.LastPage)
+
+	// page backwards through the history, one block at a time
+	var got []common.Hash
+	blockNum := uint64(0)
+	for {
+		page, err := api.SearchTransactionsBefore(ctx, otsTestAddr, blockNum, 1)
+		require.NoError(t, err)
+		require.Equal(t, blockNum == 0, page.FirstPage)
+		got = append(got, hashes(page)...)
+		if page.LastPage {
+			break
+		}
+		blockNum = page.Txs[len(page.Txs)-1].BlockNumber.ToInt().Uint64()
+	}
+	require.Equal(t, want, got)
+
+	// and forwards
+	got = nil
+	page, err := api.SearchTransactionsAfter(ctx, otsTestAddr, 0, 1)
+	require.NoError(t, err)
+	require.True(t, page.LastPage)
+	for {
+		got = append(hashes(page), got...)
+		if page.FirstPage {
+			break
+		}
+		page, err = api.SearchTransactionsAfter(ctx, otsTestAddr, page.Txs[0].BlockNumber.ToInt().Uint64(), 1)
+		require.NoError(t, err)
+		require.False(t, page.LastPage)
+	}
+	require.Equal(t, want, got)
+
+	// the searches executing too many blocks return partial pages, continued from their cursor
+	api.searchMaxBlocks = 1
+	got = nil
+	blockNum = 0
+	for {
+		page, err := api.SearchTransactionsBefore(ctx, otsTestAddr, blockNum, 1000)
+		require.NoError(t, err)
+		got = append(got, hashes(page)...)
+		if page.LastPage {
+			require.Nil(t, page.Cursor)
+			break
+		}
+		blockNum = uint64(*page.Cursor)
+	}
+	require.Equal(t, want, got)
+
+	got = nil
+	page, err = api.SearchTransactionsAfter(ctx, otsTestAddr, 0, 1000)
+	require.NoError(t, err)
+	for {
+		got = append(hashes(page), got...)
+		if page.FirstPage {
+			require.Nil(t, page.Cursor)
+			break
+		}
+		page, err = api.SearchTransactionsAfter(ctx, otsTestAddr, uint64(*page.Cursor), 1000)
+		require.NoError(t, err)
+	}
+	require.Equal(t, want, got)
+}
+
+func TestOtsGetTransactionBySenderAndNonce(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewOtterscanAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db)
+
+	// the transactions sent by the address, by nonce
+	var sent []common.Hash
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	head := *rawdb.ReadCurrentBlockNumber(tx)
+	for n := uint64(1); n <= head; n++ {
+		block, err := rawdb.ReadBlockByNumber(tx, n)
+		require.NoError(t, err)
+		senders, err := rawdb.ReadSenders(tx, block.Hash(), n)
+		require.NoError(t, err)
+		for i, txn := range block.Transactions() {
+			if senders[i] == otsTestAddr {
+				require.Equal(t, uint64(len(sent)), txn.GetNonce())
+				sent = append(sent, txn.Hash())
+			}
+		}
+	}
+	tx.Rollback()
+	require.NotEmpty(t, sent)
+
+	for nonce, want := range sent {
+		hash, err := api.GetTransactionBySenderAndNonce(ctx, otsTestAddr, uint64(nonce))
+		require.NoError(t, err)
+		require.NotNil(t, hash)
+		require.Equal(t, want, *hash)
+	}
+
+	hash, err := api.GetTransactionBySenderAndNonce(ctx, otsTestAddr, uint64(len(sent)))
+	require.NoError(t, err)
+	require.Nil(t, hash)
+}
+
+func TestOtsTraceTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewOtterscanAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db)
+
+	// the last block calls Poly.deployAndDestruct, which creates a contract and calls it to self-destruct it
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	block, err := rawdb.ReadBlockByNumber(tx, *rawdb.ReadCurrentBlockNumber(tx))
+	require.NoError(t, err)
+	tx.Rollback()
+	txn := block.Transactions()[0]
+
+	entries, err := api.TraceTransaction(ctx, txn.Hash())
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	require.Equal(t, "CALL", entries[0].Type)
+	require.Equal(t, 0, entries[0].Depth)
+	require.Equal(t, otsTestAddr, entries[0].From)
+	require.Equal(t, *txn.GetTo(), entries[0].To)
+	require.Equal(t, "CREATE2", entries[1].Type)
+	require.Equal(t, 1, entries[1].Depth)
+	created := entries[1].To
+	require.Equal(t, "CALL", entries[2].Type)
+	require.Equal(t, 1, entries[2].Depth)
+	require.Equal(t, created, entries[2].To)
+	require.Equal(t, "SELFDESTRUCT", entries[3].Type)
+	require.Equal(t, 2, entries[3].Depth)
+	require.Equal(t, created, entries[3].From)
+
+	ops, err := api.GetInternalOperations(ctx, txn.Hash())
+	require.NoError(t, err)
+	require.Equal(t, []*InternalOperation{
+		{OP_CREATE2, *txn.GetTo(), created, entries[1].Value},
+		{OP_SELF_DESTRUCT, created, entries[3].To, entries[3].Value},
+	}, ops)
+
+	entries, err = api.TraceTransaction(ctx, common.Hash{1})
+	require.NoError(t, err)
+	require.Nil(t, entries)
+}
+
+func TestOtsGetBlockDetails(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewOtterscanAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db)
+
+	details, err := api.GetBlockDetails(ctx, rpc.BlockNumber(1))
+	require.NoError(t, err)
+	block := details["block"].(map[string]interface{})
+	require.Equal(t, 1, block["transactionCount"])
+	require.NotContains(t, block, "transactions")
+	require.Contains(t, block, "totalDifficulty")
+	require.Contains(t, details, "issuance")
+	require.Contains(t, details, "totalFees")
+
+	details, err = api.GetBlockDetails(ctx, rpc.BlockNumber(1000))
+	require.NoError(t, err)
+	require.Nil(t, details)
+}
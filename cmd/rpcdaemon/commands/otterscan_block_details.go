@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// BlockIssuance is the ether created by a block, for its miner and the miners of its uncles
+type BlockIssuance struct {
+	BlockReward *hexutil.Big `json:"blockReward"`
+	UncleReward *hexutil.Big `json:"uncleReward"`
+	Issuance    *hexutil.Big `json:"issuance"`
+}
+
+// GetBlockDetails implements ots_getBlockDetails. Returns the header of a block with the number of its transactions,
+// its issuance and the fees paid by its transactions.
+func (api *OtterscanAPIImpl) GetBlockDetails(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	block, err := api.blockByRPCNumber(number, tx)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, nil
+	}
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := ethapi.RPCMarshalBlock(block, false, false)
+	if err != nil {
+		return nil, err
+	}
+	// Otterscan reads the transactions of the block separately
+	delete(fields, "transactions")
+	fields["transactionCount"] = len(block.Transactions())
+	td, err := rawdb.ReadTd(tx, block.Hash(), block.NumberU64())
+	if err != nil {
+		return nil, err
+	}
+	if td != nil {
+		fields["totalDifficulty"] = (*hexutil.Big)(td)
+	}
+
+	receipts, err := api.getReceipts(ctx, tx, chainConfig, block, block.Body().SendersFromTxs())
+	if err != nil {
+		return nil, fmt.Errorf("getReceipts error: %w", err)
+	}
+	totalFees, err := blockFees(chainConfig, block, receipts)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"block":     fields,
+		"issuance":  blockIssuance(chainConfig, block),
+		"totalFees": (*hexutil.Big)(totalFees),
+	}, nil
+}
+
+// blockIssuance computes the rewards of a block, there are none outside of ethash
+func blockIssuance(chainConfig *params.ChainConfig, block *types.Block) BlockIssuance {
+	if chainConfig.Ethash == nil {
+		return BlockIssuance{(*hexutil.Big)(new(big.Int)), (*hexutil.Big)(new(big.Int)), (*hexutil.Big)(new(big.Int))}
+	}
+	minerReward, uncleRewards := ethash.AccumulateRewards(chainConfig, block.Header(), block.Uncles())
+	uncleReward := new(uint256.Int)
+	for i := range uncleRewards {
+		uncleReward.Add(uncleReward, &uncleRewards[i])
+	}
+	issuance := new(uint256.Int).Add(&minerReward, uncleReward)
+	return BlockIssuance{
+		BlockReward: (*hexutil.Big)(minerReward.ToBig()),
+		UncleReward: (*hexutil.Big)(uncleReward.ToBig()),
+		Issuance:    (*hexutil.Big)(issuance.ToBig()),
+	}
+}
+
+// blockFees computes the fees paid by the transactions of a block, the burnt base fee included
+func blockFees(chainConfig *params.ChainConfig, block *types.Block, receipts types.Receipts) (*big.Int, error) {
+	var baseFee *uint256.Int
+	if chainConfig.IsLondon(block.NumberU64()) {
+		var overflow bool
+		if baseFee, overflow = uint256.FromBig(block.BaseFee()); overflow {
+			return nil, fmt.Errorf("baseFee overflow")
+		}
+	}
+	totalFees := new(big.Int)
+	for i, txn := range block.Transactions() {
+		gasPrice := txn.GetPrice()
+		if baseFee != nil {
+			gasPrice = new(uint256.Int).Add(baseFee, txn.GetEffectiveGasTip(baseFee))
+		}
+		fee := new(uint256.Int).Mul(gasPrice, uint256.NewInt(receipts[i].GasUsed))
+		totalFees.Add(totalFees, fee.ToBig())
+	}
+	return totalFees, nil
+}
@@ -0,0 +1,226 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/RoaringBitmap/roaring/roaring64"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/bitmapdb"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/transactions"
+)
+
+// otterscanSearchMaxBlocks is the maximum number of blocks executed by a call of ots_searchTransactionsBefore or
+// ots_searchTransactionsAfter, the page is returned partial once it's reached
+const otterscanSearchMaxBlocks = 100
+
+// TransactionsWithReceipts is a page of the transactions of an address, most recent first. The first page is the
+// most recent one.
+type TransactionsWithReceipts struct {
+	Txs       []*RPCTransaction        `json:"txs"`
+	Receipts  []map[string]interface{} `json:"receipts"`
+	FirstPage bool                     `json:"firstPage"`
+	LastPage  bool                     `json:"lastPage"`
+	// Cursor is the blockNum of the call returning the next page in the direction of the search, if any. The pages
+	// stopped by otterscanSearchMaxBlocks are partial, or even empty, and only continue from it.
+	Cursor *hexutil.Uint64 `json:"cursor,omitempty"`
+}
+
+// SearchTransactionsBefore implements ots_searchTransactionsBefore. Returns the transactions sending or receiving a
+// call from addr in the blocks before blockNum, or in all blocks if blockNum is 0. The search stops once pageSize
+// transactions are found, at the end of a block, or once otterscanSearchMaxBlocks blocks are executed.
+func (api *OtterscanAPIImpl) SearchTransactionsBefore(ctx context.Context, addr common.Address, blockNum uint64, pageSize uint16) (*TransactionsWithReceipts, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	head, err := stages.GetStageProgress(tx, stages.CallTraces)
+	if err != nil {
+		return nil, err
+	}
+	isFirstPage := blockNum == 0
+	if isFirstPage || blockNum > head+1 {
+		blockNum = head + 1
+	}
+	if blockNum == 0 {
+		return &TransactionsWithReceipts{Txs: []*RPCTransaction{}, Receipts: []map[string]interface{}{}, FirstPage: true, LastPage: true}, nil
+	}
+	blocks, err := addressBlocks(tx, addr, 0, blockNum-1)
+	if err != nil {
+		return nil, err
+	}
+	result, err := api.searchBlocks(ctx, tx, addr, blocks.ReverseIterator(), true /* backward */, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	result.FirstPage = isFirstPage
+	result.LastPage = result.Cursor == nil
+	return result, nil
+}
+
+// SearchTransactionsAfter implements ots_searchTransactionsAfter. Returns the transactions sending or receiving a
+// call from addr in the blocks after blockNum, or in all blocks if blockNum is 0. The search stops once pageSize
+// transactions are found, at the end of a block, or once otterscanSearchMaxBlocks blocks are executed.
+func (api *OtterscanAPIImpl) SearchTransactionsAfter(ctx context.Context, addr common.Address, blockNum uint64, pageSize uint16) (*TransactionsWithReceipts, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	head, err := stages.GetStageProgress(tx, stages.CallTraces)
+	if err != nil {
+		return nil, err
+	}
+	isLastPage := blockNum == 0
+	if !isLastPage {
+		blockNum++
+	}
+	if blockNum > head {
+		return &TransactionsWithReceipts{Txs: []*RPCTransaction{}, Receipts: []map[string]interface{}{}, FirstPage: true, LastPage: isLastPage}, nil
+	}
+	blocks, err := addressBlocks(tx, addr, blockNum, head)
+	if err != nil {
+		return nil, err
+	}
+	result, err := api.searchBlocks(ctx, tx, addr, blocks.Iterator(), false /* backward */, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	// the pages are ordered most recent first
+	for i, j := 0, len(result.Txs)-1; i < j; i, j = i+1, j-1 {
+		result.Txs[i], result.Txs[j] = result.Txs[j], result.Txs[i]
+		result.Receipts[i], result.Receipts[j] = result.Receipts[j], result.Receipts[i]
+	}
+	result.FirstPage = result.Cursor == nil
+	result.LastPage = isLastPage
+	return result, nil
+}
+
+// addressBlocks returns the numbers of the blocks between from and to, inclusive, in which addr made or received
+// a call according to the call traces index
+func addressBlocks(tx kv.Tx, addr common.Address, from, to uint64) (*roaring64.Bitmap, error) {
+	blocks, err := bitmapdb.Get64(tx, kv.CallFromIndex, addr.Bytes(), from, to)
+	if err != nil {
+		return nil, err
+	}
+	blocksTo, err := bitmapdb.Get64(tx, kv.CallToIndex, addr.Bytes(), from, to)
+	if err != nil {
+		return nil, err
+	}
+	blocks.Or(blocksTo)
+	blocks.RemoveRange(0, from)
+	blocks.RemoveRange(to+1, uint64(0x100000000))
+	return blocks, nil
+}
+
+// searchBlocks collects the transactions touching addr in the blocks given by it, until pageSize transactions are
+// found or api.searchMaxBlocks blocks are executed. The transactions of each block are collected in reverse order if
+// backward. The cursor of the result is the last searched block if blocks remain to be searched.
+func (api *OtterscanAPIImpl) searchBlocks(ctx context.Context, tx kv.Tx, addr common.Address, it roaring64.IntIterable64, backward bool, pageSize uint16) (*TransactionsWithReceipts, error) {
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	result := &TransactionsWithReceipts{Txs: []*RPCTransaction{}, Receipts: []map[string]interface{}{}}
+	var searched int
+	var blockNum uint64
+	for it.HasNext() {
+		if len(result.Txs) >= int(pageSize) || (api.searchMaxBlocks > 0 && searched >= api.searchMaxBlocks) {
+			cursor := hexutil.Uint64(blockNum)
+			result.Cursor = &cursor
+			return result, nil
+		}
+		blockNum = it.Next()
+		searched++
+		block, err := api.blockByNumberWithSenders(tx, blockNum)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("could not find block %d", blockNum)
+		}
+		found, err := api.touchingTransactions(ctx, tx, chainConfig, block, addr)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			continue
+		}
+		receipts, err := api.getReceipts(ctx, tx, chainConfig, block, block.Body().SendersFromTxs())
+		if err != nil {
+			return nil, fmt.Errorf("getReceipts error: %w", err)
+		}
+		if backward {
+			for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+				found[i], found[j] = found[j], found[i]
+			}
+		}
+		for _, idx := range found {
+			txn := block.Transactions()[idx]
+			result.Txs = append(result.Txs, newRPCTransaction(txn, block.Hash(), blockNum, uint64(idx), block.BaseFee()))
+			receipt := marshalReceipt(receipts[idx], txn, chainConfig, block, txn.Hash(), true)
+			receipt["timestamp"] = block.Time()
+			result.Receipts = append(result.Receipts, receipt)
+		}
+	}
+	return result, nil
+}
+
+// touchTracer detects whether a transaction makes or receives a call from an address
+type touchTracer struct {
+	DefaultTracer
+	addr    common.Address
+	touched bool
+}
+
+func (t *touchTracer) CaptureStart(env *vm.EVM, depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) {
+	if from == t.addr || to == t.addr {
+		t.touched = true
+	}
+}
+
+// touchingTransactions executes the transactions of block, returning the indices of the ones touching addr
+func (api *OtterscanAPIImpl) touchingTransactions(ctx context.Context, tx kv.Tx, chainConfig *params.ChainConfig, block *types.Block, addr common.Address) ([]int, error) {
+	getHeader := func(hash common.Hash, number uint64) *types.Header {
+		return rawdb.ReadHeader(tx, hash, number)
+	}
+	_, blockCtx, _, ibs, reader, err := transactions.ComputeTxEnv(ctx, block, chainConfig, getHeader, ethash.NewFaker(), tx, block.Hash(), 0)
+	if err != nil {
+		return nil, err
+	}
+	signer := types.MakeSigner(chainConfig, block.NumberU64())
+	rules := chainConfig.Rules(block.NumberU64())
+	var found []int
+	for idx, txn := range block.Transactions() {
+		select {
+		default:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		ibs.Prepare(txn.Hash(), block.Hash(), idx)
+		msg, _ := txn.AsMessage(*signer, block.BaseFee(), rules)
+		tracer := &touchTracer{addr: addr}
+		vmenv := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), ibs, chainConfig, vm.Config{Debug: true, Tracer: tracer})
+		if _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()), true /* refunds */, false /* gasBailout */); err != nil {
+			return nil, fmt.Errorf("tracing transaction %d of block %d: %w", idx, block.NumberU64(), err)
+		}
+		_ = ibs.FinalizeTx(rules, reader)
+		if tracer.touched {
+			found = append(found, idx)
+		}
+	}
+	return found, nil
+}
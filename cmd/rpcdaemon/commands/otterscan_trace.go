@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+)
+
+type OperationType int
+
+const (
+	OP_TRANSFER      OperationType = 0
+	OP_SELF_DESTRUCT OperationType = 1
+	OP_CREATE        OperationType = 2
+	OP_CREATE2       OperationType = 3
+)
+
+// InternalOperation is a transfer of ether made by a contract, not visible in the transaction itself
+type InternalOperation struct {
+	Type  OperationType  `json:"type"`
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Value *hexutil.Big   `json:"value"`
+}
+
+// operationsTracer collects the internal transfers, contract creations and self-destructs of a transaction
+type operationsTracer struct {
+	DefaultTracer
+	results []*InternalOperation
+}
+
+func (t *operationsTracer) CaptureStart(env *vm.EVM, depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) {
+	// the top call is the transaction itself
+	if depth == 0 {
+		return
+	}
+	switch {
+	case callType == vm.CREATET:
+		t.results = append(t.results, &InternalOperation{OP_CREATE, from, to, copyValue(value)})
+	case callType == vm.CREATE2T:
+		t.results = append(t.results, &InternalOperation{OP_CREATE2, from, to, copyValue(value)})
+	case callType == vm.CALLT && value.Sign() > 0:
+		t.results = append(t.results, &InternalOperation{OP_TRANSFER, from, to, copyValue(value)})
+	}
+}
+
+func (t *operationsTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+	t.results = append(t.results, &InternalOperation{OP_SELF_DESTRUCT, from, to, copyValue(value)})
+}
+
+// GetInternalOperations implements ots_getInternalOperations. Returns the transfers of ether made by the contracts
+// called by a transaction.
+func (api *OtterscanAPIImpl) GetInternalOperations(ctx context.Context, hash common.Hash) ([]*InternalOperation, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	tracer := &operationsTracer{results: make([]*InternalOperation, 0)}
+	result, err := api.runTracer(ctx, tx, hash, tracer)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return tracer.results, nil
+}
+
+var traceEntryTypes = map[vm.CallType]string{
+	vm.CALLT:         "CALL",
+	vm.CALLCODET:     "CALLCODE",
+	vm.DELEGATECALLT: "DELEGATECALL",
+	vm.STATICCALLT:   "STATICCALL",
+	vm.CREATET:       "CREATE",
+	vm.CREATE2T:      "CREATE2",
+}
+
+// TraceEntry is a call, contract creation or self-destruct made during a transaction
+type TraceEntry struct {
+	Type  string         `json:"type"`
+	Depth int            `json:"depth"`
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Value *hexutil.Big   `json:"value"`
+	Input hexutil.Bytes  `json:"input"`
+}
+
+// transactionTracer collects the calls of a transaction, in the order they are made
+type transactionTracer struct {
+	DefaultTracer
+	depth   int
+	results []*TraceEntry
+}
+
+func (t *transactionTracer) CaptureStart(env *vm.EVM, depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) {
+	t.depth = depth
+	t.results = append(t.results, &TraceEntry{traceEntryTypes[callType], depth, from, to, copyValue(value), common.CopyBytes(input)})
+}
+
+func (t *transactionTracer) CaptureEnd(depth int, output []byte, startGas, endGas uint64, _ time.Duration, err error) {
+	t.depth = depth - 1
+}
+
+func (t *transactionTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+	// the self-destruct is reported as a call made by the frame executing it
+	t.results = append(t.results, &TraceEntry{"SELFDESTRUCT", t.depth + 1, from, to, copyValue(value), nil})
+}
+
+// TraceTransaction implements ots_traceTransaction. Returns the calls made by a transaction, with their depth.
+func (api *OtterscanAPIImpl) TraceTransaction(ctx context.Context, hash common.Hash) ([]*TraceEntry, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	tracer := &transactionTracer{results: make([]*TraceEntry, 0)}
+	result, err := api.runTracer(ctx, tx, hash, tracer)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return tracer.results, nil
+}
+
+// GetTransactionBySenderAndNonce implements ots_getTransactionBySenderAndNonce. Returns the hash of the transaction
+// sent by addr with the given nonce, or nil if it doesn't exist.
+func (api *OtterscanAPIImpl) GetTransactionBySenderAndNonce(ctx context.Context, addr common.Address, nonce uint64) (*common.Hash, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	head, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return nil, err
+	}
+	// the nonce of an account only increases, the transaction is in the first block after which it's greater
+	// than nonce
+	reader := state.NewPlainState(tx, head+1)
+	nonceAfter := func(blockNum uint64) (uint64, error) {
+		reader.SetBlockNr(blockNum + 1)
+		acc, err := reader.ReadAccountData(addr)
+		if err != nil || acc == nil {
+			return 0, err
+		}
+		return acc.Nonce, nil
+	}
+	latest, err := nonceAfter(head)
+	if err != nil {
+		return nil, err
+	}
+	if latest <= nonce {
+		return nil, nil
+	}
+	lo, hi := uint64(0), head
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		n, err := nonceAfter(mid)
+		if err != nil {
+			return nil, err
+		}
+		if n > nonce {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	block, err := api.blockByNumberWithSenders(tx, lo)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, nil
+	}
+	for _, txn := range block.Transactions() {
+		if sender, ok := txn.GetSender(); ok && sender == addr && txn.GetNonce() == nonce {
+			hash := txn.Hash()
+			return &hash, nil
+		}
+	}
+	// the nonce was incremented by a contract creation, not by a transaction
+	return nil, nil
+}
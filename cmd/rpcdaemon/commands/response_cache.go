@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+// constantResultMethods return the same result for the lifetime of the node
+var constantResultMethods = map[string]struct{}{
+	"eth_chainId": {},
+	"net_version": {},
+}
+
+// finalizedResultMethods return results which never change once their block is finalized, given by the field of
+// their result holding the block number. The methods taking a block number as first param are cached only for
+// numbers, not for tags like "latest".
+var finalizedResultMethods = map[string]struct {
+	numberField string
+	blockParam  bool
+}{
+	"eth_getBlockByHash":                      {numberField: "number"},
+	"eth_getBlockByNumber":                    {numberField: "number", blockParam: true},
+	"eth_getTransactionByHash":                {numberField: "blockNumber"},
+	"eth_getTransactionByBlockHashAndIndex":   {numberField: "blockNumber"},
+	"eth_getTransactionByBlockNumberAndIndex": {numberField: "blockNumber", blockParam: true},
+	"eth_getTransactionReceipt":               {numberField: "blockNumber"},
+	"eth_getBlockReceipts":                    {numberField: "blockNumber", blockParam: true},
+}
+
+// ResponseCachePolicy lets the response cache of the RPC server keep the constant results, and the blocks,
+// transactions and receipts of the finalized blocks
+type ResponseCachePolicy struct {
+	db kv.RoDB
+}
+
+func NewResponseCachePolicy(db kv.RoDB) *ResponseCachePolicy {
+	return &ResponseCachePolicy{db: db}
+}
+
+var _ rpc.ResponseCachePolicy = (*ResponseCachePolicy)(nil)
+
+func (p *ResponseCachePolicy) Cacheable(method string) bool {
+	if _, ok := constantResultMethods[method]; ok {
+		return true
+	}
+	_, ok := finalizedResultMethods[method]
+	return ok
+}
+
+func (p *ResponseCachePolicy) Immutable(ctx context.Context, method string, params, result json.RawMessage) bool {
+	if _, ok := constantResultMethods[method]; ok {
+		return true
+	}
+	m, ok := finalizedResultMethods[method]
+	if !ok {
+		return false
+	}
+	if m.blockParam {
+		var args []json.RawMessage
+		if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+			return false
+		}
+		var block rpc.BlockNumberOrHash
+		if err := json.Unmarshal(args[0], &block); err != nil {
+			return false
+		}
+		if number, ok := block.Number(); ok && number < 0 {
+			return false
+		}
+	}
+	number, ok := resultBlockNumber(result, m.numberField)
+	if !ok {
+		return false
+	}
+	tx, err := p.db.BeginRo(ctx)
+	if err != nil {
+		return false
+	}
+	defer tx.Rollback()
+	finalized, err := rpchelper.GetFinalizedBlockNumber(tx)
+	return err == nil && number <= finalized
+}
+
+// resultBlockNumber returns the block number in field of result, an object or a non-empty array of objects of the
+// same block
+func resultBlockNumber(result json.RawMessage, field string) (uint64, bool) {
+	var list []json.RawMessage
+	if err := json.Unmarshal(result, &list); err == nil {
+		if len(list) == 0 {
+			return 0, false
+		}
+		result = list[0]
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(result, &fields); err != nil {
+		return 0, false
+	}
+	var number *hexutil.Uint64
+	if err := json.Unmarshal(fields[field], &number); err != nil || number == nil {
+		return 0, false
+	}
+	return uint64(*number), true
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCachePolicy(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	policy := NewResponseCachePolicy(db)
+	ctx := context.Background()
+
+	require.True(t, policy.Cacheable("eth_chainId"))
+	require.True(t, policy.Cacheable("eth_getBlockByHash"))
+	require.False(t, policy.Cacheable("eth_call"))
+	require.True(t, policy.Immutable(ctx, "eth_chainId", json.RawMessage(`[]`), json.RawMessage(`"0x539"`)))
+
+	block3 := json.RawMessage(`{"number":"0x3","hash":"0x01"}`)
+	receipts3 := json.RawMessage(`[{"blockNumber":"0x3"},{"blockNumber":"0x3"}]`)
+	// nothing is finalized yet
+	require.False(t, policy.Immutable(ctx, "eth_getBlockByHash", json.RawMessage(`["0x01",false]`), block3))
+
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		hash, err := rawdb.ReadCanonicalHash(tx, 5)
+		if err != nil {
+			return err
+		}
+		rawdb.WriteForkchoiceFinalized(tx, hash)
+		return nil
+	}))
+	for _, test := range []struct {
+		method    string
+		params    string
+		result    string
+		immutable bool
+	}{
+		{"eth_getBlockByHash", `["0x01",false]`, string(block3), true},
+		{"eth_getBlockByNumber", `["0x3",true]`, string(block3), true},
+		{"eth_getBlockByNumber", `["latest",true]`, string(block3), false},
+		{"eth_getBlockByNumber", `["finalized",true]`, string(block3), false},
+		{"eth_getBlockByHash", `["0x07",false]`, `{"number":"0x7"}`, false},
+		{"eth_getTransactionByHash", `["0x01"]`, `{"blockNumber":"0x5"}`, true},
+		{"eth_getTransactionByHash", `["0x01"]`, `{"blockNumber":null}`, false},
+		{"eth_getTransactionReceipt", `["0x01"]`, `{"blockNumber":"0x6"}`, false},
+		{"eth_getBlockReceipts", `["0x3"]`, string(receipts3), true},
+		{"eth_getBlockReceipts", `["0x4"]`, `[]`, false},
+		{"eth_getBlockReceipts", `[{"blockHash":"0x0000000000000000000000000000000000000000000000000000000000000001"}]`, string(receipts3), true},
+	} {
+		require.Equal(t, test.immutable, policy.Immutable(ctx, test.method, json.RawMessage(test.params), json.RawMessage(test.result)), "%s %s", test.method, test.params)
+	}
+}
@@ -14,8 +14,10 @@ import (
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/log/v3"
 )
 
@@ -84,8 +86,149 @@ func (api *APIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutil.By
 }
 
 // SendTransaction implements eth_sendTransaction. Creates new message call transaction or a contract creation if the data field contains code.
-func (api *APIImpl) SendTransaction(_ context.Context, txObject interface{}) (common.Hash, error) {
-	return common.Hash{0}, fmt.Errorf(NotImplemented, "eth_sendTransaction")
+// The transaction is signed by the external signer.
+func (api *APIImpl) SendTransaction(ctx context.Context, args TransactionArgs) (common.Hash, error) {
+	if api.Signer == nil {
+		return common.Hash{}, fmt.Errorf(NoExternalSigner, "eth_sendTransaction")
+	}
+	raw, _, err := api.signTransaction(ctx, &args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return api.SendRawTransaction(ctx, raw)
+}
+
+// SignTransaction implements eth_signTransaction. Returns the transaction signed by the external signer, without
+// submitting it.
+func (api *APIImpl) SignTransaction(ctx context.Context, args TransactionArgs) (*SignTransactionResult, error) {
+	if api.Signer == nil {
+		return nil, fmt.Errorf(NoExternalSigner, "eth_signTransaction")
+	}
+	raw, txn, err := api.signTransaction(ctx, &args)
+	if err != nil {
+		return nil, err
+	}
+	return &SignTransactionResult{Raw: raw, Tx: txn}, nil
+}
+
+// signTransaction fills in the defaults of args and has the transaction signed by the external signer. The signer
+// holds the keys, the sender and the chain of the signed transaction are still checked.
+func (api *APIImpl) signTransaction(ctx context.Context, args *TransactionArgs) (hexutil.Bytes, types.Transaction, error) {
+	if err := api.setTxDefaults(ctx, args); err != nil {
+		return nil, nil, err
+	}
+	raw, err := api.Signer.SignTransaction(ctx, args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("external signer: %w", err)
+	}
+	txn, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(raw), uint64(len(raw))))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid transaction from the external signer: %w", err)
+	}
+	if txn.Protected() && txn.GetChainID().ToBig().Cmp(args.ChainID.ToInt()) != 0 {
+		return nil, nil, fmt.Errorf("transaction signed for chain %d instead of %d", txn.GetChainID(), args.ChainID.ToInt())
+	}
+	from, err := txn.Sender(*types.LatestSignerForChainID(args.ChainID.ToInt()))
+	if err != nil {
+		return nil, nil, err
+	}
+	if from != *args.From {
+		return nil, nil, fmt.Errorf("transaction signed by %x instead of %x", from, *args.From)
+	}
+	return raw, txn, nil
+}
+
+// setTxDefaults fills in the chain id, fees, nonce and gas of args if they're missing
+func (api *APIImpl) setTxDefaults(ctx context.Context, args *TransactionArgs) error {
+	if args.From == nil {
+		return errors.New("missing from")
+	}
+	if args.Data != nil && args.Input != nil && !bytes.Equal(*args.Data, *args.Input) {
+		return errors.New(`both "data" and "input" are set and not equal, please use "input" to pass the transaction call data`)
+	}
+	if args.Input == nil {
+		args.Input = args.Data
+	}
+	args.Data = args.Input
+	if args.To == nil && (args.Input == nil || len(*args.Input) == 0) {
+		return errors.New("contract creation without any data provided")
+	}
+	if args.Value == nil {
+		args.Value = new(hexutil.Big)
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	head := rawdb.ReadCurrentHeader(tx)
+	tx.Rollback() // the other methods open their own transactions
+	if head == nil {
+		return errors.New("current header not found")
+	}
+
+	if args.ChainID == nil {
+		args.ChainID = (*hexutil.Big)(chainConfig.ChainID)
+	} else if args.ChainID.ToInt().Cmp(chainConfig.ChainID) != 0 {
+		return fmt.Errorf("chainId %d doesn't match the chain %d", args.ChainID.ToInt(), chainConfig.ChainID)
+	}
+
+	if args.GasPrice != nil && (args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil) {
+		return errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	}
+	if args.GasPrice == nil {
+		if head.BaseFee != nil {
+			if args.MaxPriorityFeePerGas == nil {
+				if args.MaxPriorityFeePerGas, err = api.MaxPriorityFeePerGas(ctx); err != nil {
+					return err
+				}
+			}
+			if args.MaxFeePerGas == nil {
+				maxFee := new(big.Int).Add(args.MaxPriorityFeePerGas.ToInt(), new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+				args.MaxFeePerGas = (*hexutil.Big)(maxFee)
+			}
+			if args.MaxFeePerGas.ToInt().Cmp(args.MaxPriorityFeePerGas.ToInt()) < 0 {
+				return fmt.Errorf("maxFeePerGas (%v) < maxPriorityFeePerGas (%v)", args.MaxFeePerGas, args.MaxPriorityFeePerGas)
+			}
+		} else {
+			if args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil {
+				return errors.New("maxFeePerGas or maxPriorityFeePerGas specified before London")
+			}
+			if args.GasPrice, err = api.GasPrice(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	if args.Nonce == nil {
+		if args.Nonce, err = api.GetTransactionCount(ctx, *args.From, rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)); err != nil {
+			return err
+		}
+	}
+	if args.Gas == nil {
+		gas, err := api.EstimateGas(ctx, &ethapi.CallArgs{
+			From:                 args.From,
+			To:                   args.To,
+			GasPrice:             args.GasPrice,
+			MaxFeePerGas:         args.MaxFeePerGas,
+			MaxPriorityFeePerGas: args.MaxPriorityFeePerGas,
+			Value:                args.Value,
+			Nonce:                args.Nonce,
+			Data:                 args.Input,
+			AccessList:           args.AccessList,
+			ChainID:              args.ChainID,
+		}, nil, nil)
+		if err != nil {
+			return err
+		}
+		args.Gas = &gas
+	}
+	return nil
 }
 
 // checkTxFee is an internal function used to check whether the fee of
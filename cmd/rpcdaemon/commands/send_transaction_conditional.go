@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+// maxKnownAccountsCost is the maximum number of storage roots and slots checked by
+// eth_sendRawTransactionConditional
+const maxKnownAccountsCost = 1000
+
+// KnownAccount is the expected storage of an account, either its storage root or the values of some of its slots
+type KnownAccount struct {
+	StorageRoot  *common.Hash
+	StorageSlots map[common.Hash]common.Hash
+}
+
+func (a *KnownAccount) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		a.StorageRoot = new(common.Hash)
+		return json.Unmarshal(data, a.StorageRoot)
+	}
+	return json.Unmarshal(data, &a.StorageSlots)
+}
+
+func (a KnownAccount) MarshalJSON() ([]byte, error) {
+	if a.StorageRoot != nil {
+		return json.Marshal(a.StorageRoot)
+	}
+	return json.Marshal(a.StorageSlots)
+}
+
+// TransactionConditional is the preconditions of eth_sendRawTransactionConditional
+type TransactionConditional struct {
+	KnownAccounts  map[common.Address]KnownAccount `json:"knownAccounts"`
+	BlockNumberMin *hexutil.Uint64                 `json:"blockNumberMin"`
+	BlockNumberMax *hexutil.Uint64                 `json:"blockNumberMax"`
+	TimestampMin   *hexutil.Uint64                 `json:"timestampMin"`
+	TimestampMax   *hexutil.Uint64                 `json:"timestampMax"`
+}
+
+// cost returns the number of storage roots and slots to check
+func (c *TransactionConditional) cost() int {
+	cost := 0
+	for _, account := range c.KnownAccounts {
+		if account.StorageRoot != nil {
+			cost++
+		}
+		cost += len(account.StorageSlots)
+	}
+	return cost
+}
+
+// newConditionalRejectedError returns the error of the preconditions which don't hold
+func newConditionalRejectedError(format string, args ...interface{}) error {
+	return &rpc.CustomError{Code: -32003, Message: fmt.Sprintf(format, args...)}
+}
+
+// SendRawTransactionConditional implements eth_sendRawTransactionConditional. Submits a previously-signed transaction
+// like eth_sendRawTransaction if its preconditions hold at the latest block: the block number and timestamp ranges,
+// and the storage roots or slot values of the known accounts. They are only checked at the submission: the txpool
+// doesn't keep them, so the transaction may be included after they stopped holding.
+func (api *APIImpl) SendRawTransactionConditional(ctx context.Context, encodedTx hexutil.Bytes, conditional TransactionConditional) (common.Hash, error) {
+	if cost := conditional.cost(); cost > maxKnownAccountsCost {
+		return common.Hash{}, &rpc.CustomError{Code: -32005, Message: fmt.Sprintf("conditional cost %d exceeds the maximum %d", cost, maxKnownAccountsCost)}
+	}
+	if err := api.checkConditional(ctx, &conditional); err != nil {
+		return common.Hash{}, err
+	}
+	return api.SendRawTransaction(ctx, encodedTx)
+}
+
+// checkConditional returns an rpc.CustomError if the preconditions don't hold at the latest block
+func (api *APIImpl) checkConditional(ctx context.Context, conditional *TransactionConditional) error {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	header, err := api.headerByRPCNumber(rpc.LatestBlockNumber, tx)
+	if err != nil {
+		return err
+	}
+	if header == nil {
+		return fmt.Errorf("latest header not found")
+	}
+	number := header.Number.Uint64()
+	if conditional.BlockNumberMin != nil && number < uint64(*conditional.BlockNumberMin) {
+		return newConditionalRejectedError("block number %d is below the minimum %d", number, *conditional.BlockNumberMin)
+	}
+	if conditional.BlockNumberMax != nil && number > uint64(*conditional.BlockNumberMax) {
+		return newConditionalRejectedError("block number %d is above the maximum %d", number, *conditional.BlockNumberMax)
+	}
+	if conditional.TimestampMin != nil && header.Time < uint64(*conditional.TimestampMin) {
+		return newConditionalRejectedError("timestamp %d is below the minimum %d", header.Time, *conditional.TimestampMin)
+	}
+	if conditional.TimestampMax != nil && header.Time > uint64(*conditional.TimestampMax) {
+		return newConditionalRejectedError("timestamp %d is above the maximum %d", header.Time, *conditional.TimestampMax)
+	}
+
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	reader, err := rpchelper.CreateStateReader(ctx, tx, latest, api.filters, api.stateCache)
+	if err != nil {
+		return err
+	}
+	var storageRoots []common.Address
+	for address, account := range conditional.KnownAccounts {
+		if account.StorageRoot != nil {
+			storageRoots = append(storageRoots, address)
+			continue
+		}
+		if len(account.StorageSlots) == 0 {
+			continue
+		}
+		acc, err := reader.ReadAccountData(address)
+		if err != nil {
+			return err
+		}
+		for slot, expected := range account.StorageSlots {
+			var value []byte
+			if acc != nil {
+				location := slot
+				if value, err = reader.ReadAccountStorage(address, acc.Incarnation, &location); err != nil {
+					return err
+				}
+			}
+			if actual := common.BytesToHash(value); actual != expected {
+				return newConditionalRejectedError("storage slot %x of %x is %x, expected %x", slot, address, actual, expected)
+			}
+		}
+	}
+	tx.Rollback()
+
+	// the storage roots are computed like the storage hashes of eth_getProof, each with its own transaction
+	for _, address := range storageRoots {
+		result, err := api.GetProof(ctx, address, nil, latest)
+		if err != nil {
+			return err
+		}
+		if expected := *conditional.KnownAccounts[address].StorageRoot; result.StorageHash != expected {
+			return newConditionalRejectedError("storage root of %x is %x, expected %x", address, result.StorageHash, expected)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionConditional(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewEthAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), snapshotsync.NewBlockReader(), nil, nil, false), db, nil, nil, nil, 5000000)
+	ctx := context.Background()
+
+	sender := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	// the token deployed by the sender in block 3
+	token := crypto.CreateAddress(sender, 2)
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	proof, err := api.GetProof(ctx, token, nil, latest)
+	require.NoError(t, err)
+	slot2, err := api.GetStorageAt(ctx, token, "0x2", latest)
+	require.NoError(t, err)
+	block, err := api.GetBlockByNumber(ctx, rpc.LatestBlockNumber, false)
+	require.NoError(t, err)
+	timestamp := block["timestamp"].(hexutil.Uint64)
+
+	var conditional TransactionConditional
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"knownAccounts": {
+			"`+token.Hex()+`": "`+proof.StorageHash.Hex()+`",
+			"`+sender.Hex()+`": {"0x0000000000000000000000000000000000000000000000000000000000000002": "0x0000000000000000000000000000000000000000000000000000000000000000"}
+		},
+		"blockNumberMin": "0xa",
+		"timestampMax": "`+timestamp.String()+`"
+	}`), &conditional))
+	require.Equal(t, proof.StorageHash, *conditional.KnownAccounts[token].StorageRoot)
+	require.Len(t, conditional.KnownAccounts[sender].StorageSlots, 1)
+	require.NoError(t, api.checkConditional(ctx, &conditional))
+
+	requireRejected := func(conditional TransactionConditional) {
+		t.Helper()
+		var conditionalErr *rpc.CustomError
+		require.ErrorAs(t, api.checkConditional(ctx, &conditional), &conditionalErr)
+		require.Equal(t, -32003, conditionalErr.ErrorCode())
+	}
+	eleven, nine := hexutil.Uint64(11), hexutil.Uint64(9)
+	requireRejected(TransactionConditional{BlockNumberMin: &eleven})
+	requireRejected(TransactionConditional{BlockNumberMax: &nine})
+	earlier := timestamp - 1
+	requireRejected(TransactionConditional{TimestampMax: &earlier})
+	later := timestamp + 1
+	requireRejected(TransactionConditional{TimestampMin: &later})
+	requireRejected(TransactionConditional{KnownAccounts: map[common.Address]KnownAccount{token: {StorageRoot: &common.Hash{1}}}})
+	requireRejected(TransactionConditional{KnownAccounts: map[common.Address]KnownAccount{
+		token: {StorageSlots: map[common.Hash]common.Hash{common.HexToHash("0x2"): {1}}},
+	}})
+	require.NoError(t, api.checkConditional(ctx, &TransactionConditional{KnownAccounts: map[common.Address]KnownAccount{
+		token: {StorageSlots: map[common.Hash]common.Hash{common.HexToHash("0x2"): common.HexToHash(slot2)}},
+	}}))
+
+	slots := make(map[common.Hash]common.Hash)
+	for i := 0; i <= maxKnownAccountsCost; i++ {
+		slots[common.BytesToHash([]byte{byte(i >> 8), byte(i)})] = common.Hash{}
+	}
+	_, err = api.SendRawTransactionConditional(ctx, nil, TransactionConditional{KnownAccounts: map[common.Address]KnownAccount{token: {StorageSlots: slots}}})
+	var conditionalErr *rpc.CustomError
+	require.ErrorAs(t, err, &conditionalErr)
+	require.Equal(t, -32005, conditionalErr.ErrorCode())
+}
@@ -1,10 +1,12 @@
 package commands
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"testing"
 
+	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
@@ -12,6 +14,10 @@ import (
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/stretchr/testify/require"
@@ -105,4 +111,116 @@ func TestReplayBlockTransactions(t *testing.T) {
 	addrDiff := results[0].StateDiff[common.HexToAddress("0x0000000000000001000000000000000000000000")]
 	v := addrDiff.Balance.(map[string]*hexutil.Big)["+"].ToInt().Uint64()
 	require.Equal(t, uint64(1_000_000_000_000_000), v)
+	require.Empty(t, results[0].Trace)
+
+	// both the traces and the state diffs in one pass
+	results, err = api.ReplayBlockTransactions(context.Background(), rpc.BlockNumberOrHash{BlockNumber: &n}, []string{"trace", "stateDiff"})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	for _, result := range results {
+		require.Len(t, result.Trace, 1)
+		action := result.Trace[0].Action.(*CallTraceAction)
+		require.Contains(t, result.StateDiff, action.To)
+		require.NotNil(t, result.TransactionHash)
+	}
+}
+
+func TestRawTransaction(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewTraceAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, &httpcfg.HttpCfg{})
+	ctx := context.Background()
+
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	receiver := common.HexToAddress("0xbeef")
+	var nonce uint64
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		account, err := state.NewPlainStateReader(tx).ReadAccountData(sender)
+		if err != nil {
+			return err
+		}
+		nonce = account.Nonce
+		return nil
+	}))
+
+	txn, err := types.SignTx(types.NewTransaction(nonce, receiver, uint256.NewInt(1000), params.TxGas, uint256.NewInt(params.GWei), nil), *types.LatestSignerForChainID(params.AllEthashProtocolChanges.ChainID), key)
+	require.NoError(t, err)
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, txn.MarshalBinary(buf))
+
+	result, err := api.RawTransaction(ctx, buf.Bytes(), []string{"trace", "stateDiff"}, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Trace, 1)
+	require.Equal(t, CALL, result.Trace[0].Type)
+	action := result.Trace[0].Action.(*CallTraceAction)
+	require.Equal(t, sender, action.From)
+	require.Equal(t, receiver, action.To)
+	require.Equal(t, uint64(1000), action.Value.ToInt().Uint64())
+	require.Equal(t, uint64(1000), result.StateDiff[receiver].Balance.(map[string]*hexutil.Big)["+"].ToInt().Uint64())
+	senderNonce := result.StateDiff[sender].Nonce.(map[string]*StateDiffNonce)["*"]
+	require.Equal(t, hexutil.Uint64(nonce), senderNonce.From)
+	require.Equal(t, hexutil.Uint64(nonce+1), senderNonce.To)
+
+	// the nonce is checked against the state of the block
+	n := rpc.BlockNumber(1)
+	_, err = api.RawTransaction(ctx, buf.Bytes(), []string{"trace"}, &rpc.BlockNumberOrHash{BlockNumber: &n})
+	require.Error(t, err)
+}
+
+func TestVmTrace(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewTraceAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, &httpcfg.HttpCfg{})
+	ctx := context.Background()
+
+	sender := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	token := crypto.CreateAddress(sender, 2)
+	var code []byte
+	var mintHash common.Hash
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		code = state.New(state.NewPlainStateReader(tx)).GetCode(token)
+		b, err := rawdb.ReadBlockByNumber(tx, 4)
+		if err != nil {
+			return err
+		}
+		mintHash = b.Transactions()[0].Hash()
+		return nil
+	}))
+	findOps := func(vmTrace *VmTrace, op string) []*VmTraceOp {
+		var ops []*VmTraceOp
+		for _, vmOp := range vmTrace.Ops {
+			if vmOp.Op == op {
+				ops = append(ops, vmOp)
+			}
+		}
+		return ops
+	}
+
+	// totalSupply()
+	input := hexutil.Bytes(common.FromHex("0x18160ddd"))
+	result, err := api.Call(ctx, TraceCallParam{From: &sender, To: &token, Data: input}, []string{TraceTypeVmTrace}, nil)
+	require.NoError(t, err)
+	require.Empty(t, result.Trace)
+	require.Equal(t, hexutil.Bytes(code), result.VmTrace.Code)
+	sloads := findOps(result.VmTrace, "SLOAD")
+	require.Len(t, sloads, 1)
+	require.Equal(t, []string{"0xa"}, sloads[0].Ex.Push)
+
+	// the memory written by EXTCODECOPY
+	initCode := hexutil.Bytes(append(append(common.FromHex("0x60206000600073"), token.Bytes()...), common.FromHex("0x3c00")...))
+	result, err = api.Call(ctx, TraceCallParam{From: &sender, Data: initCode}, []string{TraceTypeVmTrace}, nil)
+	require.NoError(t, err)
+	copies := findOps(result.VmTrace, "EXTCODECOPY")
+	require.Len(t, copies, 1)
+	require.Equal(t, &VmTraceMem{Data: hexutil.Encode(code[:32]), Off: 0}, copies[0].Ex.Mem)
+
+	// the storage written by mint
+	result, err = api.ReplayTransaction(ctx, mintHash, []string{TraceTypeVmTrace})
+	require.NoError(t, err)
+	var stores []VmTraceStore
+	for _, sstore := range findOps(result.VmTrace, "SSTORE") {
+		stores = append(stores, *sstore.Ex.Store)
+	}
+	require.Contains(t, stores, VmTraceStore{Key: "0x0", Val: "0xa"})
 }
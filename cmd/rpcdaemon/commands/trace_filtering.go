@@ -2,16 +2,23 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/RoaringBitmap/roaring/roaring64"
+	"github.com/holiman/uint256"
 	jsoniter "github.com/json-iterator/go"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv"
 
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/aura"
+	"github.com/ledgerwatch/erigon/consensus/aura/consensusconfig"
 	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/consensus/serenity"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/ethdb"
@@ -171,12 +178,82 @@ func (api *TraceAPIImpl) Block(ctx context.Context, blockNr rpc.BlockNumber) (Pa
 			out = append(out, *pt)
 		}
 	}
-	minerReward, uncleRewards := ethash.AccumulateRewards(chainConfig, block.Header(), block.Uncles())
+	rewards, err := rewardTraces(chainConfig, block)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, rewards...)
+
+	return out, err
+}
+
+// auraRewardParams are the parsed AuRa chain specs, by chain name
+var (
+	auraRewardParamsMu sync.Mutex
+	auraRewardParams   = map[string]aura.AuthorityRoundParams{}
+)
+
+// auraParamsByChain returns the parsed AuRa chain spec of chainName, parsed once
+func auraParamsByChain(chainName string) (aura.AuthorityRoundParams, error) {
+	auraRewardParamsMu.Lock()
+	defer auraRewardParamsMu.Unlock()
+	if p, ok := auraRewardParams[chainName]; ok {
+		return p, nil
+	}
+	var spec aura.JsonSpec
+	if err := json.Unmarshal(consensusconfig.GetConfigByChain(chainName), &spec); err != nil {
+		return aura.AuthorityRoundParams{}, err
+	}
+	p, err := aura.FromJson(spec)
+	if err != nil {
+		return aura.AuthorityRoundParams{}, err
+	}
+	auraRewardParams[chainName] = p
+	return p, nil
+}
+
+// rewardTraces returns the traces of the rewards of the author of block and of the authors of its uncles. They depend
+// on the consensus engine: ethash rewards the author and the uncles, AuRa rewards the author with the reward of the
+// chain spec, clique, bor and parlia reward nobody but with the fees, and there's no reward after the merge. The
+// blocks of an AuRa chain paying its rewards with a block reward contract, like Gnosis Chain, have no reward traces:
+// the rewards are computed by the contract, from the state.
+func rewardTraces(chainConfig *params.ChainConfig, block *types.Block) ([]ParityTrace, error) {
+	header := block.Header()
+	if block.NumberU64() == 0 || (chainConfig.TerminalTotalDifficulty != nil && serenity.IsPoSHeader(header)) {
+		return nil, nil
+	}
+	switch {
+	case chainConfig.Clique != nil, chainConfig.Bor != nil, chainConfig.Parlia != nil:
+		return nil, nil
+	case chainConfig.Aura != nil:
+		auraParams, err := auraParamsByChain(chainConfig.ChainName)
+		if err != nil {
+			return nil, err
+		}
+		reward, ok := auraParams.StaticBlockReward(block.NumberU64())
+		if !ok {
+			return nil, nil
+		}
+		return []ParityTrace{rewardTrace(block, block.Coinbase(), "block", reward)}, nil
+	}
+	minerReward, uncleRewards := ethash.AccumulateRewards(chainConfig, header, block.Uncles())
+	traces := make([]ParityTrace, 0, 1+len(uncleRewards))
+	traces = append(traces, rewardTrace(block, block.Coinbase(), "block", &minerReward)) // nolint: goconst
+	for i, uncle := range block.Uncles() {
+		if i < len(uncleRewards) {
+			traces = append(traces, rewardTrace(block, uncle.Coinbase, "uncle", &uncleRewards[i])) // nolint: goconst
+		}
+	}
+	return traces, nil
+}
+
+// rewardTrace returns the trace of the reward of author in block
+func rewardTrace(block *types.Block, author common.Address, rewardType string, value *uint256.Int) ParityTrace {
 	var tr ParityTrace
-	var rewardAction = &RewardTraceAction{}
-	rewardAction.Author = block.Coinbase()
-	rewardAction.RewardType = "block" // nolint: goconst
-	rewardAction.Value.ToInt().Set(minerReward.ToBig())
+	rewardAction := &RewardTraceAction{}
+	rewardAction.Author = author
+	rewardAction.RewardType = rewardType
+	rewardAction.Value.ToInt().Set(value.ToBig())
 	tr.Action = rewardAction
 	tr.BlockHash = &common.Hash{}
 	copy(tr.BlockHash[:], block.Hash().Bytes())
@@ -184,26 +261,7 @@ func (api *TraceAPIImpl) Block(ctx context.Context, blockNr rpc.BlockNumber) (Pa
 	*tr.BlockNumber = block.NumberU64()
 	tr.Type = "reward" // nolint: goconst
 	tr.TraceAddress = []int{}
-	out = append(out, tr)
-	for i, uncle := range block.Uncles() {
-		if i < len(uncleRewards) {
-			var tr ParityTrace
-			rewardAction = &RewardTraceAction{}
-			rewardAction.Author = uncle.Coinbase
-			rewardAction.RewardType = "uncle" // nolint: goconst
-			rewardAction.Value.ToInt().Set(uncleRewards[i].ToBig())
-			tr.Action = rewardAction
-			tr.BlockHash = &common.Hash{}
-			copy(tr.BlockHash[:], block.Hash().Bytes())
-			tr.BlockNumber = new(uint64)
-			*tr.BlockNumber = block.NumberU64()
-			tr.Type = "reward" // nolint: goconst
-			tr.TraceAddress = []int{}
-			out = append(out, tr)
-		}
-	}
-
-	return out, err
+	return tr
 }
 
 // Filter implements trace_filter
@@ -312,6 +370,17 @@ func (api *TraceAPIImpl) Filter(ctx context.Context, req TraceFilterRequest, str
 
 	it := allBlocks.Iterator()
 	for it.HasNext() {
+		if err := libcommon.Stopped(ctx.Done()); err != nil {
+			if first {
+				first = false
+			} else {
+				stream.WriteMore()
+			}
+			stream.WriteObjectStart()
+			rpc.HandleError(err, stream)
+			stream.WriteObjectEnd()
+			break
+		}
 		b := it.Next()
 		// Extract transactions from block
 		hash, hashErr := rawdb.ReadCanonicalHash(dbtx, b)
@@ -402,80 +471,41 @@ func (api *TraceAPIImpl) Filter(ctx context.Context, req TraceFilterRequest, str
 				}
 			}
 		}
-		minerReward, uncleRewards := ethash.AccumulateRewards(chainConfig, block.Header(), block.Uncles())
-		if _, ok := toAddresses[block.Coinbase()]; ok || includeAll {
-			nSeen++
-			var tr ParityTrace
-			var rewardAction = &RewardTraceAction{}
-			rewardAction.Author = block.Coinbase()
-			rewardAction.RewardType = "block" // nolint: goconst
-			rewardAction.Value.ToInt().Set(minerReward.ToBig())
-			tr.Action = rewardAction
-			tr.BlockHash = &common.Hash{}
-			copy(tr.BlockHash[:], block.Hash().Bytes())
-			tr.BlockNumber = new(uint64)
-			*tr.BlockNumber = block.NumberU64()
-			tr.Type = "reward" // nolint: goconst
-			tr.TraceAddress = []int{}
-			b, err := json.Marshal(tr)
-			if err != nil {
-				if first {
-					first = false
-				} else {
-					stream.WriteMore()
-				}
-				stream.WriteObjectStart()
-				rpc.HandleError(err, stream)
-				stream.WriteObjectEnd()
-				continue
-			}
-			if nSeen > after && nExported < count {
-				if first {
-					first = false
-				} else {
-					stream.WriteMore()
-				}
-				stream.Write(b)
-				nExported++
+		rewards, rErr := rewardTraces(chainConfig, block)
+		if rErr != nil {
+			if first {
+				first = false
+			} else {
+				stream.WriteMore()
 			}
+			stream.WriteObjectStart()
+			rpc.HandleError(rErr, stream)
+			stream.WriteObjectEnd()
+			continue
 		}
-		for i, uncle := range block.Uncles() {
-			if _, ok := toAddresses[uncle.Coinbase]; ok || includeAll {
-				if i < len(uncleRewards) {
-					nSeen++
-					var tr ParityTrace
-					rewardAction := &RewardTraceAction{}
-					rewardAction.Author = uncle.Coinbase
-					rewardAction.RewardType = "uncle" // nolint: goconst
-					rewardAction.Value.ToInt().Set(uncleRewards[i].ToBig())
-					tr.Action = rewardAction
-					tr.BlockHash = &common.Hash{}
-					copy(tr.BlockHash[:], block.Hash().Bytes())
-					tr.BlockNumber = new(uint64)
-					*tr.BlockNumber = block.NumberU64()
-					tr.Type = "reward" // nolint: goconst
-					tr.TraceAddress = []int{}
-					b, err := json.Marshal(tr)
-					if err != nil {
-						if first {
-							first = false
-						} else {
-							stream.WriteMore()
-						}
-						stream.WriteObjectStart()
-						rpc.HandleError(err, stream)
-						stream.WriteObjectEnd()
-						continue
+		for _, tr := range rewards {
+			if _, ok := toAddresses[tr.Action.(*RewardTraceAction).Author]; ok || includeAll {
+				nSeen++
+				b, err := json.Marshal(tr)
+				if err != nil {
+					if first {
+						first = false
+					} else {
+						stream.WriteMore()
 					}
-					if nSeen > after && nExported < count {
-						if first {
-							first = false
-						} else {
-							stream.WriteMore()
-						}
-						stream.Write(b)
-						nExported++
+					stream.WriteObjectStart()
+					rpc.HandleError(err, stream)
+					stream.WriteObjectEnd()
+					continue
+				}
+				if nSeen > after && nExported < count {
+					if first {
+						first = false
+					} else {
+						stream.WriteMore()
 					}
+					stream.Write(b)
+					nExported++
 				}
 			}
 		}
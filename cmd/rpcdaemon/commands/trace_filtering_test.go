@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceBlockRewards(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewTraceAPI(NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, nil, false), db, &httpcfg.HttpCfg{})
+
+	traces, err := api.Block(context.Background(), rpc.BlockNumber(1))
+	require.NoError(t, err)
+	require.NotEmpty(t, traces)
+	reward := traces[len(traces)-1]
+	require.Equal(t, "reward", reward.Type)
+	action, ok := reward.Action.(*RewardTraceAction)
+	require.True(t, ok)
+	require.Equal(t, "block", action.RewardType)
+	require.Equal(t, ethash.ConstantinopleBlockReward.ToBig(), action.Value.ToInt())
+}
+
+func TestRewardTraces(t *testing.T) {
+	author, uncleAuthor := common.HexToAddress("0xa"), common.HexToAddress("0xb")
+	block := func(number uint64, difficulty int64, uncles ...*types.Header) *types.Block {
+		header := &types.Header{Number: new(big.Int).SetUint64(number), Difficulty: big.NewInt(difficulty), Coinbase: author}
+		return types.NewBlock(header, nil, uncles, nil)
+	}
+	uncle := &types.Header{Number: big.NewInt(14_999_999), Difficulty: big.NewInt(1), Coinbase: uncleAuthor}
+
+	for _, tt := range []struct {
+		name   string
+		config *params.ChainConfig
+		block  *types.Block
+		want   map[string]*uint256.Int // reward type -> value
+	}{
+		{
+			name:   "ethash with an uncle",
+			config: params.MainnetChainConfig,
+			block:  block(15_000_000, 1, uncle),
+			want: map[string]*uint256.Int{
+				"block": new(uint256.Int).Add(ethash.ConstantinopleBlockReward, new(uint256.Int).Div(ethash.ConstantinopleBlockReward, uint256.NewInt(32))),
+				"uncle": new(uint256.Int).Div(new(uint256.Int).Mul(ethash.ConstantinopleBlockReward, uint256.NewInt(7)), uint256.NewInt(8)),
+			},
+		},
+		{
+			name:   "ethash before byzantium",
+			config: params.MainnetChainConfig,
+			block:  block(1, 1),
+			want:   map[string]*uint256.Int{"block": ethash.FrontierBlockReward},
+		},
+		{name: "after the merge", config: params.MainnetChainConfig, block: block(15_600_000, 0)},
+		{name: "clique", config: params.GoerliChainConfig, block: block(100, 2)},
+		{name: "bor", config: params.BorMainnetChainConfig, block: block(100, 1)},
+		{name: "parlia", config: params.BSCChainConfig, block: block(100, 2)},
+		{
+			name:   "aura with a static reward",
+			config: params.SokolChainConfig,
+			block:  block(100, 1),
+			want:   map[string]*uint256.Int{"block": uint256.NewInt(params.Ether)},
+		},
+		// the rewards of the block reward contracts aren't traced
+		{name: "aura with a block reward contract", config: params.SokolChainConfig, block: block(5_000_000, 1)},
+		{name: "gnosis", config: params.GnosisChainConfig, block: block(20_000_000, 1)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			traces, err := rewardTraces(tt.config, tt.block)
+			require.NoError(t, err)
+			require.Len(t, traces, len(tt.want))
+			for _, tr := range traces {
+				require.Equal(t, "reward", tr.Type)
+				require.Equal(t, tt.block.NumberU64(), *tr.BlockNumber)
+				action := tr.Action.(*RewardTraceAction)
+				want, ok := tt.want[action.RewardType]
+				require.True(t, ok, action.RewardType)
+				require.Equal(t, want.ToBig(), action.Value.ToInt())
+				if action.RewardType == "uncle" {
+					require.Equal(t, uncleAuthor, action.Author)
+				} else {
+					require.Equal(t, author, action.Author)
+				}
+			}
+		})
+	}
+}
@@ -107,7 +107,7 @@ func (api *PrivateDebugAPIImpl) traceBlock(ctx context.Context, blockNrOrHash rp
 			GasPrice: msg.GasPrice().ToBig(),
 		}
 
-		transactions.TraceTx(ctx, msg, blockCtx, txCtx, ibs, config, chainConfig, stream)
+		transactions.TraceTx(ctx, msg, blockCtx, txCtx, ibs, config, chainConfig, nil, stream)
 		_ = ibs.FinalizeTx(rules, reader)
 		if idx != len(block.Transactions())-1 {
 			stream.WriteMore()
@@ -186,7 +186,7 @@ func (api *PrivateDebugAPIImpl) TraceTransaction(ctx context.Context, hash commo
 		return err
 	}
 	// Trace the transaction and return
-	return transactions.TraceTx(ctx, msg, blockCtx, txCtx, ibs, config, chainConfig, stream)
+	return transactions.TraceTx(ctx, msg, blockCtx, txCtx, ibs, config, chainConfig, nil, stream)
 }
 
 func (api *PrivateDebugAPIImpl) TraceCall(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, config *tracers.TraceConfig, stream *jsoniter.Stream) error {
@@ -239,14 +239,27 @@ func (api *PrivateDebugAPIImpl) TraceCall(ctx context.Context, args ethapi.CallA
 			return fmt.Errorf("header.BaseFee uint256 overflow")
 		}
 	}
+	if config != nil && config.BlockOverrides != nil && config.BlockOverrides.BaseFee != nil {
+		baseFee = config.BlockOverrides.BaseFee
+	}
 	msg, err := args.ToMessage(api.GasCap, baseFee)
 	if err != nil {
 		return err
 	}
 
 	blockCtx, txCtx := transactions.GetEvmContext(msg, header, blockNrOrHash.RequireCanonical, dbtx, api._blockReader)
+	if config != nil && config.BlockOverrides != nil {
+		config.BlockOverrides.Override(&blockCtx)
+	}
+	var precompiles map[common.Address]vm.PrecompiledContract
+	if config != nil {
+		if precompiles, err = config.StateOverrides.Precompiles(chainConfig.Rules(blockCtx.BlockNumber), nil); err != nil {
+			stream.WriteNil()
+			return err
+		}
+	}
 	// Trace the transaction and return
-	return transactions.TraceTx(ctx, msg, blockCtx, txCtx, ibs, config, chainConfig, stream)
+	return transactions.TraceTx(ctx, msg, blockCtx, txCtx, ibs, config, chainConfig, precompiles, stream)
 }
 
 func (api *PrivateDebugAPIImpl) TraceCallMany(ctx context.Context, bundles []Bundle, simulateContext StateContext, config *tracers.TraceConfig, stream *jsoniter.Stream) error {
@@ -256,11 +269,9 @@ func (api *PrivateDebugAPIImpl) TraceCallMany(ctx context.Context, bundles []Bun
 		evm                *vm.EVM
 		blockCtx           vm.BlockContext
 		txCtx              vm.TxContext
-		overrideBlockHash  map[uint64]common.Hash
 		baseFee            uint256.Int
 	)
 
-	overrideBlockHash = make(map[uint64]common.Hash)
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		stream.WriteNil()
@@ -337,9 +348,6 @@ func (api *PrivateDebugAPIImpl) TraceCallMany(ctx context.Context, bundles []Bun
 	rules := chainConfig.Rules(blockNum)
 
 	getHash := func(i uint64) common.Hash {
-		if hash, ok := overrideBlockHash[i]; ok {
-			return hash
-		}
 		hash, err := rawdb.ReadCanonicalHash(tx, i)
 		if err != nil {
 			log.Debug("Can't get block hash by number", "number", i, "only-canonical", true)
@@ -393,12 +401,27 @@ func (api *PrivateDebugAPIImpl) TraceCallMany(ctx context.Context, bundles []Bun
 			return err
 		}
 	}
+	precompiles, err := config.StateOverrides.Precompiles(rules, nil)
+	if err != nil {
+		stream.WriteNil()
+		return err
+	}
 
 	stream.WriteArrayStart()
 	for bundle_index, bundle := range bundles {
 		stream.WriteArrayStart()
 		// first change blockContext
-		blockHeaderOverride(&blockCtx, bundle.BlockOverride, overrideBlockHash)
+		bundle.BlockOverride.Override(&blockCtx)
+		if bundle.StateOverride != nil {
+			if err = bundle.StateOverride.Override(evm.IntraBlockState().(*state.IntraBlockState)); err != nil {
+				stream.WriteNil()
+				return err
+			}
+			if precompiles, err = bundle.StateOverride.Precompiles(rules, precompiles); err != nil {
+				stream.WriteNil()
+				return err
+			}
+		}
 		for txn_index, txn := range bundle.Transactions {
 			if txn.Gas == nil || *(txn.Gas) == 0 {
 				txn.Gas = (*hexutil.Uint64)(&api.GasCap)
@@ -411,7 +434,7 @@ func (api *PrivateDebugAPIImpl) TraceCallMany(ctx context.Context, bundles []Bun
 			txCtx = core.NewEVMTxContext(msg)
 			ibs := evm.IntraBlockState().(*state.IntraBlockState)
 			ibs.Prepare(common.Hash{}, parent.Hash(), txn_index)
-			err = transactions.TraceTx(ctx, msg, blockCtx, txCtx, evm.IntraBlockState(), config, chainConfig, stream)
+			err = transactions.TraceTx(ctx, msg, blockCtx, txCtx, evm.IntraBlockState(), config, chainConfig, precompiles, stream)
 
 			if err != nil {
 				stream.WriteNil()
@@ -18,6 +18,7 @@ import (
 // NetAPI the interface for the net_ RPC commands
 type TxPoolAPI interface {
 	Content(ctx context.Context) (map[string]map[string]map[string]*RPCTransaction, error)
+	ContentFrom(ctx context.Context, addr common.Address) (map[string]map[string]*RPCTransaction, error)
 }
 
 // TxPoolAPIImpl data structure to store things needed for net_ commands
@@ -118,6 +119,60 @@ func (api *TxPoolAPIImpl) Content(ctx context.Context) (map[string]map[string]ma
 	return content, nil
 }
 
+// ContentFrom implements txpool_contentFrom. Returns the pending, baseFee and queued transactions of a single sender,
+// by nonce.
+func (api *TxPoolAPIImpl) ContentFrom(ctx context.Context, addr common.Address) (map[string]map[string]*RPCTransaction, error) {
+	reply, err := api.pool.All(ctx, &proto_txpool.AllRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	cc, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	curHeader := rawdb.ReadCurrentHeader(tx)
+	if curHeader == nil {
+		return nil, nil
+	}
+
+	content := map[string]map[string]*RPCTransaction{
+		"pending": make(map[string]*RPCTransaction),
+		"baseFee": make(map[string]*RPCTransaction),
+		"queued":  make(map[string]*RPCTransaction),
+	}
+	for i := range reply.Txs {
+		// only the transactions of the sender are decoded
+		if gointerfaces.ConvertH160toAddress(reply.Txs[i].Sender) != addr {
+			continue
+		}
+		stream := rlp.NewStream(bytes.NewReader(reply.Txs[i].RlpTx), 0)
+		txn, err := types.DecodeTransaction(stream)
+		if err != nil {
+			return nil, err
+		}
+		var subPool string
+		switch reply.Txs[i].TxnType {
+		case proto_txpool.AllReply_PENDING:
+			subPool = "pending"
+		case proto_txpool.AllReply_BASE_FEE:
+			subPool = "baseFee"
+		case proto_txpool.AllReply_QUEUED:
+			subPool = "queued"
+		default:
+			continue
+		}
+		content[subPool][fmt.Sprintf("%d", txn.GetNonce())] = newRPCPendingTransaction(txn, curHeader, cc)
+	}
+	return content, nil
+}
+
 // Status returns the number of pending and queued transaction in the pool.
 func (api *TxPoolAPIImpl) Status(ctx context.Context) (map[string]hexutil.Uint, error) {
 	reply, err := api.pool.Status(ctx, &proto_txpool.StatusRequest{})
@@ -56,6 +56,15 @@ func TestTxPoolContent(t *testing.T) {
 	require.Equal(1, len(content["pending"][sender]))
 	require.Equal(expectValue, content["pending"][sender]["0"].Value.ToInt().Uint64())
 
+	contentFrom, err := api.ContentFrom(ctx, m.Address)
+	require.NoError(err)
+	require.Len(contentFrom["pending"], 1)
+	require.Equal(expectValue, contentFrom["pending"]["0"].Value.ToInt().Uint64())
+	require.Empty(contentFrom["queued"])
+	contentFrom, err = api.ContentFrom(ctx, common.Address{1})
+	require.NoError(err)
+	require.Empty(contentFrom["pending"])
+
 	status, err := api.Status(ctx)
 	require.NoError(err)
 	require.Len(status, 3)
@@ -0,0 +1,5 @@
+// Package grpcapi is the gRPC service of the rpcdaemon serving the core read methods of the eth API, enabled with
+// --grpc
+package grpcapi
+
+//go:generate protoc --proto_path=.. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative grpcapi/eth.proto
@@ -0,0 +1,111 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const upstreamTimeout = 5 * time.Second
+
+var (
+	errUpstreamUnhealthy = errors.New("upstream unhealthy")
+)
+
+var upstreamClient = &http.Client{Timeout: upstreamTimeout}
+
+// aggregate forwards the healthcheck request to the upstream health endpoints, in parallel, and reports each of
+// them as a check. The report is healthy if at least Config.UpstreamQuorum upstreams are, all of them if it's 0.
+// suffix selects the probe, e.g. "/readiness", the upstreams run their own configuration of it.
+func (c *Checker) aggregate(r *http.Request, suffix string) *report {
+	headers := r.Header.Values(healthHeader)
+	var body []byte
+	if r.Body != nil {
+		body, _ = io.ReadAll(r.Body)
+		_ = r.Body.Close()
+	}
+	method := r.Method
+	if method != http.MethodHead && len(body) > 0 {
+		method = http.MethodPost
+	}
+
+	results := make([]checkResult, len(c.cfg.Upstreams))
+	var wg sync.WaitGroup
+	for i, upstream := range c.cfg.Upstreams {
+		i, url := i, strings.TrimSuffix(upstream, "/")+suffix
+		key := fmt.Sprintf("upstream %s %s %s %s", method, url, strings.Join(headers, ","), body)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result, ok := c.cache.get(key); ok {
+				results[i] = result
+				return
+			}
+			results[i] = runCheck("upstream:"+url, func() error {
+				return checkUpstream(r.Context(), method, url, headers, body)
+			})
+			c.cache.put(key, results[i])
+		}()
+	}
+	wg.Wait()
+
+	rep := newReport()
+	healthy := 0
+	for _, result := range results {
+		rep.add(result)
+		if result.Status == statusHealthy {
+			healthy++
+		}
+	}
+	quorum := c.cfg.UpstreamQuorum
+	if quorum <= 0 || quorum > len(results) {
+		quorum = len(results)
+	}
+	if healthy >= quorum {
+		rep.Status = statusHealthy
+	} else {
+		rep.Status = statusUnhealthy
+	}
+	return rep
+}
+
+// checkUpstream sends a healthcheck request to another node, it fails unless the node answers 200
+func checkUpstream(ctx context.Context, method, url string, headers []string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for _, header := range headers {
+		req.Header.Add(healthHeader, header)
+	}
+	resp, err := upstreamClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	// name the failed checks if the upstream sent a report
+	var rep report
+	if err := json.NewDecoder(resp.Body).Decode(&rep); err == nil {
+		var failed []string
+		for _, check := range rep.Checks {
+			if check.Status != statusHealthy {
+				failed = append(failed, check.Name+": "+check.Error)
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("%w: %s", errUpstreamUnhealthy, strings.Join(failed, "; "))
+		}
+	}
+	return fmt.Errorf("%w: status code %d", errUpstreamUnhealthy, resp.StatusCode)
+}
@@ -0,0 +1,104 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// upstreamNode serves the health endpoint of a node with the given peer count
+func upstreamNode(peers uint, cfg Config) *httptest.Server {
+	checker := NewChecker([]rpc.API{{Service: &netApiStub{response: hexutil.Uint(peers)}}}, cfg)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !checker.ProcessHealthcheckIfNeeded(w, r) {
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestProcessHealthcheckIfNeeded_Aggregate(t *testing.T) {
+	probes := Config{ReadinessChecks: []string{"min_peer_count2"}}
+	node1, node2, node3 := upstreamNode(1, probes), upstreamNode(2, probes), upstreamNode(3, probes)
+	defer node1.Close()
+	defer node2.Close()
+	defer node3.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	upstreams := []string{node1.URL + "/health", node2.URL + "/health", node3.URL + "/health/"}
+
+	cases := []struct {
+		upstreams          []string
+		quorum             int
+		method             string
+		path               string
+		header             string
+		body               string
+		expectedStatusCode int
+		expectedBody       map[string]string
+	}{
+		// 0 - all healthy
+		{upstreams: upstreams, method: http.MethodGet, path: "/health", header: "min_peer_count1", expectedStatusCode: http.StatusOK, expectedBody: map[string]string{
+			"upstream:" + node1.URL + "/health": "HEALTHY",
+			"upstream:" + node3.URL + "/health": "HEALTHY",
+		}},
+		// 1 - one unhealthy, all are needed
+		{upstreams: upstreams, method: http.MethodGet, path: "/health", header: "min_peer_count2", expectedStatusCode: http.StatusInternalServerError, expectedBody: map[string]string{
+			"upstream:" + node1.URL + "/health": "ERROR: upstream unhealthy: min_peer_count: not enough peers: 1 (minimum 2)",
+			"upstream:" + node2.URL + "/health": "HEALTHY",
+		}},
+		// 2 - one unhealthy, within the quorum
+		{upstreams: upstreams, quorum: 2, method: http.MethodGet, path: "/health", header: "min_peer_count2", expectedStatusCode: http.StatusOK},
+		// 3 - two unhealthy, below the quorum
+		{upstreams: upstreams, quorum: 2, method: http.MethodGet, path: "/health", header: "min_peer_count3", expectedStatusCode: http.StatusInternalServerError},
+		// 4 - the body is forwarded
+		{upstreams: upstreams, quorum: 2, method: http.MethodGet, path: "/health", body: `{"min_peer_count": 3}`, expectedStatusCode: http.StatusInternalServerError, expectedBody: map[string]string{
+			"upstream:" + node3.URL + "/health": "HEALTHY",
+		}},
+		// 5 - probes are forwarded to the probes of the upstreams
+		{upstreams: upstreams, quorum: 2, method: http.MethodGet, path: "/health/readiness", expectedStatusCode: http.StatusOK, expectedBody: map[string]string{
+			"upstream:" + node1.URL + "/health/readiness": "ERROR: upstream unhealthy: min_peer_count: not enough peers: 1 (minimum 2)",
+		}},
+		// 6 - unreachable upstream
+		{upstreams: []string{down.URL + "/health", node1.URL + "/health"}, quorum: 1, method: http.MethodGet, path: "/health", header: "min_peer_count1", expectedStatusCode: http.StatusOK},
+		{upstreams: []string{down.URL + "/health"}, method: http.MethodGet, path: "/health", header: "min_peer_count1", expectedStatusCode: http.StatusInternalServerError},
+		// 8 - upstream without a report
+		{upstreams: []string{node1.URL + "/other"}, method: http.MethodGet, path: "/health", expectedStatusCode: http.StatusInternalServerError, expectedBody: map[string]string{
+			"upstream:" + node1.URL + "/other": "ERROR: upstream unhealthy: status code 404",
+		}},
+		// 9 - HEAD
+		{upstreams: upstreams, quorum: 2, method: http.MethodHead, path: "/health", header: "min_peer_count3", expectedStatusCode: http.StatusInternalServerError},
+	}
+
+	for idx, c := range cases {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(c.method, "http://localhost:9090"+c.path, strings.NewReader(c.body))
+		if err != nil {
+			t.Errorf("%v: creating request: %v", idx, err)
+		}
+		if c.header != "" {
+			r.Header.Add("X-ERIGON-HEALTHCHECK", c.header)
+		}
+		// no local api, only the upstreams are checked
+		ProcessHealthcheckIfNeeded(w, r, nil, Config{Upstreams: c.upstreams, UpstreamQuorum: c.quorum})
+
+		if w.Code != c.expectedStatusCode {
+			t.Errorf("%v: expected status code: %v, but got: %v (%s)", idx, c.expectedStatusCode, w.Code, w.Body.String())
+		}
+		if c.method == http.MethodHead {
+			continue
+		}
+		body, err := decodeStatuses(w.Body)
+		if err != nil {
+			t.Errorf("%v: unmarshalling the response body: %s", idx, err)
+		}
+		for k, v := range c.expectedBody {
+			if val := body[k]; val != v {
+				t.Errorf("%v: expected the response body key: %s to be: %s, but it was: %s", idx, k, v, val)
+			}
+		}
+	}
+}
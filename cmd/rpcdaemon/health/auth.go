@@ -0,0 +1,65 @@
+package health
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// LoadJWTSecret reads a hex encoded 32 bytes secret, in the format of the authrpc.jwtsecret file
+func LoadJWTSecret(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	secret := common.FromHex(strings.TrimSpace(string(data)))
+	if len(secret) != 32 {
+		return nil, fmt.Errorf("invalid JWT secret in %s: %d bytes, expected 32", path, len(secret))
+	}
+	return secret, nil
+}
+
+// authorized tells whether the request carries the configured credentials in the Authorization header: either
+// "Bearer <shared secret>" or "Bearer <HS256 JWT>". If not, the request is answered with 403.
+func authorized(w http.ResponseWriter, r *http.Request, cfg Config) bool {
+	if cfg.AuthSecret == "" && cfg.AuthJWTSecret == nil {
+		return true
+	}
+	if cfg.AuthSecret != "" {
+		auth := r.Header.Get("Authorization")
+		if strings.HasPrefix(auth, "Bearer ") && subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(cfg.AuthSecret)) == 1 {
+			return true
+		}
+		if cfg.AuthJWTSecret == nil {
+			http.Error(w, "invalid token", http.StatusForbidden)
+			return false
+		}
+	}
+	return rpc.CheckJwtSecret(w, r, cfg.AuthJWTSecret)
+}
+
+// Authorization returns the Authorization header value of a request authorized by authorized: the shared secret if
+// there's one, otherwise a HS256 JWT signed with jwtSecret. It's empty if there are no credentials.
+func Authorization(secret string, jwtSecret []byte) (string, error) {
+	if secret != "" {
+		return "Bearer " + secret, nil
+	}
+	if jwtSecret == nil {
+		return "", nil
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(time.Now())}).SignedString(jwtSecret)
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + token, nil
+}
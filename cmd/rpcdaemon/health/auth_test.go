@@ -0,0 +1,112 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+func TestProcessHealthcheckIfNeeded_Auth(t *testing.T) {
+	jwtSecret := make([]byte, 32)
+	jwtSecret[0] = 1
+	signed := func(secret []byte, iat time.Time) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(iat)}).SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	otherSecret := make([]byte, 32)
+
+	cases := []struct {
+		name               string
+		cfg                Config
+		authorization      string
+		expectedStatusCode int
+	}{
+		{name: "no auth", cfg: Config{}, expectedStatusCode: http.StatusOK},
+		{name: "secret", cfg: Config{AuthSecret: "s3cret"}, authorization: "Bearer s3cret", expectedStatusCode: http.StatusOK},
+		{name: "wrong secret", cfg: Config{AuthSecret: "s3cret"}, authorization: "Bearer s3cre", expectedStatusCode: http.StatusForbidden},
+		{name: "secret without bearer", cfg: Config{AuthSecret: "s3cret"}, authorization: "s3cret", expectedStatusCode: http.StatusForbidden},
+		{name: "missing secret", cfg: Config{AuthSecret: "s3cret"}, expectedStatusCode: http.StatusForbidden},
+		{name: "jwt", cfg: Config{AuthJWTSecret: jwtSecret}, authorization: "Bearer " + signed(jwtSecret, time.Now()), expectedStatusCode: http.StatusOK},
+		{name: "jwt with other secret", cfg: Config{AuthJWTSecret: jwtSecret}, authorization: "Bearer " + signed(otherSecret, time.Now()), expectedStatusCode: http.StatusForbidden},
+		{name: "stale jwt", cfg: Config{AuthJWTSecret: jwtSecret}, authorization: "Bearer " + signed(jwtSecret, time.Now().Add(-time.Hour)), expectedStatusCode: http.StatusForbidden},
+		{name: "missing jwt", cfg: Config{AuthJWTSecret: jwtSecret}, expectedStatusCode: http.StatusForbidden},
+		{name: "secret or jwt, secret", cfg: Config{AuthSecret: "s3cret", AuthJWTSecret: jwtSecret}, authorization: "Bearer s3cret", expectedStatusCode: http.StatusOK},
+		{name: "secret or jwt, jwt", cfg: Config{AuthSecret: "s3cret", AuthJWTSecret: jwtSecret}, authorization: "Bearer " + signed(jwtSecret, time.Now()), expectedStatusCode: http.StatusOK},
+	}
+
+	apis := []rpc.API{{Service: &netApiStub{response: hexutil.Uint(1)}}}
+	for _, c := range cases {
+		for _, path := range []string{"/health", "/health/readiness", "/health/liveness"} {
+			c.cfg.ReadinessChecks = []string{"min_peer_count1"}
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "http://localhost:9090"+path, nil)
+			r.Header.Add("X-ERIGON-HEALTHCHECK", "min_peer_count1")
+			if c.authorization != "" {
+				r.Header.Set("Authorization", c.authorization)
+			}
+			if !ProcessHealthcheckIfNeeded(w, r, apis, c.cfg) {
+				t.Errorf("%s %s: request not handled", c.name, path)
+			}
+			if w.Code != c.expectedStatusCode {
+				t.Errorf("%s %s: expected status code: %v, but got: %v (%s)", c.name, path, c.expectedStatusCode, w.Code, w.Body.String())
+			}
+		}
+	}
+}
+
+func TestLoadJWTSecret(t *testing.T) {
+	secret, err := LoadJWTSecret("")
+	if secret != nil || err != nil {
+		t.Errorf("expected no secret, got: %x, %v", secret, err)
+	}
+
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "jwt.hex")
+	if err = os.WriteFile(valid, []byte("0x0100000000000000000000000000000000000000000000000000000000000002\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if secret, err = LoadJWTSecret(valid); err != nil || len(secret) != 32 || secret[0] != 1 || secret[31] != 2 {
+		t.Errorf("unexpected secret: %x, %v", secret, err)
+	}
+
+	short := filepath.Join(dir, "short.hex")
+	if err = os.WriteFile(short, []byte("0x0102"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadJWTSecret(short); err == nil {
+		t.Errorf("expected an error for a short secret")
+	}
+	if _, err = LoadJWTSecret(filepath.Join(dir, "missing.hex")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	jwtSecret := make([]byte, 32)
+	jwtSecret[0] = 1
+	for _, cfg := range []Config{{}, {AuthSecret: "s3cret"}, {AuthJWTSecret: jwtSecret}, {AuthSecret: "s3cret", AuthJWTSecret: jwtSecret}} {
+		auth, err := Authorization(cfg.AuthSecret, cfg.AuthJWTSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, "http://localhost:9090/health", nil)
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		if !authorized(w, r, cfg) {
+			t.Errorf("request with %q not authorized by %+v", auth, cfg)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package health
+
+import (
+	"sync"
+	"time"
+)
+
+// resultCache keeps check results for a while, so that frequent probes (e.g. several load balancers polling every
+// second) don't each translate into backend rpc calls
+type resultCache struct {
+	ttl     time.Duration
+	lock    sync.Mutex
+	results map[string]cachedResult
+}
+
+type cachedResult struct {
+	result checkResult
+	at     time.Time
+}
+
+func newResultCache(ttl time.Duration) *resultCache {
+	return &resultCache{ttl: ttl, results: map[string]cachedResult{}}
+}
+
+// get returns the result cached under key if it's younger than the ttl, marked with its age
+func (c *resultCache) get(key string) (checkResult, bool) {
+	if c.ttl <= 0 {
+		return checkResult{}, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	cached, ok := c.results[key]
+	if !ok {
+		return checkResult{}, false
+	}
+	age := time.Since(cached.at)
+	if age >= c.ttl {
+		delete(c.results, key)
+		return checkResult{}, false
+	}
+	result := cached.result
+	result.Cached = true
+	result.CacheAgeMs = float64(age.Microseconds()) / 1000
+	return result, true
+}
+
+func (c *resultCache) put(key string, result checkResult) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.results[key] = cachedResult{result: result, at: time.Now()}
+}
@@ -0,0 +1,74 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+var (
+	errCallNotConfigured = errors.New("no call configured")
+	errUnexpectedResult  = errors.New("unexpected call result")
+)
+
+// CallCheck is an eth_call against the latest state whose result is known in advance. It verifies state reads end
+// to end, not only that the node is up.
+type CallCheck struct {
+	To     *common.Address `json:"to"`
+	Data   hexutil.Bytes   `json:"data"`
+	Result hexutil.Bytes   `json:"result"`
+}
+
+// ParseCallCheck builds a CallCheck from hex strings, an empty address means no check is configured
+func ParseCallCheck(to, data, result string) (CallCheck, error) {
+	var c CallCheck
+	if to == "" {
+		return c, nil
+	}
+	if !common.IsHexAddress(to) {
+		return c, fmt.Errorf("invalid call check address: %s", to)
+	}
+	addr := common.HexToAddress(to)
+	c.To = &addr
+	var err error
+	if data != "" {
+		if c.Data, err = hexutil.Decode(data); err != nil {
+			return c, fmt.Errorf("invalid call check data: %w", err)
+		}
+	}
+	if c.Result, err = hexutil.Decode(result); err != nil {
+		return c, fmt.Errorf("invalid call check result: %w", err)
+	}
+	return c, nil
+}
+
+func (c CallCheck) String() string {
+	if c.To == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%x:%x", c.To.Hex(), []byte(c.Data), []byte(c.Result))
+}
+
+func checkCall(ctx context.Context, c CallCheck, api EthAPI) error {
+	if c.To == nil {
+		return errCallNotConfigured
+	}
+	if api == nil {
+		return fmt.Errorf("no connection to the Erigon server or `eth` namespace isn't enabled")
+	}
+	data := c.Data
+	res, err := api.Call(ctx, ethapi.CallArgs{To: c.To, Data: &data}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil, nil)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(res, c.Result) {
+		return fmt.Errorf("%w: got %s, expected %s", errUnexpectedResult, res, c.Result)
+	}
+	return nil
+}
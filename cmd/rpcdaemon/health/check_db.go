@@ -0,0 +1,41 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+var (
+	errNoLatestBlock = errors.New("no latest block")
+	errDBTooSlow     = errors.New("database too slow")
+)
+
+// checkDBLatency reads the latest block header, which goes through the database (or remote KV) of the node, and
+// fails if it errors or takes longer than maxLatency. A stalled backend is caught here before user queries time out.
+func checkDBLatency(ctx context.Context, maxLatency time.Duration, ethAPI EthAPI) error {
+	if ethAPI == nil {
+		return fmt.Errorf("no connection to the Erigon server or `eth` namespace isn't enabled")
+	}
+	if maxLatency > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, maxLatency)
+		defer cancel()
+	}
+	start := time.Now()
+	block, err := ethAPI.GetBlockByNumber(ctx, rpc.LatestBlockNumber, false)
+	took := time.Since(start)
+	if maxLatency > 0 && (took > maxLatency || errors.Is(err, context.DeadlineExceeded)) {
+		return fmt.Errorf("%w: latest block read took %v (maximum %v)", errDBTooSlow, took.Round(time.Millisecond), maxLatency)
+	}
+	if err != nil {
+		return err
+	}
+	if len(block) == 0 {
+		return errNoLatestBlock
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+var (
+	errHeadStalled = errors.New("head not advancing")
+)
+
+// headTracker remembers the latest block number seen by the checks and when it last changed
+type headTracker struct {
+	lock      sync.Mutex
+	number    uint64
+	changedAt time.Time
+}
+
+// observe records the current head and returns how long it has been unchanged
+func (t *headTracker) observe(number uint64, now time.Time) time.Duration {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.changedAt.IsZero() || number != t.number {
+		t.number, t.changedAt = number, now
+	}
+	return now.Sub(t.changedAt)
+}
+
+// checkHeadProgress fails if the latest block hasn't changed for longer than maxStall. It catches a node which is
+// stuck but still reports being synced. The head is only sampled when the check runs, so it passes until it has
+// been run for maxStall.
+func checkHeadProgress(ctx context.Context, maxStall time.Duration, tracker *headTracker, ethAPI EthAPI) error {
+	if ethAPI == nil {
+		return fmt.Errorf("no connection to the Erigon server or `eth` namespace isn't enabled")
+	}
+	block, err := ethAPI.GetBlockByNumber(ctx, rpc.LatestBlockNumber, false)
+	if err != nil {
+		return err
+	}
+	var number uint64
+	switch n := block["number"].(type) {
+	case *hexutil.Big:
+		number = (*big.Int)(n).Uint64()
+	case hexutil.Uint64:
+		number = uint64(n)
+	case uint64:
+		number = n
+	default:
+		return errNoLatestBlock
+	}
+	if stalled := tracker.observe(number, time.Now()); stalled > maxStall {
+		return fmt.Errorf("%w: block %d for %v (maximum %v)", errHeadStalled, number, stalled.Round(time.Second), maxStall)
+	}
+	return nil
+}
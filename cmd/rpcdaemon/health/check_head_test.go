@@ -0,0 +1,63 @@
+package health
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+func TestHeadTracker(t *testing.T) {
+	var tracker headTracker
+	start := time.Now()
+	if stalled := tracker.observe(10, start); stalled != 0 {
+		t.Errorf("expected no stall on the first observation, got: %v", stalled)
+	}
+	if stalled := tracker.observe(10, start.Add(time.Minute)); stalled != time.Minute {
+		t.Errorf("expected a minute stall, got: %v", stalled)
+	}
+	if stalled := tracker.observe(11, start.Add(2*time.Minute)); stalled != 0 {
+		t.Errorf("expected the stall to reset when the head advances, got: %v", stalled)
+	}
+	if stalled := tracker.observe(9, start.Add(3*time.Minute)); stalled != 0 {
+		t.Errorf("expected the stall to reset on a reorg to a lower head, got: %v", stalled)
+	}
+}
+
+func TestProcessHealthcheckIfNeeded_HeadStall(t *testing.T) {
+	eth := &ethApiStub{blockResult: map[string]interface{}{"number": (*hexutil.Big)(big.NewInt(100))}}
+	checker := NewChecker([]rpc.API{{Service: eth}}, Config{MaxHeadStall: 30 * time.Millisecond})
+	get := func() map[string]string {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, "http://localhost:9090/health", nil)
+		r.Header.Add("X-ERIGON-HEALTHCHECK", "max_head_stall")
+		checker.ProcessHealthcheckIfNeeded(w, r)
+		body, err := decodeStatuses(w.Body)
+		if err != nil {
+			t.Fatalf("unmarshalling the response body: %s", err)
+		}
+		return body
+	}
+
+	if status := get()[maxHeadStall]; status != "HEALTHY" {
+		t.Errorf("expected the first check to pass, got: %s", status)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if status := get()[maxHeadStall]; !strings.HasPrefix(status, "ERROR: head not advancing: block 100 for") {
+		t.Errorf("expected a stalled head, got: %s", status)
+	}
+	eth.blockResult = map[string]interface{}{"number": (*hexutil.Big)(big.NewInt(101))}
+	if status := get()[maxHeadStall]; status != "HEALTHY" {
+		t.Errorf("expected an advancing head, got: %s", status)
+	}
+
+	eth.blockResult = map[string]interface{}{}
+	if status := get()[maxHeadStall]; status != "ERROR: no latest block" {
+		t.Errorf("expected a missing head, got: %s", status)
+	}
+}
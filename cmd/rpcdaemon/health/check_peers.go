@@ -4,18 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var (
 	errNotEnoughPeers = errors.New("not enough peers")
 )
 
-func checkMinPeers(minPeerCount uint, api NetAPI) error {
+func checkMinPeers(ctx context.Context, minPeerCount uint, api NetAPI) error {
 	if api == nil {
 		return fmt.Errorf("no connection to the Erigon server or `net` namespace isn't enabled")
 	}
 
-	peerCount, err := api.PeerCount(context.TODO())
+	peerCount, err := api.PeerCount(ctx)
 	if err != nil {
 		return err
 	}
@@ -26,3 +27,32 @@ func checkMinPeers(minPeerCount uint, api NetAPI) error {
 
 	return nil
 }
+
+// checkMinProtocolPeers counts the peers which support a protocol, either with a version ("eth/67") or any version
+// of it ("eth"). The total peer count can hide that no peer speaks the protocol which matters.
+func checkMinProtocolPeers(ctx context.Context, protocol string, minPeerCount uint, api AdminAPI) error {
+	if api == nil {
+		return fmt.Errorf("no connection to the Erigon server or `admin` namespace isn't enabled")
+	}
+
+	peers, err := api.Peers(ctx)
+	if err != nil {
+		return err
+	}
+
+	var peerCount uint
+	for _, peer := range peers {
+		for _, capability := range peer.Caps {
+			if capability == protocol || strings.HasPrefix(capability, protocol+"/") {
+				peerCount++
+				break
+			}
+		}
+	}
+
+	if peerCount < minPeerCount {
+		return fmt.Errorf("%w: %d %s (minimum %d)", errNotEnoughPeers, peerCount, protocol, minPeerCount)
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+)
+
+var (
+	errExecutionLagging = errors.New("execution is lagging behind headers")
+)
+
+// checkStageLag fails if the Execution stage is more than maxLag blocks behind the Headers stage. Such a node
+// may look alive to eth_syncing based checks while its state is not moving.
+func checkStageLag(ctx context.Context, maxLag uint64, api ErigonAPI) error {
+	if api == nil {
+		return fmt.Errorf("no connection to the Erigon server or `erigon` namespace isn't enabled")
+	}
+	progress, err := api.StagesProgress(ctx)
+	if err != nil {
+		return err
+	}
+	headers, execution := uint64(progress[stages.Headers]), uint64(progress[stages.Execution])
+	if headers > execution && headers-execution > maxLag {
+		return fmt.Errorf("%w: execution %d, headers %d (maximum lag %d)", errExecutionLagging, execution, headers, maxLag)
+	}
+	return nil
+}
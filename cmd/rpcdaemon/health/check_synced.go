@@ -1,8 +1,9 @@
 package health
 
 import (
+	"context"
 	"errors"
-	"net/http"
+	"fmt"
 
 	"github.com/ledgerwatch/log/v3"
 )
@@ -11,8 +12,11 @@ var (
 	errNotSynced = errors.New("not synced")
 )
 
-func checkSynced(ethAPI EthAPI, r *http.Request) error {
-	i, err := ethAPI.Syncing(r.Context())
+func checkSynced(ctx context.Context, ethAPI EthAPI) error {
+	if ethAPI == nil {
+		return fmt.Errorf("no connection to the Erigon server or `eth` namespace isn't enabled")
+	}
+	i, err := ethAPI.Syncing(ctx)
 	if err != nil {
 		log.Root().Warn("unable to process synced request", "err", err.Error())
 		return err
@@ -1,10 +1,11 @@
 package health
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"net/http"
 
+	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/rpc"
 )
 
@@ -13,23 +14,35 @@ var (
 )
 
 func checkTime(
-	r *http.Request,
+	ctx context.Context,
 	seconds int,
 	ethAPI EthAPI,
 ) error {
-	i, err := ethAPI.GetBlockByNumber(r.Context(), rpc.LatestBlockNumber, false)
+	timestamp, err := latestBlockTimestamp(ctx, ethAPI)
 	if err != nil {
 		return err
 	}
-	timestamp := 0
-	if ts, ok := i["timestamp"]; ok {
-		if cs, ok := ts.(uint64); ok {
-			timestamp = int(cs)
-		}
-	}
-	if timestamp > seconds {
+	if timestamp < seconds {
 		return fmt.Errorf("%w: got ts: %d, need: %d", errTimestampTooOld, timestamp, seconds)
 	}
 
 	return nil
 }
+
+func latestBlockTimestamp(ctx context.Context, ethAPI EthAPI) (int, error) {
+	if ethAPI == nil {
+		return 0, fmt.Errorf("no connection to the Erigon server or `eth` namespace isn't enabled")
+	}
+	i, err := ethAPI.GetBlockByNumber(ctx, rpc.LatestBlockNumber, false)
+	if err != nil {
+		return 0, err
+	}
+	switch ts := i["timestamp"].(type) {
+	case hexutil.Uint64:
+		return int(ts), nil
+	case uint64:
+		return int(ts), nil
+	default:
+		return 0, errNoLatestBlock
+	}
+}
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	errTxPoolPendingOutOfBounds = errors.New("pending transactions out of bounds")
+)
+
+type txPoolBounds struct {
+	MinPending *uint `json:"min_pending"`
+	MaxPending *uint `json:"max_pending"`
+}
+
+// parseTxPoolBounds parses the <min>-<max> suffix of the check_txpool header value, both ends are optional:
+// "" (reachability only), "1-", "-5000", "1-5000"
+func parseTxPoolBounds(s string) (txPoolBounds, error) {
+	var bounds txPoolBounds
+	if s == "" {
+		return bounds, nil
+	}
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return bounds, errBadHeaderValue
+	}
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		v, err := strconv.ParseUint(part, 10, 0)
+		if err != nil {
+			return bounds, err
+		}
+		u := uint(v)
+		if i == 0 {
+			bounds.MinPending = &u
+		} else {
+			bounds.MaxPending = &u
+		}
+	}
+	return bounds, nil
+}
+
+// String returns the bounds in the check_txpool header syntax
+func (b txPoolBounds) String() string {
+	var s string
+	if b.MinPending != nil {
+		s = strconv.FormatUint(uint64(*b.MinPending), 10)
+	}
+	if b.MaxPending != nil {
+		s += "-" + strconv.FormatUint(uint64(*b.MaxPending), 10)
+	} else if s != "" {
+		s += "-"
+	}
+	return s
+}
+
+func checkTxPool(ctx context.Context, bounds txPoolBounds, api TxPoolAPI) error {
+	if api == nil {
+		return fmt.Errorf("no connection to the Erigon server or `txpool` namespace isn't enabled")
+	}
+	status, err := api.Status(ctx)
+	if err != nil {
+		return err
+	}
+	pending := uint(status["pending"])
+	if bounds.MinPending != nil && pending < *bounds.MinPending {
+		return fmt.Errorf("%w: %d (minimum %d)", errTxPoolPendingOutOfBounds, pending, *bounds.MinPending)
+	}
+	if bounds.MaxPending != nil && pending > *bounds.MaxPending {
+		return fmt.Errorf("%w: %d (maximum %d)", errTxPoolPendingOutOfBounds, pending, *bounds.MaxPending)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+const wsCheckTimeout = 5 * time.Second
+
+// WebsocketEndpoint returns the url the node reaches its own websocket endpoint at, which shares the listener of
+// the HTTP one, over TLS if secure
+func WebsocketEndpoint(listenAddress string, port int, secure bool) string {
+	if ip := net.ParseIP(listenAddress); listenAddress == "" || (ip != nil && ip.IsUnspecified()) {
+		listenAddress = "localhost"
+	}
+	scheme := "ws://"
+	if secure {
+		scheme = "wss://"
+	}
+	return scheme + net.JoinHostPort(listenAddress, strconv.Itoa(port))
+}
+
+// checkWebsocket opens a websocket connection to the node and makes an eth_chainId round trip through it. The
+// HTTP endpoint answering doesn't mean the websocket upgrade works, which is why it's checked separately. The check
+// passes if websockets aren't enabled (empty endpoint). If the endpoint requires JWTs, one is signed with jwtSecret.
+// The certificate of a wss endpoint isn't verified, it's issued for the public name of the node rather than the
+// address it reaches itself at.
+func checkWebsocket(ctx context.Context, endpoint string, jwtSecret []byte) error {
+	if endpoint == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, wsCheckTimeout)
+	defer cancel()
+	header := make(http.Header)
+	auth, err := Authorization("", jwtSecret)
+	if err != nil {
+		return err
+	}
+	if auth != "" {
+		header.Set("Authorization", auth)
+	}
+	dialer := websocket.Dialer{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}} //nolint:gosec
+	client, err := rpc.DialWebsocketWithHeader(ctx, endpoint, "", dialer, header)
+	if err != nil {
+		return fmt.Errorf("websocket connection failed: %w", err)
+	}
+	defer client.Close()
+	var chainID hexutil.Big
+	if err = client.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		return fmt.Errorf("eth_chainId over websocket failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+type chainIDService struct{}
+
+func (chainIDService) ChainId(_ context.Context) (*hexutil.Big, error) {
+	return (*hexutil.Big)(hexutil.MustDecodeBig("0x1")), nil
+}
+
+func TestCheckWebsocket(t *testing.T) {
+	srv := rpc.NewServer(1, false, true)
+	if err := srv.RegisterName("eth", chainIDService{}); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	ws := httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, false))
+	endpoint := "ws://" + strings.TrimPrefix(ws.URL, "http://")
+
+	if err := checkWebsocket(context.Background(), endpoint, nil); err != nil {
+		t.Errorf("expected a working websocket, got: %v", err)
+	}
+	if err := checkWebsocket(context.Background(), "", nil); err != nil {
+		t.Errorf("expected disabled websockets to pass, got: %v", err)
+	}
+
+	// plain HTTP server, the upgrade fails
+	plain := httptest.NewServer(srv)
+	defer plain.Close()
+	if err := checkWebsocket(context.Background(), "ws://"+strings.TrimPrefix(plain.URL, "http://"), nil); err == nil {
+		t.Errorf("expected a failed upgrade")
+	}
+
+	ws.Close()
+	if err := checkWebsocket(context.Background(), endpoint, nil); err == nil {
+		t.Errorf("expected a closed endpoint to fail")
+	}
+}
+
+func TestCheckWebsocketJWT(t *testing.T) {
+	srv := rpc.NewServer(1, false, true)
+	if err := srv.RegisterName("eth", chainIDService{}); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	secret := make([]byte, 32)
+	ws := httptest.NewServer(srv.WebsocketHandler([]string{"*"}, secret, false))
+	defer ws.Close()
+	endpoint := "ws://" + strings.TrimPrefix(ws.URL, "http://")
+
+	if err := checkWebsocket(context.Background(), endpoint, secret); err != nil {
+		t.Errorf("expected a working websocket, got: %v", err)
+	}
+	if err := checkWebsocket(context.Background(), endpoint, nil); err == nil {
+		t.Errorf("expected an unauthenticated connection to fail")
+	}
+}
+
+// This test checks that the self-signed certificates are accepted, the node checks its own endpoint.
+func TestCheckWebsocketTLS(t *testing.T) {
+	srv := rpc.NewServer(1, false, true)
+	if err := srv.RegisterName("eth", chainIDService{}); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	ws := httptest.NewTLSServer(srv.WebsocketHandler([]string{"*"}, nil, false))
+	defer ws.Close()
+	endpoint := "wss://" + strings.TrimPrefix(ws.URL, "https://")
+
+	if err := checkWebsocket(context.Background(), endpoint, nil); err != nil {
+		t.Errorf("expected a working websocket, got: %v", err)
+	}
+}
+
+func TestWebsocketEndpoint(t *testing.T) {
+	cases := map[string]string{
+		"":          "ws://localhost:8545",
+		"0.0.0.0":   "ws://localhost:8545",
+		"::":        "ws://localhost:8545",
+		"127.0.0.1": "ws://127.0.0.1:8545",
+		"::1":       "ws://[::1]:8545",
+		"node.lan":  "ws://node.lan:8545",
+	}
+	for addr, expected := range cases {
+		if got := WebsocketEndpoint(addr, 8545, false); got != expected {
+			t.Errorf("%q: expected %s, got: %s", addr, expected, got)
+		}
+	}
+	if got := WebsocketEndpoint("", 8545, true); got != "wss://localhost:8545" {
+		t.Errorf("expected a wss endpoint, got: %s", got)
+	}
+}
@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC health services, the empty one is the overall status of the server
+const (
+	grpcServiceDefault   = ""
+	grpcServiceReadiness = "readiness"
+	grpcServiceLiveness  = "liveness"
+)
+
+const grpcWatchInterval = 5 * time.Second
+
+// GRPCServer implements the gRPC health checking protocol (grpc.health.v1) with the checks of the HTTP endpoints:
+// service "" runs Config.DefaultChecks, "readiness" and "liveness" run the checks of the probes. A service is
+// SERVING if all its checks pass.
+type GRPCServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+
+	lock     sync.RWMutex
+	checker  *Checker
+	shutdown bool
+	quit     chan struct{}
+}
+
+// NewGRPCServer creates a server reporting the checks of checker. It may be nil if the rpc services aren't
+// created yet, everything is NOT_SERVING until SetChecker is called.
+func NewGRPCServer(checker *Checker) *GRPCServer {
+	return &GRPCServer{checker: checker, quit: make(chan struct{})}
+}
+
+func (s *GRPCServer) SetChecker(checker *Checker) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.checker = checker
+}
+
+// Shutdown makes all services NOT_SERVING, e.g. to drain the traffic before stopping the gRPC server
+func (s *GRPCServer) Shutdown() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.shutdown {
+		s.shutdown = true
+		close(s.quit)
+	}
+}
+
+func (s *GRPCServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	servingStatus, ok := s.servingStatus(ctx, req.Service)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.Service)
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+// Watch runs the checks periodically and sends the status whenever it changes
+func (s *GRPCServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	ticker := time.NewTicker(grpcWatchInterval)
+	defer ticker.Stop()
+	var last grpc_health_v1.HealthCheckResponse_ServingStatus = -1
+	for {
+		servingStatus, ok := s.servingStatus(stream.Context(), req.Service)
+		if !ok {
+			servingStatus = grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if servingStatus != last {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: servingStatus}); err != nil {
+				return status.Error(codes.Canceled, "stream has ended")
+			}
+			last = servingStatus
+		}
+		select {
+		case <-ticker.C:
+		case <-s.quit:
+			if last != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+				if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING}); err != nil {
+					return status.Error(codes.Canceled, "stream has ended")
+				}
+			}
+			return nil // lets GracefulStop of the gRPC server complete
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		}
+	}
+}
+
+// servingStatus runs the checks of a service, false is returned for unknown services
+func (s *GRPCServer) servingStatus(ctx context.Context, service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, bool) {
+	s.lock.RLock()
+	checker, shutdown := s.checker, s.shutdown
+	s.lock.RUnlock()
+
+	if service != grpcServiceDefault && service != grpcServiceReadiness && service != grpcServiceLiveness {
+		return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, false
+	}
+	if checker == nil || shutdown {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, true
+	}
+
+	checks := checker.cfg.DefaultChecks
+	switch service {
+	case grpcServiceReadiness:
+		checks = checker.cfg.ReadinessChecks
+	case grpcServiceLiveness:
+		checks = checker.cfg.LivenessChecks
+	}
+	if rep := checker.runChecks(ctx, checks); rep.Status != statusHealthy {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, true
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING, true
+}
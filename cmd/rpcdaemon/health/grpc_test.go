@@ -0,0 +1,108 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+func TestGRPCServer_Check(t *testing.T) {
+	cfg := Config{
+		DefaultChecks:   []string{"min_peer_count1"},
+		ReadinessChecks: []string{"min_peer_count3"},
+	}
+	s := NewGRPCServer(nil)
+	check := func(service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, codes.Code) {
+		resp, err := s.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			return grpc_health_v1.HealthCheckResponse_UNKNOWN, status.Code(err)
+		}
+		return resp.Status, codes.OK
+	}
+
+	if st, code := check(""); st != grpc_health_v1.HealthCheckResponse_NOT_SERVING || code != codes.OK {
+		t.Errorf("expected NOT_SERVING without checker, got: %v %v", st, code)
+	}
+
+	s.SetChecker(NewChecker([]rpc.API{{Service: &netApiStub{response: hexutil.Uint(2)}}}, cfg))
+	cases := []struct {
+		service string
+		status  grpc_health_v1.HealthCheckResponse_ServingStatus
+		code    codes.Code
+	}{
+		{"", grpc_health_v1.HealthCheckResponse_SERVING, codes.OK},
+		{"readiness", grpc_health_v1.HealthCheckResponse_NOT_SERVING, codes.OK},
+		{"liveness", grpc_health_v1.HealthCheckResponse_SERVING, codes.OK}, // no checks
+		{"other", grpc_health_v1.HealthCheckResponse_UNKNOWN, codes.NotFound},
+	}
+	for _, c := range cases {
+		if st, code := check(c.service); st != c.status || code != c.code {
+			t.Errorf("%q: expected %v %v, got: %v %v", c.service, c.status, c.code, st, code)
+		}
+	}
+
+	s.Shutdown()
+	if st, _ := check(""); st != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected NOT_SERVING after shutdown, got: %v", st)
+	}
+}
+
+type watchStreamStub struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan grpc_health_v1.HealthCheckResponse_ServingStatus
+}
+
+func (w *watchStreamStub) Context() context.Context { return w.ctx }
+
+func (w *watchStreamStub) Send(resp *grpc_health_v1.HealthCheckResponse) error {
+	w.sent <- resp.Status
+	return nil
+}
+
+func TestGRPCServer_Watch(t *testing.T) {
+	s := NewGRPCServer(NewChecker([]rpc.API{{Service: &netApiStub{response: hexutil.Uint(2)}}}, Config{DefaultChecks: []string{"min_peer_count1"}}))
+	stream := &watchStreamStub{ctx: context.Background(), sent: make(chan grpc_health_v1.HealthCheckResponse_ServingStatus, 10)}
+	done := make(chan error)
+	go func() { done <- s.Watch(&grpc_health_v1.HealthCheckRequest{}, stream) }()
+
+	expect := func(expected grpc_health_v1.HealthCheckResponse_ServingStatus) {
+		select {
+		case st := <-stream.sent:
+			if st != expected {
+				t.Errorf("expected %v, got: %v", expected, st)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %v, got nothing", expected)
+		}
+	}
+	expect(grpc_health_v1.HealthCheckResponse_SERVING)
+	s.Shutdown()
+	expect(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected the stream to end cleanly, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("watch didn't end after shutdown")
+	}
+
+	// unknown services are reported, the stream stays open until the client leaves
+	ctx, cancel := context.WithCancel(context.Background())
+	stream = &watchStreamStub{ctx: ctx, sent: make(chan grpc_health_v1.HealthCheckResponse_ServingStatus, 10)}
+	go func() { done <- NewGRPCServer(nil).Watch(&grpc_health_v1.HealthCheckRequest{Service: "other"}, stream) }()
+	expect(grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN)
+	cancel()
+	if err := <-done; status.Code(err) != codes.Canceled {
+		t.Errorf("expected the stream to be canceled, got: %v", err)
+	}
+}
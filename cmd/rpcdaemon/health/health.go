@@ -1,11 +1,14 @@
 package health
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -16,117 +19,355 @@ import (
 )
 
 type requestBody struct {
-	MinPeerCount *uint            `json:"min_peer_count"`
-	BlockNumber  *rpc.BlockNumber `json:"known_block"`
+	MinPeerCount     *uint            `json:"min_peer_count"`
+	MinProtocolPeers map[string]uint  `json:"min_protocol_peers"` // protocol, e.g. "eth/67" or "eth", -> minimal peers
+	BlockNumber      *rpc.BlockNumber `json:"known_block"`
+	CheckTxPool      *txPoolBounds    `json:"check_txpool"`
+	Synced           *bool            `json:"synced"`
+	MaxSecondsBehind *int             `json:"max_seconds_behind"`
+	MaxStageLag      *uint64          `json:"max_stage_lag"`
+	CheckDB          *uint            `json:"check_db"`   // maximal latency in milliseconds, 0 uses the configured one
+	CheckCall        *CallCheck       `json:"check_call"` // without "to" the configured call is used
+	CheckWS          *bool            `json:"check_ws"`
+	MaxHeadStall     *uint            `json:"max_head_stall"` // seconds, 0 uses the configured window
 }
 
 const (
 	urlPath          = "/health"
+	readinessPath    = "/readiness" // under the health path
+	livenessPath     = "/liveness"
 	healthHeader     = "X-ERIGON-HEALTHCHECK"
+	healthcheckQuery = "healthcheck_query"
 	synced           = "synced"
 	minPeerCount     = "min_peer_count"
 	checkBlock       = "check_block"
 	maxSecondsBehind = "max_seconds_behind"
+	checkDB          = "check_db"
+	checkCallOpt     = "check_call"
+	checkWS          = "check_ws"
+	maxHeadStall     = "max_head_stall"
+	checkTxPoolOpt   = "check_txpool"
+	maxStageLag      = "max_stage_lag"
 )
 
+// Config sets the checks run by the Kubernetes style probes, and by /health requests which specify none. Checks
+// are written like the values of the X-ERIGON-HEALTHCHECK header, e.g. "min_peer_count3". A probe without checks
+// always reports healthy.
+type Config struct {
+	Path string // of the health endpoint, the probes are under it. Empty for /health
+
+	ReadinessChecks []string // /health/readiness: is the node able to serve up to date data
+	LivenessChecks  []string // /health/liveness: is the process working at all
+	DefaultChecks   []string // /health without headers and body, for load balancers which can't send them
+
+	DBMaxLatency time.Duration // check_db fails if reading the latest block takes longer, 0 disables the limit
+	Call         CallCheck     // eth_call run by check_call
+	WSEndpoint   string        // websocket url of the node for check_ws, empty if websockets aren't enabled
+	WSJWTSecret  []byte        // if set, check_ws authenticates to the websocket endpoint with a JWT signed with it
+	MaxHeadStall time.Duration // max_head_stall fails if the latest block doesn't change for longer
+	CacheTTL     time.Duration // how long check results are reused by the following requests, 0 disables caching
+
+	Upstreams      []string // health endpoints of other nodes, if set the requests are forwarded to them instead of checking this node
+	UpstreamQuorum int      // number of healthy upstreams needed for a healthy report, 0 for all of them
+
+	AuthSecret    string // if set, requests must send "Authorization: Bearer <AuthSecret>"
+	AuthJWTSecret []byte // if set, requests must send "Authorization: Bearer <JWT>" signed with it
+}
+
 var (
-	errCheckDisabled  = errors.New("error check disabled")
 	errBadHeaderValue = errors.New("bad header value")
+	errBadBodyValue   = errors.New("bad body value")
 )
 
+// apis are the rpc services the checks are run against, nil if the namespace isn't enabled
+type apis struct {
+	net    NetAPI
+	eth    EthAPI
+	txPool TxPoolAPI
+	erigon ErigonAPI
+	admin  AdminAPI
+}
+
+// Checker serves the healthcheck endpoints of a set of rpc services. It keeps the check results cached for
+// Config.CacheTTL, so it should be created once and shared by all requests.
+type Checker struct {
+	apis  apis
+	cfg   Config
+	path  string
+	cache *resultCache
+	head  *headTracker
+}
+
+func NewChecker(rpcAPI []rpc.API, cfg Config) *Checker {
+	path := strings.ToLower(strings.TrimSuffix(cfg.Path, "/"))
+	if path == "" {
+		path = urlPath
+	} else if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return &Checker{apis: parseAPI(rpcAPI), cfg: cfg, path: path, cache: newResultCache(cfg.CacheTTL), head: &headTracker{}}
+}
+
+// ProcessHealthcheckIfNeeded is a one-off Checker, results aren't cached and the head progression isn't tracked
 func ProcessHealthcheckIfNeeded(
 	w http.ResponseWriter,
 	r *http.Request,
 	rpcAPI []rpc.API,
+	cfg Config,
 ) bool {
-	if !strings.EqualFold(r.URL.Path, urlPath) {
+	cfg.CacheTTL = 0
+	return NewChecker(rpcAPI, cfg).ProcessHealthcheckIfNeeded(w, r)
+}
+
+func (c *Checker) ProcessHealthcheckIfNeeded(w http.ResponseWriter, r *http.Request) bool {
+	path := strings.ToLower(r.URL.Path)
+	if path != c.path && path != c.path+readinessPath && path != c.path+livenessPath {
 		return false
 	}
+	if !authorized(w, r, c.cfg) {
+		return true
+	}
 
-	netAPI, ethAPI := parseAPI(rpcAPI)
-
-	headers := r.Header.Values(healthHeader)
-	if len(headers) != 0 {
-		processFromHeaders(headers, ethAPI, netAPI, w, r)
-	} else {
-		processFromBody(w, r, netAPI, ethAPI)
+	var rep *report
+	switch {
+	case len(c.cfg.Upstreams) > 0:
+		rep = c.aggregate(r, strings.TrimPrefix(path, c.path))
+	case path == c.path+readinessPath:
+		rep = c.runChecks(r.Context(), c.cfg.ReadinessChecks)
+	case path == c.path+livenessPath:
+		rep = c.runChecks(r.Context(), c.cfg.LivenessChecks)
+	default:
+		headers := r.Header.Values(healthHeader)
+		// HEAD requests have no body, they run the default checks
+		if len(headers) == 0 && (r.Method == http.MethodHead || (len(c.cfg.DefaultChecks) > 0 && !hasBody(r))) {
+			headers = c.cfg.DefaultChecks
+		}
+		if len(headers) != 0 || r.Method == http.MethodHead {
+			rep = c.runChecks(r.Context(), headers)
+		} else {
+			rep = c.runBodyChecks(r)
+		}
 	}
 
+	if err := writeResponse(w, rep, r.Method == http.MethodHead); err != nil {
+		log.Root().Warn("unable to process healthcheck request", "err", err)
+	}
 	return true
 }
 
-func processFromHeaders(headers []string, ethAPI EthAPI, netAPI NetAPI, w http.ResponseWriter, r *http.Request) {
-	var (
-		errCheckSynced  = errCheckDisabled
-		errCheckPeer    = errCheckDisabled
-		errCheckBlock   = errCheckDisabled
-		errCheckSeconds = errCheckDisabled
-	)
-
-	for _, header := range headers {
-		lHeader := strings.ToLower(header)
-		if lHeader == synced {
-			errCheckSynced = checkSynced(ethAPI, r)
+// hasBody tells whether the request has a non-empty body, the body stays readable
+func hasBody(r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody {
+		return false
+	}
+	body, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return err != nil || len(bytes.TrimSpace(body)) > 0
+}
+
+// runChecks runs checks written like the X-ERIGON-HEALTHCHECK header values, unknown ones are ignored
+func (c *Checker) runChecks(ctx context.Context, checks []string) *report {
+	rep := newReport()
+	for _, check := range checks {
+		check = strings.ToLower(check)
+		if name, run := c.parseCheck(check); run != nil {
+			c.run(rep, name, check, func() error { return run(ctx) })
 		}
-		if strings.HasPrefix(lHeader, minPeerCount) {
-			peers, err := strconv.Atoi(strings.TrimPrefix(lHeader, minPeerCount))
+	}
+	return rep
+}
+
+// run adds the result of a check to the report, reusing the cached one if it's fresh. Checks with the same key
+// must be equivalent, the header syntax is used for them.
+func (c *Checker) run(rep *report, name, key string, check func() error) {
+	if result, ok := c.cache.get(key); ok {
+		rep.add(result)
+		return
+	}
+	result := runCheck(name, check)
+	c.cache.put(key, result)
+	rep.add(result)
+}
+
+// parseCheck returns the name and the function of a check written like a X-ERIGON-HEALTHCHECK header value, or
+// a nil function if the value is unknown
+func (c *Checker) parseCheck(check string) (string, func(ctx context.Context) error) {
+	a, cfg := c.apis, c.cfg
+	switch {
+	case check == synced:
+		return synced, func(ctx context.Context) error { return checkSynced(ctx, a.eth) }
+	case strings.HasPrefix(check, minPeerCount):
+		// min_peer_count<count> or min_peer_count<count>:<protocol>
+		count, protocol, perProtocol := strings.Cut(strings.TrimPrefix(check, minPeerCount), ":")
+		name := minPeerCount
+		if perProtocol {
+			name += ":" + protocol
+		}
+		return name, func(ctx context.Context) error {
+			peers, err := strconv.Atoi(count)
 			if err != nil {
-				errCheckPeer = err
-				break
+				return err
+			}
+			if perProtocol {
+				if protocol == "" {
+					return errBadHeaderValue
+				}
+				return checkMinProtocolPeers(ctx, protocol, uint(peers), a.admin)
 			}
-			errCheckPeer = checkMinPeers(uint(peers), netAPI)
+			return checkMinPeers(ctx, uint(peers), a.net)
 		}
-		if strings.HasPrefix(lHeader, checkBlock) {
-			block, err := strconv.Atoi(strings.TrimPrefix(lHeader, checkBlock))
+	case strings.HasPrefix(check, checkBlock):
+		return checkBlock, func(ctx context.Context) error {
+			block, err := strconv.Atoi(strings.TrimPrefix(check, checkBlock))
 			if err != nil {
-				errCheckBlock = err
-				break
+				return err
 			}
-			errCheckBlock = checkBlockNumber(rpc.BlockNumber(block), ethAPI)
+			return checkBlockNumber(ctx, rpc.BlockNumber(block), a.eth)
 		}
-		if strings.HasPrefix(lHeader, maxSecondsBehind) {
-			seconds, err := strconv.Atoi(strings.TrimPrefix(lHeader, maxSecondsBehind))
+	case strings.HasPrefix(check, maxSecondsBehind):
+		return maxSecondsBehind, func(ctx context.Context) error {
+			seconds, err := strconv.Atoi(strings.TrimPrefix(check, maxSecondsBehind))
 			if err != nil {
-				errCheckSeconds = err
-				break
+				return err
 			}
 			if seconds < 0 {
-				errCheckSeconds = errBadHeaderValue
-				break
+				return errBadHeaderValue
+			}
+			return checkTime(ctx, int(time.Now().Unix())-seconds, a.eth)
+		}
+	case strings.HasPrefix(check, checkDB):
+		return checkDB, func(ctx context.Context) error {
+			maxLatency := cfg.DBMaxLatency
+			if ms := strings.TrimPrefix(check, checkDB); ms != "" {
+				v, err := strconv.ParseUint(ms, 10, 32)
+				if err != nil {
+					return err
+				}
+				maxLatency = time.Duration(v) * time.Millisecond
+			}
+			return checkDBLatency(ctx, maxLatency, a.eth)
+		}
+	case check == checkCallOpt:
+		return checkCallOpt, func(ctx context.Context) error { return checkCall(ctx, cfg.Call, a.eth) }
+	case check == checkWS:
+		return checkWS, func(ctx context.Context) error { return checkWebsocket(ctx, cfg.WSEndpoint, cfg.WSJWTSecret) }
+	case strings.HasPrefix(check, checkTxPoolOpt):
+		return checkTxPoolOpt, func(ctx context.Context) error {
+			bounds, err := parseTxPoolBounds(strings.TrimPrefix(check, checkTxPoolOpt))
+			if err != nil {
+				return err
+			}
+			return checkTxPool(ctx, bounds, a.txPool)
+		}
+	case strings.HasPrefix(check, maxHeadStall):
+		return maxHeadStall, func(ctx context.Context) error {
+			maxStall := cfg.MaxHeadStall
+			if seconds := strings.TrimPrefix(check, maxHeadStall); seconds != "" {
+				v, err := strconv.ParseUint(seconds, 10, 32)
+				if err != nil {
+					return err
+				}
+				maxStall = time.Duration(v) * time.Second
+			}
+			return checkHeadProgress(ctx, maxStall, c.head, a.eth)
+		}
+	case strings.HasPrefix(check, maxStageLag):
+		return maxStageLag, func(ctx context.Context) error {
+			lag, err := strconv.ParseUint(strings.TrimPrefix(check, maxStageLag), 10, 64)
+			if err != nil {
+				return err
 			}
-			now := time.Now().Unix()
-			errCheckSeconds = checkTime(r, int(now)-seconds, ethAPI)
+			return checkStageLag(ctx, lag, a.erigon)
 		}
 	}
-
-	reportHealthFromHeaders(errCheckSynced, errCheckPeer, errCheckBlock, errCheckSeconds, w)
+	return "", nil
 }
 
-func processFromBody(w http.ResponseWriter, r *http.Request, netAPI NetAPI, ethAPI EthAPI) {
+func (c *Checker) runBodyChecks(r *http.Request) *report {
+	rep := newReport()
 	body, errParse := parseHealthCheckBody(r.Body)
 	defer r.Body.Close()
-
-	var errMinPeerCount = errCheckDisabled
-	var errCheckBlock = errCheckDisabled
-
 	if errParse != nil {
 		log.Root().Warn("unable to process healthcheck request", "err", errParse)
-	} else {
-		// 1. net_peerCount
-		if body.MinPeerCount != nil {
-			errMinPeerCount = checkMinPeers(*body.MinPeerCount, netAPI)
+		rep.add(runCheck(healthcheckQuery, func() error { return errParse }))
+		return rep
+	}
+	ctx, a, cfg := r.Context(), c.apis, c.cfg
+
+	// 1. net_peerCount
+	if body.MinPeerCount != nil {
+		c.run(rep, minPeerCount, fmt.Sprintf("%s%d", minPeerCount, *body.MinPeerCount), func() error { return checkMinPeers(ctx, *body.MinPeerCount, a.net) })
+	}
+	// 1a. admin_peers
+	protocols := make([]string, 0, len(body.MinProtocolPeers))
+	for protocol := range body.MinProtocolPeers {
+		protocols = append(protocols, protocol)
+	}
+	sort.Strings(protocols)
+	for _, protocol := range protocols {
+		protocol, peers := protocol, body.MinProtocolPeers[protocol]
+		name := minPeerCount + ":" + protocol
+		c.run(rep, name, fmt.Sprintf("%s%d:%s", minPeerCount, peers, protocol), func() error {
+			return checkMinProtocolPeers(ctx, protocol, peers, a.admin)
+		})
+	}
+	// 2. custom query (shouldn't fail)
+	if body.BlockNumber != nil {
+		c.run(rep, checkBlock, fmt.Sprintf("%s%d", checkBlock, *body.BlockNumber), func() error { return checkBlockNumber(ctx, *body.BlockNumber, a.eth) })
+	}
+	// 3. txpool_status
+	if body.CheckTxPool != nil {
+		c.run(rep, checkTxPoolOpt, checkTxPoolOpt+body.CheckTxPool.String(), func() error { return checkTxPool(ctx, *body.CheckTxPool, a.txPool) })
+	}
+	// 4. eth_syncing
+	if body.Synced != nil && *body.Synced {
+		c.run(rep, synced, synced, func() error { return checkSynced(ctx, a.eth) })
+	}
+	// 5. timestamp of the latest block
+	if body.MaxSecondsBehind != nil {
+		c.run(rep, maxSecondsBehind, fmt.Sprintf("%s%d", maxSecondsBehind, *body.MaxSecondsBehind), func() error {
+			if *body.MaxSecondsBehind < 0 {
+				return errBadBodyValue
+			}
+			return checkTime(ctx, int(time.Now().Unix())-*body.MaxSecondsBehind, a.eth)
+		})
+	}
+	// 6. erigon_stagesProgress
+	if body.MaxStageLag != nil {
+		c.run(rep, maxStageLag, fmt.Sprintf("%s%d", maxStageLag, *body.MaxStageLag), func() error { return checkStageLag(ctx, *body.MaxStageLag, a.erigon) })
+	}
+	// 7. latest block read latency
+	if body.CheckDB != nil {
+		maxLatency := cfg.DBMaxLatency
+		if *body.CheckDB > 0 {
+			maxLatency = time.Duration(*body.CheckDB) * time.Millisecond
 		}
-		// 2. custom query (shouldn't fail)
-		if body.BlockNumber != nil {
-			errCheckBlock = checkBlockNumber(*body.BlockNumber, ethAPI)
+		c.run(rep, checkDB, fmt.Sprintf("%s%d", checkDB, maxLatency.Milliseconds()), func() error { return checkDBLatency(ctx, maxLatency, a.eth) })
+	}
+	// 8. eth_call with a known result
+	if body.CheckCall != nil {
+		call := *body.CheckCall
+		if call.To == nil {
+			call = cfg.Call
 		}
-		// TODO add time from the last sync cycle
+		c.run(rep, checkCallOpt, checkCallOpt+call.String(), func() error { return checkCall(ctx, call, a.eth) })
 	}
-
-	err := reportHealthFromBody(errParse, errMinPeerCount, errCheckBlock, w)
-	if err != nil {
-		log.Root().Warn("unable to process healthcheck request", "err", err)
+	// 9. eth_chainId over websocket
+	if body.CheckWS != nil && *body.CheckWS {
+		c.run(rep, checkWS, checkWS, func() error { return checkWebsocket(ctx, cfg.WSEndpoint, cfg.WSJWTSecret) })
 	}
+	// 10. progression of the latest block
+	if body.MaxHeadStall != nil {
+		maxStall := cfg.MaxHeadStall
+		if *body.MaxHeadStall > 0 {
+			maxStall = time.Duration(*body.MaxHeadStall) * time.Second
+		}
+		c.run(rep, maxHeadStall, fmt.Sprintf("%s%d", maxHeadStall, int64(maxStall.Seconds())), func() error {
+			return checkHeadProgress(ctx, maxStall, c.head, a.eth)
+		})
+	}
+	return rep
 }
 
 func parseHealthCheckBody(reader io.Reader) (requestBody, error) {
@@ -145,59 +386,19 @@ func parseHealthCheckBody(reader io.Reader) (requestBody, error) {
 	return body, nil
 }
 
-func reportHealthFromBody(errParse, errMinPeerCount, errCheckBlock error, w http.ResponseWriter) error {
+// writeResponse sends the report, only its status code if statusOnly (HEAD requests)
+func writeResponse(w http.ResponseWriter, rep *report, statusOnly bool) error {
 	statusCode := http.StatusOK
-	errors := make(map[string]string)
-
-	if shouldChangeStatusCode(errParse) {
-		statusCode = http.StatusInternalServerError
-	}
-	errors["healthcheck_query"] = errorStringOrOK(errParse)
-
-	if shouldChangeStatusCode(errMinPeerCount) {
-		statusCode = http.StatusInternalServerError
-	}
-	errors["min_peer_count"] = errorStringOrOK(errMinPeerCount)
-
-	if shouldChangeStatusCode(errCheckBlock) {
+	if rep.Status != statusHealthy {
 		statusCode = http.StatusInternalServerError
 	}
-	errors["check_block"] = errorStringOrOK(errCheckBlock)
-
-	return writeResponse(w, errors, statusCode)
-}
-
-func reportHealthFromHeaders(errCheckSynced, errCheckPeer, errCheckBlock, errCheckSeconds error, w http.ResponseWriter) error {
-	statusCode := http.StatusOK
-	errs := make(map[string]string)
-
-	if shouldChangeStatusCode(errCheckSynced) {
-		statusCode = http.StatusInternalServerError
-	}
-	errs[synced] = errorStringOrOK(errCheckSynced)
-
-	if shouldChangeStatusCode(errCheckPeer) {
-		statusCode = http.StatusInternalServerError
-	}
-	errs[minPeerCount] = errorStringOrOK(errCheckPeer)
-
-	if shouldChangeStatusCode(errCheckBlock) {
-		statusCode = http.StatusInternalServerError
-	}
-	errs[checkBlock] = errorStringOrOK(errCheckBlock)
-
-	if shouldChangeStatusCode(errCheckSeconds) {
-		statusCode = http.StatusInternalServerError
-	}
-	errs[maxSecondsBehind] = errorStringOrOK(errCheckSeconds)
-
-	return writeResponse(w, errs, statusCode)
-}
-
-func writeResponse(w http.ResponseWriter, errs map[string]string, statusCode int) error {
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
+	if statusOnly {
+		return nil
+	}
 
-	bodyJson, err := json.Marshal(errs)
+	bodyJson, err := json.Marshal(rep)
 	if err != nil {
 		return err
 	}
@@ -209,19 +410,3 @@ func writeResponse(w http.ResponseWriter, errs map[string]string, statusCode int
 
 	return nil
 }
-
-func shouldChangeStatusCode(err error) bool {
-	return err != nil && !errors.Is(err, errCheckDisabled)
-}
-
-func errorStringOrOK(err error) string {
-	if err == nil {
-		return "HEALTHY"
-	}
-
-	if errors.Is(err, errCheckDisabled) {
-		return "DISABLED"
-	}
-
-	return fmt.Sprintf("ERROR: %v", err)
-}
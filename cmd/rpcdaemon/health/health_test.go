@@ -1,6 +1,7 @@
 package health
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -12,15 +13,20 @@ import (
 	"time"
 
 	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/rpc"
 )
 
 type netApiStub struct {
 	response hexutil.Uint
 	error    error
+	calls    int
 }
 
 func (n *netApiStub) PeerCount(_ context.Context) (hexutil.Uint, error) {
+	n.calls++
 	return n.response, n.error
 }
 
@@ -29,9 +35,18 @@ type ethApiStub struct {
 	blockError    error
 	syncingResult interface{}
 	syncingError  error
+	blockDelay    time.Duration
+	callError     error
 }
 
-func (e *ethApiStub) GetBlockByNumber(_ context.Context, _ rpc.BlockNumber, _ bool) (map[string]interface{}, error) {
+func (e *ethApiStub) GetBlockByNumber(ctx context.Context, _ rpc.BlockNumber, _ bool) (map[string]interface{}, error) {
+	if e.blockDelay > 0 {
+		select {
+		case <-time.After(e.blockDelay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
 	return e.blockResult, e.blockError
 }
 
@@ -39,6 +54,64 @@ func (e *ethApiStub) Syncing(_ context.Context) (interface{}, error) {
 	return e.syncingResult, e.syncingError
 }
 
+func (e *ethApiStub) Call(_ context.Context, args ethapi.CallArgs, _ rpc.BlockNumberOrHash, _ *ethapi.StateOverrides, _ *ethapi.BlockOverrides) (hexutil.Bytes, error) {
+	if e.callError != nil {
+		return nil, e.callError
+	}
+	// echoes the calldata
+	return hexutil.Bytes(*args.Data), nil
+}
+
+type txPoolApiStub struct {
+	pending hexutil.Uint
+	error   error
+}
+
+func (p *txPoolApiStub) Status(_ context.Context) (map[string]hexutil.Uint, error) {
+	if p.error != nil {
+		return nil, p.error
+	}
+	return map[string]hexutil.Uint{"pending": p.pending, "baseFee": 0, "queued": 0}, nil
+}
+
+type adminApiStub struct {
+	peers []*p2p.PeerInfo
+	error error
+}
+
+func (a *adminApiStub) Peers(_ context.Context) ([]*p2p.PeerInfo, error) {
+	return a.peers, a.error
+}
+
+type erigonApiStub struct {
+	progress map[stages.SyncStage]hexutil.Uint64
+	error    error
+}
+
+func (e *erigonApiStub) StagesProgress(_ context.Context) (map[stages.SyncStage]hexutil.Uint64, error) {
+	return e.progress, e.error
+}
+
+// decodeStatuses flattens the response to check name -> "HEALTHY", "ERROR: <reason>" or "DISABLED" if the check
+// wasn't run
+func decodeStatuses(r io.Reader) (map[string]string, error) {
+	var rep report
+	if err := json.NewDecoder(r).Decode(&rep); err != nil {
+		return nil, err
+	}
+	statuses := map[string]string{}
+	for _, name := range []string{synced, minPeerCount, checkBlock, maxSecondsBehind, checkDB, checkCallOpt, checkTxPoolOpt, maxStageLag} {
+		statuses[name] = "DISABLED"
+	}
+	for _, c := range rep.Checks {
+		statuses[c.Name] = c.Status
+		if c.Error != "" {
+			statuses[c.Name] += ": " + c.Error
+		}
+	}
+	return statuses, nil
+}
+
 func TestProcessHealthcheckIfNeeded_HeadersTests(t *testing.T) {
 	cases := []struct {
 		headers             []string
@@ -244,7 +317,7 @@ func TestProcessHealthcheckIfNeeded_HeadersTests(t *testing.T) {
 			netApiResponse: hexutil.Uint(1),
 			netApiError:    nil,
 			ethApiBlockResult: map[string]interface{}{
-				"timestamp": time.Now().Add(1 * time.Second).Unix(),
+				"timestamp": hexutil.Uint64(time.Now().Add(-1 * time.Second).Unix()),
 			},
 			ethApiBlockError:    nil,
 			ethApiSyncingResult: false,
@@ -263,7 +336,7 @@ func TestProcessHealthcheckIfNeeded_HeadersTests(t *testing.T) {
 			netApiResponse: hexutil.Uint(1),
 			netApiError:    nil,
 			ethApiBlockResult: map[string]interface{}{
-				"timestamp": uint64(time.Now().Add(1 * time.Hour).Unix()),
+				"timestamp": uint64(time.Now().Add(-1 * time.Hour).Unix()),
 			},
 			ethApiBlockError:    nil,
 			ethApiSyncingResult: false,
@@ -318,7 +391,7 @@ func TestProcessHealthcheckIfNeeded_HeadersTests(t *testing.T) {
 			netApiResponse: hexutil.Uint(10),
 			netApiError:    nil,
 			ethApiBlockResult: map[string]interface{}{
-				"timestamp": time.Now().Add(1 * time.Second).Unix(),
+				"timestamp": hexutil.Uint64(time.Now().Add(-1 * time.Second).Unix()),
 			},
 			ethApiBlockError:    nil,
 			ethApiSyncingResult: false,
@@ -370,7 +443,7 @@ func TestProcessHealthcheckIfNeeded_HeadersTests(t *testing.T) {
 		apis[0] = netAPI
 		apis[1] = ethAPI
 
-		ProcessHealthcheckIfNeeded(w, r, apis)
+		ProcessHealthcheckIfNeeded(w, r, apis, Config{})
 
 		result := w.Result()
 		if result.StatusCode != c.expectedStatusCode {
@@ -382,8 +455,7 @@ func TestProcessHealthcheckIfNeeded_HeadersTests(t *testing.T) {
 			t.Errorf("%v: reading response body: %s", idx, err)
 		}
 
-		var body map[string]string
-		err = json.Unmarshal(bodyBytes, &body)
+		body, err := decodeStatuses(bytes.NewReader(bodyBytes))
 		if err != nil {
 			t.Errorf("%v: unmarshalling the response body: %s", idx, err)
 		}
@@ -420,9 +492,8 @@ func TestProcessHealthcheckIfNeeded_RequestBody(t *testing.T) {
 			ethApiBlockError:   nil,
 			expectedStatusCode: http.StatusOK,
 			expectedBody: map[string]string{
-				"healthcheck_query": "HEALTHY",
-				"min_peer_count":    "HEALTHY",
-				"check_block":       "HEALTHY",
+				"min_peer_count": "HEALTHY",
+				"check_block":    "HEALTHY",
 			},
 		},
 		// 1 - bad request body
@@ -448,9 +519,8 @@ func TestProcessHealthcheckIfNeeded_RequestBody(t *testing.T) {
 			ethApiBlockError:   nil,
 			expectedStatusCode: http.StatusInternalServerError,
 			expectedBody: map[string]string{
-				"healthcheck_query": "HEALTHY",
-				"min_peer_count":    "ERROR: problem getting peers",
-				"check_block":       "HEALTHY",
+				"min_peer_count": "ERROR: problem getting peers",
+				"check_block":    "HEALTHY",
 			},
 		},
 		// 3 - min peers - not enough peers
@@ -462,9 +532,8 @@ func TestProcessHealthcheckIfNeeded_RequestBody(t *testing.T) {
 			ethApiBlockError:   nil,
 			expectedStatusCode: http.StatusInternalServerError,
 			expectedBody: map[string]string{
-				"healthcheck_query": "HEALTHY",
-				"min_peer_count":    "ERROR: not enough peers",
-				"check_block":       "HEALTHY",
+				"min_peer_count": "ERROR: not enough peers",
+				"check_block":    "HEALTHY",
 			},
 		},
 		// 4 - check block - no block
@@ -476,9 +545,8 @@ func TestProcessHealthcheckIfNeeded_RequestBody(t *testing.T) {
 			ethApiBlockError:   nil,
 			expectedStatusCode: http.StatusInternalServerError,
 			expectedBody: map[string]string{
-				"healthcheck_query": "HEALTHY",
-				"min_peer_count":    "HEALTHY",
-				"check_block":       "ERROR: no known block with number ",
+				"min_peer_count": "HEALTHY",
+				"check_block":    "ERROR: no known block with number ",
 			},
 		},
 		// 5 - check block - error getting block info
@@ -490,9 +558,8 @@ func TestProcessHealthcheckIfNeeded_RequestBody(t *testing.T) {
 			ethApiBlockError:   errors.New("problem getting block"),
 			expectedStatusCode: http.StatusInternalServerError,
 			expectedBody: map[string]string{
-				"healthcheck_query": "HEALTHY",
-				"min_peer_count":    "HEALTHY",
-				"check_block":       "ERROR: problem getting block",
+				"min_peer_count": "HEALTHY",
+				"check_block":    "ERROR: problem getting block",
 			},
 		},
 	}
@@ -530,7 +597,7 @@ func TestProcessHealthcheckIfNeeded_RequestBody(t *testing.T) {
 		apis[0] = netAPI
 		apis[1] = ethAPI
 
-		ProcessHealthcheckIfNeeded(w, r, apis)
+		ProcessHealthcheckIfNeeded(w, r, apis, Config{})
 
 		result := w.Result()
 		if result.StatusCode != c.expectedStatusCode {
@@ -542,8 +609,7 @@ func TestProcessHealthcheckIfNeeded_RequestBody(t *testing.T) {
 			t.Errorf("%v: reading response body: %s", idx, err)
 		}
 
-		var body map[string]string
-		err = json.Unmarshal(bodyBytes, &body)
+		body, err := decodeStatuses(bytes.NewReader(bodyBytes))
 		if err != nil {
 			t.Errorf("%v: unmarshalling the response body: %s", idx, err)
 		}
@@ -560,3 +626,752 @@ func TestProcessHealthcheckIfNeeded_RequestBody(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessHealthcheckIfNeeded_Probes(t *testing.T) {
+	cfg := Config{
+		ReadinessChecks: []string{"synced", "min_peer_count3"},
+		LivenessChecks:  []string{"check_db"},
+	}
+	cases := []struct {
+		path                string
+		netApiResponse      hexutil.Uint
+		ethApiBlockResult   map[string]interface{}
+		ethApiBlockError    error
+		ethApiSyncingResult interface{}
+		expectedStatusCode  int
+		expectedBody        map[string]string
+	}{
+		// 0 - readiness - all ok
+		{
+			path:                "/health/readiness",
+			netApiResponse:      hexutil.Uint(3),
+			ethApiSyncingResult: false,
+			expectedStatusCode:  http.StatusOK,
+			expectedBody: map[string]string{
+				synced:       "HEALTHY",
+				minPeerCount: "HEALTHY",
+				checkDB:      "DISABLED",
+			},
+		},
+		// 1 - readiness - syncing, not enough peers
+		{
+			path:                "/health/readiness",
+			netApiResponse:      hexutil.Uint(1),
+			ethApiSyncingResult: struct{}{},
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedBody: map[string]string{
+				synced:       "ERROR: not synced",
+				minPeerCount: "ERROR: not enough peers",
+			},
+		},
+		// 2 - liveness - syncing without peers is still alive
+		{
+			path:                "/health/liveness",
+			netApiResponse:      hexutil.Uint(0),
+			ethApiBlockResult:   map[string]interface{}{"number": hexutil.Uint64(1)},
+			ethApiSyncingResult: struct{}{},
+			expectedStatusCode:  http.StatusOK,
+			expectedBody: map[string]string{
+				synced:       "DISABLED",
+				minPeerCount: "DISABLED",
+				checkDB:      "HEALTHY",
+			},
+		},
+		// 3 - liveness - db read fails
+		{
+			path:               "/health/liveness",
+			ethApiBlockError:   errors.New("mdbx timeout"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody: map[string]string{
+				checkDB: "ERROR: mdbx timeout",
+			},
+		},
+		// 4 - liveness - no block
+		{
+			path:               "/health/liveness",
+			ethApiBlockResult:  map[string]interface{}{},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody: map[string]string{
+				checkDB: "ERROR: no latest block",
+			},
+		},
+	}
+
+	for idx, c := range cases {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, "http://localhost:9090"+c.path, nil)
+		if err != nil {
+			t.Errorf("%v: creating request: %v", idx, err)
+		}
+		// headers are ignored by the probes
+		r.Header.Add("X-ERIGON-HEALTHCHECK", "check_block10")
+
+		apis := []rpc.API{
+			{Service: &netApiStub{response: c.netApiResponse}},
+			{Service: &ethApiStub{blockResult: c.ethApiBlockResult, blockError: c.ethApiBlockError, syncingResult: c.ethApiSyncingResult}},
+		}
+
+		if !ProcessHealthcheckIfNeeded(w, r, apis, cfg) {
+			t.Fatalf("%v: %s not handled", idx, c.path)
+		}
+
+		result := w.Result()
+		if result.StatusCode != c.expectedStatusCode {
+			t.Errorf("%v: expected status code: %v, but got: %v", idx, c.expectedStatusCode, result.StatusCode)
+		}
+
+		body, err := decodeStatuses(result.Body)
+		if err != nil {
+			t.Errorf("%v: unmarshalling the response body: %s", idx, err)
+		}
+		result.Body.Close()
+
+		if body[checkBlock] != "DISABLED" {
+			t.Errorf("%v: expected %s to be disabled, got: %s", idx, checkBlock, body[checkBlock])
+		}
+		for k, v := range c.expectedBody {
+			if val := body[k]; !strings.Contains(val, v) {
+				t.Errorf("%v: expected the response body key: %s to contain: %s, but it contained: %s", idx, k, v, val)
+			}
+		}
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "http://localhost:9090/health/other", nil)
+	if ProcessHealthcheckIfNeeded(httptest.NewRecorder(), r, nil, cfg) {
+		t.Errorf("unexpected path handled")
+	}
+}
+
+func TestProcessHealthcheckIfNeeded_TxPool(t *testing.T) {
+	cases := []struct {
+		header             string // empty: request body is used
+		body               string
+		txPoolPending      hexutil.Uint
+		txPoolError        error
+		noTxPoolAPI        bool
+		expectedStatusCode int
+		expectedResult     string
+	}{
+		// 0 - reachable
+		{header: "check_txpool", expectedStatusCode: http.StatusOK, expectedResult: "HEALTHY"},
+		// 1 - within bounds
+		{header: "check_txpool1-100", txPoolPending: 10, expectedStatusCode: http.StatusOK, expectedResult: "HEALTHY"},
+		// 2 - below the minimum
+		{header: "check_txpool1-", txPoolPending: 0, expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: pending transactions out of bounds: 0 (minimum 1)"},
+		// 3 - above the maximum
+		{header: "check_txpool-100", txPoolPending: 101, expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: pending transactions out of bounds: 101 (maximum 100)"},
+		// 4 - pool unreachable
+		{header: "check_txpool", txPoolError: errors.New("connection refused"), expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: connection refused"},
+		// 5 - namespace not enabled
+		{header: "check_txpool", noTxPoolAPI: true, expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: no connection to the Erigon server or `txpool` namespace isn't enabled"},
+		// 6 - badly formed request
+		{header: "check_txpool1", expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: bad header value"},
+		// 7 - body - within bounds
+		{body: `{"check_txpool": {"min_pending": 1}}`, txPoolPending: 5, expectedStatusCode: http.StatusOK, expectedResult: "HEALTHY"},
+		// 8 - body - above the maximum
+		{body: `{"check_txpool": {"max_pending": 1}}`, txPoolPending: 5, expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: pending transactions out of bounds"},
+		// 9 - body - not requested
+		{body: `{}`, txPoolError: errors.New("connection refused"), expectedStatusCode: http.StatusOK, expectedResult: "DISABLED"},
+	}
+
+	for idx, c := range cases {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, "http://localhost:9090/health", nil)
+		if err != nil {
+			t.Errorf("%v: creating request: %v", idx, err)
+		}
+		if c.header != "" {
+			r.Header.Add("X-ERIGON-HEALTHCHECK", c.header)
+		} else {
+			r.Body = io.NopCloser(strings.NewReader(c.body))
+		}
+
+		apis := []rpc.API{{Service: &ethApiStub{}}}
+		if !c.noTxPoolAPI {
+			apis = append(apis, rpc.API{Service: &txPoolApiStub{pending: c.txPoolPending, error: c.txPoolError}})
+		}
+
+		ProcessHealthcheckIfNeeded(w, r, apis, Config{})
+
+		result := w.Result()
+		if result.StatusCode != c.expectedStatusCode {
+			t.Errorf("%v: expected status code: %v, but got: %v", idx, c.expectedStatusCode, result.StatusCode)
+		}
+		body, err := decodeStatuses(result.Body)
+		if err != nil {
+			t.Errorf("%v: unmarshalling the response body: %s", idx, err)
+		}
+		result.Body.Close()
+		if val := body[checkTxPoolOpt]; !strings.Contains(val, c.expectedResult) {
+			t.Errorf("%v: expected %s to contain: %s, but it contained: %s", idx, checkTxPoolOpt, c.expectedResult, val)
+		}
+	}
+}
+
+func TestProcessHealthcheckIfNeeded_RequestBodySyncAndTime(t *testing.T) {
+	cases := []struct {
+		body                string
+		ethApiBlockResult   map[string]interface{}
+		ethApiSyncingResult interface{}
+		expectedStatusCode  int
+		expectedBody        map[string]string
+	}{
+		// 0 - synced and recent block
+		{
+			body:                `{"synced": true, "max_seconds_behind": 60}`,
+			ethApiBlockResult:   map[string]interface{}{"timestamp": hexutil.Uint64(time.Now().Add(-10 * time.Second).Unix())},
+			ethApiSyncingResult: false,
+			expectedStatusCode:  http.StatusOK,
+			expectedBody: map[string]string{
+				synced:           "HEALTHY",
+				maxSecondsBehind: "HEALTHY",
+			},
+		},
+		// 1 - syncing and old block
+		{
+			body:                `{"synced": true, "max_seconds_behind": 60}`,
+			ethApiBlockResult:   map[string]interface{}{"timestamp": hexutil.Uint64(time.Now().Add(-10 * time.Minute).Unix())},
+			ethApiSyncingResult: struct{}{},
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedBody: map[string]string{
+				synced:           "ERROR: not synced",
+				maxSecondsBehind: "ERROR: timestamp too old",
+			},
+		},
+		// 2 - synced false disables the check
+		{
+			body:                `{"synced": false}`,
+			ethApiSyncingResult: struct{}{},
+			expectedStatusCode:  http.StatusOK,
+			expectedBody: map[string]string{
+				synced:           "DISABLED",
+				maxSecondsBehind: "DISABLED",
+			},
+		},
+		// 3 - negative seconds
+		{
+			body:               `{"max_seconds_behind": -1}`,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody: map[string]string{
+				maxSecondsBehind: "ERROR: bad body value",
+			},
+		},
+		// 4 - no latest block
+		{
+			body:               `{"max_seconds_behind": 60}`,
+			ethApiBlockResult:  map[string]interface{}{},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody: map[string]string{
+				maxSecondsBehind: "ERROR: no latest block",
+			},
+		},
+	}
+
+	for idx, c := range cases {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodPost, "http://localhost:9090/health", strings.NewReader(c.body))
+		if err != nil {
+			t.Errorf("%v: creating request: %v", idx, err)
+		}
+
+		apis := []rpc.API{{Service: &ethApiStub{blockResult: c.ethApiBlockResult, syncingResult: c.ethApiSyncingResult}}}
+		ProcessHealthcheckIfNeeded(w, r, apis, Config{})
+
+		result := w.Result()
+		if result.StatusCode != c.expectedStatusCode {
+			t.Errorf("%v: expected status code: %v, but got: %v", idx, c.expectedStatusCode, result.StatusCode)
+		}
+		body, err := decodeStatuses(result.Body)
+		if err != nil {
+			t.Errorf("%v: unmarshalling the response body: %s", idx, err)
+		}
+		result.Body.Close()
+		for k, v := range c.expectedBody {
+			if val := body[k]; !strings.Contains(val, v) {
+				t.Errorf("%v: expected the response body key: %s to contain: %s, but it contained: %s", idx, k, v, val)
+			}
+		}
+	}
+}
+
+func TestProcessHealthcheckIfNeeded_StageLag(t *testing.T) {
+	progress := map[stages.SyncStage]hexutil.Uint64{stages.Headers: 1000, stages.Execution: 900}
+	cases := []struct {
+		header             string // empty: request body is used
+		body               string
+		erigonApiError     error
+		expectedStatusCode int
+		expectedResult     string
+	}{
+		// 0 - within the lag
+		{header: "max_stage_lag100", expectedStatusCode: http.StatusOK, expectedResult: "HEALTHY"},
+		// 1 - lagging
+		{header: "max_stage_lag99", expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: execution is lagging behind headers: execution 900, headers 1000 (maximum lag 99)"},
+		// 2 - error from api
+		{header: "max_stage_lag100", erigonApiError: errors.New("db closed"), expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: db closed"},
+		// 3 - badly formed request
+		{header: "max_stage_lag-1", expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: strconv.ParseUint"},
+		// 4 - body - within the lag
+		{body: `{"max_stage_lag": 500}`, expectedStatusCode: http.StatusOK, expectedResult: "HEALTHY"},
+		// 5 - body - lagging
+		{body: `{"max_stage_lag": 0}`, expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: execution is lagging behind headers"},
+	}
+
+	for idx, c := range cases {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, "http://localhost:9090/health", nil)
+		if err != nil {
+			t.Errorf("%v: creating request: %v", idx, err)
+		}
+		if c.header != "" {
+			r.Header.Add("X-ERIGON-HEALTHCHECK", c.header)
+		} else {
+			r.Body = io.NopCloser(strings.NewReader(c.body))
+		}
+
+		apis := []rpc.API{{Service: &erigonApiStub{progress: progress, error: c.erigonApiError}}}
+		ProcessHealthcheckIfNeeded(w, r, apis, Config{})
+
+		result := w.Result()
+		if result.StatusCode != c.expectedStatusCode {
+			t.Errorf("%v: expected status code: %v, but got: %v", idx, c.expectedStatusCode, result.StatusCode)
+		}
+		body, err := decodeStatuses(result.Body)
+		if err != nil {
+			t.Errorf("%v: unmarshalling the response body: %s", idx, err)
+		}
+		result.Body.Close()
+		if val := body[maxStageLag]; !strings.Contains(val, c.expectedResult) {
+			t.Errorf("%v: expected %s to contain: %s, but it contained: %s", idx, maxStageLag, c.expectedResult, val)
+		}
+	}
+}
+
+func TestProcessHealthcheckIfNeeded_DefaultChecks(t *testing.T) {
+	cases := []struct {
+		cfg                Config
+		header             string
+		body               string
+		expectedStatusCode int
+		expectedBody       map[string]string
+	}{
+		// 0 - plain request evaluates the defaults
+		{
+			cfg:                Config{DefaultChecks: []string{"synced", "min_peer_count3"}},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody: map[string]string{
+				synced:       "HEALTHY",
+				minPeerCount: "ERROR: not enough peers: 1 (minimum 3)",
+				checkBlock:   "DISABLED",
+			},
+		},
+		// 1 - headers take precedence
+		{
+			cfg:                Config{DefaultChecks: []string{"synced", "min_peer_count3"}},
+			header:             "min_peer_count1",
+			expectedStatusCode: http.StatusOK,
+			expectedBody: map[string]string{
+				synced:       "DISABLED",
+				minPeerCount: "HEALTHY",
+			},
+		},
+		// 2 - body takes precedence
+		{
+			cfg:                Config{DefaultChecks: []string{"synced", "min_peer_count3"}},
+			body:               `{"min_peer_count": 1}`,
+			expectedStatusCode: http.StatusOK,
+			expectedBody: map[string]string{
+				minPeerCount: "HEALTHY",
+			},
+		},
+		// 3 - no defaults, plain request is a bad query
+		{
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody: map[string]string{
+				"healthcheck_query": "ERROR: unexpected end of JSON input",
+			},
+		},
+	}
+
+	for idx, c := range cases {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, "http://localhost:9090/health", strings.NewReader(c.body))
+		if err != nil {
+			t.Errorf("%v: creating request: %v", idx, err)
+		}
+		if c.header != "" {
+			r.Header.Add("X-ERIGON-HEALTHCHECK", c.header)
+		}
+
+		apis := []rpc.API{{Service: &netApiStub{response: 1}}, {Service: &ethApiStub{syncingResult: false}}}
+		ProcessHealthcheckIfNeeded(w, r, apis, c.cfg)
+
+		result := w.Result()
+		if result.StatusCode != c.expectedStatusCode {
+			t.Errorf("%v: expected status code: %v, but got: %v", idx, c.expectedStatusCode, result.StatusCode)
+		}
+		body, err := decodeStatuses(result.Body)
+		if err != nil {
+			t.Errorf("%v: unmarshalling the response body: %s", idx, err)
+		}
+		result.Body.Close()
+		for k, v := range c.expectedBody {
+			if val := body[k]; !strings.Contains(val, v) {
+				t.Errorf("%v: expected the response body key: %s to contain: %s, but it contained: %s", idx, k, v, val)
+			}
+		}
+	}
+}
+
+func TestProcessHealthcheckIfNeeded_DBLatency(t *testing.T) {
+	block := map[string]interface{}{"number": hexutil.Uint64(1)}
+	cases := []struct {
+		cfg                Config
+		header             string // empty: request body is used
+		body               string
+		blockDelay         time.Duration
+		expectedStatusCode int
+		expectedResult     string
+	}{
+		// 0 - no limit
+		{header: "check_db", blockDelay: 20 * time.Millisecond, expectedStatusCode: http.StatusOK, expectedResult: "HEALTHY"},
+		// 1 - configured limit
+		{cfg: Config{DBMaxLatency: 5 * time.Millisecond}, header: "check_db", blockDelay: time.Second, expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: database too slow"},
+		// 2 - header overrides the configured limit
+		{cfg: Config{DBMaxLatency: 5 * time.Millisecond}, header: "check_db1000", blockDelay: 20 * time.Millisecond, expectedStatusCode: http.StatusOK, expectedResult: "HEALTHY"},
+		// 3 - badly formed request
+		{header: "check_dbabc", expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: strconv.ParseUint"},
+		// 4 - body - own limit
+		{body: `{"check_db": 5}`, blockDelay: time.Second, expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: database too slow: latest block read took"},
+		// 5 - body - configured limit
+		{cfg: Config{DBMaxLatency: time.Second}, body: `{"check_db": 0}`, expectedStatusCode: http.StatusOK, expectedResult: "HEALTHY"},
+	}
+
+	for idx, c := range cases {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, "http://localhost:9090/health", strings.NewReader(c.body))
+		if err != nil {
+			t.Errorf("%v: creating request: %v", idx, err)
+		}
+		if c.header != "" {
+			r.Header.Add("X-ERIGON-HEALTHCHECK", c.header)
+		}
+
+		apis := []rpc.API{{Service: &ethApiStub{blockResult: block, blockDelay: c.blockDelay}}}
+		ProcessHealthcheckIfNeeded(w, r, apis, c.cfg)
+
+		result := w.Result()
+		if result.StatusCode != c.expectedStatusCode {
+			t.Errorf("%v: expected status code: %v, but got: %v", idx, c.expectedStatusCode, result.StatusCode)
+		}
+		body, err := decodeStatuses(result.Body)
+		if err != nil {
+			t.Errorf("%v: unmarshalling the response body: %s", idx, err)
+		}
+		result.Body.Close()
+		if val := body[checkDB]; !strings.Contains(val, c.expectedResult) {
+			t.Errorf("%v: expected %s to contain: %s, but it contained: %s", idx, checkDB, c.expectedResult, val)
+		}
+	}
+}
+
+func TestProcessHealthcheckIfNeeded_Call(t *testing.T) {
+	configured, err := ParseCallCheck("0x00000000000000000000000000000000000000aa", "0x1234", "0x1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		cfg                Config
+		header             string // empty: request body is used
+		body               string
+		callError          error
+		expectedStatusCode int
+		expectedResult     string
+	}{
+		// 0 - configured call
+		{cfg: Config{Call: configured}, header: "check_call", expectedStatusCode: http.StatusOK, expectedResult: "HEALTHY"},
+		// 1 - not configured
+		{header: "check_call", expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: no call configured"},
+		// 2 - call fails
+		{cfg: Config{Call: configured}, header: "check_call", callError: errors.New("execution reverted"), expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: execution reverted"},
+		// 3 - body - own call with unexpected result
+		{body: `{"check_call": {"to": "0x00000000000000000000000000000000000000aa", "data": "0x01", "result": "0x02"}}`, expectedStatusCode: http.StatusInternalServerError, expectedResult: "ERROR: unexpected call result: got 0x01, expected 0x02"},
+		// 4 - body - configured call
+		{cfg: Config{Call: configured}, body: `{"check_call": {}}`, expectedStatusCode: http.StatusOK, expectedResult: "HEALTHY"},
+	}
+
+	for idx, c := range cases {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, "http://localhost:9090/health", strings.NewReader(c.body))
+		if err != nil {
+			t.Errorf("%v: creating request: %v", idx, err)
+		}
+		if c.header != "" {
+			r.Header.Add("X-ERIGON-HEALTHCHECK", c.header)
+		}
+
+		apis := []rpc.API{{Service: &ethApiStub{callError: c.callError}}}
+		ProcessHealthcheckIfNeeded(w, r, apis, c.cfg)
+
+		result := w.Result()
+		if result.StatusCode != c.expectedStatusCode {
+			t.Errorf("%v: expected status code: %v, but got: %v", idx, c.expectedStatusCode, result.StatusCode)
+		}
+		body, err := decodeStatuses(result.Body)
+		if err != nil {
+			t.Errorf("%v: unmarshalling the response body: %s", idx, err)
+		}
+		result.Body.Close()
+		if val := body[checkCallOpt]; !strings.Contains(val, c.expectedResult) {
+			t.Errorf("%v: expected %s to contain: %s, but it contained: %s", idx, checkCallOpt, c.expectedResult, val)
+		}
+	}
+
+	for _, bad := range [][3]string{{"0x12", "", "0x"}, {"0x00000000000000000000000000000000000000aa", "zz", "0x"}, {"0x00000000000000000000000000000000000000aa", "", "1"}} {
+		if _, err := ParseCallCheck(bad[0], bad[1], bad[2]); err == nil {
+			t.Errorf("expected an error for %v", bad)
+		}
+	}
+}
+
+func TestProcessHealthcheckIfNeeded_Report(t *testing.T) {
+	apis := []rpc.API{
+		{Service: &netApiStub{response: hexutil.Uint(1)}},
+		{Service: &ethApiStub{syncingResult: false, blockDelay: 5 * time.Millisecond}},
+	}
+	before := time.Now()
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "http://localhost:9090/health", nil)
+	r.Header.Add("X-ERIGON-HEALTHCHECK", "synced")
+	r.Header.Add("X-ERIGON-HEALTHCHECK", "min_peer_count3")
+	r.Header.Add("X-ERIGON-HEALTHCHECK", "check_block1")
+	r.Header.Add("X-ERIGON-HEALTHCHECK", "unknown")
+	ProcessHealthcheckIfNeeded(w, r, apis, Config{})
+
+	result := w.Result()
+	defer result.Body.Close()
+	if result.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected status code: %v, but got: %v", http.StatusInternalServerError, result.StatusCode)
+	}
+	if ct := result.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+	var rep report
+	if err := json.NewDecoder(result.Body).Decode(&rep); err != nil {
+		t.Fatalf("unmarshalling the response body: %s", err)
+	}
+	if rep.Status != statusUnhealthy {
+		t.Errorf("expected overall status %s, got: %s", statusUnhealthy, rep.Status)
+	}
+	if rep.Timestamp.Before(before.Add(-time.Second)) {
+		t.Errorf("unexpected report timestamp: %v", rep.Timestamp)
+	}
+	if len(rep.Checks) != 3 {
+		t.Fatalf("expected 3 checks in the request order, got: %+v", rep.Checks)
+	}
+	expected := []struct{ name, status, err string }{
+		{synced, statusHealthy, ""},
+		{minPeerCount, statusError, "not enough peers: 1 (minimum 3)"},
+		{checkBlock, statusError, "no known block with number 1"},
+	}
+	for i, e := range expected {
+		c := rep.Checks[i]
+		if c.Name != e.name || c.Status != e.status || !strings.Contains(c.Error, e.err) || (e.err == "") != (c.Error == "") {
+			t.Errorf("%v: expected %s %s %q, got: %+v", i, e.name, e.status, e.err, c)
+		}
+		if c.Timestamp.Before(rep.Timestamp.Add(-time.Second)) {
+			t.Errorf("%v: unexpected check timestamp: %v", i, c.Timestamp)
+		}
+	}
+	if rep.Checks[2].DurationMs < 5 {
+		t.Errorf("expected the block check to take at least 5ms, got: %v", rep.Checks[2].DurationMs)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest(http.MethodGet, "http://localhost:9090/health", nil)
+	r.Header.Add("X-ERIGON-HEALTHCHECK", "synced")
+	ProcessHealthcheckIfNeeded(w, r, apis, Config{})
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code: %v, but got: %v", http.StatusOK, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"status":"HEALTHY"`) {
+		t.Errorf("expected a healthy report, got: %s", w.Body.String())
+	}
+}
+
+func TestChecker_Cache(t *testing.T) {
+	net := &netApiStub{response: hexutil.Uint(1)}
+	checker := NewChecker([]rpc.API{{Service: net}}, Config{CacheTTL: 50 * time.Millisecond})
+
+	get := func(headers ...string) report {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, "http://localhost:9090/health", nil)
+		for _, header := range headers {
+			r.Header.Add("X-ERIGON-HEALTHCHECK", header)
+		}
+		checker.ProcessHealthcheckIfNeeded(w, r)
+		var rep report
+		if err := json.NewDecoder(w.Body).Decode(&rep); err != nil {
+			t.Fatalf("unmarshalling the response body: %s", err)
+		}
+		return rep
+	}
+
+	rep := get("min_peer_count1")
+	if net.calls != 1 || rep.Checks[0].Cached {
+		t.Errorf("expected a fresh result, got: %+v after %d calls", rep.Checks[0], net.calls)
+	}
+	rep = get("min_peer_count1")
+	if net.calls != 1 || !rep.Checks[0].Cached || rep.Checks[0].Status != statusHealthy {
+		t.Errorf("expected a cached result, got: %+v after %d calls", rep.Checks[0], net.calls)
+	}
+	rep = get("min_peer_count2") // other parameters aren't served from the cache
+	if net.calls != 2 || rep.Checks[0].Cached || rep.Status != statusUnhealthy {
+		t.Errorf("expected a fresh failed result, got: %+v after %d calls", rep, net.calls)
+	}
+	rep = get("min_peer_count2") // cached failures keep the report unhealthy
+	if net.calls != 2 || !rep.Checks[0].Cached || rep.Status != statusUnhealthy {
+		t.Errorf("expected a cached failed result, got: %+v after %d calls", rep, net.calls)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	rep = get("min_peer_count1")
+	if net.calls != 3 || rep.Checks[0].Cached {
+		t.Errorf("expected the cached result to expire, got: %+v after %d calls", rep.Checks[0], net.calls)
+	}
+
+	// the request body uses the same cache
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "http://localhost:9090/health", strings.NewReader(`{"min_peer_count": 1}`))
+	checker.ProcessHealthcheckIfNeeded(w, r)
+	if net.calls != 3 || !strings.Contains(w.Body.String(), `"cached":true`) {
+		t.Errorf("expected a cached result, got: %s after %d calls", w.Body.String(), net.calls)
+	}
+
+	// one-off checks are never cached
+	for i := 0; i < 2; i++ {
+		r, _ = http.NewRequest(http.MethodGet, "http://localhost:9090/health", nil)
+		r.Header.Add("X-ERIGON-HEALTHCHECK", "min_peer_count1")
+		ProcessHealthcheckIfNeeded(httptest.NewRecorder(), r, []rpc.API{{Service: net}}, Config{CacheTTL: time.Minute})
+	}
+	if net.calls != 5 {
+		t.Errorf("expected uncached calls, got %d calls", net.calls)
+	}
+}
+
+func TestProcessHealthcheckIfNeeded_ProtocolPeers(t *testing.T) {
+	peers := []*p2p.PeerInfo{
+		{Caps: []string{"eth/66", "eth/67"}},
+		{Caps: []string{"eth/66"}},
+		{Caps: []string{"eth/66", "snap/1"}},
+	}
+	cases := []struct {
+		header             string // empty: request body is used
+		body               string
+		adminApiError      error
+		expectedStatusCode int
+		expectedBody       map[string]string
+	}{
+		// 0 - enough peers with the exact protocol version
+		{header: "min_peer_count1:eth/67", expectedStatusCode: http.StatusOK, expectedBody: map[string]string{"min_peer_count:eth/67": "HEALTHY"}},
+		// 1 - not enough peers with the protocol version
+		{header: "min_peer_count2:eth/67", expectedStatusCode: http.StatusInternalServerError, expectedBody: map[string]string{"min_peer_count:eth/67": "ERROR: not enough peers: 1 eth/67 (minimum 2)"}},
+		// 2 - any version of the protocol, peers are counted once
+		{header: "min_peer_count3:eth", expectedStatusCode: http.StatusOK, expectedBody: map[string]string{"min_peer_count:eth": "HEALTHY"}},
+		// 3 - unknown protocol
+		{header: "min_peer_count1:snap/2", expectedStatusCode: http.StatusInternalServerError, expectedBody: map[string]string{"min_peer_count:snap/2": "ERROR: not enough peers: 0 snap/2 (minimum 1)"}},
+		// 4 - error from api
+		{header: "min_peer_count1:eth", adminApiError: errors.New("no sentry"), expectedStatusCode: http.StatusInternalServerError, expectedBody: map[string]string{"min_peer_count:eth": "ERROR: no sentry"}},
+		// 5 - badly formed request
+		{header: "min_peer_count1:", expectedStatusCode: http.StatusInternalServerError, expectedBody: map[string]string{"min_peer_count:": "ERROR: bad header value"}},
+		// 6 - body, checks run in the order of the protocols
+		{body: `{"min_protocol_peers": {"snap": 1, "eth/66": 4}}`, expectedStatusCode: http.StatusInternalServerError, expectedBody: map[string]string{
+			"min_peer_count:eth/66": "ERROR: not enough peers: 3 eth/66 (minimum 4)",
+			"min_peer_count:snap":   "HEALTHY",
+		}},
+	}
+
+	for idx, c := range cases {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, "http://localhost:9090/health", strings.NewReader(c.body))
+		if err != nil {
+			t.Errorf("%v: creating request: %v", idx, err)
+		}
+		if c.header != "" {
+			r.Header.Add("X-ERIGON-HEALTHCHECK", c.header)
+		}
+
+		apis := []rpc.API{{Service: &adminApiStub{peers: peers, error: c.adminApiError}}}
+		ProcessHealthcheckIfNeeded(w, r, apis, Config{})
+
+		result := w.Result()
+		if result.StatusCode != c.expectedStatusCode {
+			t.Errorf("%v: expected status code: %v, but got: %v", idx, c.expectedStatusCode, result.StatusCode)
+		}
+		body, err := decodeStatuses(result.Body)
+		if err != nil {
+			t.Errorf("%v: unmarshalling the response body: %s", idx, err)
+		}
+		result.Body.Close()
+		for k, v := range c.expectedBody {
+			if val := body[k]; val != v {
+				t.Errorf("%v: expected the response body key: %s to be: %s, but it was: %s", idx, k, v, val)
+			}
+		}
+		if body[minPeerCount] != "DISABLED" {
+			t.Errorf("%v: expected the total peer count not to be checked, got: %s", idx, body[minPeerCount])
+		}
+	}
+}
+
+func TestProcessHealthcheckIfNeeded_PathAndHead(t *testing.T) {
+	apis := []rpc.API{{Service: &netApiStub{response: hexutil.Uint(2)}}}
+	cases := []struct {
+		cfg                Config
+		method             string
+		path               string
+		header             string
+		handled            bool
+		expectedStatusCode int
+		emptyBody          bool
+	}{
+		// 0 - default path
+		{method: http.MethodGet, path: "/health", header: "min_peer_count1", handled: true, expectedStatusCode: http.StatusOK},
+		// 1 - configured path replaces the default one
+		{cfg: Config{Path: "/healthz"}, method: http.MethodGet, path: "/health", header: "min_peer_count1"},
+		{cfg: Config{Path: "/healthz"}, method: http.MethodGet, path: "/healthz", header: "min_peer_count3", handled: true, expectedStatusCode: http.StatusInternalServerError},
+		// 3 - probes follow the configured path, which is normalized
+		{cfg: Config{Path: "Status/", ReadinessChecks: []string{"min_peer_count3"}}, method: http.MethodGet, path: "/status/readiness", handled: true, expectedStatusCode: http.StatusInternalServerError},
+		{cfg: Config{Path: "/status"}, method: http.MethodGet, path: "/status/liveness", handled: true, expectedStatusCode: http.StatusOK},
+		{cfg: Config{Path: "/status"}, method: http.MethodGet, path: "/health/liveness"},
+		// 6 - HEAD gets only the status code
+		{method: http.MethodHead, path: "/health", header: "min_peer_count3", handled: true, expectedStatusCode: http.StatusInternalServerError, emptyBody: true},
+		{method: http.MethodHead, path: "/health/readiness", handled: true, expectedStatusCode: http.StatusOK, emptyBody: true},
+		// 8 - HEAD without headers runs the default checks
+		{method: http.MethodHead, path: "/health", handled: true, expectedStatusCode: http.StatusOK, emptyBody: true},
+		{cfg: Config{DefaultChecks: []string{"min_peer_count3"}}, method: http.MethodHead, path: "/health", handled: true, expectedStatusCode: http.StatusInternalServerError, emptyBody: true},
+	}
+
+	for idx, c := range cases {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(c.method, "http://localhost:9090"+c.path, nil)
+		if err != nil {
+			t.Errorf("%v: creating request: %v", idx, err)
+		}
+		if c.header != "" {
+			r.Header.Add("X-ERIGON-HEALTHCHECK", c.header)
+		}
+		if handled := ProcessHealthcheckIfNeeded(w, r, apis, c.cfg); handled != c.handled {
+			t.Errorf("%v: expected handled: %v, got: %v", idx, c.handled, handled)
+		}
+		if !c.handled {
+			continue
+		}
+		if w.Code != c.expectedStatusCode {
+			t.Errorf("%v: expected status code: %v, but got: %v", idx, c.expectedStatusCode, w.Code)
+		}
+		if empty := w.Body.Len() == 0; empty != c.emptyBody {
+			t.Errorf("%v: expected empty body: %v, got: %s", idx, c.emptyBody, w.Body.String())
+		}
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 
 	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/rpc"
 )
 
@@ -14,4 +17,17 @@ type NetAPI interface {
 type EthAPI interface {
 	GetBlockByNumber(_ context.Context, number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error)
 	Syncing(ctx context.Context) (interface{}, error)
+	Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi.StateOverrides, blockOverrides *ethapi.BlockOverrides) (hexutil.Bytes, error)
+}
+
+type AdminAPI interface {
+	Peers(ctx context.Context) ([]*p2p.PeerInfo, error)
+}
+
+type ErigonAPI interface {
+	StagesProgress(ctx context.Context) (map[stages.SyncStage]hexutil.Uint64, error)
+}
+
+type TxPoolAPI interface {
+	Status(ctx context.Context) (map[string]hexutil.Uint, error)
 }
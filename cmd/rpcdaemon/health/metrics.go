@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+const metricsCheckTimeout = 5 * time.Second
+
+// RegisterMetrics exposes the basic health checks as gauges on the metrics endpoint, so that alerting doesn't
+// need to probe /health. The gauges are evaluated on scrape. When a value can't be read it's reported as
+// unhealthy: not synced, no peers, infinitely behind.
+func RegisterMetrics(rpcAPI []rpc.API) {
+	a := parseAPI(rpcAPI)
+	netAPI, ethAPI := a.net, a.eth
+
+	metrics.GetOrCreateGauge("erigon_health_synced", func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), metricsCheckTimeout)
+		defer cancel()
+		if ethAPI == nil {
+			return 0
+		}
+		syncing, err := ethAPI.Syncing(ctx)
+		if err != nil || (syncing != nil && syncing != false) {
+			return 0
+		}
+		return 1
+	})
+	metrics.GetOrCreateGauge("erigon_health_peer_count", func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), metricsCheckTimeout)
+		defer cancel()
+		if netAPI == nil {
+			return 0
+		}
+		peers, err := netAPI.PeerCount(ctx)
+		if err != nil {
+			return 0
+		}
+		return float64(peers)
+	})
+	metrics.GetOrCreateGauge("erigon_health_seconds_behind", func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), metricsCheckTimeout)
+		defer cancel()
+		timestamp, err := latestBlockTimestamp(ctx, ethAPI)
+		if err != nil {
+			return math.Inf(1)
+		}
+		return float64(time.Now().Unix() - int64(timestamp))
+	})
+}
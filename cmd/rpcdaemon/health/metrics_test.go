@@ -0,0 +1,36 @@
+package health
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+func TestRegisterMetrics(t *testing.T) {
+	ethAPI := &ethApiStub{
+		blockResult:   map[string]interface{}{"timestamp": hexutil.Uint64(time.Now().Add(-time.Hour).Unix())},
+		syncingResult: false,
+	}
+	RegisterMetrics([]rpc.API{{Service: &netApiStub{response: 7}}, {Service: ethAPI}})
+
+	var buf bytes.Buffer
+	metrics.WritePrometheus(&buf, false)
+	out := buf.String()
+	require.Contains(t, out, "erigon_health_synced 1\n")
+	require.Contains(t, out, "erigon_health_peer_count 7\n")
+	require.Regexp(t, `erigon_health_seconds_behind 36\d\d\n`, out)
+
+	ethAPI.syncingResult = struct{}{}
+	ethAPI.blockResult = map[string]interface{}{}
+	buf.Reset()
+	metrics.WritePrometheus(&buf, false)
+	out = buf.String()
+	require.Contains(t, out, "erigon_health_synced 0\n")
+	require.Contains(t, out, "erigon_health_seconds_behind +Inf\n")
+}
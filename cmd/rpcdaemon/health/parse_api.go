@@ -4,19 +4,31 @@ import (
 	"github.com/ledgerwatch/erigon/rpc"
 )
 
-func parseAPI(api []rpc.API) (netAPI NetAPI, ethAPI EthAPI) {
+func parseAPI(api []rpc.API) (a apis) {
 	for _, rpc := range api {
 		if rpc.Service == nil {
 			continue
 		}
 
 		if netCandidate, ok := rpc.Service.(NetAPI); ok {
-			netAPI = netCandidate
+			a.net = netCandidate
 		}
 
 		if ethCandidate, ok := rpc.Service.(EthAPI); ok {
-			ethAPI = ethCandidate
+			a.eth = ethCandidate
+		}
+
+		if txPoolCandidate, ok := rpc.Service.(TxPoolAPI); ok {
+			a.txPool = txPoolCandidate
+		}
+
+		if erigonCandidate, ok := rpc.Service.(ErigonAPI); ok {
+			a.erigon = erigonCandidate
+		}
+
+		if adminCandidate, ok := rpc.Service.(AdminAPI); ok {
+			a.admin = adminCandidate
 		}
 	}
-	return netAPI, ethAPI
+	return a
 }
@@ -0,0 +1,57 @@
+package health
+
+import (
+	"time"
+)
+
+const (
+	statusHealthy   = "HEALTHY"
+	statusUnhealthy = "UNHEALTHY"
+	statusError     = "ERROR"
+)
+
+// checkResult is the outcome of a single check of a healthcheck request
+type checkResult struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`          // HEALTHY or ERROR
+	Error      string    `json:"error,omitempty"` // why the check failed
+	DurationMs float64   `json:"duration_ms"`
+	Timestamp  time.Time `json:"timestamp"` // when the check was started
+	Cached     bool      `json:"cached,omitempty"`
+	CacheAgeMs float64   `json:"cache_age_ms,omitempty"` // how long ago the cached result was obtained
+}
+
+// report is the response to a healthcheck request: the overall status is HEALTHY if all executed checks passed
+type report struct {
+	Status    string        `json:"status"`
+	Timestamp time.Time     `json:"timestamp"`
+	Checks    []checkResult `json:"checks"`
+}
+
+func newReport() *report {
+	return &report{Status: statusHealthy, Timestamp: time.Now().UTC(), Checks: []checkResult{}}
+}
+
+// add appends the result of a check, the report becomes unhealthy if the check failed
+func (rep *report) add(result checkResult) {
+	if result.Status != statusHealthy {
+		rep.Status = statusUnhealthy
+	}
+	rep.Checks = append(rep.Checks, result)
+}
+
+// runCheck executes a check and records its outcome
+func runCheck(name string, check func() error) checkResult {
+	start := time.Now()
+	err := check()
+	result := checkResult{
+		Name:       name,
+		Status:     statusHealthy,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		Timestamp:  start.UTC(),
+	}
+	if err != nil {
+		result.Status, result.Error = statusError, err.Error()
+	}
+	return result
+}
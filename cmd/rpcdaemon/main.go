@@ -6,6 +6,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/cobra"
 )
@@ -27,7 +28,13 @@ func main() {
 		}
 
 		apiList := commands.APIList(db, borDb, backend, txPool, mining, ff, stateCache, blockReader, agg, txNums, *cfg)
-		if err := cli.StartRpcServer(ctx, *cfg, apiList, nil); err != nil {
+		ethGrpc := commands.NewEthGrpcServer(commands.NewBaseApi(ff, stateCache, blockReader, agg, txNums, cfg.WithDatadir), db)
+		ethGrpc.GetLogsLimits = commands.GetLogsLimits{MaxBlockRange: cfg.GetLogsMaxBlockRange, MaxResults: cfg.GetLogsMaxResults, Timeout: cfg.GetLogsTimeout}
+		if ethGrpc.MethodTimeouts, err = rpc.ParseMethodTimeouts(cfg.RpcMethodTimeouts); err != nil {
+			log.Error(err.Error())
+			return nil
+		}
+		if err := cli.StartRpcServer(ctx, *cfg, apiList, nil, commands.NewResponseCachePolicy(db), ethGrpc.Register); err != nil {
 			log.Error(err.Error())
 			return nil
 		}
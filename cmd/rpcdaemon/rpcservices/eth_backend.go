@@ -13,11 +13,13 @@ import (
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cmd/sentry/sentryadmin"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/ethdb/privateapi"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/services"
 	"github.com/ledgerwatch/log/v3"
 	"google.golang.org/grpc"
@@ -27,6 +29,7 @@ import (
 
 type RemoteBackend struct {
 	remoteEthBackend remote.ETHBACKENDClient
+	sentryAdmin      sentryadmin.SentryAdminClient
 	log              log.Logger
 	version          gointerfaces.Version
 	db               kv.RoDB
@@ -43,6 +46,13 @@ func NewRemoteBackend(client remote.ETHBACKENDClient, db kv.RoDB, blockReader se
 	}
 }
 
+// WithSentryAdmin sets the client managing the peers of the sentries, behind AddPeer, RemovePeer, AddTrustedPeer and
+// RemoveTrustedPeer
+func (back *RemoteBackend) WithSentryAdmin(client sentryadmin.SentryAdminClient) *RemoteBackend {
+	back.sentryAdmin = client
+	return back
+}
+
 func (back *RemoteBackend) EnsureVersionCompatibility() bool {
 	versionReply, err := back.remoteEthBackend.Version(context.Background(), &emptypb.Empty{}, grpc.WaitForReady(true))
 	if err != nil {
@@ -308,3 +318,43 @@ func (back *RemoteBackend) PendingBlock(ctx context.Context) (*types.Block, erro
 
 	return &block, nil
 }
+
+func (back *RemoteBackend) AddPeer(ctx context.Context, url string) error {
+	if back.sentryAdmin == nil {
+		return rpchelper.ErrPeerManagementUnavailable
+	}
+	if _, err := back.sentryAdmin.AddPeer(ctx, &sentryadmin.PeerRequest{Url: url}); err != nil {
+		return fmt.Errorf("SentryAdminClient.AddPeer() error: %w", err)
+	}
+	return nil
+}
+
+func (back *RemoteBackend) RemovePeer(ctx context.Context, url string) error {
+	if back.sentryAdmin == nil {
+		return rpchelper.ErrPeerManagementUnavailable
+	}
+	if _, err := back.sentryAdmin.RemovePeer(ctx, &sentryadmin.PeerRequest{Url: url}); err != nil {
+		return fmt.Errorf("SentryAdminClient.RemovePeer() error: %w", err)
+	}
+	return nil
+}
+
+func (back *RemoteBackend) AddTrustedPeer(ctx context.Context, url string) error {
+	if back.sentryAdmin == nil {
+		return rpchelper.ErrPeerManagementUnavailable
+	}
+	if _, err := back.sentryAdmin.AddTrustedPeer(ctx, &sentryadmin.PeerRequest{Url: url}); err != nil {
+		return fmt.Errorf("SentryAdminClient.AddTrustedPeer() error: %w", err)
+	}
+	return nil
+}
+
+func (back *RemoteBackend) RemoveTrustedPeer(ctx context.Context, url string) error {
+	if back.sentryAdmin == nil {
+		return rpchelper.ErrPeerManagementUnavailable
+	}
+	if _, err := back.sentryAdmin.RemoveTrustedPeer(ctx, &sentryadmin.PeerRequest{Url: url}); err != nil {
+		return fmt.Errorf("SentryAdminClient.RemoveTrustedPeer() error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package rpcservices
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/log/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	// failoverCheckInterval is the time between the health checks of the backends
+	failoverCheckInterval = 2 * time.Second
+	// failoverCheckTimeout is the time a backend has to answer a health check
+	failoverCheckTimeout = time.Second
+)
+
+// failoverIdempotent are the methods whose calls are retried on the next healthy backend when the current one is
+// unavailable. The others change the state of the backend, or depend on it like the payloads being built.
+var failoverIdempotent = map[string]bool{
+	"/remote.ETHBACKEND/Block":           true,
+	"/remote.ETHBACKEND/ClientVersion":   true,
+	"/remote.ETHBACKEND/Etherbase":       true,
+	"/remote.ETHBACKEND/NetPeerCount":    true,
+	"/remote.ETHBACKEND/NetVersion":      true,
+	"/remote.ETHBACKEND/NodeInfo":        true,
+	"/remote.ETHBACKEND/Peers":           true,
+	"/remote.ETHBACKEND/PendingBlock":    true,
+	"/remote.ETHBACKEND/ProtocolVersion": true,
+	"/remote.ETHBACKEND/TxnLookup":       true,
+	"/remote.ETHBACKEND/Version":         true,
+	"/remote.KV/Snapshots":               true,
+	"/remote.KV/Version":                 true,
+	"/txpool.Mining/GetWork":             true,
+	"/txpool.Mining/HashRate":            true,
+	"/txpool.Mining/Mining":              true,
+	"/txpool.Mining/Version":             true,
+	"/txpool.Txpool/All":                 true,
+	"/txpool.Txpool/FindUnknown":         true,
+	"/txpool.Txpool/Nonce":               true,
+	"/txpool.Txpool/Pending":             true,
+	"/txpool.Txpool/Status":              true,
+	"/txpool.Txpool/Transactions":        true,
+	"/txpool.Txpool/Version":             true,
+}
+
+// FailoverConn is a connection to several erigon backends serving the same chain through their private API. The calls
+// and streams go to the first healthy backend, in the order they're given, the following ones being the fallbacks of
+// the previous ones. A backend is healthy if it answers the version of its ETHBACKEND service, it's checked every few
+// seconds and as soon as a call fails because it's unavailable. If no backend is healthy, the last used one is kept.
+// The idempotent calls failing because the backend is unavailable are retried once on the next healthy one. A stream
+// stays on the backend which opened it, Switched tells when to open it again.
+type FailoverConn struct {
+	addrs []string
+	conns []*grpc.ClientConn
+
+	mu       sync.Mutex
+	healthy  []bool
+	current  int
+	switched chan struct{} // closed when switching backend, then replaced
+}
+
+// NewFailoverConn returns a connection to the backends at addrs, connected by conns, whose health is checked until
+// ctx is done
+func NewFailoverConn(ctx context.Context, addrs []string, conns []*grpc.ClientConn) *FailoverConn {
+	c := &FailoverConn{addrs: addrs, conns: conns, healthy: make([]bool, len(conns)), switched: make(chan struct{})}
+	for i := range c.healthy {
+		c.healthy[i] = true // until checked, the first backend being used
+	}
+	go c.checkLoop(ctx)
+	return c
+}
+
+func (c *FailoverConn) checkLoop(ctx context.Context) {
+	ticker := time.NewTicker(failoverCheckInterval)
+	defer ticker.Stop()
+	for {
+		c.checkAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkAll checks the health of all the backends, concurrently
+func (c *FailoverConn) checkAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i, conn := range c.conns {
+		wg.Add(1)
+		go func(i int, conn *grpc.ClientConn) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, failoverCheckTimeout)
+			defer cancel()
+			_, err := remote.NewETHBACKENDClient(conn).Version(checkCtx, &emptypb.Empty{})
+			c.setHealthy(i, err == nil)
+		}(i, conn)
+	}
+	wg.Wait()
+}
+
+// setHealthy records the health of the backend i, switching to the first healthy backend if it changes
+func (c *FailoverConn) setHealthy(i int, healthy bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.healthy[i] == healthy {
+		return
+	}
+	c.healthy[i] = healthy
+	if healthy {
+		log.Info("[rpc] backend is healthy", "addr", c.addrs[i])
+	} else {
+		log.Warn("[rpc] backend is unhealthy", "addr", c.addrs[i])
+	}
+	for j, h := range c.healthy {
+		if h {
+			if j != c.current {
+				log.Info("[rpc] switching backend", "from", c.addrs[c.current], "to", c.addrs[j])
+				c.current = j
+				close(c.switched)
+				c.switched = make(chan struct{})
+			}
+			return
+		}
+	}
+}
+
+// pick returns the backend to use
+func (c *FailoverConn) pick() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current
+}
+
+// Switched returns a channel closed when the connection switches to another backend
+func (c *FailoverConn) Switched() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.switched
+}
+
+// failed marks the backend i as unhealthy if err shows it's unavailable
+func (c *FailoverConn) failed(i int, err error) {
+	if status.Code(err) == codes.Unavailable {
+		c.setHealthy(i, false)
+	}
+}
+
+// Invoke implements grpc.ClientConnInterface
+func (c *FailoverConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	i := c.pick()
+	err := c.conns[i].Invoke(ctx, method, args, reply, opts...)
+	if err == nil {
+		return nil
+	}
+	c.failed(i, err)
+	if status.Code(err) != codes.Unavailable || !failoverIdempotent[method] {
+		return err
+	}
+	next := c.pick()
+	if next == i {
+		return err // no other healthy backend
+	}
+	if err = c.conns[next].Invoke(ctx, method, args, reply, opts...); err != nil {
+		c.failed(next, err)
+	}
+	return err
+}
+
+// NewStream implements grpc.ClientConnInterface
+func (c *FailoverConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	i := c.pick()
+	stream, err := c.conns[i].NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		c.failed(i, err)
+	}
+	return stream, err
+}
+
+// Close closes the connections to all the backends
+func (c *FailoverConn) Close() error {
+	var firstErr error
+	for _, conn := range c.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// FailoverCache is the state cache of a FailoverConn. Its views are the ones of the database of a backend, which the
+// others don't share, so it's reset when the connection switches backend.
+type FailoverCache struct {
+	cfg kvcache.CoherentConfig
+
+	mu    sync.RWMutex
+	cache kvcache.Cache
+}
+
+// NewFailoverCache returns the failover cache of cache, a coherent one being replaced by a new one with cfg on reset
+func NewFailoverCache(cache kvcache.Cache, cfg kvcache.CoherentConfig) *FailoverCache {
+	return &FailoverCache{cfg: cfg, cache: cache}
+}
+
+func (c *FailoverCache) get() kvcache.Cache {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cache
+}
+
+func (c *FailoverCache) View(ctx context.Context, tx kv.Tx) (kvcache.CacheView, error) {
+	return c.get().View(ctx, tx)
+}
+
+func (c *FailoverCache) OnNewBlock(sc *remote.StateChangeBatch) {
+	c.get().OnNewBlock(sc)
+}
+
+func (c *FailoverCache) Len() int {
+	return c.get().Len()
+}
+
+// Reset empties the cache, the views already taken keep reading the previous one
+func (c *FailoverCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.cache.(*kvcache.Coherent); ok {
+		c.cache = kvcache.New(c.cfg)
+	}
+}
@@ -0,0 +1,120 @@
+package rpcservices
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// versionServer answers its version with its index as patch version
+type versionServer struct {
+	remote.UnimplementedETHBACKENDServer
+	index uint32
+}
+
+func (s *versionServer) Version(context.Context, *emptypb.Empty) (*types.VersionReply, error) {
+	return &types.VersionReply{Patch: s.index}, nil
+}
+
+// startBackends starts n backends answering their version, and returns their servers and the failover connection to them
+func startBackends(t *testing.T, ctx context.Context, n int) ([]*grpc.Server, *FailoverConn) {
+	var servers []*grpc.Server
+	var conns []*grpc.ClientConn
+	var addrs []string
+	for i := 0; i < n; i++ {
+		listener := bufconn.Listen(1024 * 1024)
+		server := grpc.NewServer()
+		remote.RegisterETHBACKENDServer(server, &versionServer{index: uint32(i)})
+		go server.Serve(listener) //nolint:errcheck
+		t.Cleanup(server.Stop)
+		servers = append(servers, server)
+		conn, err := grpc.DialContext(ctx, "", grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }))
+		require.NoError(t, err)
+		conns = append(conns, conn)
+		addrs = append(addrs, fmt.Sprintf("backend%d", i))
+	}
+	conn := NewFailoverConn(ctx, addrs, conns)
+	t.Cleanup(func() { conn.Close() })
+	return servers, conn
+}
+
+func TestFailoverConn(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	servers, conn := startBackends(t, ctx, 2)
+	client := remote.NewETHBACKENDClient(conn)
+
+	reply, err := client.Version(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), reply.Patch, "the first backend is used while it's healthy")
+
+	// the call failing on the stopped backend switches to the next one, where it's retried
+	switched := conn.Switched()
+	servers[0].Stop()
+	reply, err = client.Version(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), reply.Patch)
+	select {
+	case <-switched:
+	default:
+		t.Fatal("the switch wasn't notified")
+	}
+	require.Equal(t, 1, conn.pick())
+
+	// none is healthy, the last used one is kept until a check passes
+	conn.setHealthy(1, false)
+	require.Equal(t, 1, conn.pick())
+	conn.checkAll(ctx)
+	require.Equal(t, 1, conn.pick())
+}
+
+func TestFailoverConnNoRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	servers, conn := startBackends(t, ctx, 2)
+	client := remote.NewETHBACKENDClient(conn)
+
+	// the payloads are built by a backend, getting one isn't retried on another
+	servers[0].Stop()
+	_, err := client.EngineGetPayloadV1(ctx, &remote.EngineGetPayloadRequest{PayloadId: 1})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, 1, conn.pick())
+	_, err = client.EngineGetPayloadV1(ctx, &remote.EngineGetPayloadRequest{PayloadId: 1})
+	require.Equal(t, codes.Unimplemented, status.Code(err), "the next call goes to the healthy backend")
+}
+
+func TestFailoverCache(t *testing.T) {
+	coherent := kvcache.New(kvcache.DefaultCoherentConfig)
+	cache := NewFailoverCache(coherent, kvcache.DefaultCoherentConfig)
+	cache.OnNewBlock(&remote.StateChangeBatch{DatabaseViewID: 1, ChangeBatch: []*remote.StateChange{{
+		Direction: remote.Direction_FORWARD,
+		Changes:   []*remote.AccountChange{{Action: remote.Action_UPSERT, Address: gointerfaces.ConvertAddressToH160(common.HexToAddress("0x1")), Data: []byte{1}}},
+	}}})
+	require.Equal(t, 1, cache.Len())
+
+	// the state of the previous backend is dropped
+	cache.Reset()
+	require.Equal(t, 0, cache.Len())
+	require.NotSame(t, coherent, cache.get())
+
+	// a dummy cache keeps nothing, it's kept
+	dummy := kvcache.NewDummy()
+	cache = NewFailoverCache(dummy, kvcache.DefaultCoherentConfig)
+	cache.Reset()
+	require.Same(t, dummy, cache.get())
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/forkid"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
 	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
 )
 
 func readAndValidatePeerStatusMessage(
@@ -71,13 +72,30 @@ func checkPeerStatusCompatibility(
 		return fmt.Errorf("genesis hash does not match: theirs %x, ours %x", reply.Genesis, genesisHash)
 	}
 
-	forks := make([]uint64, len(status.ForkData.Forks))
-	// copy because forkid.NewFilterFromForks will write into this slice
-	copy(forks, status.ForkData.Forks)
-	forkFilter := forkid.NewFilterFromForks(forks, genesisHash, status.MaxBlock)
-	if err := forkFilter(reply.ForkID); err != nil {
+	if err := makeForkFilter(status)(reply.ForkID); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// checkNodeForkID returns an error if a node found by the discovery doesn't announce
+// in its ENR a fork ID compatible with ours, the handshake with it would fail
+func checkNodeForkID(node *enode.Node, status *proto_sentry.StatusData) error {
+	forkID, err := eth.LoadENRForkID(node.Record())
+	if err != nil {
+		return err
+	}
+	if forkID == nil {
+		return fmt.Errorf("no eth entry in the ENR of node %s", node.ID())
+	}
+	return makeForkFilter(status)(*forkID)
+}
+
+func makeForkFilter(status *proto_sentry.StatusData) forkid.Filter {
+	genesisHash := gointerfaces.ConvertH256ToHash(status.ForkData.Genesis)
+	forks := make([]uint64, len(status.ForkData.Forks))
+	// copy because forkid.NewFilterFromForks will write into this slice
+	copy(forks, status.ForkData.Forks)
+	return forkid.NewFilterFromForks(forks, genesisHash, status.MaxBlock)
+}
@@ -9,9 +9,13 @@ import (
 	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/core/forkid"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/enr"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCheckPeerStatusCompatibility(t *testing.T) {
@@ -76,3 +80,42 @@ func TestCheckPeerStatusCompatibility(t *testing.T) {
 		assert.ErrorIs(t, err, forkid.ErrLocalIncompatibleOrStale)
 	})
 }
+
+func TestCheckNodeForkID(t *testing.T) {
+	forks := forkid.GatherForks(params.MainnetChainConfig)
+	status := proto_sentry.StatusData{
+		ForkData: &proto_sentry.Forks{
+			Genesis: gointerfaces.ConvertHashToH256(params.MainnetGenesisHash),
+			Forks:   forks,
+		},
+		MaxBlock: 0,
+	}
+	newNode := func(entries ...enr.Entry) *enode.Node {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		var r enr.Record
+		for _, e := range entries {
+			r.Set(e)
+		}
+		require.NoError(t, enode.SignV4(&r, key))
+		node, err := enode.New(enode.ValidSchemes, &r)
+		require.NoError(t, err)
+		return node
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		node := newNode(eth.CurrentENREntryFromForks(forks, params.MainnetGenesisHash, 0))
+		assert.Nil(t, checkNodeForkID(node, &status))
+	})
+	t.Run("fork mismatch", func(t *testing.T) {
+		goerliForks := forkid.GatherForks(params.GoerliChainConfig)
+		node := newNode(eth.CurrentENREntryFromForks(goerliForks, params.GoerliGenesisHash, 0))
+		err := checkNodeForkID(node, &status)
+		assert.ErrorIs(t, err, forkid.ErrLocalIncompatibleOrStale)
+	})
+	t.Run("no eth entry", func(t *testing.T) {
+		err := checkNodeForkID(newNode(), &status)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "no eth entry")
+	})
+}
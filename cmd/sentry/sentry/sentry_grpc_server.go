@@ -23,6 +23,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/grpcutil"
 	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
 	proto_types "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon/cmd/sentry/sentryadmin"
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/debug"
@@ -45,7 +46,8 @@ const (
 	// handshakeTimeout is the maximum allowed time for the `eth` handshake to
 	// complete before dropping the connection.= as malicious.
 	handshakeTimeout  = 5 * time.Second
-	maxPermitsPerPeer = 4 // How many outstanding requests per peer we may have
+	maxPermitsPerPeer = 4              // How many outstanding requests per peer we may have
+	knownPeersMaxAge  = 72 * time.Hour // Peers of the previous runs connected within this time are dialed at startup
 )
 
 // PeerInfo collects various extra bits of information about the peer,
@@ -55,6 +57,7 @@ type PeerInfo struct {
 	lock      sync.RWMutex
 	deadlines []time.Time // Request deadlines
 	height    uint64
+	useful    uint64 // Number of block headers and bodies responses received
 	rw        p2p.MsgReadWriter
 
 	removed    chan struct{} // close this channel on remove
@@ -121,6 +124,11 @@ func (pi *PeerInfo) SetIncreasedHeight(newHeight uint64) {
 	}
 }
 
+// Useful returns the number of block headers and bodies responses received from the peer
+func (pi *PeerInfo) Useful() uint64 {
+	return atomic.LoadUint64(&pi.useful)
+}
+
 // ClearDeadlines goes through the deadlines of
 // given peers and removes the ones that have passed
 // Optionally, it also clears one extra deadline - this is used when response is received
@@ -459,6 +467,9 @@ func runPeer(
 		}
 		msg.Discard()
 		peerInfo.ClearDeadlines(time.Now(), givePermit)
+		if givePermit {
+			atomic.AddUint64(&peerInfo.useful, 1)
+		}
 	}
 }
 
@@ -477,6 +488,7 @@ func grpcSentryServer(ctx context.Context, sentryAddr string, ss *GrpcServer, he
 	}
 	grpcServer := grpcutil.NewServer(100, nil)
 	proto_sentry.RegisterSentryServer(grpcServer, ss)
+	sentryadmin.RegisterSentryAdminServer(grpcServer, ss)
 	var healthServer *health.Server
 	if healthCheck {
 		healthServer = health.NewServer()
@@ -532,6 +544,7 @@ func NewGrpcServer(ctx context.Context, dialCandidates enode.Iterator, readNodeI
 				return fmt.Errorf("handshake to peer %s: %w", printablePeerID, err)
 			}
 			log.Trace(fmt.Sprintf("[%s] Received status message OK", printablePeerID), "name", peer.Name())
+			ss.saveKnownPeer(peer, 0)
 
 			err = runPeer(
 				ctx,
@@ -543,6 +556,7 @@ func NewGrpcServer(ctx context.Context, dialCandidates enode.Iterator, readNodeI
 				ss.hasSubscribers,
 			) // runPeer never returns a nil error
 			log.Trace(fmt.Sprintf("[%s] Error while running peer: %v", printablePeerID, err))
+			ss.saveKnownPeer(peer, peerInfo.Useful())
 			ss.sendGonePeerToClients(gointerfaces.ConvertHashToH512(peerID))
 			return nil
 		},
@@ -578,6 +592,7 @@ func Sentry(ctx context.Context, dirs datadir.Dirs, sentryAddr string, discovery
 
 type GrpcServer struct {
 	proto_sentry.UnimplementedSentryServer
+	sentryadmin.UnimplementedSentryAdminServer
 	ctx                  context.Context
 	Protocol             p2p.Protocol
 	discoveryDNS         []string
@@ -591,6 +606,7 @@ type GrpcServer struct {
 	messageStreamsLock   sync.RWMutex
 	peersStreams         *PeersStreams
 	p2p                  *p2p.Config
+	knownPeersLock       sync.Mutex // serialises the updates of the known peers in the node database
 }
 
 func (ss *GrpcServer) rangePeers(f func(peerInfo *PeerInfo) bool) {
@@ -843,7 +859,11 @@ func (ss *GrpcServer) SetStatus(ctx context.Context, statusData *proto_sentry.St
 			}
 		}
 
-		srv, err := makeP2PServer(*ss.p2p, genesisHash, ss.Protocol)
+		// the nodes found by the V5 discovery are dialed only if they are on our chain
+		p2pConfig := *ss.p2p
+		p2pConfig.DiscoveryV5Filter = ss.acceptDialCandidate
+		p2pConfig.KnownPeersMaxAge = knownPeersMaxAge
+		srv, err := makeP2PServer(p2pConfig, genesisHash, ss.Protocol)
 		if err != nil {
 			return reply, err
 		}
@@ -931,6 +951,61 @@ func (ss *GrpcServer) PeerById(_ context.Context, req *proto_sentry.PeerByIdRequ
 	return &proto_sentry.PeerByIdReply{Peer: rpcPeer}, nil
 }
 
+// AddPeer adds a static peer, which is dialed and reconnected whenever it disconnects
+func (ss *GrpcServer) AddPeer(_ context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error) {
+	srv, node, err := ss.adminPeer(req)
+	if err != nil {
+		return nil, err
+	}
+	srv.AddPeer(node)
+	return &emptypb.Empty{}, nil
+}
+
+// RemovePeer removes a static peer, and disconnects it
+func (ss *GrpcServer) RemovePeer(_ context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error) {
+	srv, node, err := ss.adminPeer(req)
+	if err != nil {
+		return nil, err
+	}
+	srv.RemovePeer(node)
+	return &emptypb.Empty{}, nil
+}
+
+// AddTrustedPeer adds a trusted peer, which is always allowed to connect, even above the maximum number of peers
+func (ss *GrpcServer) AddTrustedPeer(_ context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error) {
+	srv, node, err := ss.adminPeer(req)
+	if err != nil {
+		return nil, err
+	}
+	srv.AddTrustedPeer(node)
+	return &emptypb.Empty{}, nil
+}
+
+// RemoveTrustedPeer removes a trusted peer, without disconnecting it
+func (ss *GrpcServer) RemoveTrustedPeer(_ context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error) {
+	srv, node, err := ss.adminPeer(req)
+	if err != nil {
+		return nil, err
+	}
+	srv.RemoveTrustedPeer(node)
+	return &emptypb.Empty{}, nil
+}
+
+// adminPeer returns the p2p server and the node of the enode URL of req
+func (ss *GrpcServer) adminPeer(req *sentryadmin.PeerRequest) (*p2p.Server, *enode.Node, error) {
+	node, err := enode.Parse(enode.ValidSchemes, req.Url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid enode: %w", err)
+	}
+	ss.lock.RLock()
+	srv := ss.P2pServer
+	ss.lock.RUnlock()
+	if srv == nil {
+		return nil, nil, errors.New("p2p server was not started")
+	}
+	return srv, node, nil
+}
+
 // setupDiscovery creates the node discovery source for the `eth` and `snap`
 // protocols.
 func setupDiscovery(urls []string) (enode.Iterator, error) {
@@ -941,6 +1016,38 @@ func setupDiscovery(urls []string) (enode.Iterator, error) {
 	return client.NewIterator(urls...)
 }
 
+// saveKnownPeer stores the peer, which passed the handshake, in the node database with the number of useful
+// messages it sent, so that it's dialed again after a restart. The inbound peers aren't, their address being the one
+// they dialed from.
+func (ss *GrpcServer) saveKnownPeer(peer *p2p.Peer, useful uint64) {
+	ss.lock.RLock()
+	srv := ss.P2pServer
+	ss.lock.RUnlock()
+	if srv == nil || peer.Inbound() {
+		return
+	}
+	node := peer.Node()
+	db := srv.LocalNode().Database()
+	ss.knownPeersLock.Lock()
+	defer ss.knownPeersLock.Unlock()
+	stats, _ := db.PeerStats(node.ID())
+	stats.LastSeen = time.Now()
+	stats.Useful += useful
+	if err := db.UpdatePeer(node, stats); err != nil {
+		log.Warn("Failed to store known peer", "id", node.ID(), "err", err)
+	}
+}
+
+// acceptDialCandidate returns whether a node found by the V5 discovery announces a fork ID
+// compatible with ours, the other nodes aren't dialed
+func (ss *GrpcServer) acceptDialCandidate(node *enode.Node) bool {
+	status := ss.GetStatus()
+	if status == nil {
+		return false
+	}
+	return checkNodeForkID(node, status) == nil
+}
+
 func (ss *GrpcServer) GetStatus() *proto_sentry.StatusData {
 	ss.lock.RLock()
 	defer ss.lock.RUnlock()
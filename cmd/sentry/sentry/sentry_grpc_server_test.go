@@ -11,13 +11,16 @@ import (
 	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/cmd/sentry/sentryadmin"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/forkid"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/log/v3"
 	"github.com/stretchr/testify/require"
 )
 
@@ -176,3 +179,41 @@ func TestSentryServerImpl_SetStatusInitPanic(t *testing.T) {
 		t.Fatalf("error expected")
 	}
 }
+
+func TestSentryAdmin(t *testing.T) {
+	ctx := context.Background()
+	newServer := func() *p2p.Server {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		srv := &p2p.Server{Config: p2p.Config{PrivateKey: key, MaxPeers: 10, MaxPendingPeers: 10, ListenAddr: "127.0.0.1:0", NoDiscovery: true, Log: log.New()}}
+		require.NoError(t, srv.Start(ctx))
+		t.Cleanup(srv.Stop)
+		return srv
+	}
+	remote := newServer()
+	req := &sentryadmin.PeerRequest{Url: remote.Self().URLv4()}
+
+	ss := &GrpcServer{p2p: &p2p.Config{}}
+	_, err := ss.AddPeer(ctx, req)
+	require.EqualError(t, err, "p2p server was not started")
+	ss.P2pServer = newServer()
+	_, err = ss.AddPeer(ctx, &sentryadmin.PeerRequest{Url: "enode://invalid"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid enode")
+
+	// the static peer is dialed, made trusted, then disconnected
+	_, err = ss.AddPeer(ctx, req)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return ss.P2pServer.PeerCount() == 1 }, 10*time.Second, 10*time.Millisecond)
+	_, err = ss.AddTrustedPeer(ctx, req)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		peers := ss.P2pServer.PeersInfo()
+		return len(peers) == 1 && peers[0].Network.Trusted
+	}, 10*time.Second, 10*time.Millisecond)
+	_, err = ss.RemoveTrustedPeer(ctx, req)
+	require.NoError(t, err)
+	_, err = ss.RemovePeer(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 0, ss.P2pServer.PeerCount())
+}
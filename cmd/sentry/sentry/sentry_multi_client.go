@@ -21,6 +21,7 @@ import (
 	proto_types "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/cmd/hack/tool"
+	"github.com/ledgerwatch/erigon/cmd/sentry/sentryadmin"
 	"github.com/ledgerwatch/log/v3"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/backoff"
@@ -758,7 +759,16 @@ func (cs *MultiClient) makeStatusData() *proto_sentry.StatusData {
 	}
 }
 
-func GrpcClient(ctx context.Context, sentryAddr string) (*direct.SentryClientRemote, error) {
+// GrpcClient returns the client of the sentry and the one managing its peers, which share a connection
+func GrpcClient(ctx context.Context, sentryAddr string) (*direct.SentryClientRemote, sentryadmin.SentryAdminClient, error) {
+	conn, err := grpcConn(ctx, sentryAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return direct.NewSentryClientRemote(proto_sentry.NewSentryClient(conn)), sentryadmin.NewSentryAdminClient(conn), nil
+}
+
+func grpcConn(ctx context.Context, sentryAddr string) (*grpc.ClientConn, error) {
 	// creating grpc client connection
 	var dialOpts []grpc.DialOption
 
@@ -776,5 +786,5 @@ func GrpcClient(ctx context.Context, sentryAddr string) (*direct.SentryClientRem
 	if err != nil {
 		return nil, fmt.Errorf("creating client connection to sentry P2P: %w", err)
 	}
-	return direct.NewSentryClientRemote(proto_sentry.NewSentryClient(conn)), nil
+	return conn, nil
 }
@@ -0,0 +1,35 @@
+package sentryadmin
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// DirectClient is a SentryAdminClient calling a server of the same process
+type DirectClient struct {
+	server SentryAdminServer
+}
+
+var _ SentryAdminClient = (*DirectClient)(nil) // compile-time interface check
+
+func NewDirectClient(server SentryAdminServer) *DirectClient {
+	return &DirectClient{server: server}
+}
+
+func (c *DirectClient) AddPeer(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return c.server.AddPeer(ctx, in)
+}
+
+func (c *DirectClient) RemovePeer(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return c.server.RemovePeer(ctx, in)
+}
+
+func (c *DirectClient) AddTrustedPeer(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return c.server.AddTrustedPeer(ctx, in)
+}
+
+func (c *DirectClient) RemoveTrustedPeer(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return c.server.RemoveTrustedPeer(ctx, in)
+}
@@ -0,0 +1,5 @@
+// Package sentryadmin is the gRPC service managing the peers of the sentries at runtime, behind the admin_addPeer,
+// admin_removePeer, admin_addTrustedPeer and admin_removeTrustedPeer methods of the rpcdaemon
+package sentryadmin
+
+//go:generate protoc --proto_path=.. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative sentryadmin/sentryadmin.proto
@@ -29,6 +29,7 @@ import (
 	"strings"
 	"text/tabwriter"
 	"text/template"
+	"time"
 
 	"github.com/c2h5oh/datasize"
 	"github.com/ledgerwatch/erigon-lib/common/cmp"
@@ -60,6 +61,7 @@ import (
 	"github.com/ledgerwatch/erigon/p2p/nat"
 	"github.com/ledgerwatch/erigon/p2p/netutil"
 	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rpc"
 )
 
 func init() {
@@ -226,6 +228,10 @@ var (
 		Name:  "watch-the-burn",
 		Usage: "Enable WatchTheBurn stage to keep track of ETH issuance",
 	}
+	EnabledChainStats = cli.BoolFlag{
+		Name:  "chain-stats",
+		Usage: "Enable ChainStats stage to keep the daily and epoch rollups served by erigon_chainStats",
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -340,6 +346,20 @@ var (
 		Value: "",
 	}
 
+	HTTPJWTSecretFlag = cli.StringFlag{
+		Name:  "http.jwtsecret",
+		Usage: "Path to the hex encoded secret of the JWTs required on the HTTP-RPC and WebSocket endpoints, generated if the file doesn't exist. Distinct from the Engine API one, no JWT is required if not set",
+	}
+
+	HTTPTLSCertFlag = cli.StringFlag{
+		Name:  "http.tls.cert",
+		Usage: "Path to the PEM certificate served on the HTTP-RPC and WebSocket endpoints, reloaded when it changes. TLS is enabled if it's set with --http.tls.key",
+	}
+	HTTPTLSKeyFlag = cli.StringFlag{
+		Name:  "http.tls.key",
+		Usage: "Path to the PEM private key of --http.tls.cert",
+	}
+
 	HttpCompressionFlag = cli.BoolFlag{
 		Name:  "http.compression",
 		Usage: "Enable compression over HTTP-RPC",
@@ -348,6 +368,32 @@ var (
 		Name:  "ws.compression",
 		Usage: "Enable compression over WebSocket",
 	}
+	WsCompressionLevelFlag = cli.IntFlag{
+		Name:  "ws.compression.level",
+		Usage: "Compression level of the WebSocket messages, from -2 (huffman only) to 9 (best compression). Higher levels use more memory per connection",
+		Value: rpc.DefaultWsCompressionLevel,
+	}
+	WsMessageSizeLimitFlag = cli.Int64Flag{
+		Name:  "ws.message.limit",
+		Usage: "Maximum size in bytes of the WebSocket requests, once decompressed",
+		Value: 32 * 1024 * 1024,
+	}
+	WsConnsLimitFlag = cli.IntFlag{
+		Name:  "ws.conns.limit",
+		Usage: "Maximum number of concurrent WebSocket connections (0 = no limit)",
+	}
+	WsConnsPerIPLimitFlag = cli.IntFlag{
+		Name:  "ws.conns.perip.limit",
+		Usage: "Maximum number of concurrent WebSocket connections from the same IP (0 = no limit)",
+	}
+	WsSubscriptionsLimitFlag = cli.IntFlag{
+		Name:  "ws.subscriptions.limit",
+		Usage: "Maximum number of active subscriptions of a WebSocket connection (0 = no limit)",
+	}
+	WsIdleTimeoutFlag = cli.DurationFlag{
+		Name:  "ws.idle.timeout",
+		Usage: "The WebSocket connections without calls nor subscriptions for this long are closed (0 = never)",
+	}
 	HTTPCORSDomainFlag = cli.StringFlag{
 		Name:  "http.corsdomain",
 		Usage: "Comma separated list of domains from which to accept cross origin requests (browser enforced)",
@@ -358,6 +404,10 @@ var (
 		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
 		Value: strings.Join(nodecfg.DefaultConfig.HTTPVirtualHosts, ","),
 	}
+	HTTPVirtualHostRulesFlag = cli.StringFlag{
+		Name:  "http.vhostrules",
+		Usage: "JSON file mapping virtual hostnames to their CORS domains and methods and their API namespaces, for example {\"rpc.example.com\": {\"corsDomains\": [\"*\"], \"api\": [\"eth\", \"net\"]}}. The hostnames with a rule are always accepted",
+	}
 	AuthRpcVirtualHostsFlag = cli.StringFlag{
 		Name:  "authrpc.vhosts",
 		Usage: "Comma separated list of virtual hostnames from which to accept Engine API requests (server enforced). Accepts '*' wildcard.",
@@ -370,9 +420,113 @@ var (
 	}
 	RpcBatchConcurrencyFlag = cli.UintFlag{
 		Name:  "rpc.batch.concurrency",
-		Usage: "Does limit amount of goroutines to process 1 batch request. Means 1 bach request can't overload server. 1 batch still can have unlimited amount of request",
+		Usage: "Does limit amount of goroutines to process 1 batch request. Means 1 bach request can't overload server. The calls changing the node's state are processed in order by 1 goroutine",
 		Value: 2,
 	}
+	RpcBatchLimitFlag = cli.IntFlag{
+		Name:  "rpc.batch.limit",
+		Usage: "Maximum number of requests in a batch, 0 for no limit",
+		Value: 1000,
+	}
+	RpcBatchResponseMaxSizeFlag = cli.IntFlag{
+		Name:  "rpc.batch.response.maxsize",
+		Usage: "Maximum number of bytes returned from a batch, the requests past it are answered with an error. 0 for no limit",
+		Value: 25 * 1000 * 1000,
+	}
+	RpcRequestMaxSizeFlag = cli.Int64Flag{
+		Name:  "rpc.request.maxsize",
+		Usage: "Maximum size in bytes of the HTTP request bodies",
+		Value: 5 * 1024 * 1024,
+	}
+	RpcRequestMaxDepthFlag = cli.IntFlag{
+		Name:  "rpc.request.maxdepth",
+		Usage: "Maximum nesting depth of the params of a request, the params array being at depth 1 (0 = no limit)",
+	}
+	RpcRequestMaxStringFlag = cli.IntFlag{
+		Name:  "rpc.request.maxstring",
+		Usage: "Maximum length in bytes of the strings of the params of a request (0 = no limit)",
+	}
+	RpcRequestMaxArrayFlag = cli.IntFlag{
+		Name:  "rpc.request.maxarray",
+		Usage: "Maximum number of elements of the arrays and objects of the params of a request (0 = no limit)",
+	}
+	RpcResponseCacheSizeFlag = cli.IntFlag{
+		Name:  "rpc.responsecache.size",
+		Usage: "Bytes of memory kept for the responses of the finalized blocks, transactions and receipts, and of eth_chainId. 0 to disable",
+	}
+	RpcSlowThresholdFlag = cli.DurationFlag{
+		Name:  "rpc.slow",
+		Usage: "Log the calls taking longer than this as warnings, with their params, caller and timings. 0 to disable",
+	}
+	RpcSampleRateFlag = cli.Float64Flag{
+		Name:  "rpc.sample.rate",
+		Usage: "Fraction of all the calls logged with their params, caller and timings, between 0 and 1",
+	}
+	RpcSampleFileFlag = cli.StringFlag{
+		Name:  "rpc.sample.file",
+		Usage: "File the sampled calls are logged to as JSON lines, instead of the main log",
+	}
+	HealthPathFlag = cli.StringFlag{
+		Name:  "health.path",
+		Usage: "URL path of the health endpoint, the readiness and liveness probes are under it (e.g. /healthz/readiness)",
+		Value: "/health",
+	}
+	HealthReadinessFlag = cli.StringFlag{
+		Name:  "health.readiness",
+		Usage: "Comma separated list of checks of the /health/readiness probe, same syntax as the X-ERIGON-HEALTHCHECK header values",
+		Value: "synced,min_peer_count1,max_seconds_behind600",
+	}
+	HealthLivenessFlag = cli.StringFlag{
+		Name:  "health.liveness",
+		Usage: "Comma separated list of checks of the /health/liveness probe, same syntax as the X-ERIGON-HEALTHCHECK header values",
+		Value: "check_db",
+	}
+	HealthDefaultFlag = cli.StringFlag{
+		Name:  "health.default",
+		Usage: "Comma separated list of checks evaluated by /health requests without X-ERIGON-HEALTHCHECK headers and body, e.g. synced,min_peer_count3,max_seconds_behind600",
+	}
+	HealthDBMaxLatencyFlag = cli.DurationFlag{
+		Name:  "health.db.latency",
+		Usage: "The check_db health check fails if reading the latest block takes longer than this (0 = no limit)",
+		Value: time.Second,
+	}
+	HealthCallToFlag = cli.StringFlag{
+		Name:  "health.call.to",
+		Usage: "Contract address of the eth_call run by the check_call health check",
+	}
+	HealthCallDataFlag = cli.StringFlag{
+		Name:  "health.call.data",
+		Usage: "Hex encoded calldata of the eth_call run by the check_call health check",
+	}
+	HealthCallResultFlag = cli.StringFlag{
+		Name:  "health.call.result",
+		Usage: "Hex encoded result expected from the eth_call run by the check_call health check",
+	}
+	HealthHeadStallFlag = cli.DurationFlag{
+		Name:  "health.head.stall",
+		Usage: "The max_head_stall health check fails if the latest block doesn't change for longer than this",
+		Value: 5 * time.Minute,
+	}
+	HealthUpstreamsFlag = cli.StringFlag{
+		Name:  "health.upstreams",
+		Usage: "Comma separated list of health endpoints of other nodes (e.g. http://node1:8545/health). If set, health checks are forwarded to them and their aggregate status is reported",
+	}
+	HealthUpstreamsQuorumFlag = cli.IntFlag{
+		Name:  "health.upstreams.quorum",
+		Usage: "Number of healthy upstreams needed for a healthy aggregate status (0 = all of them)",
+	}
+	HealthCacheTTLFlag = cli.DurationFlag{
+		Name:  "health.cache.ttl",
+		Usage: "Reuse health check results for this long, so that frequent probes don't hit the backend each time (0 = no caching)",
+	}
+	HealthAuthSecretFlag = cli.StringFlag{
+		Name:  "health.auth.secret",
+		Usage: "Shared secret which health check requests must send as \"Authorization: Bearer <secret>\"",
+	}
+	HealthAuthJWTSecretFlag = cli.StringFlag{
+		Name:  "health.auth.jwtsecret",
+		Usage: "Path to a hex encoded 32 bytes secret, health check requests must send a HS256 JWT signed with it as \"Authorization: Bearer <token>\"",
+	}
 	RpcStreamingDisableFlag = cli.BoolFlag{
 		Name:  "rpc.streaming.disable",
 		Usage: "Erigon has enalbed json streaming for some heavy endpoints (like trace_*). It's treadoff: greatly reduce amount of RAM (in some cases from 30GB to 30mb), but it produce invalid json format if error happened in the middle of streaming (because json is not streaming-friendly format)",
@@ -390,12 +544,51 @@ var (
 		Name:  "rpc.accessList",
 		Usage: "Specify granular (method-by-method) API allowlist",
 	}
+	RpcACLFlag = cli.StringFlag{
+		Name:  "rpc.acl",
+		Usage: "JSON file mapping API keys, origins and IP ranges to the methods they may call, reloaded when it changes",
+	}
+	RpcMethodLimitsFlag = cli.StringFlag{
+		Name:  "rpc.methodlimits",
+		Usage: "JSON file of the rate (calls per second) and concurrency limits by method, for example {\"debug_traceBlockByNumber\": {\"rate\": 2, \"concurrency\": 4}}",
+	}
+
+	RpcMethodTimeoutsFlag = cli.StringFlag{
+		Name:  "rpc.methodtimeouts",
+		Usage: "Comma separated time limits of the calls by method, a method ending with * matching the methods starting with the rest of it, for example eth_call=5s,debug_trace*=10m",
+	}
+	RpcEvmTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.evmtimeout",
+		Usage: "Maximum time of the EVM execution of eth_call, eth_estimateGas, trace_call and the JS tracers, unless --rpc.methodtimeouts limits their method (0 = no limit)",
+		Value: 5 * time.Minute,
+	}
+	RpcSignerFlag = cli.StringFlag{
+		Name:  "rpc.signer",
+		Usage: "URL (http(s) or ws(s)) of the external signer, like clef, which signs the transactions of eth_sendTransaction and eth_signTransaction",
+	}
 
 	RpcGasCapFlag = cli.UintFlag{
 		Name:  "rpc.gascap",
 		Usage: "Sets a cap on gas that can be used in eth_call/estimateGas",
 		Value: 50000000,
 	}
+	RpcMaxGetProofRewindBlockCountFlag = cli.IntFlag{
+		Name:  "rpc.maxgetproofrewindblockcount.limit",
+		Usage: "Sets the maximum number of blocks eth_getProof can rewind the state to, from the head block",
+		Value: 100_000,
+	}
+	RpcGetLogsMaxBlockRangeFlag = cli.Uint64Flag{
+		Name:  "rpc.getlogs.maxblockrange",
+		Usage: "Sets the maximum number of blocks in the range of an eth_getLogs call (0 = no limit)",
+	}
+	RpcGetLogsMaxResultsFlag = cli.IntFlag{
+		Name:  "rpc.getlogs.maxresults",
+		Usage: "Sets the maximum number of logs returned by an eth_getLogs call (0 = no limit)",
+	}
+	RpcGetLogsTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.getlogs.timeout",
+		Usage: "Sets the maximum time an eth_getLogs call can spend reading logs (0 = no limit)",
+	}
 	RpcTraceCompatFlag = cli.BoolFlag{
 		Name:  "trace.compat",
 		Usage: "Bug for bug compatibility with OE for trace_ routines",
@@ -597,6 +790,11 @@ var (
 		Usage: "Maximum gas price will be recommended by gpo",
 		Value: ethconfig.Defaults.GPO.MaxPrice.Int64(),
 	}
+	GpoIgnoreGasPriceFlag = cli.Int64Flag{
+		Name:  "gpo.ignoreprice",
+		Usage: "Gas price below which gpo will ignore transactions",
+		Value: ethconfig.Defaults.GPO.IgnorePrice.Int64(),
+	}
 
 	// Metrics flags
 	MetricsEnabledFlag = cli.BoolFlag{
@@ -1165,7 +1363,8 @@ func setDataDirCobra(f *pflag.FlagSet, cfg *nodecfg.Config) {
 	cfg.Dirs = datadir.New(cfg.Dirs.DataDir)
 }
 
-func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
+// SetGPO applies the gas price oracle flags to cfg
+func SetGPO(ctx *cli.Context, cfg *gasprice.Config) {
 	if ctx.GlobalIsSet(GpoBlocksFlag.Name) {
 		cfg.Blocks = ctx.GlobalInt(GpoBlocksFlag.Name)
 	}
@@ -1175,6 +1374,9 @@ func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
 	if ctx.GlobalIsSet(GpoMaxGasPriceFlag.Name) {
 		cfg.MaxPrice = big.NewInt(ctx.GlobalInt64(GpoMaxGasPriceFlag.Name))
 	}
+	if ctx.GlobalIsSet(GpoIgnoreGasPriceFlag.Name) {
+		cfg.IgnorePrice = big.NewInt(ctx.GlobalInt64(GpoIgnoreGasPriceFlag.Name))
+	}
 }
 
 // nolint
@@ -1466,7 +1668,7 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 	}
 
 	setEtherbase(ctx, cfg)
-	setGPO(ctx, &cfg.GPO)
+	SetGPO(ctx, &cfg.GPO)
 
 	setTxPool(ctx, &cfg.DeprecatedTxPool)
 	cfg.TxPool = core.DefaultTxPool2Config(cfg.DeprecatedTxPool)
@@ -1483,6 +1685,7 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 	cfg.Ethstats = ctx.GlobalString(EthStatsURLFlag.Name)
 	cfg.P2PEnabled = len(nodeConfig.P2P.SentryAddr) == 0
 	cfg.EnabledIssuance = ctx.GlobalIsSet(EnabledIssuance.Name)
+	cfg.EnabledChainStats = ctx.GlobalIsSet(EnabledChainStats.Name)
 	cfg.HistoryV2 = ctx.GlobalIsSet(HistoryV2Flag.Name)
 	if ctx.GlobalIsSet(NetworkIdFlag.Name) {
 		cfg.NetworkID = ctx.GlobalUint64(NetworkIdFlag.Name)
@@ -272,3 +272,21 @@ func FromJson(jsonParams JsonSpec) (AuthorityRoundParams, error) {
 
 	return params, nil
 }
+
+// StaticBlockReward returns the reward of the author of block num set in the spec, or false if it's paid by a block
+// reward contract, the rewards of which depend on the state
+func (p AuthorityRoundParams) StaticBlockReward(num uint64) (*uint256.Int, bool) {
+	for _, c := range p.BlockRewardContractTransitions {
+		if c.blockNum <= num {
+			return nil, false
+		}
+	}
+	var reward *uint256.Int
+	for _, r := range p.BlockReward {
+		if r.blockNum > num {
+			break
+		}
+		reward = r.amount
+	}
+	return reward, reward != nil
+}
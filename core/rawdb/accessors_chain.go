@@ -220,6 +220,89 @@ func WriteForkchoiceFinalized(db kv.Putter, hash common.Hash) {
 	}
 }
 
+// badBlockToKeep is the number of the most recent bad blocks kept by WriteBadBlock
+const badBlockToKeep = 10
+
+// badBlocksKey is the key of the list of the bad blocks in kv.DatabaseInfo
+var badBlocksKey = []byte("InvalidBlock")
+
+// BadBlock is a block rejected by the validation, with the reason of the rejection
+type BadBlock struct {
+	Block  *types.Block
+	Reason string
+}
+
+// badBlockRLP is the storage encoding of a BadBlock
+type badBlockRLP struct {
+	Hash   common.Hash
+	Block  []byte // RLP of the block
+	Reason string
+}
+
+func readBadBlocksRLP(db kv.Getter) ([]badBlockRLP, error) {
+	data, err := db.GetOne(kv.DatabaseInfo, badBlocksKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var badBlocks []badBlockRLP
+	if err := rlp.DecodeBytes(data, &badBlocks); err != nil {
+		return nil, fmt.Errorf("invalid bad blocks RLP: %w", err)
+	}
+	return badBlocks, nil
+}
+
+// ReadAllBadBlocks retrieves the most recent bad blocks, the last rejected first.
+func ReadAllBadBlocks(db kv.Getter) ([]*BadBlock, error) {
+	stored, err := readBadBlocksRLP(db)
+	if err != nil {
+		return nil, err
+	}
+	badBlocks := make([]*BadBlock, 0, len(stored))
+	for _, badBlock := range stored {
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(badBlock.Block, block); err != nil {
+			return nil, fmt.Errorf("invalid bad block RLP %x: %w", badBlock.Hash, err)
+		}
+		badBlocks = append(badBlocks, &BadBlock{Block: block, Reason: badBlock.Reason})
+	}
+	return badBlocks, nil
+}
+
+// WriteBadBlock stores a block rejected by the validation for the reason, among the most recent bad blocks. A block
+// already stored is kept with its first reason.
+func WriteBadBlock(db kv.RwTx, block *types.Block, reason error) error {
+	stored, err := readBadBlocksRLP(db)
+	if err != nil {
+		return err
+	}
+	hash := block.Hash()
+	for _, badBlock := range stored {
+		if badBlock.Hash == hash {
+			return nil
+		}
+	}
+	blockRLP, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return err
+	}
+	badBlock := badBlockRLP{Hash: hash, Block: blockRLP}
+	if reason != nil {
+		badBlock.Reason = reason.Error()
+	}
+	stored = append([]badBlockRLP{badBlock}, stored...)
+	if len(stored) > badBlockToKeep {
+		stored = stored[:badBlockToKeep]
+	}
+	data, err := rlp.EncodeToBytes(stored)
+	if err != nil {
+		return err
+	}
+	return db.Put(kv.DatabaseInfo, badBlocksKey, data)
+}
+
 // ReadHeaderRLP retrieves a block header in its raw RLP database encoding.
 func ReadHeaderRLP(db kv.Getter, hash common.Hash, number uint64) rlp.RawValue {
 	data, err := db.GetOne(kv.Headers, dbutils.HeaderKey(number, hash))
@@ -1426,6 +1509,96 @@ func WriteTotalBurnt(db kv.Putter, number uint64, totalBurnt *big.Int) error {
 	return db.Put(kv.Issuance, append([]byte("burnt"), dbutils.EncodeBlockNumber(number)...), totalBurnt.Bytes())
 }
 
+// ChainStats is a rollup of the canonical blocks of a period, written by the ChainStats stage once the period is
+// complete. The rollups are kept in the Issuance table, next to the other statistics of the blocks, under the
+// "chainStats" prefix, the period and the first block.
+type ChainStats struct {
+	FromBlock     uint64
+	ToBlock       uint64
+	FromTimestamp uint64
+	ToTimestamp   uint64
+	TxCount       uint64
+	GasUsed       uint64
+	UniqueSenders uint64
+	BaseFeeSum    *big.Int // sum of the base fees of the blocks having one
+	BaseFeeBlocks uint64
+}
+
+var chainStatsPrefix = []byte("chainStats")
+
+func chainStatsKey(period byte, from uint64) []byte {
+	k := make([]byte, 0, len(chainStatsPrefix)+1+8)
+	k = append(k, chainStatsPrefix...)
+	k = append(k, period)
+	return append(k, dbutils.EncodeBlockNumber(from)...)
+}
+
+// ReadChainStats returns the rollup of the period starting at block from, nil if it wasn't written
+func ReadChainStats(db kv.Getter, period byte, from uint64) (*ChainStats, error) {
+	data, err := db.GetOne(kv.Issuance, chainStatsKey(period, from))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	stats := new(ChainStats)
+	if err := rlp.DecodeBytes(data, stats); err != nil {
+		return nil, fmt.Errorf("invalid chain stats RLP of block %d: %w", from, err)
+	}
+	return stats, nil
+}
+
+// ReadLastChainStats returns the rollup of the last period written, nil if there are none
+func ReadLastChainStats(tx kv.Tx, period byte) (*ChainStats, error) {
+	c, err := tx.Cursor(kv.Issuance)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	k, _, err := c.Seek(chainStatsKey(period, math.MaxUint64))
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		k, _, err = c.Last()
+	} else {
+		k, _, err = c.Prev()
+	}
+	if err != nil {
+		return nil, err
+	}
+	prefix := chainStatsKey(period, 0)[:len(chainStatsPrefix)+1]
+	if len(k) != len(prefix)+8 || !bytes.HasPrefix(k, prefix) {
+		return nil, nil
+	}
+	return ReadChainStats(tx, period, binary.BigEndian.Uint64(k[len(prefix):]))
+}
+
+func WriteChainStats(db kv.Putter, period byte, stats *ChainStats) error {
+	data, err := rlp.EncodeToBytes(stats)
+	if err != nil {
+		return err
+	}
+	return db.Put(kv.Issuance, chainStatsKey(period, stats.FromBlock), data)
+}
+
+// TruncateChainStats deletes the rollups of the periods ending at block or after it
+func TruncateChainStats(tx kv.RwTx, period byte, block uint64) error {
+	for {
+		last, err := ReadLastChainStats(tx, period)
+		if err != nil {
+			return err
+		}
+		if last == nil || last.ToBlock < block {
+			return nil
+		}
+		if err := tx.Delete(kv.Issuance, chainStatsKey(period, last.FromBlock)); err != nil {
+			return err
+		}
+	}
+}
+
 func ReadCumulativeGasUsed(db kv.Getter, number uint64) (*big.Int, error) {
 	data, err := db.GetOne(kv.CumulativeGasIndex, dbutils.EncodeBlockNumber(number))
 	if err != nil {
@@ -193,6 +193,31 @@ func TestBlockStorage(t *testing.T) {
 	}
 }
 
+// Tests that the most recent bad blocks are kept, without duplicates.
+func TestBadBlockStorage(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	badBlocks, err := ReadAllBadBlocks(tx)
+	require.NoError(t, err)
+	require.Empty(t, badBlocks)
+
+	blocks := make([]*types.Block, badBlockToKeep+2)
+	for i := range blocks {
+		blocks[i] = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(int64(i)), Extra: []byte("bad block")})
+		require.NoError(t, WriteBadBlock(tx, blocks[i], fmt.Errorf("invalid block %d", i)))
+	}
+	// the duplicate keeps its first reason
+	require.NoError(t, WriteBadBlock(tx, blocks[len(blocks)-1], fmt.Errorf("duplicate")))
+
+	badBlocks, err = ReadAllBadBlocks(tx)
+	require.NoError(t, err)
+	require.Len(t, badBlocks, badBlockToKeep)
+	for i, badBlock := range badBlocks {
+		number := len(blocks) - 1 - i
+		require.Equal(t, blocks[number].Hash(), badBlock.Block.Hash())
+		require.Equal(t, fmt.Sprintf("invalid block %d", number), badBlock.Reason)
+	}
+}
+
 // Tests that partial block contents don't get reassembled into full blocks.
 func TestPartialBlockStorage(t *testing.T) {
 	_, tx := memdb.NewTestTx(t)
@@ -204,7 +204,7 @@ func (d *Dumper) DumpToCollector(c DumpCollector, excludeCode, excludeStorage bo
 				addr,
 				incarnation,
 				common.Hash{}, /* startLocation */
-				d.blockNumber,
+				d.blockNumber+1,
 				func(_, loc, vs []byte) (bool, error) {
 					account.Storage[common.BytesToHash(loc).String()] = common.Bytes2Hex(vs)
 					h, _ := common.HashData(loc)
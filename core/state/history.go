@@ -180,24 +180,12 @@ func WalkAsOfStorage(tx kv.Tx, address common.Address, incarnation uint64, start
 			if _, err = index.ReadFrom(bytes.NewReader(hV)); err != nil {
 				return err
 			}
-			found, ok := bitmapdb.SeekInBitmap64(index, timestamp)
-			changeSetBlock := found
-
+			// the history index is shared by the incarnations of the account, the changes of the others are skipped
+			data, ok, err3 := findStorageChange(csCursor, index, address, incarnation, hLoc, timestamp)
+			if err3 != nil {
+				return err3
+			}
 			if ok {
-				// Extract value from the changeSet
-				csKey := make([]byte, 8+common.AddressLength+common.IncarnationLength)
-				copy(csKey, dbutils.EncodeBlockNumber(changeSetBlock))
-				copy(csKey[8:], address[:]) // address + incarnation
-				binary.BigEndian.PutUint64(csKey[8+common.AddressLength:], incarnation)
-				kData := csKey
-				data, err3 := csCursor.SeekBothRange(csKey, hLoc)
-				if err3 != nil {
-					return err3
-				}
-				if !bytes.Equal(kData, csKey) || !bytes.HasPrefix(data, hLoc) {
-					return fmt.Errorf("inconsistent storage changeset and history kData %x, csKey %x, data %x, hLoc %x", kData, csKey, data, hLoc)
-				}
-				data = data[common.HashLength:]
 				if len(data) > 0 { // Skip deleted entries
 					goOn, err = walker(hAddr, hLoc, data)
 				}
@@ -227,6 +215,29 @@ func WalkAsOfStorage(tx kv.Tx, address common.Address, incarnation uint64, start
 	return nil
 }
 
+// findStorageChange returns the value the location of the given incarnation had before its first change at or after
+// the timestamp, looking up the changesets of the blocks of the history index.
+func findStorageChange(csCursor kv.CursorDupSort, index *roaring64.Bitmap, address common.Address, incarnation uint64, location []byte, timestamp uint64) ([]byte, bool, error) {
+	csKey := make([]byte, 8+common.AddressLength+common.IncarnationLength)
+	copy(csKey[8:], address[:])
+	binary.BigEndian.PutUint64(csKey[8+common.AddressLength:], incarnation)
+	for {
+		changeSetBlock, ok := bitmapdb.SeekInBitmap64(index, timestamp)
+		if !ok {
+			return nil, false, nil
+		}
+		copy(csKey, dbutils.EncodeBlockNumber(changeSetBlock))
+		data, err := csCursor.SeekBothRange(csKey, location)
+		if err != nil {
+			return nil, false, err
+		}
+		if bytes.HasPrefix(data, location) {
+			return data[common.HashLength:], true, nil
+		}
+		timestamp = changeSetBlock + 1
+	}
+}
+
 func WalkAsOfAccounts(tx kv.Tx, startAddress common.Address, timestamp uint64, walker func(k []byte, v []byte) (bool, error)) error {
 	mainCursor, err := tx.Cursor(kv.PlainState)
 	if err != nil {
@@ -653,6 +653,42 @@ func TestWalkAsOfUsingFixedBytesStatePlain(t *testing.T) {
 	assertChangesEquals(t, block6, block6Expected)
 }
 
+func TestWalkAsOfStoragePlain_Incarnations(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+
+	emptyVal := uint256.NewInt(0)
+	block3Val := uint256.NewInt(0).SetBytes([]byte("block 3"))
+	stateVal := uint256.NewInt(0).SetBytes([]byte("state"))
+	addr := common.Address{1}
+	key := common.Hash{1}
+
+	writeStorageBlockData(t, NewPlainStateWriter(tx, tx, 3), []storageData{
+		{addr: addr, inc: 1, key: key, oldVal: emptyVal, newVal: block3Val},
+	})
+	// the contract is re-created, the location of both incarnations share the history index
+	writeStorageBlockData(t, NewPlainStateWriter(tx, tx, 5), []storageData{
+		{addr: addr, inc: 1, key: key, oldVal: block3Val, newVal: emptyVal},
+		{addr: addr, inc: 2, key: key, oldVal: emptyVal, newVal: stateVal},
+	})
+
+	walk := func(incarnation, timestamp uint64) map[common.Hash][]byte {
+		values := map[common.Hash][]byte{}
+		if err := WalkAsOfStorage(tx, addr, incarnation, common.Hash{}, timestamp, func(kAddr, kLoc []byte, v []byte) (bool, error) {
+			values[common.BytesToHash(kLoc)] = common.CopyBytes(v)
+			return true, nil
+		}); err != nil {
+			t.Fatalf("incarnation %d at %d: %v", incarnation, timestamp, err)
+		}
+		return values
+	}
+	assert.Empty(t, walk(1, 2))
+	assert.Equal(t, map[common.Hash][]byte{key: block3Val.Bytes()}, walk(1, 4))
+	assert.Empty(t, walk(1, 6))
+	assert.Empty(t, walk(2, 2))
+	assert.Empty(t, walk(2, 4))
+	assert.Equal(t, map[common.Hash][]byte{key: stateVal.Bytes()}, walk(2, 6))
+}
+
 func TestWalkAsOfAccountPlain(t *testing.T) {
 	_, tx := memdb.NewTestTx(t)
 
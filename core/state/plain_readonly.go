@@ -47,6 +47,7 @@ type PlainState struct {
 	tx                           kv.Tx
 	blockNr                      uint64
 	storage                      map[common.Address]*btree.BTree
+	cleared                      map[common.Address]struct{} // self-destructed or re-created since the beginning of blockNr
 	trace                        bool
 }
 
@@ -60,6 +61,7 @@ func NewPlainState(tx kv.Tx, blockNr uint64) *PlainState {
 		tx:          tx,
 		blockNr:     blockNr,
 		storage:     make(map[common.Address]*btree.BTree),
+		cleared:     make(map[common.Address]struct{}),
 		accHistoryC: c1, storageHistoryC: c2, accChangesC: c3, storageChangesC: c4,
 	}
 }
@@ -107,35 +109,39 @@ func (s *PlainState) ForEachStorage(addr common.Address, startLocation common.Ha
 		})
 	}
 	numDeletes := st.Len() - overrideCounter
-	if err := WalkAsOfStorage(s.tx, addr, acc.Incarnation, startLocation, s.blockNr, func(kAddr, kLoc, vs []byte) (bool, error) {
-		if !bytes.Equal(kAddr, addr[:]) {
-			return false, nil
-		}
-		if len(vs) == 0 {
-			// Skip deleted entries
-			return true, nil
-		}
-		keyHash, err1 := common.HashData(kLoc)
-		if err1 != nil {
-			return false, err1
-		}
-		//fmt.Printf("seckey: %x\n", seckey)
-		si := storageItem{}
-		copy(si.key[:], kLoc)
-		copy(si.seckey[:], keyHash[:])
-		if st.Has(&si) {
-			return true, nil
-		}
-		si.value.SetBytes(vs)
-		st.ReplaceOrInsert(&si)
-		if bytes.Compare(kLoc, lastKey[:]) > 0 {
-			// Beyond overrides
-			return st.Len() < maxResults+numDeletes, nil
+	// the storage of the incarnation at the beginning of the block is gone once the account is self-destructed or
+	// re-created
+	if _, cleared := s.cleared[addr]; !cleared {
+		if err := WalkAsOfStorage(s.tx, addr, acc.Incarnation, startLocation, s.blockNr, func(kAddr, kLoc, vs []byte) (bool, error) {
+			if !bytes.Equal(kAddr, addr[:]) {
+				return false, nil
+			}
+			if len(vs) == 0 {
+				// Skip deleted entries
+				return true, nil
+			}
+			keyHash, err1 := common.HashData(kLoc)
+			if err1 != nil {
+				return false, err1
+			}
+			//fmt.Printf("seckey: %x\n", seckey)
+			si := storageItem{}
+			copy(si.key[:], kLoc)
+			copy(si.seckey[:], keyHash[:])
+			if st.Has(&si) {
+				return true, nil
+			}
+			si.value.SetBytes(vs)
+			st.ReplaceOrInsert(&si)
+			if bytes.Compare(kLoc, lastKey[:]) > 0 {
+				// Beyond overrides
+				return st.Len() < maxResults+numDeletes, nil
+			}
+			return st.Len() < maxResults+overrideCounter+numDeletes, nil
+		}); err != nil {
+			log.Error("ForEachStorage walk error", "err", err)
+			return err
 		}
-		return st.Len() < maxResults+overrideCounter+numDeletes, nil
-	}); err != nil {
-		log.Error("ForEachStorage walk error", "err", err)
-		return err
 	}
 	results := 0
 	var innerErr error
@@ -236,6 +242,8 @@ func (s *PlainState) UpdateAccountData(address common.Address, original, account
 }
 
 func (s *PlainState) DeleteAccount(address common.Address, original *accounts.Account) error {
+	delete(s.storage, address)
+	s.cleared[address] = struct{}{}
 	return nil
 }
 
@@ -268,5 +276,6 @@ func (s *PlainState) WriteAccountStorage(address common.Address, incarnation uin
 
 func (s *PlainState) CreateContract(address common.Address) error {
 	delete(s.storage, address)
+	s.cleared[address] = struct{}{}
 	return nil
 }
@@ -399,7 +399,8 @@ func (st *StateTransition) TransitionDb(refunds bool, gasBailout bool) (*Executi
 
 	// Set up the initial access list.
 	if st.evm.ChainRules().IsBerlin {
-		st.state.PrepareAccessList(msg.From(), msg.To(), vm.ActivePrecompiles(st.evm.ChainRules()), msg.AccessList())
+		vmConfig := st.evm.Config()
+		st.state.PrepareAccessList(msg.From(), msg.To(), vmConfig.ActivePrecompiles(st.evm.ChainRules()), msg.AccessList())
 	}
 
 	var (
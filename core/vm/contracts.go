@@ -166,6 +166,46 @@ func ActivePrecompiles(rules *params.Rules) []common.Address {
 	}
 }
 
+// ActivePrecompiles returns the addresses of the precompiles enabled with the configuration and the chain rules.
+func (cfg *Config) ActivePrecompiles(rules *params.Rules) []common.Address {
+	if cfg.Precompiles == nil {
+		return ActivePrecompiles(rules)
+	}
+	addresses := make([]common.Address, 0, len(cfg.Precompiles))
+	for addr := range cfg.Precompiles {
+		addresses = append(addresses, addr)
+	}
+	return addresses
+}
+
+// activePrecompiledContracts returns the precompiled contracts enabled with the current configuration.
+func activePrecompiledContracts(rules *params.Rules) map[common.Address]PrecompiledContract {
+	switch {
+	case rules.IsBerlin:
+		return PrecompiledContractsBerlin
+	case rules.IsIstanbul:
+		if rules.IsParlia {
+			return PrecompiledContractsIstanbulForBSC
+		}
+		return PrecompiledContractsIstanbul
+	case rules.IsByzantium:
+		return PrecompiledContractsByzantium
+	default:
+		return PrecompiledContractsHomestead
+	}
+}
+
+// ActivePrecompiledContracts returns a copy of the precompiled contracts enabled with the current configuration,
+// which can be modified and set as Config.Precompiles.
+func ActivePrecompiledContracts(rules *params.Rules) map[common.Address]PrecompiledContract {
+	active := activePrecompiledContracts(rules)
+	precompiles := make(map[common.Address]PrecompiledContract, len(active))
+	for addr, p := range active {
+		precompiles[addr] = p
+	}
+	return precompiles
+}
+
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 // It returns
 // - the returned bytes,
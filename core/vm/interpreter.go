@@ -38,6 +38,8 @@ type Config struct {
 	ReadOnly      bool   // Do no perform any block finalisation
 
 	ExtraEips []int // Additional EIPS that are to be enabled
+
+	Precompiles map[common.Address]PrecompiledContract // Replaces the precompiles of the chain rules if not nil
 }
 
 // Interpreter is used to run Ethereum based contracts and will utilise the
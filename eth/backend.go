@@ -52,7 +52,9 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/hack/tool"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/health"
 	"github.com/ledgerwatch/erigon/cmd/sentry/sentry"
+	"github.com/ledgerwatch/erigon/cmd/sentry/sentryadmin"
 	"github.com/ledgerwatch/erigon/cmd/state/exec22"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/debug"
@@ -91,6 +93,7 @@ import (
 	"golang.org/x/exp/slices"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -126,6 +129,7 @@ type Ethereum struct {
 	sentryCancel   context.CancelFunc
 	sentriesClient *sentry.MultiClient
 	sentryServers  []*sentry.GrpcServer
+	sentryAdmins   []sentryadmin.SentryAdminClient
 
 	stagedSync *stagedsync.Sync
 
@@ -269,11 +273,12 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 	var sentries []direct.SentryClient
 	if len(stack.Config().P2P.SentryAddr) > 0 {
 		for _, addr := range stack.Config().P2P.SentryAddr {
-			sentryClient, err := sentry.GrpcClient(backend.sentryCtx, addr)
+			sentryClient, sentryAdmin, err := sentry.GrpcClient(backend.sentryCtx, addr)
 			if err != nil {
 				return nil, err
 			}
 			sentries = append(sentries, sentryClient)
+			backend.sentryAdmins = append(backend.sentryAdmins, sentryAdmin)
 		}
 	} else {
 		var readNodeInfo = func() *eth.NodeInfo {
@@ -296,6 +301,7 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 
 		backend.sentryServers = append(backend.sentryServers, server)
 		sentries = []direct.SentryClient{direct.NewSentryClientDirect(cfg.ProtocolVersion, server)}
+		backend.sentryAdmins = []sentryadmin.SentryAdminClient{sentryadmin.NewDirectClient(server)}
 
 		go func() {
 			logEvery := time.NewTicker(120 * time.Second)
@@ -445,7 +451,14 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 		blockReader, chainConfig, assembleBlockPOS, backend.sentriesClient.Hd, config.Miner.EnabledPOS)
 	miningRPC = privateapi.NewMiningServer(ctx, backend, ethashApi)
 
+	// reports the checks of the rpc services, which are created below
+	var healthServer *health.GRPCServer
 	if stack.Config().PrivateApiAddr != "" {
+		var grpcHealthServer grpc_health_v1.HealthServer
+		if stack.Config().HealthCheck {
+			healthServer = health.NewGRPCServer(nil)
+			grpcHealthServer = healthServer
+		}
 		var creds credentials.TransportCredentials
 		if stack.Config().TLSConnection {
 			creds, err = grpcutil.TLS(stack.Config().TLSCACert, stack.Config().TLSCertFile, stack.Config().TLSKeyFile)
@@ -461,7 +474,7 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 			stack.Config().PrivateApiAddr,
 			stack.Config().PrivateApiRateLimit,
 			creds,
-			stack.Config().HealthCheck)
+			grpcHealthServer)
 		if err != nil {
 			return nil, fmt.Errorf("private api: %w", err)
 		}
@@ -583,7 +596,8 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 	}
 	// start HTTP API
 	httpRpcCfg := stack.Config().Http
-	ethRpcClient, txPoolRpcClient, miningRpcClient, stateCache, ff, txNums, err := cli.EmbeddedServices(ctx, chainKv, httpRpcCfg.StateCache, blockReader, allSnapshots, ethBackendRPC, backend.txPool2GrpcServer, miningRPC)
+	httpRpcCfg.AggregatorDir = aggDir
+	ethRpcClient, txPoolRpcClient, miningRpcClient, stateCache, ff, txNums, err := cli.EmbeddedServices(ctx, chainKv, httpRpcCfg.StateCache, blockReader, allSnapshots, ethBackendRPC, backend.txPool2GrpcServer, miningRPC, ethBackendRPC)
 	if err != nil {
 		return nil, err
 	}
@@ -594,8 +608,15 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 	}
 	apiList := commands.APIList(chainKv, borDb, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, agg, txNums, httpRpcCfg)
 	authApiList := commands.AuthAPIList(chainKv, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, httpRpcCfg)
+	if healthServer != nil {
+		healthCfg := httpRpcCfg.Health
+		if httpRpcCfg.WebsocketEnabled {
+			healthCfg.WSEndpoint = health.WebsocketEndpoint(httpRpcCfg.HttpListenAddress, httpRpcCfg.HttpPort, httpRpcCfg.HttpTLSCertFile != "")
+		}
+		healthServer.SetChecker(health.NewChecker(apiList, healthCfg))
+	}
 	go func() {
-		if err := cli.StartRpcServer(ctx, httpRpcCfg, apiList, authApiList); err != nil {
+		if err := cli.StartRpcServer(ctx, httpRpcCfg, apiList, authApiList, commands.NewResponseCachePolicy(chainKv)); err != nil {
 			log.Error(err.Error())
 			return
 		}
@@ -864,6 +885,46 @@ func (s *Ethereum) Peers(ctx context.Context) (*remote.PeersReply, error) {
 	return &reply, nil
 }
 
+// AddPeer adds a static peer to all the sentries
+func (s *Ethereum) AddPeer(ctx context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error) {
+	for _, sentryAdmin := range s.sentryAdmins {
+		if _, err := sentryAdmin.AddPeer(ctx, req); err != nil {
+			return nil, fmt.Errorf("ethereum backend AddPeer error: %w", err)
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// RemovePeer removes a static peer from all the sentries
+func (s *Ethereum) RemovePeer(ctx context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error) {
+	for _, sentryAdmin := range s.sentryAdmins {
+		if _, err := sentryAdmin.RemovePeer(ctx, req); err != nil {
+			return nil, fmt.Errorf("ethereum backend RemovePeer error: %w", err)
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// AddTrustedPeer adds a trusted peer to all the sentries
+func (s *Ethereum) AddTrustedPeer(ctx context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error) {
+	for _, sentryAdmin := range s.sentryAdmins {
+		if _, err := sentryAdmin.AddTrustedPeer(ctx, req); err != nil {
+			return nil, fmt.Errorf("ethereum backend AddTrustedPeer error: %w", err)
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// RemoveTrustedPeer removes a trusted peer from all the sentries
+func (s *Ethereum) RemoveTrustedPeer(ctx context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error) {
+	for _, sentryAdmin := range s.sentryAdmins {
+		if _, err := sentryAdmin.RemoveTrustedPeer(ctx, req); err != nil {
+			return nil, fmt.Errorf("ethereum backend RemoveTrustedPeer error: %w", err)
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
 // Protocols returns all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
@@ -223,6 +223,9 @@ type Config struct {
 	// Enable WatchTheBurn stage
 	EnabledIssuance bool
 
+	// Enable ChainStats stage
+	EnabledChainStats bool
+
 	//  New DB and Snapshots format of history allows: parallel blocks execution, get state as of given transaction without executing whole block.",
 	HistoryV2 bool
 
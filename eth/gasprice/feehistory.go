@@ -49,8 +49,9 @@ type blockFees struct {
 	// set by the caller
 	blockNumber uint64
 	header      *types.Header
-	block       *types.Block // only set if reward percentiles are requested
+	block       *types.Block // only set if reward percentiles are requested and the rewards aren't cached
 	receipts    types.Receipts
+	rewards     *blockRewards // set if cached
 	// filled by processBlock
 	reward               []*big.Int
 	baseFee, nextBaseFee *big.Int
@@ -75,6 +76,27 @@ func (s sortGasAndReward) Less(i, j int) bool {
 	return s[i].reward.Cmp(s[j].reward) < 0
 }
 
+// blockRewards are the rewards of the transactions of a block sorted in ascending order, from which the reward
+// percentiles are read
+type blockRewards struct {
+	gasUsed uint64
+	sorted  sortGasAndReward
+}
+
+func newBlockRewards(block *types.Block, receipts types.Receipts) *blockRewards {
+	sorter := make(sortGasAndReward, len(block.Transactions()))
+	baseFee := uint256.NewInt(0)
+	if block.BaseFee() != nil {
+		baseFee.SetFromBig(block.BaseFee())
+	}
+	for i, tx := range block.Transactions() {
+		reward := tx.GetEffectiveGasTip(baseFee)
+		sorter[i] = txGasAndReward{gasUsed: receipts[i].GasUsed, reward: reward.ToBig()}
+	}
+	sort.Sort(sorter)
+	return &blockRewards{gasUsed: block.GasUsed(), sorted: sorter}
+}
+
 // processBlock takes a blockFees structure with the blockNumber, the header and optionally
 // the block field filled in, retrieves the block from the backend if not present yet and
 // fills in the rest of the fields.
@@ -93,13 +115,17 @@ func (oracle *Oracle) processBlock(bf *blockFees, percentiles []float64) {
 		// rewards were not requested, return null
 		return
 	}
-	if bf.block == nil || (bf.receipts == nil && len(bf.block.Transactions()) != 0) {
-		log.Error("Block or receipts are missing while reward percentiles are requested")
-		return
+	if bf.rewards == nil {
+		if bf.block == nil || (bf.receipts == nil && len(bf.block.Transactions()) != 0) {
+			log.Error("Block or receipts are missing while reward percentiles are requested")
+			return
+		}
+		bf.rewards = newBlockRewards(bf.block, bf.receipts)
 	}
 
 	bf.reward = make([]*big.Int, len(percentiles))
-	if len(bf.block.Transactions()) == 0 {
+	sorted := bf.rewards.sorted
+	if len(sorted) == 0 {
 		// return an all zero row if there are no transactions to gather data from
 		for i := range bf.reward {
 			bf.reward[i] = new(big.Int)
@@ -107,27 +133,16 @@ func (oracle *Oracle) processBlock(bf *blockFees, percentiles []float64) {
 		return
 	}
 
-	sorter := make(sortGasAndReward, len(bf.block.Transactions()))
-	baseFee := uint256.NewInt(0)
-	if bf.block.BaseFee() != nil {
-		baseFee.SetFromBig(bf.block.BaseFee())
-	}
-	for i, tx := range bf.block.Transactions() {
-		reward := tx.GetEffectiveGasTip(baseFee)
-		sorter[i] = txGasAndReward{gasUsed: bf.receipts[i].GasUsed, reward: reward.ToBig()}
-	}
-	sort.Sort(sorter)
-
 	var txIndex int
-	sumGasUsed := sorter[0].gasUsed
+	sumGasUsed := sorted[0].gasUsed
 
 	for i, p := range percentiles {
-		thresholdGasUsed := uint64(float64(bf.block.GasUsed()) * p / 100)
-		for sumGasUsed < thresholdGasUsed && txIndex < len(bf.block.Transactions())-1 {
+		thresholdGasUsed := uint64(float64(bf.rewards.gasUsed) * p / 100)
+		for sumGasUsed < thresholdGasUsed && txIndex < len(sorted)-1 {
 			txIndex++
-			sumGasUsed += sorter[txIndex].gasUsed
+			sumGasUsed += sorted[txIndex].gasUsed
 		}
-		bf.reward[i] = sorter[txIndex].reward
+		bf.reward[i] = sorted[txIndex].reward
 	}
 }
 
@@ -256,20 +271,28 @@ func (oracle *Oracle) FeeHistory(ctx context.Context, blocks int, unresolvedLast
 		if pendingBlock != nil && blockNumber >= pendingBlock.NumberU64() {
 			fees.block, fees.receipts = pendingBlock, pendingReceipts
 		} else {
-			if len(rewardPercentiles) != 0 {
-				fees.block, fees.err = oracle.backend.BlockByNumber(ctx, rpc.BlockNumber(blockNumber))
-				if fees.block != nil && fees.err == nil {
-					fees.receipts, fees.err = oracle.backend.GetReceipts(ctx, fees.block.Hash())
+			fees.header, fees.err = oracle.backend.HeaderByNumber(ctx, rpc.BlockNumber(blockNumber))
+			if len(rewardPercentiles) != 0 && fees.header != nil && fees.err == nil {
+				if rewards, ok := oracle.cache.rewards.Get(fees.header.Hash()); ok {
+					fees.rewards = rewards.(*blockRewards)
+				} else {
+					fees.block, fees.err = oracle.backend.BlockByNumber(ctx, rpc.BlockNumber(blockNumber))
+					if fees.block != nil && fees.err == nil {
+						fees.receipts, fees.err = oracle.backend.GetReceipts(ctx, fees.block.Hash())
+					}
 				}
-			} else {
-				fees.header, fees.err = oracle.backend.HeaderByNumber(ctx, rpc.BlockNumber(blockNumber))
 			}
 		}
 		if fees.block != nil {
 			fees.header = fees.block.Header()
 		}
-		if fees.header != nil {
+		if fees.header != nil && fees.err == nil {
+			cached := fees.rewards != nil
 			oracle.processBlock(fees, rewardPercentiles)
+			// the pending block is still subject to change
+			if !cached && fees.rewards != nil && fees.block != pendingBlock {
+				oracle.cache.rewards.Add(fees.header.Hash(), fees.rewards)
+			}
 		}
 
 		if fees.err != nil {
@@ -19,6 +19,7 @@ package gasprice_test
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/ledgerwatch/erigon/eth/gasprice"
@@ -57,7 +58,7 @@ func TestFeeHistory(t *testing.T) {
 			MaxBlockHistory:  c.maxBlock,
 		}
 		backend := newTestBackend(t) //, big.NewInt(16), c.pending)
-		oracle := gasprice.NewOracle(backend, config)
+		oracle := gasprice.NewOracle(backend, config, nil)
 
 		first, reward, baseFee, ratio, err := oracle.FeeHistory(context.Background(), c.count, c.last, c.percent)
 
@@ -87,3 +88,33 @@ func TestFeeHistory(t *testing.T) {
 		}
 	}
 }
+
+func TestFeeHistoryCache(t *testing.T) {
+	backend := newTestBackend(t)
+	cache := gasprice.NewCache(gasprice.DefaultCacheBlocks)
+
+	_, _, _, _, err := gasprice.NewOracle(backend, gasprice.Config{}, cache).FeeHistory(context.Background(), 10, 30, []float64{50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backend.blockReads != 10 {
+		t.Fatalf("block reads mismatch, want 10, got %d", backend.blockReads)
+	}
+
+	// the rewards of the blocks are shared by the oracles, whatever the percentiles
+	percentiles := []float64{0, 10, 90}
+	first, reward, _, _, err := gasprice.NewOracle(backend, gasprice.Config{}, cache).FeeHistory(context.Background(), 12, 30, percentiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backend.blockReads != 12 {
+		t.Fatalf("block reads mismatch, want 12, got %d", backend.blockReads)
+	}
+	expFirst, expReward, _, _, err := gasprice.NewOracle(backend, gasprice.Config{}, nil).FeeHistory(context.Background(), 12, 30, percentiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Cmp(expFirst) != 0 || !reflect.DeepEqual(reward, expReward) {
+		t.Fatalf("cached fee history mismatch, want %d %v, got %d %v", expFirst, expReward, first, reward)
+	}
+}
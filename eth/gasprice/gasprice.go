@@ -23,6 +23,7 @@ import (
 	"math/big"
 	"sync"
 
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/core/types"
@@ -31,7 +32,12 @@ import (
 	"github.com/ledgerwatch/log/v3"
 )
 
-const sampleNumber = 3 // Number of transactions sampled in a block
+const (
+	sampleNumber = 3 // Number of transactions sampled in a block
+
+	// DefaultCacheBlocks is the number of blocks whose rewards are kept by the caches
+	DefaultCacheBlocks = 2048
+)
 
 var (
 	DefaultMaxPrice    = big.NewInt(500 * params.GWei)
@@ -58,15 +64,34 @@ type OracleBackend interface {
 	PendingBlockAndReceipts() (*types.Block, types.Receipts)
 }
 
+// Cache holds what the oracles compute from the chain and is worth sharing between them: the last suggested tip and
+// the sorted rewards of the recently processed blocks. Oracles are cheap and usually created per request, sharing a
+// cache saves busy endpoints from going through the same blocks over and over.
+type Cache struct {
+	lock      sync.RWMutex
+	lastHead  common.Hash
+	lastPrice *big.Int
+
+	rewards *lru.Cache // block hash -> *blockRewards
+}
+
+// NewCache returns a cache keeping the rewards of the given number of blocks.
+func NewCache(blocks int) *Cache {
+	rewards, err := lru.New(blocks)
+	if err != nil {
+		panic(err)
+	}
+	return &Cache{rewards: rewards}
+}
+
 // Oracle recommends gas prices based on the content of recent
 // blocks. Suitable for both light and full clients.
 type Oracle struct {
-	backend     OracleBackend
-	lastHead    common.Hash
-	lastPrice   *big.Int
-	maxPrice    *big.Int
-	ignorePrice *big.Int
-	cacheLock   sync.RWMutex
+	backend      OracleBackend
+	cache        *Cache
+	defaultPrice *big.Int
+	maxPrice     *big.Int
+	ignorePrice  *big.Int
 
 	checkBlocks                       int
 	percentile                        int
@@ -74,8 +99,9 @@ type Oracle struct {
 }
 
 // NewOracle returns a new gasprice oracle which can recommend suitable
-// gasprice for newly created transaction.
-func NewOracle(backend OracleBackend, params Config) *Oracle {
+// gasprice for newly created transaction. The oracle gets its own cache
+// if none is given.
+func NewOracle(backend OracleBackend, params Config, cache *Cache) *Oracle {
 	blocks := params.Blocks
 	if blocks < 1 {
 		blocks = 1
@@ -100,9 +126,13 @@ func NewOracle(backend OracleBackend, params Config) *Oracle {
 		ignorePrice = DefaultIgnorePrice
 		log.Warn("Sanitizing invalid gasprice oracle ignore price", "provided", params.IgnorePrice, "updated", ignorePrice)
 	}
+	if cache == nil {
+		cache = NewCache(DefaultCacheBlocks)
+	}
 	return &Oracle{
 		backend:          backend,
-		lastPrice:        params.Default,
+		cache:            cache,
+		defaultPrice:     params.Default,
 		maxPrice:         maxPrice,
 		ignorePrice:      ignorePrice,
 		checkBlocks:      blocks,
@@ -117,27 +147,22 @@ func NewOracle(backend OracleBackend, params Config) *Oracle {
 // NODE: if caller wants legacy tx SuggestedPrice, we need to add
 // baseFee to the returned bigInt
 func (gpo *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
+	// If the latest gasprice is still available, return it.
+	gpo.cache.lock.RLock()
+	lastHead, lastPrice := gpo.cache.lastHead, gpo.cache.lastPrice
+	gpo.cache.lock.RUnlock()
+	if lastPrice == nil {
+		lastPrice = gpo.defaultPrice
+	}
+
 	head, err := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 	if err != nil {
-		return gpo.lastPrice, err
+		return lastPrice, err
 	}
 	if head == nil {
-		return gpo.lastPrice, nil
-	}
-	headHash := head.Hash()
-
-	// If the latest gasprice is still available, return it.
-	gpo.cacheLock.RLock()
-	lastHead, lastPrice := gpo.lastHead, gpo.lastPrice
-	gpo.cacheLock.RUnlock()
-	if headHash == lastHead {
 		return lastPrice, nil
 	}
-
-	// Try checking the cache again, maybe the last fetch fetched what we need
-	gpo.cacheLock.RLock()
-	lastHead, lastPrice = gpo.lastHead, gpo.lastPrice
-	gpo.cacheLock.RUnlock()
+	headHash := head.Hash()
 	if headHash == lastHead {
 		return lastPrice, nil
 	}
@@ -166,10 +191,10 @@ func (gpo *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
 	if price.Cmp(gpo.maxPrice) > 0 {
 		price = new(big.Int).Set(gpo.maxPrice)
 	}
-	gpo.cacheLock.Lock()
-	gpo.lastHead = headHash
-	gpo.lastPrice = price
-	gpo.cacheLock.Unlock()
+	gpo.cache.lock.Lock()
+	gpo.cache.lastHead = headHash
+	gpo.cache.lastPrice = price
+	gpo.cache.lock.Unlock()
 	return price, nil
 }
 
@@ -20,6 +20,7 @@ import (
 	"context"
 	"math"
 	"math/big"
+	"sync/atomic"
 	"testing"
 
 	"github.com/holiman/uint256"
@@ -38,6 +39,8 @@ import (
 type testBackend struct {
 	db  kv.RwDB
 	cfg *params.ChainConfig
+
+	blockReads int32 // number of BlockByNumber calls
 }
 
 func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
@@ -69,6 +72,7 @@ func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber
 }
 
 func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	atomic.AddInt32(&b.blockReads, 1)
 	tx, err := b.db.BeginRo(context.Background())
 	if err != nil {
 		return nil, err
@@ -144,7 +148,7 @@ func TestSuggestPrice(t *testing.T) {
 		Default:    big.NewInt(params.GWei),
 	}
 	backend := newTestBackend(t)
-	oracle := gasprice.NewOracle(backend, config)
+	oracle := gasprice.NewOracle(backend, config, nil)
 
 	// The gas price sampled is: 32G, 31G, 30G, 29G, 28G, 27G
 	got, err := oracle.SuggestTipCap(context.Background())
@@ -0,0 +1,229 @@
+package stagedsync
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/services"
+	"github.com/ledgerwatch/log/v3"
+)
+
+const (
+	ChainStatsDay   byte = 'd' // UTC calendar days, by block timestamp
+	ChainStatsEpoch byte = 'e' // fixed windows of ChainStatsEpochLength blocks
+
+	ChainStatsEpochLength = 32 // blocks per epoch, one beacon chain epoch worth of slots
+	secondsPerDay         = 24 * 60 * 60
+)
+
+type ChainStatsCfg struct {
+	db          kv.RwDB
+	blockReader services.FullBlockReader
+	enabled     bool
+}
+
+func StageChainStatsCfg(db kv.RwDB, blockReader services.FullBlockReader, enabled bool) ChainStatsCfg {
+	return ChainStatsCfg{
+		db:          db,
+		blockReader: blockReader,
+		enabled:     enabled,
+	}
+}
+
+// SpawnStageChainStats writes the rollups of the days and epochs completed by the blocks whose senders are recovered.
+// The periods are rolled up once, when the first block of the next period is known, erigon_chainStats computing
+// only the period in progress.
+func SpawnStageChainStats(cfg ChainStatsCfg, s *StageState, tx kv.RwTx, ctx context.Context) error {
+	useExternalTx := tx != nil
+	if !useExternalTx {
+		var err error
+		tx, err = cfg.db.BeginRw(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+	}
+
+	headNumber, err := stages.GetStageProgress(tx, stages.Senders)
+	if err != nil {
+		return fmt.Errorf("getting senders progress: %w", err)
+	}
+	if !cfg.enabled || headNumber == s.BlockNumber {
+		if !useExternalTx {
+			return tx.Commit()
+		}
+		return nil
+	}
+
+	logEvery := time.NewTicker(logInterval)
+	defer logEvery.Stop()
+	stopped := false
+	for _, period := range []byte{ChainStatsDay, ChainStatsEpoch} {
+		last, err := rawdb.ReadLastChainStats(tx, period)
+		if err != nil {
+			return err
+		}
+		var start uint64
+		if last != nil {
+			start = last.ToBlock + 1
+		}
+		for start <= headNumber && !stopped {
+			end, complete, err := ChainStatsPeriodEnd(ctx, tx, cfg.blockReader, period, start, headNumber)
+			if err != nil {
+				return err
+			}
+			if !complete {
+				break
+			}
+			stats, err := ComputeChainStats(ctx, tx, cfg.blockReader, start, end)
+			if err != nil {
+				return err
+			}
+			if err := rawdb.WriteChainStats(tx, period, stats); err != nil {
+				return err
+			}
+			start = end + 1
+
+			select {
+			case <-ctx.Done():
+				stopped = true
+			case <-logEvery.C:
+				log.Info(fmt.Sprintf("[%s] Wrote chain stats", s.LogPrefix()), "period", string(period), "block", end)
+			default:
+			}
+		}
+	}
+	// the rollups written before a stop are kept, the following runs start after them
+	if !stopped {
+		if err = s.Update(tx, headNumber); err != nil {
+			return err
+		}
+	}
+	if !useExternalTx {
+		return tx.Commit()
+	}
+	return nil
+}
+
+func UnwindChainStatsStage(u *UnwindState, cfg ChainStatsCfg, tx kv.RwTx, ctx context.Context) (err error) {
+	useExternalTx := tx != nil
+	if !useExternalTx {
+		tx, err = cfg.db.BeginRw(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+	}
+
+	// a period ending at the unwind point isn't known to be complete anymore
+	for _, period := range []byte{ChainStatsDay, ChainStatsEpoch} {
+		if err = rawdb.TruncateChainStats(tx, period, u.UnwindPoint); err != nil {
+			return err
+		}
+	}
+	if err = u.Done(tx); err != nil {
+		return err
+	}
+	if !useExternalTx {
+		return tx.Commit()
+	}
+	return nil
+}
+
+// ChainStatsPeriodEnd returns the last block of the period starting at start, capped by head, and whether the
+// period is complete: start is its first block and the block following the returned one is in the next period.
+func ChainStatsPeriodEnd(ctx context.Context, tx kv.Tx, blockReader services.FullBlockReader, period byte, start, head uint64) (uint64, bool, error) {
+	switch period {
+	case ChainStatsEpoch:
+		periodStart := start - start%ChainStatsEpochLength
+		periodEnd := periodStart + ChainStatsEpochLength - 1
+		if periodEnd > head {
+			return head, false, nil
+		}
+		return periodEnd, periodStart == start, nil
+	case ChainStatsDay:
+	default:
+		return 0, false, fmt.Errorf("unknown chain stats period %q", period)
+	}
+
+	header, err := blockReader.HeaderByNumber(ctx, tx, start)
+	if err != nil {
+		return 0, false, err
+	}
+	if header == nil {
+		return 0, false, fmt.Errorf("block header not found: %d", start)
+	}
+	nextDay := (header.Time/secondsPerDay + 1) * secondsPerDay
+	complete := start == 0
+	if !complete {
+		prev, err := blockReader.HeaderByNumber(ctx, tx, start-1)
+		if err != nil {
+			return 0, false, err
+		}
+		complete = prev != nil && prev.Time < nextDay-secondsPerDay
+	}
+
+	var searchErr error
+	// index of the first block after start which belongs to the next day
+	idx := sort.Search(int(head-start), func(i int) bool {
+		h, err := blockReader.HeaderByNumber(ctx, tx, start+1+uint64(i))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return h == nil || h.Time >= nextDay
+	})
+	if searchErr != nil {
+		return 0, false, searchErr
+	}
+	periodEnd := start + uint64(idx)
+	if periodEnd >= head {
+		// the day is not over yet
+		return head, false, nil
+	}
+	return periodEnd, complete, nil
+}
+
+// ComputeChainStats rolls up the canonical blocks from from to to, included
+func ComputeChainStats(ctx context.Context, tx kv.Tx, blockReader services.FullBlockReader, from, to uint64) (*rawdb.ChainStats, error) {
+	stats := &rawdb.ChainStats{FromBlock: from, ToBlock: to, BaseFeeSum: new(big.Int)}
+	senders := make(map[common.Address]struct{})
+	for n := from; n <= to; n++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hash, err := blockReader.CanonicalHash(ctx, tx, n)
+		if err != nil {
+			return nil, err
+		}
+		block, blockSenders, err := blockReader.BlockWithSenders(ctx, tx, hash, n)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("block not found: %d", n)
+		}
+		if n == from {
+			stats.FromTimestamp = block.Time()
+		}
+		stats.ToTimestamp = block.Time()
+		stats.TxCount += uint64(len(block.Transactions()))
+		stats.GasUsed += block.GasUsed()
+		for _, sender := range blockSenders {
+			senders[sender] = struct{}{}
+		}
+		if baseFee := block.BaseFee(); baseFee != nil {
+			stats.BaseFeeSum.Add(stats.BaseFeeSum, baseFee)
+			stats.BaseFeeBlocks++
+		}
+	}
+	stats.UniqueSenders = uint64(len(senders))
+	return stats, nil
+}
@@ -0,0 +1,59 @@
+package stagedsync
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainStatsStage(t *testing.T) {
+	ctx := context.Background()
+	db, tx := memdb.NewTestTx(t)
+
+	// one block per hour, the days starting at the blocks 24 and 48
+	const head = 69
+	for n := uint64(0); n <= head; n++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(n), Time: n * 3600, GasUsed: 1000, BaseFee: big.NewInt(int64(n)), Eip1559: true}
+		rawdb.WriteHeader(tx, header)
+		require.NoError(t, rawdb.WriteCanonicalHash(tx, header.Hash(), n))
+		require.NoError(t, rawdb.WriteBody(tx, header.Hash(), n, &types.Body{}))
+	}
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Senders, head))
+
+	cfg := StageChainStatsCfg(db, snapshotsync.NewBlockReader(), true)
+	require.NoError(t, SpawnStageChainStats(cfg, &StageState{ID: stages.ChainStats}, tx, ctx))
+
+	// only the complete periods are written
+	last, err := rawdb.ReadLastChainStats(tx, ChainStatsDay)
+	require.NoError(t, err)
+	require.Equal(t, uint64(24), last.FromBlock)
+	require.Equal(t, uint64(47), last.ToBlock)
+	require.Equal(t, uint64(24*1000), last.GasUsed)
+	require.Equal(t, uint64(24), last.BaseFeeBlocks)
+	require.Equal(t, big.NewInt((24+47)*24/2), last.BaseFeeSum)
+	last, err = rawdb.ReadLastChainStats(tx, ChainStatsEpoch)
+	require.NoError(t, err)
+	require.Equal(t, uint64(32), last.FromBlock)
+	require.Equal(t, uint64(63), last.ToBlock)
+	first, err := rawdb.ReadChainStats(tx, ChainStatsEpoch, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(31), first.ToBlock)
+	require.Equal(t, uint64(31*3600), first.ToTimestamp)
+
+	// the periods ending at the unwind point or after it are deleted
+	u := &UnwindState{ID: stages.ChainStats, UnwindPoint: 40}
+	require.NoError(t, UnwindChainStatsStage(u, cfg, tx, ctx))
+	last, err = rawdb.ReadLastChainStats(tx, ChainStatsDay)
+	require.NoError(t, err)
+	require.Equal(t, uint64(23), last.ToBlock)
+	last, err = rawdb.ReadLastChainStats(tx, ChainStatsEpoch)
+	require.NoError(t, err)
+	require.Equal(t, uint64(31), last.ToBlock)
+}
@@ -442,6 +442,9 @@ Loop:
 				if cfg.badBlockHalt {
 					return err
 				}
+				if writeErr := rawdb.WriteBadBlock(tx, block, err); writeErr != nil {
+					return writeErr
+				}
 			}
 			u.UnwindTo(blockNum-1, block.Hash())
 			break Loop
@@ -532,6 +532,9 @@ func verifyAndSaveNewPoSHeader(
 	if verificationErr := cfg.hd.VerifyHeader(header); verificationErr != nil {
 		log.Warn("Verification failed for header", "hash", headerHash, "height", headerNumber, "err", verificationErr)
 		cfg.hd.ReportBadHeaderPoS(headerHash, header.ParentHash)
+		if err := rawdb.WriteBadBlock(tx, block, verificationErr); err != nil {
+			return nil, false, err
+		}
 		return &engineapi.PayloadStatus{
 			Status:          remote.EngineStatus_INVALID,
 			LatestValidHash: header.ParentHash,
@@ -560,6 +563,9 @@ func verifyAndSaveNewPoSHeader(
 		if !success {
 			log.Warn("Validation failed for header", "hash", headerHash, "height", headerNumber, "err", validationError)
 			cfg.hd.ReportBadHeaderPoS(headerHash, latestValidHash)
+			if err := rawdb.WriteBadBlock(tx, block, validationError); err != nil {
+				return nil, false, err
+			}
 		} else if err := headerInserter.FeedHeaderPoS(tx, header, headerHash); err != nil {
 			return nil, false, err
 		}
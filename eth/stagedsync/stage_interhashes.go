@@ -16,6 +16,7 @@ import (
 	"github.com/ledgerwatch/erigon/common/changeset"
 	"github.com/ledgerwatch/erigon/common/dbutils"
 	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
@@ -118,6 +119,15 @@ func SpawnIntermediateHashesStage(s *StageState, u Unwinder, tx kv.RwTx, cfg Tri
 		if cfg.hd != nil {
 			cfg.hd.ReportBadHeaderPoS(headerHash, syncHeadHeader.ParentHash)
 		}
+		block, _, err := cfg.blockReader.BlockWithSenders(ctx, tx, headerHash, to)
+		if err != nil {
+			return trie.EmptyRoot, err
+		}
+		if block != nil {
+			if err = rawdb.WriteBadBlock(tx, block, fmt.Errorf("wrong trie root %x, expected %x", root, expectedRootHash)); err != nil {
+				return trie.EmptyRoot, err
+			}
+		}
 		if to > s.BlockNumber {
 			unwindTo := (to + s.BlockNumber) / 2 // Binary search for the correct block, biased to the lower numbers
 			log.Warn("Unwinding due to incorrect root hash", "to", unwindTo)
@@ -639,9 +649,28 @@ func UnwindIntermediateHashesStage(u *UnwindState, s *StageState, tx kv.RwTx, cf
 	return nil
 }
 
-func unwindIntermediateHashesStageImpl(logPrefix string, u *UnwindState, s *StageState, db kv.RwTx, cfg TrieCfg, expectedRootHash common.Hash, quit <-chan struct{}) error {
+// UnwindIntermediateHashesForTrieLoader unwinds the hashed state of db from s.BlockNumber to u.UnwindPoint and
+// returns a trie loader computing the state root at u.UnwindPoint, which doesn't use the intermediate hashes on the
+// paths of the unwound keys nor of the keys of rl. db is meant to be a memory batch over a read transaction, whose
+// changes are discarded.
+func UnwindIntermediateHashesForTrieLoader(logPrefix string, rl *trie.RetainList, u *UnwindState, s *StageState, db kv.RwTx, hashStateCfg HashStateCfg, cfg TrieCfg, quit <-chan struct{}) (*trie.FlatDBTrieLoader, error) {
+	if err := unwindHashStateStageImpl(logPrefix, u, s, db, hashStateCfg, quit); err != nil {
+		return nil, err
+	}
+	if err := retainUnwoundKeys(logPrefix, rl, u, s, db, cfg, quit); err != nil {
+		return nil, err
+	}
+	loader := trie.NewFlatDBTrieLoader(logPrefix)
+	if err := loader.Reset(rl, nil, nil, false); err != nil {
+		return nil, err
+	}
+	return loader, nil
+}
+
+// retainUnwoundKeys adds the keys changed between u.UnwindPoint and s.BlockNumber to rl, marking the ones which
+// didn't exist at u.UnwindPoint
+func retainUnwoundKeys(logPrefix string, rl *trie.RetainList, u *UnwindState, s *StageState, db kv.RwTx, cfg TrieCfg, quit <-chan struct{}) error {
 	p := NewHashPromoter(db, cfg.tmpDir, quit, logPrefix)
-	rl := trie.NewRetainList(0)
 	if cfg.historyV2 {
 		cfg.agg.SetTx(db)
 		collect := func(k, v []byte, _ etl.CurrentTableReader, _ etl.LoadNextFunc) error {
@@ -666,6 +695,14 @@ func unwindIntermediateHashesStageImpl(logPrefix string, u *UnwindState, s *Stag
 			return err
 		}
 	}
+	return nil
+}
+
+func unwindIntermediateHashesStageImpl(logPrefix string, u *UnwindState, s *StageState, db kv.RwTx, cfg TrieCfg, expectedRootHash common.Hash, quit <-chan struct{}) error {
+	rl := trie.NewRetainList(0)
+	if err := retainUnwoundKeys(logPrefix, rl, u, s, db, cfg, quit); err != nil {
+		return err
+	}
 
 	accTrieCollector := etl.NewCollector(logPrefix, cfg.tmpDir, etl.NewSortableBuffer(etl.BufferOptimalSize))
 	defer accTrieCollector.Close()
@@ -265,6 +265,11 @@ Loop:
 		if cfg.hd != nil {
 			cfg.hd.ReportBadHeaderPoS(minBlockHash, minHeader.ParentHash)
 		}
+		if minBlock := rawdb.ReadBlock(tx, minBlockHash, minBlockNum); minBlock != nil {
+			if err := rawdb.WriteBadBlock(tx, minBlock, minBlockErr); err != nil {
+				return err
+			}
+		}
 		if to > s.BlockNumber {
 			u.UnwindTo(minBlockNum-1, minBlockHash)
 		}
@@ -44,6 +44,7 @@ var (
 	CallTraces          SyncStage = "CallTraces"          // Generating call traces index
 	TxLookup            SyncStage = "TxLookup"            // Generating transactions lookup index
 	Issuance            SyncStage = "WatchTheBurn"        // Compute ether issuance for each block
+	ChainStats          SyncStage = "ChainStats"          // Roll up the activity of the completed days and epochs
 	Finish              SyncStage = "Finish"              // Nominal stage after all other stages
 
 	MiningCreateBlock SyncStage = "MiningCreateBlock"
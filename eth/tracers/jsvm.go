@@ -105,7 +105,7 @@ func (vm *JSVM) GetPropString(objIndex int, key string) bool {
 	obj := vm.stack[objIndex].ToObject(vm.vm)
 	v := obj.Get(key)
 	vm.stack = append(vm.stack, v)
-	return !goja.IsUndefined(v)
+	return v != nil && !goja.IsUndefined(v) // missing properties are nil
 }
 
 func (vm *JSVM) PutPropString(objIndex int, key string) {
@@ -0,0 +1,196 @@
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/vm"
+)
+
+var callTypes = map[vm.CallType]string{
+	vm.CALLT:         "CALL",
+	vm.CALLCODET:     "CALLCODE",
+	vm.DELEGATECALLT: "DELEGATECALL",
+	vm.STATICCALLT:   "STATICCALL",
+	vm.CREATET:       "CREATE",
+	vm.CREATE2T:      "CREATE2",
+}
+
+type callLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+type callFrame struct {
+	Type         string          `json:"type"`
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to,omitempty"`
+	Value        *hexutil.Big    `json:"value,omitempty"`
+	Gas          hexutil.Uint64  `json:"gas"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	Input        hexutil.Bytes   `json:"input"`
+	Output       hexutil.Bytes   `json:"output,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+	Calls        []callFrame     `json:"calls,omitempty"`
+	Logs         []callLog       `json:"logs,omitempty"`
+}
+
+// setResult records the outcome of the call, the output is kept on failure only if the call reverted
+func (f *callFrame) setResult(output []byte, gasUsed uint64, err error) {
+	f.GasUsed = hexutil.Uint64(gasUsed)
+	if err == nil {
+		f.Output = common.CopyBytes(output)
+		return
+	}
+	f.Error = err.Error()
+	if f.Type == "CREATE" || f.Type == "CREATE2" {
+		f.To = nil
+	}
+	// the logs of a failed call are reverted
+	f.clearLogs()
+	if !errors.Is(err, vm.ErrExecutionReverted) || len(output) == 0 {
+		return
+	}
+	f.Output = common.CopyBytes(output)
+	if reason, err := abi.UnpackRevert(output); err == nil {
+		f.RevertReason = reason
+	}
+}
+
+func (f *callFrame) clearLogs() {
+	f.Logs = nil
+	for i := range f.Calls {
+		f.Calls[i].clearLogs()
+	}
+}
+
+type callTracerConfig struct {
+	OnlyTopCall bool `json:"onlyTopCall"` // don't trace the inner calls
+	WithLog     bool `json:"withLog"`     // include the logs emitted by the calls
+}
+
+// callTracer reports the tree of calls of a transaction, like the callTracer JavaScript tracer
+type callTracer struct {
+	interrupt
+	config    callTracerConfig
+	callstack []callFrame
+	gasLimit  uint64
+}
+
+func newCallTracer(cfg json.RawMessage) (Tracer, error) {
+	t := &callTracer{}
+	if err := parseConfig(cfg, &t.config); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *callTracer) CaptureTxStart(_ vm.IntraBlockState, msg core.Message) {
+	t.gasLimit = msg.Gas()
+}
+
+// CaptureTxEnd reports the gas of the transaction for the top call, instead of the gas left after the intrinsic
+// gas was charged
+func (t *callTracer) CaptureTxEnd(usedGas uint64) {
+	if len(t.callstack) == 0 {
+		return
+	}
+	t.callstack[0].Gas = hexutil.Uint64(t.gasLimit)
+	t.callstack[0].GasUsed = hexutil.Uint64(usedGas)
+}
+
+func (t *callTracer) CaptureStart(env *vm.EVM, depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) {
+	if t.interrupted() {
+		env.Cancel()
+	}
+	if depth > 0 && t.config.OnlyTopCall {
+		return
+	}
+	frame := callFrame{
+		Type:  callTypes[callType],
+		From:  from,
+		To:    &to,
+		Gas:   hexutil.Uint64(gas),
+		Input: common.CopyBytes(input),
+	}
+	// delegate and static calls are reported with negative values, they don't transfer any
+	if value != nil && value.Sign() >= 0 {
+		frame.Value = (*hexutil.Big)(new(big.Int).Set(value))
+	}
+	if depth == 0 {
+		t.callstack = []callFrame{frame}
+		return
+	}
+	t.callstack = append(t.callstack, frame)
+}
+
+func (t *callTracer) CaptureEnd(depth int, output []byte, startGas, endGas uint64, _ time.Duration, err error) {
+	if depth == 0 {
+		if len(t.callstack) > 0 {
+			t.callstack[0].setResult(output, startGas-endGas, err)
+		}
+		return
+	}
+	if t.config.OnlyTopCall || len(t.callstack) <= 1 {
+		return
+	}
+	frame := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	frame.setResult(output, startGas-endGas, err)
+	parent := &t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, frame)
+}
+
+func (t *callTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if !t.config.WithLog || err != nil || op < vm.LOG0 || op > vm.LOG4 || len(t.callstack) == 0 {
+		return
+	}
+	// the steps of the top call are at depth 1
+	if t.config.OnlyTopCall && depth > 1 {
+		return
+	}
+	offset, size := scope.Stack.Back(0), scope.Stack.Back(1)
+	topics := make([]common.Hash, int(op-vm.LOG0))
+	for i := range topics {
+		topics[i] = common.Hash(scope.Stack.Back(2 + i).Bytes32())
+	}
+	frame := &t.callstack[len(t.callstack)-1]
+	frame.Logs = append(frame.Logs, callLog{
+		Address: scope.Contract.Address(),
+		Topics:  topics,
+		Data:    scope.Memory.GetCopy(offset.Uint64(), size.Uint64()),
+	})
+}
+
+func (t *callTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *callTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+}
+
+func (t *callTracer) CaptureAccountRead(account common.Address) error {
+	return nil
+}
+
+func (t *callTracer) CaptureAccountWrite(account common.Address) error {
+	return nil
+}
+
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	if len(t.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	res, err := json.Marshal(t.callstack[0])
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
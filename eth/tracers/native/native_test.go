@@ -0,0 +1,118 @@
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/tests"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	sender    = common.HexToAddress("0x1000")
+	contractA = common.HexToAddress("0xaa")
+	contractB = common.HexToAddress("0xbb")
+	coinbase  = common.HexToAddress("0xc0")
+)
+
+// traceTx runs a transaction calling contractA with the tracer, like TraceTx does
+func traceTx(t *testing.T, name string, cfg string) (json.RawMessage, *core.ExecutionResult) {
+	t.Helper()
+	alloc := core.GenesisAlloc{
+		sender: {Balance: big.NewInt(1e18)},
+		// stores 42 in memory and logs it with topic 1, sets slot 0 to 1 and calls contractB
+		contractA: {Balance: new(big.Int), Code: hexutil.MustDecode("0x602a600052600160206000a160016000556000600060006000600060bb5af15000")},
+		// logs and reverts
+		contractB: {Balance: new(big.Int), Code: hexutil.MustDecode("0x60006000a060006000fd")},
+	}
+	_, tx := memdb.NewTestTx(t)
+	rules := &params.Rules{}
+	ibs, err := tests.MakePreState(rules, tx, alloc, 1)
+	require.NoError(t, err)
+
+	tracer, ok, err := New(name, json.RawMessage(cfg))
+	require.True(t, ok)
+	require.NoError(t, err)
+
+	blockCtx := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Coinbase:    coinbase,
+		BlockNumber: 1,
+		Difficulty:  big.NewInt(1),
+		GasLimit:    1000000,
+	}
+	msg := types.NewMessage(sender, &contractA, 0, uint256.NewInt(0), 100000, uint256.NewInt(1), uint256.NewInt(1), uint256.NewInt(1), nil, nil, true)
+	evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), ibs, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+
+	txTracer := tracer.(interface {
+		CaptureTxStart(ibs vm.IntraBlockState, msg core.Message)
+		CaptureTxEnd(usedGas uint64)
+	})
+	txTracer.CaptureTxStart(ibs, msg)
+	result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(msg.Gas()), true, false)
+	require.NoError(t, err)
+	txTracer.CaptureTxEnd(result.UsedGas)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	return res, result
+}
+
+func TestCallTracer(t *testing.T) {
+	res, result := traceTx(t, "callTracer", `{"withLog":true}`)
+	var frame callFrame
+	require.NoError(t, json.Unmarshal(res, &frame))
+
+	require.Equal(t, "CALL", frame.Type)
+	require.Equal(t, contractA, *frame.To)
+	require.Equal(t, hexutil.Uint64(100000), frame.Gas)
+	require.Equal(t, hexutil.Uint64(result.UsedGas), frame.GasUsed)
+	require.Equal(t, []callLog{{Address: contractA, Topics: []common.Hash{common.BigToHash(big.NewInt(1))}, Data: common.BigToHash(big.NewInt(42)).Bytes()}}, frame.Logs)
+
+	require.Len(t, frame.Calls, 1)
+	inner := frame.Calls[0]
+	require.Equal(t, contractB, *inner.To)
+	require.Equal(t, "execution reverted", inner.Error)
+	require.Empty(t, inner.Logs, "the logs of a reverted call must be dropped")
+
+	res, _ = traceTx(t, "callTracer", `{"onlyTopCall":true}`)
+	frame = callFrame{}
+	require.NoError(t, json.Unmarshal(res, &frame))
+	require.Empty(t, frame.Calls)
+	require.Empty(t, frame.Logs)
+}
+
+func TestPrestateTracer(t *testing.T) {
+	res, _ := traceTx(t, "prestateTracer", "")
+	var pre map[common.Address]*account
+	require.NoError(t, json.Unmarshal(res, &pre))
+	require.Contains(t, pre, contractB)
+	require.Equal(t, big.NewInt(1e18), pre[sender].Balance.ToInt())
+	require.Equal(t, uint64(0), pre[sender].Nonce)
+	require.Equal(t, map[common.Hash]common.Hash{{}: {}}, pre[contractA].Storage)
+
+	res, result := traceTx(t, "prestateTracer", `{"diffMode":true}`)
+	var diff struct {
+		Pre  map[common.Address]*account `json:"pre"`
+		Post map[common.Address]*account `json:"post"`
+	}
+	require.NoError(t, json.Unmarshal(res, &diff))
+	require.NotContains(t, diff.Pre, contractB, "unmodified accounts are not reported")
+	require.NotContains(t, diff.Post, contractB)
+
+	require.Equal(t, uint64(1), diff.Post[sender].Nonce)
+	require.Equal(t, new(big.Int).Sub(big.NewInt(1e18), new(big.Int).SetUint64(result.UsedGas)), diff.Post[sender].Balance.ToInt())
+	require.Equal(t, map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(1))}, diff.Post[contractA].Storage)
+	require.Nil(t, diff.Post[contractA].Balance)
+	require.Equal(t, new(big.Int).SetUint64(result.UsedGas), diff.Post[coinbase].Balance.ToInt())
+}
@@ -0,0 +1,208 @@
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/vm"
+)
+
+type account struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+func (a *account) exists() bool {
+	return a.Nonce > 0 || len(a.Code) > 0 || len(a.Storage) > 0 || (a.Balance != nil && a.Balance.ToInt().Sign() != 0)
+}
+
+type prestateTracerConfig struct {
+	DiffMode bool `json:"diffMode"` // report the state before and after the transaction, of the modified accounts only
+}
+
+// prestateTracer reports the state of the accounts touched by a transaction before its execution, like the
+// prestateTracer JavaScript tracer. In diffMode it reports the modified fields of the accounts before and after.
+type prestateTracer struct {
+	interrupt
+	config  prestateTracerConfig
+	ibs     vm.IntraBlockState
+	pre     map[common.Address]*account
+	post    map[common.Address]*account
+	created map[common.Address]bool
+}
+
+func newPrestateTracer(cfg json.RawMessage) (Tracer, error) {
+	t := &prestateTracer{
+		pre:     make(map[common.Address]*account),
+		post:    make(map[common.Address]*account),
+		created: make(map[common.Address]bool),
+	}
+	if err := parseConfig(cfg, &t.config); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CaptureTxStart records the sender and the recipient before the gas is bought and the nonce incremented
+func (t *prestateTracer) CaptureTxStart(ibs vm.IntraBlockState, msg core.Message) {
+	t.ibs = ibs
+	t.lookupAccount(msg.From())
+	if msg.To() != nil {
+		t.lookupAccount(*msg.To())
+	}
+}
+
+// CaptureTxEnd computes the changes made by the transaction in diffMode
+func (t *prestateTracer) CaptureTxEnd(uint64) {
+	if t.ibs == nil || !t.config.DiffMode {
+		return
+	}
+	for addr, pre := range t.pre {
+		// selfdestructed accounts are only reported in pre
+		if t.ibs.HasSuicided(addr) {
+			continue
+		}
+		post := &account{}
+		modified := false
+		if balance := t.ibs.GetBalance(addr).ToBig(); balance.Cmp(pre.Balance.ToInt()) != 0 {
+			post.Balance = (*hexutil.Big)(balance)
+			modified = true
+		}
+		if nonce := t.ibs.GetNonce(addr); nonce != pre.Nonce {
+			post.Nonce = nonce
+			modified = true
+		}
+		if code := t.ibs.GetCode(addr); !bytes.Equal(code, pre.Code) {
+			post.Code = common.CopyBytes(code)
+			modified = true
+		}
+		for key, preValue := range pre.Storage {
+			key := key
+			var value uint256.Int
+			t.ibs.GetState(addr, &key, &value)
+			postValue := common.Hash(value.Bytes32())
+			if postValue == preValue {
+				// only the modified slots are reported
+				delete(pre.Storage, key)
+				continue
+			}
+			if post.Storage == nil {
+				post.Storage = make(map[common.Hash]common.Hash)
+			}
+			post.Storage[key] = postValue
+			modified = true
+		}
+		if modified {
+			t.post[addr] = post
+		} else {
+			delete(t.pre, addr)
+		}
+	}
+}
+
+func (t *prestateTracer) CaptureStart(env *vm.EVM, depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) {
+	if t.interrupted() {
+		env.Cancel()
+		return
+	}
+	if t.ibs == nil {
+		t.ibs = env.IntraBlockState()
+	}
+	if depth == 0 {
+		t.lookupAccount(from)
+		t.lookupAccount(env.Context().Coinbase)
+	}
+	t.lookupAccount(to)
+	if create {
+		t.created[to] = true
+	}
+}
+
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if err != nil || t.interrupted() {
+		return
+	}
+	stack := scope.Stack
+	switch {
+	case stack.Len() >= 1 && (op == vm.SLOAD || op == vm.SSTORE):
+		t.lookupStorage(scope.Contract.Address(), common.Hash(stack.Back(0).Bytes32()))
+	case stack.Len() >= 1 && (op == vm.EXTCODECOPY || op == vm.EXTCODEHASH || op == vm.EXTCODESIZE || op == vm.BALANCE || op == vm.SELFDESTRUCT):
+		t.lookupAccount(common.Address(stack.Back(0).Bytes20()))
+	}
+}
+
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *prestateTracer) CaptureEnd(depth int, output []byte, startGas, endGas uint64, _ time.Duration, err error) {
+}
+
+func (t *prestateTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+}
+
+func (t *prestateTracer) CaptureAccountRead(account common.Address) error {
+	return nil
+}
+
+func (t *prestateTracer) CaptureAccountWrite(account common.Address) error {
+	return nil
+}
+
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	// the created contracts didn't exist before
+	for addr := range t.created {
+		if pre, ok := t.pre[addr]; ok && !pre.exists() {
+			delete(t.pre, addr)
+		}
+	}
+	var res []byte
+	var err error
+	if t.config.DiffMode {
+		res, err = json.Marshal(struct {
+			Post map[common.Address]*account `json:"post"`
+			Pre  map[common.Address]*account `json:"pre"`
+		}{t.post, t.pre})
+	} else {
+		res, err = json.Marshal(t.pre)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// lookupAccount records the state of an account the first time it is accessed
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.pre[addr]; ok {
+		return
+	}
+	t.pre[addr] = &account{
+		Balance: (*hexutil.Big)(t.ibs.GetBalance(addr).ToBig()),
+		Nonce:   t.ibs.GetNonce(addr),
+		Code:    common.CopyBytes(t.ibs.GetCode(addr)),
+	}
+}
+
+// lookupStorage records the value of a storage slot at the beginning of the transaction, the first time it is
+// accessed
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+	acc := t.pre[addr]
+	if _, ok := acc.Storage[key]; ok {
+		return
+	}
+	if acc.Storage == nil {
+		acc.Storage = make(map[common.Hash]common.Hash)
+	}
+	var value uint256.Int
+	t.ibs.GetCommittedState(addr, &key, &value)
+	acc.Storage[key] = common.Hash(value.Bytes32())
+}
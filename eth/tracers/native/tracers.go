@@ -0,0 +1,58 @@
+// Package native is a collection of tracers written in Go, they produce the same output as the JavaScript tracers
+// of the same name, much faster.
+package native
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/ledgerwatch/erigon/core/vm"
+)
+
+// Tracer is a vm.Tracer which produces a JSON result once the transaction has been traced
+type Tracer interface {
+	vm.Tracer
+	GetResult() (json.RawMessage, error)
+	Stop(err error)
+}
+
+var ctors = map[string]func(cfg json.RawMessage) (Tracer, error){
+	"callTracer":     newCallTracer,
+	"prestateTracer": newPrestateTracer,
+}
+
+// New creates the native tracer called name, configured with cfg. false is returned if there is no such tracer.
+func New(name string, cfg json.RawMessage) (Tracer, bool, error) {
+	ctor, ok := ctors[name]
+	if !ok {
+		return nil, false, nil
+	}
+	tracer, err := ctor(cfg)
+	if err != nil {
+		return nil, true, err
+	}
+	return tracer, true, nil
+}
+
+// parseConfig decodes the tracerConfig of a trace request, it may be empty
+func parseConfig(cfg json.RawMessage, v interface{}) error {
+	if len(cfg) == 0 {
+		return nil
+	}
+	return json.Unmarshal(cfg, v)
+}
+
+// interrupt implements Stop for the tracers, they stop recording once it's called
+type interrupt struct {
+	stopped uint32
+	reason  error
+}
+
+func (i *interrupt) Stop(err error) {
+	i.reason = err
+	atomic.StoreUint32(&i.stopped, 1)
+}
+
+func (i *interrupt) interrupted() bool {
+	return atomic.LoadUint32(&i.stopped) > 0
+}
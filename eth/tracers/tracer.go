@@ -330,8 +330,9 @@ type Context struct {
 
 // New instantiates a new tracer instance. code specifies a Javascript snippet,
 // which must evaluate to an expression returning an object with 'step', 'fault'
-// and 'result' functions.
-func New(code string, ctx *Context) (*Tracer, error) {
+// and 'result' functions. If the object also has a 'setup' function, it is
+// called once with cfg as a JSON string, "{}" if cfg is empty.
+func New(code string, ctx *Context, cfg json.RawMessage) (*Tracer, error) {
 	// Resolve any tracers by name and assemble the tracer object
 	if tracer, ok := tracer(code); ok {
 		code = tracer
@@ -533,6 +534,20 @@ func New(code string, ctx *Context) (*Tracer, error) {
 	tracer.dbWrapper.pushObject(tracer.vm)
 	tracer.vm.PutPropString(tracer.stateObject, "db")
 
+	// Hand the user supplied configuration to the tracer if it accepts one
+	hasSetup := tracer.vm.GetPropString(tracer.tracerObject, "setup")
+	tracer.vm.Pop()
+	if hasSetup {
+		if len(cfg) == 0 {
+			cfg = json.RawMessage("{}")
+		}
+		tracer.vm.PushString(string(cfg))
+		tracer.vm.PutPropString(tracer.stateObject, "config")
+		if _, err := tracer.call(true, "setup", "config"); err != nil {
+			return nil, wrapError("setup", err)
+		}
+	}
+
 	return tracer, nil
 }
 
@@ -86,7 +86,7 @@ func TestTracer(t *testing.T) {
 		ctx := &vmContext{blockCtx: vm.BlockContext{
 			BlockNumber: 1,
 		}, txCtx: vm.TxContext{GasPrice: big.NewInt(100000)}}
-		tracer, err := New(code, new(Context))
+		tracer, err := New(code, new(Context), nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -130,12 +130,39 @@ func TestTracer(t *testing.T) {
 	}
 }
 
+func TestTracerSetup(t *testing.T) {
+	code := "{opcodes: [], setup: function(cfg) { this.only = JSON.parse(cfg).only; }, step: function(log) { if (!this.only || log.op.toString() == this.only) this.opcodes.push(log.op.toString()); }, fault: function() {}, result: function() { return this.opcodes; }}"
+	for i, tt := range []struct {
+		cfg  json.RawMessage
+		want string
+	}{
+		{cfg: nil, want: `["PUSH1","PUSH1","STOP"]`},
+		{cfg: json.RawMessage(`{"only":"STOP"}`), want: `["STOP"]`},
+	} {
+		tracer, err := New(code, new(Context), tt.cfg)
+		if err != nil {
+			t.Fatalf("testcase %d: %v", i, err)
+		}
+		have, err := runTrace(tracer, testCtx())
+		if err != nil {
+			t.Fatalf("testcase %d: %v", i, err)
+		}
+		if string(have) != tt.want {
+			t.Errorf("testcase %d: expected %s, got %s", i, tt.want, have)
+		}
+	}
+
+	if _, err := New("{setup: function(cfg) { JSON.parse(cfg); }, step: function() {}, fault: function() {}, result: function() {}}", new(Context), json.RawMessage(`{`)); err == nil {
+		t.Error("expected an error for an invalid config")
+	}
+}
+
 func TestHalt(t *testing.T) {
 	t.Skip("duktape doesn't support abortion")
 
 	timeout := errors.New("stahp")
 	vmctx := testCtx()
-	tracer, err := New("{step: function() { while(1); }, result: function() { return null; }}", new(Context))
+	tracer, err := New("{step: function() { while(1); }, result: function() { return null; }}", new(Context), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -151,7 +178,7 @@ func TestHalt(t *testing.T) {
 }
 
 func TestHaltBetweenSteps(t *testing.T) {
-	tracer, err := New("{step: function() {}, fault: function() {}, result: function() { return null; }}", new(Context))
+	tracer, err := New("{step: function() {}, fault: function() {}, result: function() { return null; }}", new(Context), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -184,7 +211,7 @@ func TestNoStepExec(t *testing.T) {
 	execTracer := func(code string) []byte {
 		t.Helper()
 		ctx := &vmContext{blockCtx: vm.BlockContext{BlockNumber: 1}, txCtx: vm.TxContext{GasPrice: big.NewInt(100000)}}
-		tracer, err := New(code, new(Context))
+		tracer, err := New(code, new(Context), nil)
 		if err != nil {
 			t.Fatal(err)
 		}
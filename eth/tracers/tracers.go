@@ -18,12 +18,46 @@
 package tracers
 
 import (
+	"encoding/json"
 	"strings"
 	"unicode"
 
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/eth/tracers/internal/tracers"
+	"github.com/ledgerwatch/erigon/eth/tracers/native"
 )
 
+// ResultTracer is a vm.Tracer which produces a JSON result once the transaction has been traced, the JavaScript
+// and the native tracers implement it.
+type ResultTracer interface {
+	vm.Tracer
+	GetResult() (json.RawMessage, error)
+	Stop(err error)
+}
+
+// TxTracer is implemented by the tracers which observe the whole transaction rather than only its execution in
+// the EVM: CaptureTxStart is called before the message is applied, CaptureTxEnd with the gas it used.
+type TxTracer interface {
+	CaptureTxStart(ibs vm.IntraBlockState, msg core.Message)
+	CaptureTxEnd(usedGas uint64)
+}
+
+// NewTracer creates the native tracer called code if there is one, otherwise the JavaScript tracer of code
+func NewTracer(code string, ctx *Context, cfg json.RawMessage) (ResultTracer, error) {
+	if tracer, ok, err := native.New(code, cfg); ok {
+		if err != nil {
+			return nil, err
+		}
+		return tracer, nil
+	}
+	tracer, err := New(code, ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return tracer, nil
+}
+
 // all contains all the built in JavaScript tracers by name.
 var all = make(map[string]string)
 
@@ -13,6 +13,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cmd/sentry/sentryadmin"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/consensus/serenity"
 	"github.com/ledgerwatch/erigon/core"
@@ -46,6 +47,7 @@ var InvalidPayloadAttributesErr = rpc.CustomError{Code: -38003, Message: "Invali
 
 type EthBackendServer struct {
 	remote.UnimplementedETHBACKENDServer // must be embedded to have forward compatible implementations.
+	sentryadmin.UnimplementedSentryAdminServer
 
 	ctx         context.Context
 	eth         EthBackend
@@ -70,6 +72,10 @@ type EthBackend interface {
 	NetPeerCount() (uint64, error)
 	NodesInfo(limit int) (*remote.NodesInfoReply, error)
 	Peers(ctx context.Context) (*remote.PeersReply, error)
+	AddPeer(ctx context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error)
+	RemovePeer(ctx context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error)
+	AddTrustedPeer(ctx context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error)
+	RemoveTrustedPeer(ctx context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error)
 }
 
 func NewEthBackendServer(ctx context.Context, eth EthBackend, db kv.RwDB, events *Events, blockReader services.BlockAndTxnReader,
@@ -672,6 +678,22 @@ func (s *EthBackendServer) Peers(ctx context.Context, _ *emptypb.Empty) (*remote
 	return s.eth.Peers(ctx)
 }
 
+func (s *EthBackendServer) AddPeer(ctx context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error) {
+	return s.eth.AddPeer(ctx, req)
+}
+
+func (s *EthBackendServer) RemovePeer(ctx context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error) {
+	return s.eth.RemovePeer(ctx, req)
+}
+
+func (s *EthBackendServer) AddTrustedPeer(ctx context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error) {
+	return s.eth.AddTrustedPeer(ctx, req)
+}
+
+func (s *EthBackendServer) RemoveTrustedPeer(ctx context.Context, req *sentryadmin.PeerRequest) (*emptypb.Empty, error) {
+	return s.eth.RemoveTrustedPeer(ctx, req)
+}
+
 func (s *EthBackendServer) SubscribeLogs(server remote.ETHBACKEND_SubscribeLogsServer) (err error) {
 	if s.logsFilter != nil {
 		return s.logsFilter.subscribeLogs(server)
@@ -20,7 +20,6 @@ import (
 	"fmt"
 	"net/http"
 	_ "net/http/pprof" //nolint:gosec
-	"os"
 
 	metrics2 "github.com/VictoriaMetrics/metrics"
 	"github.com/ledgerwatch/erigon/common/fdlimit"
@@ -41,10 +40,9 @@ var (
 		Name:  "log.json",
 		Usage: "Format logs with JSON",
 	}
-	//nolint
 	vmoduleFlag = cli.StringFlag{
 		Name:  "vmodule",
-		Usage: "Per-module verbosity: comma-separated list of <pattern>=<level> (e.g. eth/*=5,p2p=4)",
+		Usage: "Per-module verbosity of console and log files: comma-separated list of <pattern>=<level> (e.g. eth/*=5,p2p=4)",
 		Value: "",
 	}
 	metricsAddrFlag = cli.StringFlag{
@@ -80,7 +78,9 @@ var (
 
 // Flags holds all command-line flags required for debugging.
 var Flags = []cli.Flag{
-	verbosityFlag, logjsonFlag, //backtraceAtFlag, vmoduleFlag, debugFlag,
+	verbosityFlag, logjsonFlag, vmoduleFlag, //backtraceAtFlag, debugFlag,
+	logDirPathFlag, logDirVerbosityFlag, logDirJsonFlag,
+	logRotateSizeFlag, logRotateIntervalFlag, logRotateKeepFlag, logRotateCompressFlag,
 	pprofFlag, pprofAddrFlag, pprofPortFlag,
 	cpuprofileFlag, traceFlag,
 }
@@ -97,7 +97,7 @@ func init() {
 func SetupCobra(cmd *cobra.Command) error {
 	RaiseFdLimit()
 	flags := cmd.Flags()
-	lvl, err := flags.GetInt(verbosityFlag.Name)
+	logCfg, err := loggingConfigFromCobra(flags)
 	if err != nil {
 		return err
 	}
@@ -119,7 +119,9 @@ func SetupCobra(cmd *cobra.Command) error {
 		_, glogger = log.SetupDefaultTerminalLogger(log.Lvl(lvl), vmodule, backtrace)
 		log.PrintOrigins(dbg)
 	*/
-	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(lvl), log.StderrHandler))
+	if err = setupLogging(logCfg); err != nil {
+		return err
+	}
 
 	traceFile, err := flags.GetString(traceFlag.Name)
 	if err != nil {
@@ -182,15 +184,9 @@ func SetupCobra(cmd *cobra.Command) error {
 // It should be called as early as possible in the program.
 func Setup(ctx *cli.Context) error {
 	RaiseFdLimit()
-	//var ostream log.Handler
-	//output := io.Writer(os.Stderr)
-	if ctx.Bool(logjsonFlag.Name) {
-		log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(ctx.Int(verbosityFlag.Name)), log.StreamHandler(os.Stderr, log.JsonFormat())))
-		//ostream = log.StreamHandler(output, log.JsonFormat())
-	} else {
-		log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(ctx.Int(verbosityFlag.Name)), log.StderrHandler))
+	if err := setupLogging(loggingConfigFromCli(ctx)); err != nil {
+		return err
 	}
-	//log.Root().SetHandler(ostream)
 
 	/*
 		glogger.SetHandler(ostream)
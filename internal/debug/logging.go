@@ -0,0 +1,384 @@
+package debug
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/spf13/pflag"
+	"github.com/urfave/cli"
+)
+
+var (
+	logDirPathFlag = cli.StringFlag{
+		Name:  "log.dir.path",
+		Usage: "Path to a directory for log files, they are written in addition to the console and rotated. Disabled if empty",
+	}
+	logDirVerbosityFlag = cli.IntFlag{
+		Name:  "log.dir.verbosity",
+		Usage: "Verbosity of the log files: 0=silent, 1=error, 2=warn, 3=info, 4=debug, 5=detail",
+		Value: int(log.LvlInfo),
+	}
+	logDirJsonFlag = cli.BoolFlag{
+		Name:  "log.dir.json",
+		Usage: "Format log files with JSON",
+	}
+	logRotateSizeFlag = cli.IntFlag{
+		Name:  "log.rotate.size",
+		Usage: "Rotate the log file when it reaches this size, in megabytes. 0 disables size based rotation",
+		Value: 100,
+	}
+	logRotateIntervalFlag = cli.StringFlag{
+		Name:  "log.rotate.interval",
+		Usage: "Rotate the log file when it gets older than this duration (e.g. 24h). 0 disables time based rotation",
+		Value: "24h",
+	}
+	logRotateKeepFlag = cli.IntFlag{
+		Name:  "log.rotate.keep",
+		Usage: "Number of rotated log files to keep, older ones are removed. 0 keeps all",
+		Value: 10,
+	}
+	logRotateCompressFlag = cli.BoolFlag{
+		Name:  "log.rotate.compress",
+		Usage: "Gzip rotated log files",
+	}
+)
+
+// loggingConfig is the logging part of the debug flags, shared by the urfave and the cobra based binaries
+type loggingConfig struct {
+	consoleVerbosity int
+	consoleJson      bool
+	vmodule          string
+
+	dirPath      string
+	dirVerbosity int
+	dirJson      bool
+
+	rotateSize     int
+	rotateInterval string
+	rotateKeep     int
+	rotateCompress bool
+}
+
+func loggingConfigFromCli(ctx *cli.Context) loggingConfig {
+	return loggingConfig{
+		consoleVerbosity: ctx.Int(verbosityFlag.Name),
+		consoleJson:      ctx.Bool(logjsonFlag.Name),
+		vmodule:          ctx.String(vmoduleFlag.Name),
+		dirPath:          ctx.String(logDirPathFlag.Name),
+		dirVerbosity:     ctx.Int(logDirVerbosityFlag.Name),
+		dirJson:          ctx.Bool(logDirJsonFlag.Name),
+		rotateSize:       ctx.Int(logRotateSizeFlag.Name),
+		rotateInterval:   ctx.String(logRotateIntervalFlag.Name),
+		rotateKeep:       ctx.Int(logRotateKeepFlag.Name),
+		rotateCompress:   ctx.Bool(logRotateCompressFlag.Name),
+	}
+}
+
+func loggingConfigFromCobra(flags *pflag.FlagSet) (cfg loggingConfig, err error) {
+	if cfg.consoleVerbosity, err = flags.GetInt(verbosityFlag.Name); err != nil {
+		return cfg, err
+	}
+	if cfg.consoleJson, err = flags.GetBool(logjsonFlag.Name); err != nil {
+		return cfg, err
+	}
+	if cfg.vmodule, err = flags.GetString(vmoduleFlag.Name); err != nil {
+		return cfg, err
+	}
+	if cfg.dirPath, err = flags.GetString(logDirPathFlag.Name); err != nil {
+		return cfg, err
+	}
+	if cfg.dirVerbosity, err = flags.GetInt(logDirVerbosityFlag.Name); err != nil {
+		return cfg, err
+	}
+	if cfg.dirJson, err = flags.GetBool(logDirJsonFlag.Name); err != nil {
+		return cfg, err
+	}
+	if cfg.rotateSize, err = flags.GetInt(logRotateSizeFlag.Name); err != nil {
+		return cfg, err
+	}
+	if cfg.rotateInterval, err = flags.GetString(logRotateIntervalFlag.Name); err != nil {
+		return cfg, err
+	}
+	if cfg.rotateKeep, err = flags.GetInt(logRotateKeepFlag.Name); err != nil {
+		return cfg, err
+	}
+	if cfg.rotateCompress, err = flags.GetBool(logRotateCompressFlag.Name); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// setupLogging installs the root log handler: console output and, if configured, rotated log files. Both of
+// them have their own verbosity, which can be overridden per source directory with --vmodule.
+func setupLogging(cfg loggingConfig) error {
+	rules, err := parseVmodule(cfg.vmodule)
+	if err != nil {
+		return err
+	}
+	console := log.StderrHandler
+	if cfg.consoleJson {
+		console = log.StreamHandler(os.Stderr, log.JsonFormat())
+	}
+	handler := vmoduleFilterHandler(log.Lvl(cfg.consoleVerbosity), rules, console)
+
+	if cfg.dirPath != "" {
+		interval, err := parseRotateInterval(cfg.rotateInterval)
+		if err != nil {
+			return err
+		}
+		w, err := newRotatingFile(cfg.dirPath, filepath.Base(os.Args[0]), int64(cfg.rotateSize)*1024*1024, interval, cfg.rotateKeep, cfg.rotateCompress)
+		if err != nil {
+			return err
+		}
+		format := log.TerminalFormatNoColor()
+		if cfg.dirJson {
+			format = log.JsonFormat()
+		}
+		file := vmoduleFilterHandler(log.Lvl(cfg.dirVerbosity), rules, log.StreamHandler(w, format))
+		handler = log.MultiHandler(handler, file)
+	}
+	log.Root().SetHandler(handler)
+	return nil
+}
+
+func parseRotateInterval(s string) (time.Duration, error) {
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --%s: %w", logRotateIntervalFlag.Name, err)
+	}
+	return d, nil
+}
+
+// vmoduleRule overrides the verbosity of records logged from source files in matching directories
+type vmoduleRule struct {
+	dir       string // slash separated directory suffix, e.g. "eth/stagedsync"
+	recursive bool   // pattern ended with "/*", subdirectories match too
+	lvl       log.Lvl
+}
+
+func (r vmoduleRule) match(dir string) bool {
+	if strings.HasSuffix(dir, "/"+r.dir) || dir == r.dir {
+		return true
+	}
+	return r.recursive && strings.Contains(dir+"/", "/"+r.dir+"/")
+}
+
+// parseVmodule parses the --vmodule syntax: comma-separated list of <pattern>=<level>, e.g. "eth/*=5,p2p=4"
+func parseVmodule(s string) ([]vmoduleRule, error) {
+	var rules []vmoduleRule
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, "=")
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --%s item %q, expected <pattern>=<level>", vmoduleFlag.Name, item)
+		}
+		lvl, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s level in %q: %w", vmoduleFlag.Name, item, err)
+		}
+		rule := vmoduleRule{dir: strings.Trim(parts[0], "/"), lvl: log.Lvl(lvl)}
+		if strings.HasSuffix(rule.dir, "/*") || rule.dir == "*" {
+			rule.recursive = true
+			rule.dir = strings.TrimSuffix(strings.TrimSuffix(rule.dir, "*"), "/")
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func vmoduleFilterHandler(lvl log.Lvl, rules []vmoduleRule, h log.Handler) log.Handler {
+	if len(rules) == 0 {
+		return log.LvlFilterHandler(lvl, h)
+	}
+	var cache sync.Map // source file -> log.Lvl
+	return log.FilterHandler(func(r *log.Record) bool {
+		file := r.Call.Frame().File
+		maxLvl, ok := cache.Load(file)
+		if !ok {
+			maxLvl = lvl
+			dir := filepath.ToSlash(filepath.Dir(file))
+			for _, rule := range rules {
+				if rule.dir == "" || rule.match(dir) {
+					maxLvl = rule.lvl
+				}
+			}
+			cache.Store(file, maxLvl)
+		}
+		return r.Lvl <= maxLvl.(log.Lvl)
+	}, h)
+}
+
+// rotatingFile is an io.Writer appending to <dir>/<name>.log, which is renamed to <name>-<timestamp>.log (and
+// optionally gzipped) when it gets too big or too old
+type rotatingFile struct {
+	lock sync.Mutex
+
+	dir, name string
+	maxSize   int64
+	maxAge    time.Duration
+	keep      int
+	compress  bool
+
+	f        *os.File
+	size     int64
+	openedAt time.Time
+
+	// the rotated files are compressed and the old ones removed by one goroutine at a time
+	cleanupLock sync.Mutex
+	rotated     []string // the rotated files waiting for the cleanup
+	cleaning    bool
+}
+
+func newRotatingFile(dir, name string, maxSize int64, maxAge time.Duration, keep int, compress bool) (*rotatingFile, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	w := &rotatingFile{dir: dir, name: name, maxSize: maxSize, maxAge: maxAge, keep: keep, compress: compress}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotatingFile) path() string { return filepath.Join(w.dir, w.name+".log") }
+
+func (w *rotatingFile) open() error {
+	f, err := os.OpenFile(w.path(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.f, w.size, w.openedAt = f, st.Size(), time.Now()
+	return nil
+}
+
+func (w *rotatingFile) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.size > 0 && ((w.maxSize > 0 && w.size+int64(len(p)) > w.maxSize) || (w.maxAge > 0 && time.Since(w.openedAt) > w.maxAge)) {
+		if err := w.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingFile) rotate() error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	rotated := w.rotatedPath(time.Now())
+	if err := os.Rename(w.path(), rotated); err != nil {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	w.cleanupLock.Lock()
+	w.rotated = append(w.rotated, rotated)
+	start := !w.cleaning
+	w.cleaning = true
+	w.cleanupLock.Unlock()
+	if start {
+		go w.cleanup()
+	}
+	return nil
+}
+
+// cleanup compresses the rotated files and removes the old ones, until no file waits for it
+func (w *rotatingFile) cleanup() {
+	for {
+		w.cleanupLock.Lock()
+		rotated := w.rotated
+		w.rotated = nil
+		if len(rotated) == 0 {
+			w.cleaning = false
+		}
+		w.cleanupLock.Unlock()
+		if len(rotated) == 0 {
+			return
+		}
+		if w.compress {
+			for _, path := range rotated {
+				if err := gzipFile(path); err != nil {
+					fmt.Fprintf(os.Stderr, "log compression failed: %v\n", err)
+				}
+			}
+		}
+		w.removeOld()
+	}
+}
+
+// rotatedPath returns an unused name for the file rotated at t
+func (w *rotatingFile) rotatedPath(t time.Time) string {
+	for {
+		p := filepath.Join(w.dir, fmt.Sprintf("%s-%s.log", w.name, t.UTC().Format("2006-01-02T15-04-05.000000000")))
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			if _, err := os.Stat(p + ".gz"); os.IsNotExist(err) {
+				return p
+			}
+		}
+		t = t.Add(time.Nanosecond)
+	}
+}
+
+// removeOld deletes the oldest rotated files beyond the configured number to keep
+func (w *rotatingFile) removeOld() {
+	if w.keep <= 0 {
+		return
+	}
+	matches, err := filepath.Glob(filepath.Join(w.dir, w.name+"-*.log*"))
+	if err != nil {
+		return
+	}
+	sort.Strings(matches) // timestamps in the names sort chronologically
+	for len(matches) > w.keep {
+		_ = os.Remove(matches[0])
+		matches = matches[1:]
+	}
+}
+
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err = io.Copy(zw, in); err == nil {
+		err = zw.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,73 @@
+package debug
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVmodule(t *testing.T) {
+	rules, err := parseVmodule("eth/*=5, p2p=4")
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+
+	require.True(t, rules[0].match("/src/erigon/eth"))
+	require.True(t, rules[0].match("/src/erigon/eth/stagedsync"))
+	require.False(t, rules[0].match("/src/erigon/ethdb"))
+	require.Equal(t, log.LvlTrace, rules[0].lvl)
+
+	require.True(t, rules[1].match("/src/erigon/p2p"))
+	require.False(t, rules[1].match("/src/erigon/p2p/discover"))
+
+	for _, bad := range []string{"eth", "eth=x", "=3", "a=1=2"} {
+		_, err = parseVmodule(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		dir := t.TempDir()
+		w, err := newRotatingFile(dir, "test", 10, 0, 2, compress)
+		require.NoError(t, err)
+
+		for i := 0; i < 5; i++ {
+			_, err = w.Write([]byte("0123456789"))
+			require.NoError(t, err)
+		}
+		// the rotated files are cleaned up in background
+		require.Eventually(t, func() bool {
+			w.cleanupLock.Lock()
+			defer w.cleanupLock.Unlock()
+			return !w.cleaning
+		}, 10*time.Second, 10*time.Millisecond)
+
+		current, err := os.ReadFile(filepath.Join(dir, "test.log"))
+		require.NoError(t, err)
+		require.Equal(t, "0123456789", string(current))
+		rotated, err := filepath.Glob(filepath.Join(dir, "test-*.log*"))
+		require.NoError(t, err)
+		require.Len(t, rotated, 2)
+		compressed, err := filepath.Glob(filepath.Join(dir, "test-*.log.gz"))
+		require.NoError(t, err)
+		if compress {
+			require.Len(t, compressed, 2)
+		} else {
+			require.Empty(t, compressed)
+		}
+	}
+}
+
+func TestGzipFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.log")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0600))
+	require.NoError(t, gzipFile(path))
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(path + ".gz")
+	require.NoError(t, err)
+}
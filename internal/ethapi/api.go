@@ -158,6 +158,8 @@ type Account struct {
 	Balance   **hexutil.Big                `json:"balance"`
 	State     *map[common.Hash]uint256.Int `json:"state"`
 	StateDiff *map[common.Hash]uint256.Int `json:"stateDiff"`
+
+	MovePrecompileTo *common.Address `json:"movePrecompileToAddress"`
 }
 
 func NewRevertError(result *core.ExecutionResult) *RevertError {
@@ -0,0 +1,90 @@
+package ethapi
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/vm"
+)
+
+// BlockOverrides is a set of header fields to replace when executing calls, to simulate them under hypothetical
+// block conditions
+type BlockOverrides struct {
+	BlockNumber *hexutil.Uint64         `json:"blockNumber"`
+	Coinbase    *common.Address         `json:"coinbase"`
+	Timestamp   *hexutil.Uint64         `json:"timestamp"`
+	GasLimit    *hexutil.Uint           `json:"gasLimit"`
+	Difficulty  *hexutil.Uint           `json:"difficulty"`
+	BaseFee     *uint256.Int            `json:"baseFee"`
+	PrevRandao  *common.Hash            `json:"prevRandao"`
+	BlockHash   *map[uint64]common.Hash `json:"blockHash"`
+}
+
+// UnmarshalJSON accepts the names of the fields in geth (number, time and random) next to the ones of eth_callMany,
+// which win if both are given
+func (overrides *BlockOverrides) UnmarshalJSON(input []byte) error {
+	type blockOverrides BlockOverrides
+	var dec struct {
+		blockOverrides
+		Number *hexutil.Uint64 `json:"number"`
+		Time   *hexutil.Uint64 `json:"time"`
+		Random *common.Hash    `json:"random"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	*overrides = BlockOverrides(dec.blockOverrides)
+	if overrides.BlockNumber == nil {
+		overrides.BlockNumber = dec.Number
+	}
+	if overrides.Timestamp == nil {
+		overrides.Timestamp = dec.Time
+	}
+	if overrides.PrevRandao == nil {
+		overrides.PrevRandao = dec.Random
+	}
+	return nil
+}
+
+// Override replaces the fields of the block context, BLOCKHASH returns the overridden hashes before the ones
+// of the original context
+func (overrides *BlockOverrides) Override(blockCtx *vm.BlockContext) {
+	if overrides.BlockNumber != nil {
+		blockCtx.BlockNumber = uint64(*overrides.BlockNumber)
+	}
+	if overrides.BaseFee != nil {
+		blockCtx.BaseFee = overrides.BaseFee
+	}
+	if overrides.Coinbase != nil {
+		blockCtx.Coinbase = *overrides.Coinbase
+	}
+	if overrides.Difficulty != nil {
+		blockCtx.Difficulty = big.NewInt(int64(*overrides.Difficulty))
+	}
+	if overrides.Timestamp != nil {
+		blockCtx.Time = uint64(*overrides.Timestamp)
+	}
+	if overrides.GasLimit != nil {
+		blockCtx.GasLimit = uint64(*overrides.GasLimit)
+	}
+	if overrides.PrevRandao != nil {
+		prevRandao := *overrides.PrevRandao
+		blockCtx.PrevRanDao = &prevRandao
+	}
+	if overrides.BlockHash != nil && len(*overrides.BlockHash) > 0 {
+		hashes := make(map[uint64]common.Hash, len(*overrides.BlockHash))
+		for blockNum, hash := range *overrides.BlockHash {
+			hashes[blockNum] = hash
+		}
+		getHash := blockCtx.GetHash
+		blockCtx.GetHash = func(blockNum uint64) common.Hash {
+			if hash, ok := hashes[blockNum]; ok {
+				return hash
+			}
+			return getHash(blockNum)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package ethapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockOverridesUnmarshalJSON(t *testing.T) {
+	// the names of geth
+	var overrides BlockOverrides
+	require.NoError(t, json.Unmarshal([]byte(`{"number":"0x10","time":"0x20","random":"0x0000000000000000000000000000000000000000000000000000000000000003","gasLimit":"0x30","coinbase":"0x0000000000000000000000000000000000000004","baseFee":"0x5","difficulty":"0x6"}`), &overrides))
+	require.Equal(t, uint64(0x10), uint64(*overrides.BlockNumber))
+	require.Equal(t, uint64(0x20), uint64(*overrides.Timestamp))
+	require.Equal(t, common.HexToHash("0x03"), *overrides.PrevRandao)
+	require.Equal(t, uint(0x30), uint(*overrides.GasLimit))
+	require.Equal(t, common.HexToAddress("0x04"), *overrides.Coinbase)
+	require.Equal(t, uint64(5), overrides.BaseFee.Uint64())
+	require.Equal(t, uint(6), uint(*overrides.Difficulty))
+	require.Nil(t, overrides.BlockHash)
+
+	// the names of eth_callMany win
+	overrides = BlockOverrides{}
+	require.NoError(t, json.Unmarshal([]byte(`{"blockNumber":"0x11","number":"0x10","timestamp":"0x21","prevRandao":"0x0000000000000000000000000000000000000000000000000000000000000007","blockHash":{"1":"0x0000000000000000000000000000000000000000000000000000000000000008"}}`), &overrides))
+	require.Equal(t, uint64(0x11), uint64(*overrides.BlockNumber))
+	require.Equal(t, uint64(0x21), uint64(*overrides.Timestamp))
+	require.Equal(t, common.HexToHash("0x07"), *overrides.PrevRandao)
+	require.Equal(t, map[uint64]common.Hash{1: common.HexToHash("0x08")}, *overrides.BlockHash)
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/params"
 )
 
 type StateOverrides map[common.Address]Account
@@ -48,3 +50,47 @@ func (overrides *StateOverrides) Override(state *state.IntraBlockState) error {
 
 	return nil
 }
+
+// Precompiles moves the precompiles of the accounts with a movePrecompileToAddress, so that the code of the account
+// runs at the original address, which simulations use to stub out signature checks or oracles. The precompiles are
+// the ones of the chain rules if nil, and are returned unchanged if no precompile is moved. A precompile can't be
+// moved to the address of another one, nor two precompiles to the same address.
+func (overrides *StateOverrides) Precompiles(rules *params.Rules, precompiles map[common.Address]vm.PrecompiledContract) (map[common.Address]vm.PrecompiledContract, error) {
+	if overrides == nil {
+		return precompiles, nil
+	}
+	moves := make(map[common.Address]common.Address) // destination -> precompile
+	for addr, account := range *overrides {
+		if account.MovePrecompileTo == nil {
+			continue
+		}
+		if precompiles == nil {
+			precompiles = vm.ActivePrecompiledContracts(rules)
+		}
+		to := *account.MovePrecompileTo
+		if _, ok := precompiles[addr]; !ok {
+			return nil, fmt.Errorf("account %s is not a precompile", addr.Hex())
+		}
+		if _, ok := precompiles[to]; ok {
+			return nil, fmt.Errorf("can't move precompile %s to %s, which is a precompile", addr.Hex(), to.Hex())
+		}
+		if from, ok := moves[to]; ok {
+			return nil, fmt.Errorf("can't move both precompiles %s and %s to %s", from.Hex(), addr.Hex(), to.Hex())
+		}
+		moves[to] = addr
+	}
+	if len(moves) == 0 {
+		return precompiles, nil
+	}
+	moved := make(map[common.Address]vm.PrecompiledContract, len(precompiles))
+	for addr, p := range precompiles {
+		moved[addr] = p
+	}
+	for _, from := range moves {
+		delete(moved, from)
+	}
+	for to, from := range moves {
+		moved[to] = precompiles[from]
+	}
+	return moved, nil
+}
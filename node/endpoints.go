@@ -17,6 +17,7 @@
 package node
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"time"
@@ -28,6 +29,11 @@ import (
 
 // StartHTTPEndpoint starts the HTTP RPC endpoint.
 func StartHTTPEndpoint(endpoint string, timeouts rpccfg.HTTPTimeouts, handler http.Handler) (*http.Server, net.Addr, error) {
+	return StartHTTPSEndpoint(endpoint, timeouts, handler, nil)
+}
+
+// StartHTTPSEndpoint starts the HTTP RPC endpoint, terminating TLS with tlsConfig unless it's nil.
+func StartHTTPSEndpoint(endpoint string, timeouts rpccfg.HTTPTimeouts, handler http.Handler, tlsConfig *tls.Config) (*http.Server, net.Addr, error) {
 	// start the HTTP listener
 	var (
 		listener net.Listener
@@ -36,6 +42,12 @@ func StartHTTPEndpoint(endpoint string, timeouts rpccfg.HTTPTimeouts, handler ht
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return nil, nil, err
 	}
+	if tlsConfig != nil {
+		// HTTP/1.1 only, the websockets are upgraded from it
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.NextProtos = []string{"http/1.1"}
+		listener = tls.NewListener(listener, tlsConfig)
+	}
 	// make sure timeout values are meaningful
 	CheckTimeouts(&timeouts)
 	// Bundle and start the HTTP server
@@ -29,9 +29,9 @@ import (
 
 	"github.com/c2h5oh/datasize"
 	"github.com/ledgerwatch/erigon/node/nodecfg"
+	"github.com/ledgerwatch/erigon/node/nodecfg/datadir"
 	"github.com/ledgerwatch/erigon/params"
 
-	"github.com/gofrs/flock"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
@@ -44,7 +44,7 @@ import (
 type Node struct {
 	config        *nodecfg.Config
 	log           log.Logger
-	dirLock       *flock.Flock  // prevents concurrent use of instance directory
+	dirLock       *datadir.Lock // prevents concurrent use of instance directory
 	stop          chan struct{} // Channel to wait for termination notifications
 	server        *p2p.Server   // Currently running P2P networking layer
 	startStopLock sync.Mutex    // Start/Stop are protected by an additional lock
@@ -237,15 +237,14 @@ func (n *Node) openDataDir() error {
 		return err
 	}
 	// Lock the instance directory to prevent concurrent use by another instance as well as
-	// accidental use of the instance directory as a database.
-	l := flock.New(filepath.Join(instdir, "LOCK"))
-	locked, err := l.TryLock()
+	// accidental use of the instance directory as a database. Read-only tools are still allowed in.
+	l, err := datadir.TryLock(n.config.Dirs, datadir.Node)
+	if errors.Is(err, datadir.ErrDataDirLocked) {
+		return fmt.Errorf("%w: %s", ErrDataDirUsed, instdir)
+	}
 	if err != nil {
 		return convertFileLockError(err)
 	}
-	if !locked {
-		return fmt.Errorf("%w: %s", ErrDataDirUsed, instdir)
-	}
 	n.dirLock = l
 	return nil
 }
@@ -0,0 +1,108 @@
+package datadir
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/gofrs/flock"
+)
+
+// AccessMode describes how a process is going to use a datadir which may be shared with other processes
+type AccessMode int
+
+const (
+	// ReadOnly processes (db inspection, backups) open databases with Readonly() only and write no files. They can
+	// run next to a live node and next to each other.
+	ReadOnly AccessMode = iota
+	// ReadWrite processes are auxiliary tools which modify chaindata, snapshots or their indices, they need the
+	// datadir for themselves: no node and no read-only tools may be running.
+	ReadWrite
+	// Node is the long-running owner of the datadir. It excludes other nodes and ReadWrite tools, but lets
+	// ReadOnly tools in.
+	Node
+)
+
+func (m AccessMode) String() string {
+	switch m {
+	case ReadOnly:
+		return "read-only"
+	case ReadWrite:
+		return "read-write"
+	case Node:
+		return "node"
+	}
+	return fmt.Sprintf("AccessMode(%d)", int(m))
+}
+
+var ErrDataDirLocked = errors.New("datadir already used by another process")
+
+const (
+	ownerLockFile   = "LOCK"         // held exclusively by the node or a ReadWrite tool
+	readersLockFile = "LOCK.readers" // shared by ReadOnly tools, exclusive for ReadWrite tools
+)
+
+// Lock is a lease on a datadir taken according to an AccessMode. It is built on advisory file locks, so it's
+// released by the OS if the process dies.
+type Lock struct {
+	mode  AccessMode
+	locks []*flock.Flock
+}
+
+// TryLock takes a lease on dirs.DataDir without blocking, ErrDataDirLocked is returned if the requested mode
+// conflicts with another process using the datadir
+func TryLock(dirs Dirs, mode AccessMode) (*Lock, error) {
+	l := &Lock{mode: mode}
+	var err error
+	switch mode {
+	case ReadOnly:
+		err = l.tryLock(dirs.DataDir, readersLockFile, true, "a read-write tool is running")
+	case ReadWrite:
+		if err = l.tryLock(dirs.DataDir, ownerLockFile, false, "a node or read-write tool is running"); err == nil {
+			err = l.tryLock(dirs.DataDir, readersLockFile, false, "read-only tools are running")
+		}
+	case Node:
+		err = l.tryLock(dirs.DataDir, ownerLockFile, false, "a node or read-write tool is running")
+	default:
+		err = fmt.Errorf("unknown datadir access mode %d", mode)
+	}
+	if err != nil {
+		_ = l.Unlock()
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Lock) tryLock(dir, name string, shared bool, reason string) error {
+	fl := flock.New(filepath.Join(dir, name))
+	var locked bool
+	var err error
+	if shared {
+		locked, err = fl.TryRLock()
+	} else {
+		locked, err = fl.TryLock()
+	}
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return fmt.Errorf("%w: %s (%s access impossible: %s)", ErrDataDirLocked, dir, l.mode, reason)
+	}
+	l.locks = append(l.locks, fl)
+	return nil
+}
+
+// Mode returns the access mode the lease was taken with
+func (l *Lock) Mode() AccessMode { return l.mode }
+
+// Unlock releases the lease
+func (l *Lock) Unlock() error {
+	var firstErr error
+	for i := len(l.locks) - 1; i >= 0; i-- {
+		if err := l.locks[i].Unlock(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	l.locks = nil
+	return firstErr
+}
@@ -0,0 +1,55 @@
+package datadir
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLockModes(t *testing.T) {
+	dirs := New(t.TempDir())
+
+	node, err := TryLock(dirs, Node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TryLock(dirs, Node); !errors.Is(err, ErrDataDirLocked) {
+		t.Fatalf("second node: have %v, want %v", err, ErrDataDirLocked)
+	}
+	if _, err := TryLock(dirs, ReadWrite); !errors.Is(err, ErrDataDirLocked) {
+		t.Fatalf("read-write next to node: have %v, want %v", err, ErrDataDirLocked)
+	}
+	ro1, err := TryLock(dirs, ReadOnly)
+	if err != nil {
+		t.Fatalf("read-only next to node: %v", err)
+	}
+	ro2, err := TryLock(dirs, ReadOnly)
+	if err != nil {
+		t.Fatalf("second read-only: %v", err)
+	}
+	if err := node.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TryLock(dirs, ReadWrite); !errors.Is(err, ErrDataDirLocked) {
+		t.Fatalf("read-write next to readers: have %v, want %v", err, ErrDataDirLocked)
+	}
+	if err := ro1.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ro2.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	rw, err := TryLock(dirs, ReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TryLock(dirs, ReadOnly); !errors.Is(err, ErrDataDirLocked) {
+		t.Fatalf("read-only next to read-write: have %v, want %v", err, ErrDataDirLocked)
+	}
+	if _, err := TryLock(dirs, Node); !errors.Is(err, ErrDataDirLocked) {
+		t.Fatalf("node next to read-write: have %v, want %v", err, ErrDataDirLocked)
+	}
+	if err := rw.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+}
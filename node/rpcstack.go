@@ -340,7 +340,7 @@ func isWebsocket(r *http.Request) bool {
 // NewHTTPHandlerStack returns wrapped http-related handlers
 func NewHTTPHandlerStack(srv http.Handler, cors []string, vhosts []string, compression bool) http.Handler {
 	// Wrap the CORS-handler within a host-handler
-	handler := newCorsHandler(srv, cors)
+	handler := newCorsHandler(srv, cors, nil)
 	handler = newVHostHandler(vhosts, handler)
 	if compression {
 		handler = newGzipHandler(handler)
@@ -348,14 +348,94 @@ func NewHTTPHandlerStack(srv http.Handler, cors []string, vhosts []string, compr
 	return handler
 }
 
-func newCorsHandler(srv http.Handler, allowedOrigins []string) http.Handler {
+// VirtualHostRule configures the requests sent to a virtual host: the origins of the cross origin requests and their
+// HTTP methods, POST and GET if there are none, and the API namespaces they may call, all the enabled ones if there
+// are none.
+type VirtualHostRule struct {
+	CORSDomains []string `json:"corsDomains"`
+	CORSMethods []string `json:"corsMethods"`
+	API         []string `json:"api"`
+}
+
+// NewVirtualHostHandlerStack returns wrapped http-related handlers for a virtual host with a rule
+func NewVirtualHostHandlerStack(srv http.Handler, rule *VirtualHostRule, compression bool) http.Handler {
+	handler := newCorsHandler(srv, rule.CORSDomains, rule.CORSMethods)
+	if compression {
+		handler = newGzipHandler(handler)
+	}
+	return handler
+}
+
+// virtualHostRouter serves the requests sent to the virtual hosts with a rule with their own handler, restricted to
+// the namespaces of the rule, and the other requests with next
+type virtualHostRouter struct {
+	hosts map[string]*virtualHost
+	next  http.Handler
+}
+
+type virtualHost struct {
+	methods []string // the method patterns of the namespaces of the rule, nil if they're unrestricted
+	handler http.Handler
+}
+
+// NewVirtualHostRouter serves the requests sent to the hosts of rules with the handler newHandler returns for their
+// rule, and the other requests with next. The host names are case-insensitive and without port. The rules only
+// restrict: their hosts must be accepted by vhosts too, like the other ones. As the clients choose their Host header,
+// they aren't an authentication boundary.
+func NewVirtualHostRouter(rules map[string]*VirtualHostRule, vhosts []string, newHandler func(*VirtualHostRule) (http.Handler, error), next http.Handler) (http.Handler, error) {
+	if len(rules) == 0 {
+		return next, nil
+	}
+	accepted := make(map[string]struct{}, len(vhosts))
+	for _, vhost := range vhosts {
+		accepted[strings.ToLower(vhost)] = struct{}{}
+	}
+	_, all := accepted["*"]
+	router := &virtualHostRouter{hosts: make(map[string]*virtualHost, len(rules)), next: next}
+	for host, rule := range rules {
+		if rule == nil {
+			return nil, fmt.Errorf("virtual host %q: empty rule", host)
+		}
+		if _, ok := accepted[strings.ToLower(host)]; !ok && !all && net.ParseIP(host) == nil {
+			return nil, fmt.Errorf("virtual host %q isn't accepted by the vhosts", host)
+		}
+		handler, err := newHandler(rule)
+		if err != nil {
+			return nil, fmt.Errorf("virtual host %q: %w", host, err)
+		}
+		vhost := &virtualHost{handler: handler}
+		for _, namespace := range rule.API {
+			vhost.methods = append(vhost.methods, namespace+"_*")
+		}
+		router.hosts[strings.ToLower(host)] = vhost
+	}
+	return router, nil
+}
+
+func (h *virtualHostRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	vhost, ok := h.hosts[strings.ToLower(host)]
+	if !ok {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	vhost.handler.ServeHTTP(w, rpc.WithAllowedMethods(r, vhost.methods))
+}
+
+func newCorsHandler(srv http.Handler, allowedOrigins []string, allowedMethods []string) http.Handler {
 	// disable CORS support if user has not specified a custom CORS configuration
 	if len(allowedOrigins) == 0 {
 		return srv
 	}
+	if len(allowedMethods) == 0 {
+		allowedMethods = []string{http.MethodPost, http.MethodGet}
+	}
 	c := cors.New(cors.Options{
 		AllowedOrigins: allowedOrigins,
-		AllowedMethods: []string{http.MethodPost, http.MethodGet},
+		AllowedMethods: allowedMethods,
 		AllowedHeaders: []string{"*"},
 		MaxAge:         600,
 	})
@@ -409,6 +489,10 @@ func (h *virtualHostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "invalid host specified", http.StatusForbidden)
 }
 
+// gzipMinSize is the size from which the responses are compressed, the smaller ones don't shrink enough to be worth
+// it
+const gzipMinSize = 1024
+
 var gzPool = sync.Pool{
 	New: func() interface{} {
 		w := gzip.NewWriter(io.Discard)
@@ -416,36 +500,72 @@ var gzPool = sync.Pool{
 	},
 }
 
+// gzipResponseWriter holds back the response until it reaches gzipMinSize, and then streams it compressed
 type gzipResponseWriter struct {
-	io.Writer
 	http.ResponseWriter
+	gz     *gzip.Writer // nil until the response is large enough
+	buf    []byte
+	status int
 }
 
 func (w *gzipResponseWriter) WriteHeader(status int) {
-	w.Header().Del("Content-Length")
-	w.ResponseWriter.WriteHeader(status)
+	if w.status == 0 {
+		w.status = status
+	}
 }
 
 func (w *gzipResponseWriter) Write(b []byte) (int, error) {
-	return w.Writer.Write(b)
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	if len(w.buf)+len(b) < gzipMinSize {
+		w.buf = append(w.buf, b...)
+		return len(b), nil
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.writeHeader()
+	w.gz = gzPool.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+	if len(w.buf) > 0 {
+		if _, err := w.gz.Write(w.buf); err != nil {
+			return 0, err
+		}
+		w.buf = nil
+	}
+	return w.gz.Write(b)
+}
+
+func (w *gzipResponseWriter) writeHeader() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// close ends the response, sending it uncompressed if it's smaller than gzipMinSize
+func (w *gzipResponseWriter) close() {
+	if w.gz != nil {
+		w.gz.Close()
+		gzPool.Put(w.gz)
+		return
+	}
+	w.writeHeader()
+	if len(w.buf) > 0 {
+		w.ResponseWriter.Write(w.buf) //nolint:errcheck
+	}
 }
 
 func newGzipHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
 		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		w.Header().Set("Content-Encoding", "gzip")
-
-		gz := gzPool.Get().(*gzip.Writer)
-		defer gzPool.Put(gz)
-
-		gz.Reset(w)
-		defer gz.Close()
-
-		next.ServeHTTP(&gzipResponseWriter{ResponseWriter: w, Writer: gz}, r)
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
 	})
 }
 
@@ -18,9 +18,11 @@ package node
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strconv"
 	"strings"
@@ -65,6 +67,51 @@ func TestVhosts(t *testing.T) {
 	assert.Equal(t, resp2.StatusCode, http.StatusForbidden)
 }
 
+// TestVirtualHostRouter makes sure the virtual hosts with a rule get their own CORS configuration and namespaces.
+func TestVirtualHostRouter(t *testing.T) {
+	srv := rpc.NewServer(50, false, true)
+	defer srv.Stop()
+	rules := map[string]*VirtualHostRule{
+		"admin.test":  {},
+		"public.test": {CORSDomains: []string{"https://app.test"}, API: []string{"eth"}},
+	}
+	vhosts := []string{"test", "admin.test", "public.test"}
+	newHandler := func(rule *VirtualHostRule) (http.Handler, error) {
+		return NewVirtualHostHandlerStack(srv, rule, false), nil
+	}
+	handler, err := NewVirtualHostRouter(rules, vhosts, newHandler, NewHTTPHandlerStack(srv, nil, vhosts, false))
+	assert.NoError(t, err)
+
+	// a rule can't accept a host the vhosts reject
+	_, err = NewVirtualHostRouter(rules, []string{"test", "public.test"}, newHandler, NewHTTPHandlerStack(srv, nil, vhosts, false))
+	assert.Error(t, err)
+	httpsrv := httptest.NewServer(handler)
+	defer httpsrv.Close()
+
+	for _, test := range []struct {
+		host, origin string
+		status       int
+		allowed      bool
+		allowOrigin  string
+	}{
+		{host: "test", status: http.StatusOK, allowed: true},
+		{host: "bad", status: http.StatusForbidden},
+		{host: "Admin.test:8545", status: http.StatusOK, allowed: true},
+		{host: "public.test", origin: "https://app.test", status: http.StatusOK, allowOrigin: "https://app.test"},
+		{host: "public.test", origin: "https://bad.test", status: http.StatusOK},
+	} {
+		resp := rpcRequest(t, httpsrv.URL, "host", test.host, "origin", test.origin)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, test.status, resp.StatusCode, test.host)
+		if test.status == http.StatusOK {
+			assert.Equal(t, test.allowed, !strings.Contains(string(body), "error"), test.host)
+		}
+		assert.Equal(t, test.allowOrigin, resp.Header.Get("Access-Control-Allow-Origin"), test.host)
+	}
+}
+
 type originTest struct {
 	spec    string
 	expOk   []string
@@ -339,3 +386,39 @@ func rpcRequest(t *testing.T, url string, extraHeaders ...string) *http.Response
 	}
 	return resp
 }
+
+// This test checks that only the responses from gzipMinSize are compressed.
+func TestGzipHandler(t *testing.T) {
+	for _, size := range []int{10, gzipMinSize - 1, gzipMinSize, 10 * gzipMinSize} {
+		payload := bytes.Repeat([]byte("a"), size)
+		handler := newGzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			// streamed in chunks like the rpc responses
+			for i := 0; i < len(payload); i += 100 {
+				end := i + 100
+				if end > len(payload) {
+					end = len(payload)
+				}
+				w.Write(payload[i:end]) //nolint:errcheck
+			}
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+		body := rec.Body.Bytes()
+		if size < gzipMinSize {
+			assert.Empty(t, rec.Header().Get("Content-Encoding"), size)
+		} else {
+			assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"), size)
+			gz, err := gzip.NewReader(rec.Body)
+			assert.NoError(t, err)
+			body, err = io.ReadAll(gz)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, payload, body, size)
+	}
+}
@@ -0,0 +1,79 @@
+package node
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCert writes a self-signed certificate for localhost with the given common name and its key
+func writeTestCert(t *testing.T, certFile, keyFile, commonName string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCert(t, certFile, keyFile, "first")
+
+	r, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	srv, addr, err := StartHTTPSEndpoint("127.0.0.1:0", rpccfg.DefaultHTTPTimeouts, http.NotFoundHandler(), r.TLSConfig())
+	require.NoError(t, err)
+	defer srv.Close()
+
+	servedName := func() string {
+		conn, err := tls.Dial("tcp", addr.String(), &tls.Config{InsecureSkipVerify: true}) //nolint:gosec
+		require.NoError(t, err)
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+	require.Equal(t, "first", servedName())
+
+	// rotated certificate, picked up at the next check
+	writeTestCert(t, certFile, keyFile, "second")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+	require.Equal(t, "first", servedName())
+	r.mu.Lock()
+	r.lastCheck = time.Time{}
+	r.mu.Unlock()
+	require.Equal(t, "second", servedName())
+
+	// invalid files keep the previous certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("invalid"), 0600))
+	r.mu.Lock()
+	r.lastCheck = time.Time{}
+	r.mu.Unlock()
+	require.Equal(t, "second", servedName())
+
+	_, err = NewCertReloader(certFile, keyFile)
+	require.Error(t, err)
+}
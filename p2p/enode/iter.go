@@ -105,6 +105,40 @@ func (it *sliceIter) Close() {
 	it.nodes = nil
 }
 
+// Prepend makes an iterator which runs through the given nodes once, then through the
+// nodes of it. Close also closes it.
+func Prepend(nodes []*Node, it Iterator) Iterator {
+	return &prependIter{first: &sliceIter{nodes: nodes, index: -1}, rest: it}
+}
+
+type prependIter struct {
+	first  *sliceIter
+	rest   Iterator
+	inRest bool // only accessed by Next and Node
+}
+
+func (it *prependIter) Next() bool {
+	if !it.inRest {
+		if it.first.Next() {
+			return true
+		}
+		it.inRest = true
+	}
+	return it.rest.Next()
+}
+
+func (it *prependIter) Node() *Node {
+	if !it.inRest {
+		return it.first.Node()
+	}
+	return it.rest.Node()
+}
+
+func (it *prependIter) Close() {
+	it.first.Close()
+	it.rest.Close()
+}
+
 // Filter wraps an iterator such that Next only returns nodes for which
 // the 'check' function returns true.
 func Filter(it Iterator, check func(*Node) bool) Iterator {
@@ -70,6 +70,34 @@ func TestFilterNodes(t *testing.T) {
 	}
 }
 
+func TestPrependNodes(t *testing.T) {
+	nodes := make([]*Node, 4)
+	for i := range nodes {
+		nodes[i] = testNode(uint64(i), uint64(i))
+	}
+
+	it := Prepend(nodes[:2], IterNodes(nodes[2:]))
+	for i := range nodes {
+		if !it.Next() {
+			t.Fatal("Next returned false")
+		}
+		if it.Node() != nodes[i] {
+			t.Fatalf("iterator returned wrong node %v\nwant %v", it.Node(), nodes[i])
+		}
+	}
+	if it.Next() {
+		t.Fatal("Next returned true after underlying iterator has ended")
+	}
+
+	// Closing it ends both the nodes and the underlying iterator.
+	rest := NewFairMix(0)
+	it = Prepend(nodes, rest)
+	it.Close()
+	if it.Next() {
+		t.Fatal("Next returned true after Close")
+	}
+}
+
 func checkNodes(t *testing.T, nodes []*Node, wantLen int) {
 	if len(nodes) != wantLen {
 		t.Errorf("slice has %d nodes, want %d", len(nodes), wantLen)
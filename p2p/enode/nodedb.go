@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -33,6 +34,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
 
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/p2p/enr"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/log/v3"
 
@@ -45,6 +47,7 @@ const (
 	dbVersionKey   = "version" // Version of the database to flush if changes
 	dbNodePrefix   = "n:"      // Identifier to prefix node entries with
 	dbLocalPrefix  = "local:"
+	dbPeerPrefix   = "peer:" // Known-good peers are keyed by ID only, the full key is "peer:<ID>".
 	dbDiscoverRoot = "v4"
 	dbDiscv5Root   = "v5"
 
@@ -61,8 +64,9 @@ const (
 )
 
 const (
-	dbNodeExpiration = 24 * time.Hour // Time after which an unseen node should be dropped.
-	dbCleanupCycle   = time.Hour      // Time period for running the expiration task.
+	dbNodeExpiration = 24 * time.Hour     // Time after which an unseen node should be dropped.
+	dbPeerExpiration = 7 * 24 * time.Hour // Time after which an unseen known-good peer should be dropped.
+	dbCleanupCycle   = time.Hour          // Time period for running the expiration task.
 	dbVersion        = 10
 )
 
@@ -398,6 +402,7 @@ func (db *DB) expirer() {
 		select {
 		case <-tick.C:
 			db.expireNodes()
+			db.expirePeers()
 		case <-db.quit:
 			return
 		}
@@ -459,6 +464,34 @@ func (db *DB) expireNodes() {
 	}
 }
 
+// expirePeers deletes all the known-good peers that have not been connected for some time.
+func (db *DB) expirePeers() {
+	threshold := time.Now().Add(-dbPeerExpiration)
+	var toDelete [][]byte
+	if err := db.kv.View(context.Background(), func(tx kv.Tx) error {
+		return db.forEachPeer(tx, func(node *Node, stats PeerStats) {
+			if stats.LastSeen.Before(threshold) {
+				toDelete = append(toDelete, peerKey(node.ID()))
+			}
+		})
+	}); err != nil {
+		log.Warn("nodeDB.expirePeers failed", "err", err)
+	}
+	if len(toDelete) == 0 {
+		return
+	}
+	if err := db.kv.Update(context.Background(), func(tx kv.RwTx) error {
+		for _, key := range toDelete {
+			if err := tx.Delete(kv.Inodes, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		log.Warn("nodeDB.expirePeers failed", "err", err)
+	}
+}
+
 // LastPingReceived retrieves the time of the last ping packet received from
 // a remote node.
 func (db *DB) LastPingReceived(id ID, ip net.IP) time.Time {
@@ -601,6 +634,125 @@ func (db *DB) QuerySeeds(n int, maxAge time.Duration) []*Node {
 	return nodes
 }
 
+// PeerStats is what is known of a known-good peer, a node with which a protocol ran.
+type PeerStats struct {
+	LastSeen time.Time // Last time the peer was connected.
+	Useful   uint64    // Number of useful messages received from the peer, as counted by the protocol.
+}
+
+// peerEntry is the stored value of a known-good peer.
+type peerEntry struct {
+	Record   enr.Record
+	LastSeen uint64
+	Useful   uint64
+}
+
+// peerKey returns the database key of a known-good peer.
+func peerKey(id ID) []byte {
+	return append([]byte(dbPeerPrefix), id[:]...)
+}
+
+// UpdatePeer inserts - potentially overwriting - a known-good peer and its
+// statistics into the database, they are kept for dbPeerExpiration.
+func (db *DB) UpdatePeer(node *Node, stats PeerStats) error {
+	blob, err := rlp.EncodeToBytes(&peerEntry{Record: node.r, LastSeen: uint64(stats.LastSeen.Unix()), Useful: stats.Useful})
+	if err != nil {
+		return err
+	}
+	return db.kv.Update(context.Background(), func(tx kv.RwTx) error {
+		return tx.Put(kv.Inodes, peerKey(node.ID()), blob)
+	})
+}
+
+// PeerStats retrieves the statistics of a known-good peer, false if it's unknown.
+func (db *DB) PeerStats(id ID) (PeerStats, bool) {
+	var stats PeerStats
+	var found bool
+	if err := db.kv.View(context.Background(), func(tx kv.Tx) error {
+		blob, errGet := tx.GetOne(kv.Inodes, peerKey(id))
+		if errGet != nil || blob == nil {
+			return errGet
+		}
+		_, stats, errGet = decodePeer(id, blob)
+		found = errGet == nil
+		return errGet
+	}); err != nil {
+		return PeerStats{}, false
+	}
+	return stats, found
+}
+
+// QueryPeers retrieves up to n known-good peers connected within maxAge, the
+// ones which sent the most useful messages first.
+func (db *DB) QueryPeers(n int, maxAge time.Duration) []*Node {
+	type peer struct {
+		node  *Node
+		stats PeerStats
+	}
+	var (
+		threshold = time.Now().Add(-maxAge)
+		peers     []peer
+	)
+	if err := db.kv.View(context.Background(), func(tx kv.Tx) error {
+		return db.forEachPeer(tx, func(node *Node, stats PeerStats) {
+			if !stats.LastSeen.Before(threshold) {
+				peers = append(peers, peer{node, stats})
+			}
+		})
+	}); err != nil {
+		log.Warn("nodeDB.QueryPeers failed", "err", err)
+	}
+	db.ensureExpirer()
+
+	sort.Slice(peers, func(i, j int) bool {
+		if peers[i].stats.Useful != peers[j].stats.Useful {
+			return peers[i].stats.Useful > peers[j].stats.Useful
+		}
+		return peers[i].stats.LastSeen.After(peers[j].stats.LastSeen)
+	})
+	if len(peers) > n {
+		peers = peers[:n]
+	}
+	nodes := make([]*Node, len(peers))
+	for i, p := range peers {
+		nodes[i] = p.node
+	}
+	return nodes
+}
+
+// forEachPeer calls f with every known-good peer, the ones which can't be decoded are skipped.
+func (db *DB) forEachPeer(tx kv.Tx, f func(node *Node, stats PeerStats)) error {
+	c, err := tx.Cursor(kv.Inodes)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	p := []byte(dbPeerPrefix)
+	for k, v, err := c.Seek(p); bytes.HasPrefix(k, p); k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		var id ID
+		copy(id[:], k[len(p):])
+		node, stats, err := decodePeer(id, v)
+		if err != nil {
+			log.Trace("Skipping undecodable known-good peer", "id", id, "err", err)
+			continue
+		}
+		f(node, stats)
+	}
+	return nil
+}
+
+func decodePeer(id ID, data []byte) (*Node, PeerStats, error) {
+	var entry peerEntry
+	if err := rlp.DecodeBytes(data, &entry); err != nil {
+		return nil, PeerStats{}, fmt.Errorf("p2p/enode: can't decode peer %x in DB: %w", id, err)
+	}
+	node := &Node{r: entry.Record, id: id}
+	return node, PeerStats{LastSeen: time.Unix(int64(entry.LastSeen), 0), Useful: entry.Useful}, nil
+}
+
 // close flushes and closes the database files.
 func (db *DB) Close() {
 	select {
@@ -481,3 +481,57 @@ func TestDBExpireV5(t *testing.T) {
 	db.UpdateFindFailsV5(ID{}, ip, 4)
 	db.expireNodes()
 }
+
+func TestDBPeers(t *testing.T) {
+	db, err := OpenDB("")
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	now := time.Now().Truncate(time.Second)
+	peers := []struct {
+		node  *Node
+		stats PeerStats
+	}{
+		{node: nodeDBSeedQueryNodes[0].node, stats: PeerStats{LastSeen: now.Add(-time.Hour), Useful: 5}},
+		{node: nodeDBSeedQueryNodes[1].node, stats: PeerStats{LastSeen: now, Useful: 5}},
+		{node: nodeDBSeedQueryNodes[2].node, stats: PeerStats{LastSeen: now, Useful: 10}},
+		// This one is too old to be queried, and is expired.
+		{node: nodeDBSeedQueryNodes[3].node, stats: PeerStats{LastSeen: now.Add(-dbPeerExpiration - time.Hour), Useful: 100}},
+	}
+	for i, p := range peers {
+		if err := db.UpdatePeer(p.node, p.stats); err != nil {
+			t.Fatalf("peer %d: failed to insert: %v", i, err)
+		}
+	}
+	if stats, ok := db.PeerStats(peers[0].node.ID()); !ok || stats != peers[0].stats {
+		t.Errorf("peer stats mismatch: have %v %v, want %v", stats, ok, peers[0].stats)
+	}
+	if _, ok := db.PeerStats(nodeDBSeedQueryNodes[4].node.ID()); ok {
+		t.Errorf("unknown peer has stats")
+	}
+
+	// The most useful peers come first, then the most recent ones.
+	queried := db.QueryPeers(len(peers), dbPeerExpiration)
+	want := []*Node{peers[2].node, peers[1].node, peers[0].node}
+	if len(queried) != len(want) {
+		t.Fatalf("peer count mismatch: have %d, want %d", len(queried), len(want))
+	}
+	for i := range want {
+		if queried[i].ID() != want[i].ID() || !reflect.DeepEqual(queried[i].Record(), want[i].Record()) {
+			t.Errorf("peer %d mismatch: have %v, want %v", i, queried[i], want[i])
+		}
+	}
+	if queried := db.QueryPeers(1, dbPeerExpiration); len(queried) != 1 || queried[0].ID() != peers[2].node.ID() {
+		t.Errorf("limited query mismatch: have %v", queried)
+	}
+
+	db.expirePeers()
+	if _, ok := db.PeerStats(peers[3].node.ID()); ok {
+		t.Errorf("peer should be expired")
+	}
+	if _, ok := db.PeerStats(peers[0].node.ID()); !ok {
+		t.Errorf("peer shouldn't be expired")
+	}
+}
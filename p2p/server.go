@@ -96,6 +96,10 @@ type Config struct {
 	// protocol should be started or not.
 	DiscoveryV5 bool `toml:",omitempty"`
 
+	// DiscoveryV5Filter selects the nodes found by the V5 discovery which are
+	// dialed. None of them are dialed if it's nil.
+	DiscoveryV5Filter func(*enode.Node) bool `toml:"-"`
+
 	// Name sets the node name of this server.
 	// Use common.MakeName to create a name that follows existing conventions.
 	Name string `toml:"-"`
@@ -117,6 +121,12 @@ type Config struct {
 	// allowed to connect, even above the peer limit.
 	TrustedNodes []*enode.Node
 
+	// KnownPeersMaxAge enables dialing the known-good peers of the node database
+	// connected within this time, before the nodes found by the discovery. Unlike
+	// the static nodes, they aren't re-connected. They aren't dialed if the
+	// discovery is disabled.
+	KnownPeersMaxAge time.Duration `toml:",omitempty"`
+
 	// Connectivity can be restricted to certain IP networks.
 	// If this option is set to a non-nil value, only hosts which match one of the
 	// IP networks contained in the list are considered.
@@ -643,6 +653,9 @@ func (srv *Server) setupDiscovery(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		if srv.DiscoveryV5Filter != nil {
+			srv.discmix.AddSource(enode.Filter(srv.DiscV5.RandomNodes(), srv.DiscoveryV5Filter))
+		}
 	}
 	return nil
 }
@@ -667,7 +680,15 @@ func (srv *Server) setupDialScheduler() {
 	if len(srv.Protocols) > 0 {
 		subProtocolVersion = srv.Protocols[0].Version
 	}
-	srv.dialsched = newDialScheduler(config, srv.discmix, srv.SetupConn, subProtocolVersion)
+	var dialIter enode.Iterator = srv.discmix
+	if srv.KnownPeersMaxAge > 0 && !srv.NoDiscovery {
+		// the known peers come first, not to wait for the discovery
+		if known := srv.nodedb.QueryPeers(srv.MaxPeers, srv.KnownPeersMaxAge); len(known) > 0 {
+			srv.log.Debug("Dialing known peers", "count", len(known))
+			dialIter = enode.Prepend(known, srv.discmix)
+		}
+	}
+	srv.dialsched = newDialScheduler(config, dialIter, srv.SetupConn, subProtocolVersion)
 	for _, n := range srv.StaticNodes {
 		srv.dialsched.addStatic(n)
 	}
@@ -0,0 +1,178 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// APIKeyHeader is the header giving the API key of a peer
+	APIKeyHeader = "X-Api-Key"
+	// APIKeyQueryParam is the URL query parameter giving the API key of a peer, for the clients which can't set
+	// headers like the browsers opening websockets
+	APIKeyQueryParam = "apikey"
+)
+
+// ACL maps the peers to the methods they may call. The first rule matching a peer applies, the peers matching no
+// rule can't call any method.
+type ACL struct {
+	Rules []*ACLRule `json:"rules"`
+}
+
+// ACLRule allows a set of methods to the peers with one of its API keys, origins or IP ranges, or to every peer if
+// it has none of them. The methods are given by name or by namespace, like "debug_*", "*" standing for all the
+// methods. The denied methods take precedence over the allowed ones.
+type ACLRule struct {
+	APIKeys  []string `json:"apiKeys"`
+	Origins  []string `json:"origins"`
+	IPRanges []string `json:"ipRanges"` // CIDR ranges or single IPs
+	Allow    []string `json:"allow"`
+	Deny     []string `json:"deny"`
+
+	ipNets []*net.IPNet
+}
+
+// Peer identifies the sender of the calls of a connection
+type Peer struct {
+	Transport string // http, ws, ipc or inproc
+	APIKey    string
+	Origin    string
+	IP        net.IP
+
+	methods []string // the methods it may call regardless of the ACL, all of them if nil
+}
+
+// peerConn is implemented by the connections knowing their peer
+type peerConn interface {
+	peer() Peer
+}
+
+type allowedMethodsKey struct{}
+
+// WithAllowedMethods restricts the methods the sender of a HTTP request or of the websocket it opens may call to the
+// ones matching patterns, names or namespaces like in the ACL rules, on top of the ACL of the server. nil patterns
+// leave them unrestricted.
+func WithAllowedMethods(r *http.Request, patterns []string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), allowedMethodsKey{}, patterns))
+}
+
+// ParseACL decodes and validates a JSON ACL.
+func ParseACL(data []byte) (*ACL, error) {
+	acl := &ACL{}
+	if err := json.Unmarshal(data, acl); err != nil {
+		return nil, err
+	}
+	for i, rule := range acl.Rules {
+		if rule == nil {
+			return nil, fmt.Errorf("rule %d: empty rule", i)
+		}
+		for _, ipRange := range rule.IPRanges {
+			if !strings.Contains(ipRange, "/") {
+				ip := net.ParseIP(ipRange)
+				if ip == nil {
+					return nil, fmt.Errorf("rule %d: invalid IP %q", i, ipRange)
+				}
+				rule.ipNets = append(rule.ipNets, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+				continue
+			}
+			_, ipNet, err := net.ParseCIDR(ipRange)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %w", i, err)
+			}
+			rule.ipNets = append(rule.ipNets, ipNet)
+		}
+		for _, pattern := range append(append([]string{}, rule.Allow...), rule.Deny...) {
+			if pattern != "*" && strings.Contains(strings.TrimSuffix(pattern, "_*"), "*") {
+				return nil, fmt.Errorf("rule %d: invalid method pattern %q, only whole namespaces can be matched", i, pattern)
+			}
+		}
+	}
+	return acl, nil
+}
+
+// allows returns true if peer may call method
+func (acl *ACL) allows(peer Peer, method string) bool {
+	for _, rule := range acl.Rules {
+		if rule.matches(peer) {
+			return matchMethod(rule.Allow, method) && !matchMethod(rule.Deny, method)
+		}
+	}
+	return false
+}
+
+func (r *ACLRule) matches(peer Peer) bool {
+	if len(r.APIKeys) == 0 && len(r.Origins) == 0 && len(r.ipNets) == 0 {
+		return true
+	}
+	if peer.APIKey != "" {
+		for _, key := range r.APIKeys {
+			if key == peer.APIKey {
+				return true
+			}
+		}
+	}
+	if peer.Origin != "" {
+		for _, origin := range r.Origins {
+			if strings.EqualFold(origin, peer.Origin) {
+				return true
+			}
+		}
+	}
+	if peer.IP != nil {
+		for _, ipNet := range r.ipNets {
+			if ipNet.Contains(peer.IP) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchMethod(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == method {
+			return true
+		}
+		if strings.HasSuffix(pattern, "_*") && strings.HasPrefix(method, pattern[:len(pattern)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerFromRequest identifies the peer sending a HTTP request or opening a websocket
+func peerFromRequest(r *http.Request) Peer {
+	peer := Peer{APIKey: r.Header.Get(APIKeyHeader), Origin: r.Header.Get("Origin")}
+	if peer.APIKey == "" {
+		peer.APIKey = r.URL.Query().Get(APIKeyQueryParam)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer.IP = net.ParseIP(host)
+	peer.methods, _ = r.Context().Value(allowedMethodsKey{}).([]string)
+	return peer
+}
+
+// aclRef holds the ACL of a server, which can be replaced while it runs
+type aclRef struct {
+	v atomic.Value
+}
+
+func (r *aclRef) load() *ACL {
+	if r == nil {
+		return nil
+	}
+	acl, _ := r.v.Load().(*ACL)
+	return acl
+}
+
+func (r *aclRef) store(acl *ACL) {
+	r.v.Store(acl)
+}
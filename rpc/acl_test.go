@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testACL = `{"rules": [
+	{"apiKeys": ["secret"], "allow": ["*"]},
+	{"ipRanges": ["10.0.0.0/8", "192.168.1.1"], "allow": ["*"], "deny": ["debug_*"]},
+	{"origins": ["https://example.com"], "allow": ["test_echo"]}
+]}`
+
+func TestACLAllows(t *testing.T) {
+	acl, err := ParseACL([]byte(testACL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		peer    Peer
+		method  string
+		allowed bool
+	}{
+		{Peer{APIKey: "secret"}, "debug_traceBlockByNumber", true},
+		{Peer{APIKey: "other"}, "eth_blockNumber", false},
+		{Peer{IP: net.ParseIP("10.1.2.3")}, "eth_blockNumber", true},
+		{Peer{IP: net.ParseIP("10.1.2.3")}, "debug_traceBlockByNumber", false},
+		{Peer{IP: net.ParseIP("192.168.1.1")}, "eth_blockNumber", true},
+		{Peer{IP: net.ParseIP("192.168.1.2")}, "eth_blockNumber", false},
+		{Peer{Origin: "https://EXAMPLE.com"}, "test_echo", true},
+		{Peer{Origin: "https://example.com"}, "test_echoWithCtx", false},
+		{Peer{}, "test_echo", false},
+	}
+	for _, tt := range tests {
+		if allowed := acl.allows(tt.peer, tt.method); allowed != tt.allowed {
+			t.Errorf("%+v calling %s: got allowed %t, want %t", tt.peer, tt.method, allowed, tt.allowed)
+		}
+	}
+
+	for _, invalid := range []string{
+		`{"rules": [{"ipRanges": ["10.0.0.0/99"]}]}`,
+		`{"rules": [{"allow": ["eth_get*"]}]}`,
+		`{"rules": [null]}`,
+	} {
+		if _, err := ParseACL([]byte(invalid)); err == nil {
+			t.Errorf("no error for invalid ACL %s", invalid)
+		}
+	}
+}
+
+// This test checks that the ACL applies to the HTTP peers according to their API key, and can be replaced.
+func TestServerACL(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+	acl, err := ParseACL([]byte(testACL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetACL(acl)
+
+	client, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var result echoResult
+	if err := client.Call(&result, "test_echo", "x", 1); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("wrong error for a call denied by the ACL: %v", err)
+	}
+
+	client.SetHeader(APIKeyHeader, "secret")
+	if err := client.CallContext(context.Background(), &result, "test_echo", "x", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	server.SetACL(nil)
+	client.SetHeader(APIKeyHeader, "")
+	if err := client.Call(&result, "test_echo", "x", 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAllowedMethods(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	wsHandler := server.WebsocketHandler([]string{"*"}, nil, false)
+	httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = WithAllowedMethods(r, []string{"rpc_*"})
+		if r.Header.Get("Upgrade") == "websocket" {
+			wsHandler.ServeHTTP(w, r)
+			return
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer httpsrv.Close()
+
+	for _, url := range []string{httpsrv.URL, "ws" + strings.TrimPrefix(httpsrv.URL, "http")} {
+		client, err := DialContext(context.Background(), url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result echoResult
+		if err := client.Call(&result, "test_echo", "x", 1); err == nil || !strings.Contains(err.Error(), "does not exist") {
+			t.Fatalf("%s: wrong error for a method outside of the allowed ones: %v", url, err)
+		}
+		var modules map[string]string
+		if err := client.Call(&modules, "rpc_modules"); err != nil {
+			t.Fatalf("%s: %v", url, err)
+		}
+		client.Close()
+	}
+}
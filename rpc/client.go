@@ -78,6 +78,7 @@ type Client struct {
 	isHTTP          bool
 	services        *serviceRegistry
 	methodAllowList AllowList
+	handlerConfig   handlerConfig // the settings of the server, for the connections it serves
 
 	idCounter uint32
 
@@ -113,6 +114,7 @@ type clientConn struct {
 func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	ctx := context.WithValue(context.Background(), clientContextKey{}, c)
 	handler := newHandler(ctx, conn, c.idgen, c.services, c.methodAllowList, 50, false /* traceRequests */)
+	handler.handlerConfig = c.handlerConfig
 	return &clientConn{conn, handler}
 }
 
@@ -196,12 +198,12 @@ func newClient(initctx context.Context, connect reconnectFunc) (*Client, error)
 	if err != nil {
 		return nil, err
 	}
-	c := initClient(conn, randomIDGenerator(), new(serviceRegistry))
+	c := initClient(conn, randomIDGenerator(), new(serviceRegistry), handlerConfig{})
 	c.reconnectFunc = connect
 	return c, nil
 }
 
-func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *Client {
+func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry, handlerConfig handlerConfig) *Client {
 	_, isHTTP := conn.(*httpConn)
 	c := &Client{
 		idgen:       idgen,
@@ -218,6 +220,7 @@ func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *C
 		reqSent:     make(chan error, 1),
 		reqTimeout:  make(chan *requestOp),
 	}
+	c.handlerConfig = handlerConfig
 	if !isHTTP {
 		go c.dispatch(conn)
 	}
@@ -550,6 +553,17 @@ func (c *Client) dispatch(codec ServerCodec) {
 	// Spawn the initial read loop.
 	go c.read(codec)
 
+	// The served connections without calls nor subscriptions for idleTimeout are closed
+	var (
+		idleTimer *time.Timer
+		idle      <-chan time.Time
+	)
+	if c.handlerConfig.idleTimeout > 0 {
+		idleTimer = time.NewTimer(c.handlerConfig.idleTimeout)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
+
 	for {
 		select {
 		case <-c.close:
@@ -562,6 +576,24 @@ func (c *Client) dispatch(codec ServerCodec) {
 			} else {
 				conn.handler.handleMsg(op.msgs[0], nil)
 			}
+			if idleTimer != nil {
+				if !idleTimer.Stop() {
+					select {
+					case <-idleTimer.C:
+					default:
+					}
+				}
+				idleTimer.Reset(c.handlerConfig.idleTimeout)
+			}
+
+		case <-idle:
+			if reading && conn.handler.idle() {
+				log.Debug("Closing idle RPC connection", "conn", codec.remoteAddr(), "timeout", c.handlerConfig.idleTimeout)
+				conn.codec.close()
+				idle = nil
+			} else {
+				idleTimer.Reset(c.handlerConfig.idleTimeout)
+			}
 
 		case err := <-c.readErr:
 			conn.handler.log.Trace("RPC connection read error", "err", err)
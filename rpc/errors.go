@@ -67,6 +67,22 @@ func (e *invalidMessageError) ErrorCode() int { return -32700 }
 
 func (e *invalidMessageError) Error() string { return e.message }
 
+// the results of a batch exceed the size limit
+type responseTooLargeError struct{}
+
+func (e *responseTooLargeError) ErrorCode() int { return -32003 }
+
+func (e *responseTooLargeError) Error() string { return "response too large" }
+
+// the connection has as many subscriptions as allowed
+type tooManySubscriptionsError struct{ limit int }
+
+func (e *tooManySubscriptionsError) ErrorCode() int { return -32005 }
+
+func (e *tooManySubscriptionsError) Error() string {
+	return fmt.Sprintf("too many subscriptions, the limit is %d per connection", e.limit)
+}
+
 // unable to decode supplied params, or an invalid number of parameters
 type invalidParamsError struct{ message string }
 
@@ -21,10 +21,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
@@ -69,13 +71,54 @@ type handler struct {
 
 	subLock             sync.Mutex
 	serverSubs          map[ID]*Subscription
+	pendingSubs         int   // subscribe calls in progress, counted against maxSubscriptions
+	inflight            int32 // calls in progress, the connection isn't idle while there are some
 	maxBatchConcurrency uint
 	traceRequests       bool
+	peer                Peer // the sender of the calls, if known
+	ndjson              bool // the client accepts the results of the streamable methods as newline-delimited frames
+
+	handlerConfig
+}
+
+// handlerConfig are the settings of a server applied to the calls of its connections
+type handlerConfig struct {
+	batchLimits   batchLimits
+	methodLimiter methodLimiter
+	acl           *aclRef
+	responseCache *responseCache
+	requestLog    *requestLogger
+	timeouts      *methodTimeouts
+	parseLimits   ParseLimits
+
+	disableStreaming bool // the results of the streamable methods are buffered before being written
+
+	maxSubscriptions int           // maximum number of active subscriptions of a connection, 0 is no limit
+	idleTimeout      time.Duration // the connections without calls nor subscriptions for this long are closed
 }
 
+// batchLimits bound the work done for a batch, zero means no limit
+type batchLimits struct {
+	maxItems        int // maximum number of messages in a batch
+	maxResponseSize int // maximum size of the results of a batch, in bytes
+}
+
+// sequentialNamespaces and sequentialMethods change the state of the node, their calls in a batch are executed in
+// order instead of concurrently with the other calls
+var (
+	sequentialNamespaces = map[string]struct{}{"engine": {}}
+	sequentialMethods    = map[string]struct{}{
+		"eth_sendRawTransaction": {},
+		"eth_sendTransaction":    {},
+		"eth_submitWork":         {},
+		"eth_submitHashrate":     {},
+	}
+)
+
 type callProc struct {
 	ctx       context.Context
 	notifiers []*Notifier
+	ndjson    bool // the result of a streamable method is written as newline-delimited frames
 }
 
 func HandleError(err error, stream *jsoniter.Stream) error {
@@ -134,6 +177,9 @@ func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *
 	if conn.remoteAddr() != "" {
 		h.log = h.log.New("conn", conn.remoteAddr())
 	}
+	if pc, ok := conn.(peerConn); ok {
+		h.peer = pc.peer()
+	}
 	h.unsubscribeCb = newCallback(reflect.Value{}, reflect.ValueOf(h.unsubscribe), "unsubscribe")
 	return h
 }
@@ -148,6 +194,21 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 		return
 	}
 
+	if h.batchLimits.maxItems > 0 && len(msgs) > h.batchLimits.maxItems {
+		h.startCallProc(func(cp *callProc) {
+			resp := errorMessage(&invalidRequestError{"batch too large"})
+			// Answer with the id of the first call.
+			for _, msg := range msgs {
+				if msg.isCall() {
+					resp.ID = msg.ID
+					break
+				}
+			}
+			h.conn.writeJSON(cp.ctx, []*jsonrpcMessage{resp})
+		})
+		return
+	}
+
 	// Handle non-call messages first:
 	calls := make([]*jsonrpcMessage, 0, len(msgs))
 	for _, msg := range msgs {
@@ -161,13 +222,60 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 	// Process calls on a goroutine because they may block indefinitely:
 	h.startCallProc(func(cp *callProc) {
 		// All goroutines will place results right to this array. Because requests order must match reply orders.
-		answersWithNils := make([]interface{}, len(msgs))
+		answersWithNils := make([]interface{}, len(calls))
+		var responseSize int64
+		handleCall := func(i int) {
+			if h.batchLimits.maxResponseSize > 0 && atomic.LoadInt64(&responseSize) > int64(h.batchLimits.maxResponseSize) {
+				// The response is already too large, the remaining calls aren't executed.
+				if calls[i].isCall() {
+					answersWithNils[i] = calls[i].errorResponse(&responseTooLargeError{})
+				}
+				return
+			}
+			buf := bytes.NewBuffer(nil)
+			stream := jsoniter.NewStream(jsoniter.ConfigDefault, buf, 4096)
+			if res := h.handleCallMsg(cp, calls[i], stream); res != nil {
+				answersWithNils[i] = res
+			}
+			_ = stream.Flush()
+			if buf.Len() > 0 && answersWithNils[i] == nil {
+				answersWithNils[i] = json.RawMessage(buf.Bytes())
+			}
+			atomic.AddInt64(&responseSize, int64(answerSize(answersWithNils[i])))
+		}
+
+		// The calls changing the state of the node are executed in order, one after the other, the other calls
+		// are independent and executed concurrently.
+		var sequential, concurrent []int
+		for i, msg := range calls {
+			if msg.isSequential() {
+				sequential = append(sequential, i)
+			} else {
+				concurrent = append(concurrent, i)
+			}
+		}
 		// Bounded parallelism pattern explanation https://blog.golang.org/pipelines#TOC_9.
 		boundedConcurrency := make(chan struct{}, h.maxBatchConcurrency)
 		defer close(boundedConcurrency)
 		wg := sync.WaitGroup{}
-		wg.Add(len(msgs))
-		for i := range calls {
+		if len(sequential) > 0 {
+			wg.Add(1)
+			boundedConcurrency <- struct{}{}
+			go func() {
+				defer func() {
+					wg.Done()
+					<-boundedConcurrency
+				}()
+				for _, i := range sequential {
+					if cp.ctx.Err() != nil {
+						return
+					}
+					handleCall(i)
+				}
+			}()
+		}
+		wg.Add(len(concurrent))
+		for _, i := range concurrent {
 			boundedConcurrency <- struct{}{}
 			go func(i int) {
 				defer func() {
@@ -180,24 +288,23 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 					return
 				default:
 				}
-
-				buf := bytes.NewBuffer(nil)
-				stream := jsoniter.NewStream(jsoniter.ConfigDefault, buf, 4096)
-				if res := h.handleCallMsg(cp, calls[i], stream); res != nil {
-					answersWithNils[i] = res
-				}
-				_ = stream.Flush()
-				if buf.Len() > 0 && answersWithNils[i] == nil {
-					answersWithNils[i] = json.RawMessage(buf.Bytes())
-				}
+				handleCall(i)
 			}(i)
 		}
 		wg.Wait()
-		answers := make([]interface{}, 0, len(msgs))
-		for _, answer := range answersWithNils {
-			if answer != nil {
-				answers = append(answers, answer)
+		answers := make([]interface{}, 0, len(calls))
+		var size int
+		for i, answer := range answersWithNils {
+			if answer == nil {
+				continue
+			}
+			// The answers past the size limit, in the order of the calls, are replaced by errors. The subscriptions
+			// are kept as they're active.
+			size += answerSize(answer)
+			if h.batchLimits.maxResponseSize > 0 && size > h.batchLimits.maxResponseSize && calls[i].isCall() && !calls[i].isSubscribe() {
+				answer = calls[i].errorResponse(&responseTooLargeError{})
 			}
+			answers = append(answers, answer)
 		}
 		h.addSubscriptions(cp.notifiers)
 		if len(answers) > 0 {
@@ -209,16 +316,43 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 	})
 }
 
+// answerSize returns the size of the result of an answer to a batch call
+func answerSize(answer interface{}) int {
+	switch answer := answer.(type) {
+	case json.RawMessage:
+		return len(answer)
+	case *jsonrpcMessage:
+		return len(answer.Result)
+	default:
+		return 0
+	}
+}
+
 // handleMsg handles a single message.
 func (h *handler) handleMsg(msg *jsonrpcMessage, stream *jsoniter.Stream) {
 	if ok := h.handleImmediate(msg); ok {
 		return
 	}
 	h.startCallProc(func(cp *callProc) {
+		if sw, ok := h.conn.(streamWriter); ok && stream == nil && !h.disableStreaming && h.isStreamable(msg) {
+			// Write the result to the connection while it's produced instead of buffering all of it.
+			sw.writeStream(cp.ctx, func(w io.Writer) error {
+				stream := jsoniter.NewStream(jsoniter.ConfigDefault, w, 4096)
+				if answer := h.handleCallMsg(cp, msg, stream); answer != nil {
+					buffer, _ := json.Marshal(answer)
+					stream.Write(buffer)
+				}
+				_ = stream.Flush()
+				return stream.Error
+			})
+			return
+		}
 		needWriteStream := false
 		if stream == nil {
 			stream = jsoniter.NewStream(jsoniter.ConfigDefault, nil, 4096)
 			needWriteStream = true
+		} else {
+			cp.ndjson = h.ndjson
 		}
 		answer := h.handleCallMsg(cp, msg, stream)
 		h.addSubscriptions(cp.notifiers)
@@ -287,6 +421,7 @@ func (h *handler) addSubscriptions(nn []*Notifier) {
 	h.subLock.Lock()
 	defer h.subLock.Unlock()
 
+	h.pendingSubs -= len(nn)
 	for _, n := range nn {
 		if sub := n.takeSubscription(); sub != nil {
 			h.serverSubs[sub.ID] = sub
@@ -306,12 +441,35 @@ func (h *handler) cancelServerSubscriptions(err error) {
 	}
 }
 
+// reserveSubscription counts a subscribe call against maxSubscriptions, until addSubscriptions. It returns false if
+// the connection has as many subscriptions as allowed.
+func (h *handler) reserveSubscription() bool {
+	h.subLock.Lock()
+	defer h.subLock.Unlock()
+
+	if h.maxSubscriptions > 0 && len(h.serverSubs)+h.pendingSubs >= h.maxSubscriptions {
+		return false
+	}
+	h.pendingSubs++
+	return true
+}
+
+// idle returns true if the connection has no call in progress nor subscription
+func (h *handler) idle() bool {
+	h.subLock.Lock()
+	defer h.subLock.Unlock()
+
+	return atomic.LoadInt32(&h.inflight) == 0 && len(h.serverSubs) == 0 && h.pendingSubs == 0
+}
+
 // startCallProc runs fn in a new goroutine and starts tracking it in the h.calls wait group.
 func (h *handler) startCallProc(fn func(*callProc)) {
 	h.callWG.Add(1)
+	atomic.AddInt32(&h.inflight, 1)
 	go func() {
 		ctx, cancel := context.WithCancel(h.rootCtx)
 		defer h.callWG.Done()
+		defer atomic.AddInt32(&h.inflight, -1)
 		defer cancel()
 		fn(&callProc{ctx: ctx})
 	}()
@@ -412,7 +570,19 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage, stream *json
 	}
 }
 
+// isStreamable returns true if msg is a call of a method writing its result to a stream.
+func (h *handler) isStreamable(msg *jsonrpcMessage) bool {
+	if !msg.isCall() || msg.isSubscribe() || msg.isUnsubscribe() || !h.isMethodAllowedByGranularControl(msg.Method) {
+		return false
+	}
+	callb := h.reg.callback(msg.Method)
+	return callb != nil && callb.streamable
+}
+
 func (h *handler) isMethodAllowedByGranularControl(method string) bool {
+	if !h.isMethodAllowedByACL(method) {
+		return false
+	}
 	_, isForbidden := h.forbiddenList[method]
 	if len(h.allowList) == 0 {
 		return !isForbidden
@@ -422,8 +592,22 @@ func (h *handler) isMethodAllowedByGranularControl(method string) bool {
 	return ok
 }
 
+// isMethodAllowedByACL returns true if the ACL of the server, if any, and the methods allowed to the peer by its
+// request, if restricted, allow it to call method.
+func (h *handler) isMethodAllowedByACL(method string) bool {
+	if h.peer.methods != nil && !matchMethod(h.peer.methods, method) {
+		return false
+	}
+	acl := h.acl.load()
+	return acl == nil || acl.allows(h.peer, method)
+}
+
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter.Stream) *jsonrpcMessage {
+	received := time.Now()
+	if err := h.parseLimits.check(msg.Params); err != nil {
+		return msg.errorResponse(err)
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg, stream)
 	}
@@ -436,12 +620,32 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter
 	if callb == nil {
 		return msg.errorResponse(&methodNotFoundError{method: msg.Method})
 	}
+	// the limits are only taken by the calls which are allowed and will run
+	release, err := h.methodLimiter.acquire(msg.Method)
+	if err != nil {
+		return msg.errorResponse(err)
+	}
+	defer release()
+	acquired := time.Now()
+
 	args, err := parsePositionalArguments(msg.Params, callb.argTypes)
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
 	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args, stream)
+	ctx, cancel := h.timeouts.withTimeout(cp.ctx, msg.Method)
+	defer cancel()
+	var answer *jsonrpcMessage
+	if result, ok := h.responseCache.get(msg.Method, msg.Params); ok {
+		answer = &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: result}
+	} else if cp.ndjson && callb.streamable {
+		h.runMethodNDJSON(ctx, msg, callb, args, stream)
+	} else {
+		answer = h.runMethod(ctx, msg, callb, args, stream)
+		if answer != nil && answer.Error == nil && callb != h.unsubscribeCb {
+			h.responseCache.put(cp.ctx, msg.Method, msg.Params, answer.Result)
+		}
+	}
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -451,7 +655,15 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter
 			failedReqeustGauge.Inc()
 		}
 		newRPCServingTimerMS(msg.Method, answer == nil || answer.Error == nil).UpdateDuration(start)
+
+		calls, failures, duration := newRPCMethodMetrics(msg.Method, h.peer.Transport)
+		calls.Inc()
+		if answer != nil && answer.Error != nil {
+			failures.Inc()
+		}
+		duration.UpdateDuration(start)
 	}
+	h.requestLog.log(cp.ctx, h, msg, answer, callTimings{wait: acquired.Sub(received), parse: start.Sub(acquired), exec: time.Since(start)})
 	return answer
 }
 
@@ -460,6 +672,9 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage, stream *jso
 	if !h.allowSubscribe {
 		return msg.errorResponse(ErrNotificationsUnsupported)
 	}
+	if !h.isMethodAllowedByACL(msg.Method) {
+		return msg.errorResponse(&methodNotFoundError{method: msg.Method})
+	}
 
 	// Subscription method name is first argument.
 	name, err := parseSubscriptionName(msg.Params)
@@ -480,6 +695,14 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage, stream *jso
 	}
 	args = args[1:]
 
+	release, err := h.methodLimiter.acquire(msg.Method)
+	if err != nil {
+		return msg.errorResponse(err)
+	}
+	defer release()
+	if !h.reserveSubscription() {
+		return msg.errorResponse(&tooManySubscriptionsError{limit: h.maxSubscriptions})
+	}
 	// Install notifier in context so the subscription handler can find it.
 	n := &Notifier{h: h, namespace: namespace}
 	cp.notifiers = append(cp.notifiers, n)
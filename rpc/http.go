@@ -178,8 +178,8 @@ type httpServerConn struct {
 	r *http.Request
 }
 
-func newHTTPServerConn(r *http.Request, w http.ResponseWriter) ServerCodec {
-	body := io.LimitReader(r.Body, maxRequestContentLength)
+func newHTTPServerConn(r *http.Request, w http.ResponseWriter, maxBodySize int64) ServerCodec {
+	body := io.LimitReader(r.Body, maxBodySize)
 	conn := &httpServerConn{Reader: body, Writer: w, r: r}
 	return NewCodec(conn)
 }
@@ -192,6 +192,12 @@ func (t *httpServerConn) RemoteAddr() string {
 	return t.r.RemoteAddr
 }
 
+func (t *httpServerConn) peer() Peer {
+	peer := peerFromRequest(t.r)
+	peer.Transport = "http"
+	return peer
+}
+
 // SetWriteDeadline does nothing and always returns nil.
 func (t *httpServerConn) SetWriteDeadline(time.Time) error { return nil }
 
@@ -202,7 +208,8 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
-	if code, err := validateRequest(r); err != nil {
+	maxBodySize := s.handlerConfig.parseLimits.maxBodySize()
+	if code, err := validateRequest(r, maxBodySize); err != nil {
 		http.Error(w, err.Error(), code)
 		return
 	}
@@ -220,24 +227,30 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ctx = context.WithValue(ctx, "Origin", origin)
 	}
 
-	w.Header().Set("content-type", contentType)
-	codec := newHTTPServerConn(r, w)
+	// the results of the streamable methods are framed as newline-delimited JSON if the client accepts it
+	ndjson := !s.handlerConfig.disableStreaming && acceptsNDJSON(r)
+	if ndjson {
+		w.Header().Set("content-type", ndjsonContentType)
+	} else {
+		w.Header().Set("content-type", contentType)
+	}
+	codec := newHTTPServerConn(r, w, maxBodySize)
 	defer codec.close()
 	var stream *jsoniter.Stream
-	if !s.disableStreaming {
+	if !s.handlerConfig.disableStreaming {
 		stream = jsoniter.NewStream(jsoniter.ConfigDefault, w, 4096)
 	}
-	s.serveSingleRequest(ctx, codec, stream)
+	s.serveSingleRequest(ctx, codec, stream, ndjson)
 }
 
 // validateRequest returns a non-zero response code and error message if the
 // request is invalid.
-func validateRequest(r *http.Request) (int, error) {
+func validateRequest(r *http.Request, maxBodySize int64) (int, error) {
 	if r.Method == http.MethodPut || r.Method == http.MethodDelete {
 		return http.StatusMethodNotAllowed, errors.New("method not allowed")
 	}
-	if r.ContentLength > maxRequestContentLength {
-		err := fmt.Errorf("content length too large (%d>%d)", r.ContentLength, maxRequestContentLength)
+	if r.ContentLength > maxBodySize {
+		err := fmt.Errorf("content length too large (%d>%d)", r.ContentLength, maxBodySize)
 		return http.StatusRequestEntityTooLarge, err
 	}
 	// Allow OPTIONS (regardless of content-type)
@@ -263,10 +276,18 @@ func CheckJwtSecret(w http.ResponseWriter, r *http.Request, jwtSecret []byte) bo
 	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
 		tokenStr = strings.TrimPrefix(auth, "Bearer ")
 	}
+	if err := CheckJwtToken(tokenStr, jwtSecret); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return false
+	}
+	return true
+}
 
+// CheckJwtToken checks a HS256 JWT signed with jwtSecret, issued within jwtTokenExpiry of now, for the transports
+// other than HTTP
+func CheckJwtToken(tokenStr string, jwtSecret []byte) error {
 	if len(tokenStr) == 0 {
-		http.Error(w, "missing token", http.StatusForbidden)
-		return false
+		return errors.New("missing token")
 	}
 
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
@@ -282,20 +303,17 @@ func CheckJwtSecret(w http.ResponseWriter, r *http.Request, jwtSecret []byte) bo
 
 	switch {
 	case err != nil:
-		http.Error(w, err.Error(), http.StatusForbidden)
+		return err
 	case !token.Valid:
-		http.Error(w, "invalid token", http.StatusForbidden)
+		return errors.New("invalid token")
 	case !claims.VerifyExpiresAt(time.Now(), false): // optional
-		http.Error(w, "token is expired", http.StatusForbidden)
+		return errors.New("token is expired")
 	case claims.IssuedAt == nil:
-		http.Error(w, "missing issued-at", http.StatusForbidden)
+		return errors.New("missing issued-at")
 	case time.Since(claims.IssuedAt.Time) > jwtTokenExpiry:
-		http.Error(w, "stale token", http.StatusForbidden)
+		return errors.New("stale token")
 	case time.Until(claims.IssuedAt.Time) > jwtTokenExpiry:
-		http.Error(w, "future token", http.StatusForbidden)
-	default:
-		return true
+		return errors.New("future token")
 	}
-
-	return false
+	return nil
 }
@@ -17,6 +17,8 @@
 package rpc
 
 import (
+	"bytes"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -42,7 +44,7 @@ func confirmRequestValidationCode(t *testing.T, method, contentType, body string
 	if len(contentType) > 0 {
 		request.Header.Set("Content-Type", contentType)
 	}
-	code, err := validateRequest(request)
+	code, err := validateRequest(request, maxRequestContentLength)
 	if code == 0 {
 		if err != nil {
 			t.Errorf("validation: got error %v, expected nil", err)
@@ -126,3 +128,80 @@ func TestHTTPRespBodyUnlimited(t *testing.T) {
 		t.Fatalf("response has wrong length %d, want %d", len(r), respLength)
 	}
 }
+
+func TestHTTPNDJSON(t *testing.T) {
+	s := NewServer(50, false /* traceRequests */, false)
+	defer s.Stop()
+	if err := s.RegisterName("test", new(testService)); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	post := func(body string) (string, string) {
+		t.Helper()
+		request, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Content-Type", contentType)
+		request.Header.Set("Accept", "application/json, "+ndjsonContentType)
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		response, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Header.Get("Content-Type"), string(response)
+	}
+
+	responseType, response := post(`{"jsonrpc":"2.0","id":1,"method":"test_stream","params":[3]}`)
+	if responseType != ndjsonContentType {
+		t.Fatalf("wrong content type %q", responseType)
+	}
+	want := `{"jsonrpc":"2.0","id":1,"item":0}
+{"jsonrpc":"2.0","id":1,"item":1}
+{"jsonrpc":"2.0","id":1,"item":2}
+{"jsonrpc":"2.0","id":1,"result":[]}
+`
+	if response != want {
+		t.Fatalf("wrong response\ngot:\n%s\nwant:\n%s", response, want)
+	}
+
+	// the results of the other methods are sent in a single line
+	_, response = post(`{"jsonrpc":"2.0","id":2,"method":"test_echo","params":["x",1]}`)
+	if want := `{"jsonrpc":"2.0","id":2,"result":{"String":"x","Int":1,"Args":null}}` + "\n"; response != want {
+		t.Fatalf("wrong response\ngot:\n%s\nwant:\n%s", response, want)
+	}
+}
+
+func TestNDJSONFramer(t *testing.T) {
+	value := `{"a": "x]\"[" , "logs": [{"b":[1, 2]}, "s,]\n", 3 , null], "c": [4]}`
+	var out bytes.Buffer
+	f := &ndjsonFramer{out: &out, prefix: []byte(`{"item":`)}
+	// the value is written in pieces splitting the strings and the elements
+	for i := 0; i < len(value); i += 5 {
+		end := i + 5
+		if end > len(value) {
+			end = len(value)
+		}
+		if _, err := f.Write([]byte(value[i:end])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.finish()
+	wantItems := `{"item":{"b":[1,2]}}
+{"item":"s,]\n"}
+{"item":3}
+{"item":null}
+`
+	if out.String() != wantItems {
+		t.Fatalf("wrong items\ngot:\n%s\nwant:\n%s", out.String(), wantItems)
+	}
+	if wantRest := `{"a":"x]\"[","logs":[],"c":[4]}`; f.rest.String() != wantRest {
+		t.Fatalf("wrong rest %s, want %s", f.rest.String(), wantRest)
+	}
+}
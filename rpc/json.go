@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"reflect"
 	"strings"
 	"sync"
@@ -81,6 +82,18 @@ func (msg *jsonrpcMessage) isUnsubscribe() bool {
 	return strings.HasSuffix(msg.Method, unsubscribeMethodSuffix)
 }
 
+// isSequential returns true if msg can't be executed concurrently with the other messages of its batch
+func (msg *jsonrpcMessage) isSequential() bool {
+	if msg.isSubscribe() || msg.isUnsubscribe() {
+		return true
+	}
+	if _, ok := sequentialMethods[msg.Method]; ok {
+		return true
+	}
+	_, ok := sequentialNamespaces[msg.namespace()]
+	return ok
+}
+
 func (msg *jsonrpcMessage) namespace() string {
 	elem := strings.SplitN(msg.Method, serviceMethodSeparator, 2)
 	return elem[0]
@@ -198,6 +211,17 @@ func NewCodec(conn Conn) ServerCodec {
 	return NewFuncCodec(conn, enc.Encode, dec.Decode)
 }
 
+func (c *jsonCodec) peer() Peer {
+	if pc, ok := c.conn.(peerConn); ok {
+		return pc.peer()
+	}
+	// the in-process connections are pipes, the others are served by ServeListener
+	if la, ok := c.conn.(interface{ LocalAddr() net.Addr }); ok && la.LocalAddr().Network() == "pipe" {
+		return Peer{Transport: "inproc"}
+	}
+	return Peer{Transport: "ipc"}
+}
+
 func (c *jsonCodec) remoteAddr() string {
 	return c.remote
 }
@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// concurrencyRetryAfter is the delay suggested to the clients whose calls exceed the concurrency limit of a method
+const concurrencyRetryAfter = time.Second
+
+// MethodLimit limits the calls of a method, zero means no limit
+type MethodLimit struct {
+	Rate        float64 `json:"rate"`        // calls per second
+	Burst       int     `json:"burst"`       // calls allowed at once above the rate, Rate rounded up by default
+	Concurrency int     `json:"concurrency"` // calls executed at the same time
+}
+
+// MethodLimits are the limits of the calls by method name, shared by all the connections of a server
+type MethodLimits map[string]MethodLimit
+
+// methodLimiter enforces MethodLimits
+type methodLimiter map[string]*methodLimit
+
+type methodLimit struct {
+	rate    *rate.Limiter
+	running chan struct{}
+}
+
+func newMethodLimiter(limits MethodLimits) (methodLimiter, error) {
+	limiter := make(methodLimiter, len(limits))
+	for method, limit := range limits {
+		if limit.Rate < 0 || limit.Burst < 0 || limit.Concurrency < 0 {
+			return nil, fmt.Errorf("invalid limit of method %s: negative value", method)
+		}
+		l := &methodLimit{}
+		if limit.Rate > 0 {
+			burst := limit.Burst
+			if burst == 0 {
+				burst = int(math.Ceil(limit.Rate))
+			}
+			l.rate = rate.NewLimiter(rate.Limit(limit.Rate), burst)
+		}
+		if limit.Concurrency > 0 {
+			l.running = make(chan struct{}, limit.Concurrency)
+		}
+		limiter[method] = l
+	}
+	return limiter, nil
+}
+
+// acquire admits a call of method, release must be called once it's done. It fails with a limitExceededError if
+// the call exceeds the limits of the method.
+func (l methodLimiter) acquire(method string) (release func(), err error) {
+	limit, ok := l[method]
+	if !ok {
+		return func() {}, nil
+	}
+	if limit.running != nil {
+		select {
+		case limit.running <- struct{}{}:
+		default:
+			return nil, &limitExceededError{method: method, reason: "too many concurrent calls", retryAfter: concurrencyRetryAfter}
+		}
+	}
+	release = func() {
+		if limit.running != nil {
+			<-limit.running
+		}
+	}
+	if limit.rate != nil {
+		r := limit.rate.Reserve()
+		if delay := r.Delay(); !r.OK() || delay > 0 {
+			r.Cancel()
+			release()
+			return nil, &limitExceededError{method: method, reason: "rate limit exceeded", retryAfter: delay}
+		}
+	}
+	return release, nil
+}
+
+// limitExceededError is returned for the calls exceeding the limits of their method, with the delay after which
+// the call may be retried
+type limitExceededError struct {
+	method     string
+	reason     string
+	retryAfter time.Duration
+}
+
+func (e *limitExceededError) ErrorCode() int { return -32005 }
+
+func (e *limitExceededError) Error() string {
+	return fmt.Sprintf("limit exceeded for %s: %s", e.method, e.reason)
+}
+
+// ErrorData returns the retry hint, in seconds
+func (e *limitExceededError) ErrorData() interface{} {
+	return map[string]interface{}{"retryAfter": e.retryAfter.Seconds()}
+}
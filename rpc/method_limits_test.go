@@ -0,0 +1,106 @@
+package rpc
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMethodLimiterConcurrency(t *testing.T) {
+	limiter, err := newMethodLimiter(MethodLimits{"test_block": {Concurrency: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release1, err := limiter.acquire("test_block")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.acquire("test_block"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.acquire("test_block"); err == nil {
+		t.Fatal("no error for too many concurrent calls")
+	}
+	// the other methods aren't limited
+	if _, err := limiter.acquire("test_echo"); err != nil {
+		t.Fatal(err)
+	}
+	release1()
+	if _, err := limiter.acquire("test_block"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMethodLimiterInvalid(t *testing.T) {
+	if _, err := newMethodLimiter(MethodLimits{"test_echo": {Rate: -1}}); err == nil {
+		t.Fatal("no error for negative rate")
+	}
+}
+
+// This test checks that the calls over the rate limit are answered with a retry hint.
+func TestServerMethodRateLimit(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	if err := server.SetMethodLimits(MethodLimits{"test_echo": {Rate: 0.001, Burst: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	client := DialInProc(server)
+	defer client.Close()
+
+	var result echoResult
+	if err := client.Call(&result, "test_echo", "x", 1); err != nil {
+		t.Fatal(err)
+	}
+	err := client.Call(&result, "test_echo", "x", 1)
+	if err == nil {
+		t.Fatal("no error for call over the rate limit")
+	}
+	if code := err.(Error).ErrorCode(); code != -32005 {
+		t.Fatalf("wrong error code %d", code)
+	}
+	data := err.(DataError).ErrorData().(map[string]interface{})
+	if retryAfter := data["retryAfter"].(float64); retryAfter <= 0 {
+		t.Fatalf("wrong retry hint %v", retryAfter)
+	}
+	// the other methods aren't limited
+	if err := client.Call(nil, "test_noArgsRets"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// This test checks that the calls denied by the ACL or of unknown methods don't take the limits of the allowed ones.
+func TestServerMethodLimitDenied(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	if err := server.SetMethodLimits(MethodLimits{"test_echo": {Rate: 0.001, Burst: 1}, "test_missing": {Rate: 0.001, Burst: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	acl, err := ParseACL([]byte(testACL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetACL(acl)
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+	client, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var result echoResult
+	for i := 0; i < 3; i++ {
+		if err := client.Call(&result, "test_echo", "x", 1); err == nil || !strings.Contains(err.Error(), "does not exist") {
+			t.Fatalf("wrong error for a call denied by the ACL: %v", err)
+		}
+	}
+	client.SetHeader(APIKeyHeader, "secret")
+	for i := 0; i < 3; i++ {
+		if err := client.Call(&result, "test_missing"); err == nil || !strings.Contains(err.Error(), "does not exist") {
+			t.Fatalf("wrong error for an unknown method: %v", err)
+		}
+	}
+	if err := client.Call(&result, "test_echo", "x", 1); err != nil {
+		t.Fatalf("allowed call limited by the denied ones: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MethodTimeouts are the time limits of the calls by method. A method ending with * applies to all the methods
+// starting with the rest of it, like debug_trace*, the longest match being used.
+type MethodTimeouts map[string]time.Duration
+
+// ParseMethodTimeouts parses the comma separated timeouts of the methods, for example eth_call=5s,debug_trace*=10m
+func ParseMethodTimeouts(s string) (MethodTimeouts, error) {
+	timeouts := MethodTimeouts{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		method, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid method timeout %q, expected method=duration", item)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout of method %s: %w", method, err)
+		}
+		timeouts[strings.TrimSpace(method)] = timeout
+	}
+	return timeouts, nil
+}
+
+// WithTimeout returns the context of a call of method, which is cancelled once its time limit is reached, for the
+// servers other than Server. The invalid timeouts, rejected by Server.SetMethodTimeouts, are ignored.
+func (timeouts MethodTimeouts) WithTimeout(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	t, _ := newMethodTimeouts(timeouts)
+	return t.withTimeout(ctx, method)
+}
+
+// methodTimeouts enforces MethodTimeouts
+type methodTimeouts struct {
+	exact    map[string]time.Duration
+	prefixes []methodTimeoutPrefix // longest first
+}
+
+type methodTimeoutPrefix struct {
+	prefix  string
+	timeout time.Duration
+}
+
+func newMethodTimeouts(timeouts MethodTimeouts) (*methodTimeouts, error) {
+	if len(timeouts) == 0 {
+		return nil, nil
+	}
+	t := &methodTimeouts{exact: make(map[string]time.Duration, len(timeouts))}
+	for method, timeout := range timeouts {
+		if timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout of method %s: %v", method, timeout)
+		}
+		if strings.HasSuffix(method, "*") {
+			t.prefixes = append(t.prefixes, methodTimeoutPrefix{prefix: strings.TrimSuffix(method, "*"), timeout: timeout})
+		} else {
+			t.exact[method] = timeout
+		}
+	}
+	sort.Slice(t.prefixes, func(i, j int) bool { return len(t.prefixes[i].prefix) > len(t.prefixes[j].prefix) })
+	return t, nil
+}
+
+// timeout returns the time limit of the calls of method, 0 if there's none
+func (t *methodTimeouts) timeout(method string) time.Duration {
+	if t == nil {
+		return 0
+	}
+	if timeout, ok := t.exact[method]; ok {
+		return timeout
+	}
+	for _, p := range t.prefixes {
+		if strings.HasPrefix(method, p.prefix) {
+			return p.timeout
+		}
+	}
+	return 0
+}
+
+// withTimeout returns the context of a call of method, which is cancelled once its time limit is reached
+func (t *methodTimeouts) withTimeout(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	if timeout := t.timeout(method); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMethodTimeouts(t *testing.T) {
+	timeouts, err := newMethodTimeouts(MethodTimeouts{
+		"eth_call":                time.Second,
+		"debug_*":                 time.Minute,
+		"debug_trace*":            10 * time.Minute,
+		"debug_traceTransaction*": 20 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for method, want := range map[string]time.Duration{
+		"eth_call":               time.Second,
+		"eth_callMany":           0,
+		"debug_getBadBlocks":     time.Minute,
+		"debug_traceBlockByHash": 10 * time.Minute,
+		"debug_traceTransaction": 20 * time.Minute,
+		"trace_call":             0,
+	} {
+		if got := timeouts.timeout(method); got != want {
+			t.Errorf("wrong timeout of %s: got %v, want %v", method, got, want)
+		}
+	}
+
+	if _, err := newMethodTimeouts(MethodTimeouts{"eth_call": -time.Second}); err == nil {
+		t.Fatal("no error for a negative timeout")
+	}
+}
+
+func TestParseMethodTimeouts(t *testing.T) {
+	timeouts, err := ParseMethodTimeouts(" eth_call=5s, debug_trace*=10m,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timeouts) != 2 || timeouts["eth_call"] != 5*time.Second || timeouts["debug_trace*"] != 10*time.Minute {
+		t.Fatalf("wrong timeouts: %v", timeouts)
+	}
+	ctx, cancel := timeouts.WithTimeout(context.Background(), "debug_traceCall")
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > 10*time.Minute {
+		t.Fatalf("wrong deadline: %v", deadline)
+	}
+
+	for _, s := range []string{"eth_call", "eth_call=5"} {
+		if _, err := ParseMethodTimeouts(s); err == nil {
+			t.Errorf("no error for %q", s)
+		}
+	}
+}
+
+// This test checks that the context of the calls over their time limit is cancelled.
+func TestServerMethodTimeout(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	if err := server.SetMethodTimeouts(MethodTimeouts{"test_bl*": 50 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	client := DialInProc(server)
+	defer client.Close()
+
+	start := time.Now()
+	if err := client.Call(nil, "test_block"); err == nil {
+		t.Fatal("no error for a call over its time limit")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("the call wasn't cancelled, it took %v", elapsed)
+	}
+	// the other methods aren't limited
+	if err := client.Call(nil, "test_sleep", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+}
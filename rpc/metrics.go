@@ -25,8 +25,20 @@ import (
 var (
 	rpcRequestGauge    = metrics.GetOrCreateCounter("rpc_total")
 	failedReqeustGauge = metrics.GetOrCreateCounter("rpc_failure")
+
+	responseCacheHits   = metrics.GetOrCreateCounter("rpc_response_cache_hits_total")
+	responseCacheMisses = metrics.GetOrCreateCounter("rpc_response_cache_misses_total")
 )
 
+// newRPCMethodMetrics returns the counters of the calls and failures of method over transport, and the histogram of
+// their durations
+func newRPCMethodMetrics(method, transport string) (calls, failures *metrics.Counter, duration *metrics.Histogram) {
+	labels := fmt.Sprintf(`{method="%s",transport="%s"}`, method, transport)
+	return metrics.GetOrCreateCounter("rpc_method_calls_total" + labels),
+		metrics.GetOrCreateCounter("rpc_method_errors_total" + labels),
+		metrics.GetOrCreateHistogram("rpc_method_duration_seconds" + labels)
+}
+
 func newRPCServingTimerMS(method string, valid bool) *metrics.Summary {
 	flag := "success"
 	if !valid {
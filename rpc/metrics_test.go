@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// This test checks that the calls are counted by method and transport.
+func TestMethodMetrics(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	echoCalls, _, _ := newRPCMethodMetrics("test_echo", "inproc")
+	errorCalls, errorFailures, _ := newRPCMethodMetrics("test_returnError", "inproc")
+	httpEchoCalls, _, _ := newRPCMethodMetrics("test_echo", "http")
+	echoBefore, errorBefore, failuresBefore, httpEchoBefore := echoCalls.Get(), errorCalls.Get(), errorFailures.Get(), httpEchoCalls.Get()
+
+	client := DialInProc(server)
+	defer client.Close()
+	var result echoResult
+	for i := 0; i < 2; i++ {
+		if err := client.Call(&result, "test_echo", "x", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := client.Call(nil, "test_returnError"); err == nil {
+		t.Fatal("no error from test_returnError")
+	}
+
+	httpClient, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer httpClient.Close()
+	if err := httpClient.Call(&result, "test_echo", "x", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := echoCalls.Get() - echoBefore; got != 2 {
+		t.Errorf("wrong count of inproc test_echo calls %d", got)
+	}
+	if got := errorCalls.Get() - errorBefore; got != 1 {
+		t.Errorf("wrong count of test_returnError calls %d", got)
+	}
+	if got := errorFailures.Get() - failuresBefore; got != 1 {
+		t.Errorf("wrong count of test_returnError failures %d", got)
+	}
+	if got := httpEchoCalls.Get() - httpEchoBefore; got != 1 {
+		t.Errorf("wrong count of http test_echo calls %d", got)
+	}
+}
@@ -0,0 +1,178 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// ndjsonContentType is the content type of the HTTP responses framing the results of the streamable methods as
+// newline-delimited JSON, the clients opt in by accepting it
+const ndjsonContentType = "application/x-ndjson"
+
+// acceptsNDJSON returns true if the request accepts the newline-delimited JSON responses
+func acceptsNDJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			if mt, _, err := mime.ParseMediaType(mediaType); err == nil && mt == ndjsonContentType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runMethodNDJSON runs a streamable method, writing the elements of the first array of its result as they are
+// produced, each on its own line in the "item" field of an object with the jsonrpc version and the id of the call.
+// The last line is the response to the call, with the result without the elements of the array, or the error.
+func (h *handler) runMethodNDJSON(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value, stream *jsoniter.Stream) {
+	// the lines start like the response
+	writeHeader := func(stream *jsoniter.Stream) {
+		stream.WriteObjectStart()
+		stream.WriteObjectField("jsonrpc")
+		stream.WriteString(vsn)
+		stream.WriteMore()
+		if msg.ID != nil {
+			stream.WriteObjectField("id")
+			stream.Write(msg.ID)
+			stream.WriteMore()
+		}
+	}
+	prefix := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+	writeHeader(prefix)
+	prefix.WriteObjectField("item")
+	framer := &ndjsonFramer{out: stream, prefix: prefix.Buffer()}
+
+	result := jsoniter.NewStream(jsoniter.ConfigDefault, framer, 4096)
+	_, err := callb.call(ctx, msg.Method, args, result)
+	_ = result.Flush()
+	framer.finish()
+	writeHeader(stream)
+	if err != nil {
+		HandleError(err, stream)
+	} else {
+		stream.WriteObjectField("result")
+		stream.Write(framer.rest.Bytes())
+	}
+	stream.WriteObjectEnd()
+	stream.Flush()
+}
+
+// ndjsonFramer writes the elements of the first array of a JSON value, written to it as it's produced, as lines
+// starting with prefix. The rest of the value is kept, with the array emptied. The whitespace outside the strings is
+// dropped, an element is never split across lines.
+type ndjsonFramer struct {
+	out    io.Writer
+	prefix []byte
+	rest   bytes.Buffer
+
+	depth      int
+	arrayDepth int  // the depth inside the framed array, 0 until it's found
+	framed     bool // the framed array ended
+	inItem     bool
+	inString   bool
+	escaped    bool
+	err        error
+}
+
+func (f *ndjsonFramer) Write(p []byte) (int, error) {
+	span := -1 // the start of the bytes of the current item in p, not written yet
+	writeSpan := func(end int) {
+		if span >= 0 {
+			f.write(p[span:end])
+			span = -1
+		}
+	}
+	for i, c := range p {
+		if f.inString {
+			switch {
+			case f.escaped:
+				f.escaped = false
+			case c == '\\':
+				f.escaped = true
+			case c == '"':
+				f.inString = false
+			}
+			if !f.inItem {
+				f.rest.WriteByte(c)
+			} else if span < 0 {
+				span = i
+			}
+			continue
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			writeSpan(i)
+			continue
+		}
+		if f.inItem {
+			if f.depth != f.arrayDepth || (c != ',' && c != ']') {
+				if span < 0 {
+					span = i
+				}
+				f.track(c)
+				continue
+			}
+			writeSpan(i)
+			f.write([]byte("}\n"))
+			f.inItem = false
+		}
+		if f.arrayDepth > 0 && !f.framed && f.depth == f.arrayDepth {
+			switch c {
+			case ',':
+			case ']':
+				f.framed = true
+				f.rest.WriteByte(c)
+				f.track(c)
+			default:
+				f.write(f.prefix)
+				f.inItem = true
+				span = i
+				f.track(c)
+			}
+			continue
+		}
+		f.rest.WriteByte(c)
+		f.track(c)
+		if c == '[' && f.arrayDepth == 0 {
+			f.arrayDepth = f.depth
+		}
+	}
+	writeSpan(len(p))
+	if f.err != nil {
+		return 0, f.err
+	}
+	return len(p), nil
+}
+
+// track follows the nesting of the value
+func (f *ndjsonFramer) track(c byte) {
+	switch c {
+	case '{', '[':
+		f.depth++
+	case '}', ']':
+		f.depth--
+	case '"':
+		f.inString = true
+	}
+}
+
+func (f *ndjsonFramer) write(p []byte) {
+	if f.err == nil {
+		_, f.err = f.out.Write(p)
+	}
+}
+
+// finish ends the line of an item interrupted by an error, the item is truncated
+func (f *ndjsonFramer) finish() {
+	if f.inItem {
+		f.write([]byte("\n"))
+		f.inItem = false
+	}
+}
@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"fmt"
+)
+
+// ParseLimits bound the requests read by a server, so that their params can't allocate unbounded memory once
+// decoded for the call. The params exceeding a limit are answered with an invalid params error (-32602) before the
+// method is called. Zero means no limit, except for MaxBodySize whose default is 5 MB.
+type ParseLimits struct {
+	MaxBodySize     int64 // maximum size of the HTTP request bodies, in bytes
+	MaxDepth        int   // maximum nesting depth of the params, the params array being at depth 1
+	MaxStringLength int   // maximum length of the strings of the params, in bytes as sent
+	MaxArrayLength  int   // maximum number of elements of the arrays and members of the objects of the params
+}
+
+// SetParseLimits sets the limits of the requests read by the server.
+func (s *Server) SetParseLimits(limits ParseLimits) error {
+	if limits.MaxBodySize < 0 || limits.MaxDepth < 0 || limits.MaxStringLength < 0 || limits.MaxArrayLength < 0 {
+		return fmt.Errorf("invalid parse limits %+v", limits)
+	}
+	s.handlerConfig.parseLimits = limits
+	return nil
+}
+
+// maxBodySize returns the maximum size of the HTTP request bodies
+func (l ParseLimits) maxBodySize() int64 {
+	if l.MaxBodySize == 0 {
+		return maxRequestContentLength
+	}
+	return l.MaxBodySize
+}
+
+// check returns an error if the params, which are valid JSON, exceed a limit. They are scanned without being
+// decoded.
+func (l ParseLimits) check(params []byte) error {
+	if l.MaxDepth == 0 && l.MaxStringLength == 0 && l.MaxArrayLength == 0 {
+		return nil
+	}
+	var (
+		counts   []int // the number of separators of the enclosing arrays and objects, innermost last
+		inString bool
+		escaped  bool
+		start    int
+	)
+	for i, c := range params {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				if length := i - start - 1; l.MaxStringLength > 0 && length > l.MaxStringLength {
+					return &invalidParamsError{fmt.Sprintf("string of %d bytes exceeds the limit of %d", length, l.MaxStringLength)}
+				}
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString, start = true, i
+		case '[', '{':
+			counts = append(counts, 0)
+			if l.MaxDepth > 0 && len(counts) > l.MaxDepth {
+				return &invalidParamsError{fmt.Sprintf("nesting depth exceeds the limit of %d", l.MaxDepth)}
+			}
+		case ']', '}':
+			if len(counts) > 0 {
+				counts = counts[:len(counts)-1]
+			}
+		case ',':
+			if len(counts) == 0 {
+				continue
+			}
+			counts[len(counts)-1]++
+			if l.MaxArrayLength > 0 && counts[len(counts)-1] >= l.MaxArrayLength {
+				return &invalidParamsError{fmt.Sprintf("array or object exceeds the limit of %d elements", l.MaxArrayLength)}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits := ParseLimits{MaxDepth: 3, MaxStringLength: 8, MaxArrayLength: 4}
+	for params, ok := range map[string]bool{
+		`["abc", {"a": [1, 2]}]`:   true,
+		`[[[1]]]`:                  true,
+		`[[[[1]]]]`:                false,
+		`["12345678"]`:             true,
+		`["123456789"]`:            false,
+		`["123456\"", "\\\\\\\\"]`: true,
+		`["[[[[[[[[", "{{{{{{{{"]`: true,
+		`[1, 2, 3, 4]`:             true,
+		`[1, 2, 3, 4, 5]`:          false,
+		`[{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5}]`: false,
+	} {
+		err := limits.check([]byte(params))
+		if ok && err != nil {
+			t.Errorf("unexpected error for %s: %v", params, err)
+		}
+		if !ok {
+			var paramsErr *invalidParamsError
+			if !errors.As(err, &paramsErr) {
+				t.Errorf("expected an invalid params error for %s, got %v", params, err)
+			}
+		}
+	}
+
+	if err := (ParseLimits{}).check([]byte(`[[[[[[["very long string"]]]]]]]`)); err != nil {
+		t.Errorf("unexpected error without limits: %v", err)
+	}
+	if err := NewServer(50, false, true).SetParseLimits(ParseLimits{MaxDepth: -1}); err == nil {
+		t.Error("no error for a negative limit")
+	}
+}
+
+// This test checks that the params exceeding the limits are rejected before the call, and that the body size of the
+// HTTP requests is limited.
+func TestServerParseLimits(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	if err := server.SetParseLimits(ParseLimits{MaxBodySize: 1024, MaxDepth: 2}); err != nil {
+		t.Fatal(err)
+	}
+	client := DialInProc(server)
+	defer client.Close()
+
+	var res echoResult
+	if err := client.Call(&res, "test_echo", "x", 1, &echoArgs{S: "y"}); err != nil {
+		t.Fatal(err)
+	}
+	err := client.Call(&res, "test_echo", "x", 1, map[string]interface{}{"S": []string{"y"}})
+	var rpcErr Error
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32602 {
+		t.Fatalf("expected an invalid params error, got %v", err)
+	}
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	body := `{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["` + strings.Repeat("x", 1024) + `",1,null]}`
+	resp, err := http.Post(ts.URL, contentType, bytes.NewReader([]byte(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	confirmStatusCode(t, resp.StatusCode, http.StatusRequestEntityTooLarge)
+}
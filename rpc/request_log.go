@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+)
+
+// maxLoggedParamsLength is the number of bytes of the params of the logged calls kept, the rest is truncated
+const maxLoggedParamsLength = 256
+
+// RequestLogConfig configures the logging of the calls of a server: the slow ones and a sample of all of them, to
+// triage its performance
+type RequestLogConfig struct {
+	SlowThreshold time.Duration // the calls taking longer are logged as warnings, 0 disables them
+	SampleRate    float64       // the fraction of all the calls logged to Samples, between 0 and 1
+	Samples       log.Logger    // the log of the sampled calls, the root logger if nil
+}
+
+// requestLogger logs the calls according to its config
+type requestLogger struct {
+	RequestLogConfig
+}
+
+// callTimings break down the time spent on a call
+type callTimings struct {
+	wait  time.Duration // waiting for the limits of the method
+	parse time.Duration // decoding the params
+	exec  time.Duration // running the method, and writing the result of a streamable method
+}
+
+func (t callTimings) total() time.Duration {
+	return t.wait + t.parse + t.exec
+}
+
+func newRequestLogger(cfg RequestLogConfig) (*requestLogger, error) {
+	if cfg.SampleRate < 0 || cfg.SampleRate > 1 {
+		return nil, fmt.Errorf("invalid sample rate %v, it must be between 0 and 1", cfg.SampleRate)
+	}
+	if cfg.SlowThreshold <= 0 && cfg.SampleRate == 0 {
+		return nil, nil
+	}
+	if cfg.Samples == nil {
+		cfg.Samples = log.Root()
+	}
+	return &requestLogger{cfg}, nil
+}
+
+// log logs the call of msg answered with answer, nil if its result was streamed, if it's slow or sampled
+func (l *requestLogger) log(ctx context.Context, h *handler, msg, answer *jsonrpcMessage, timings callTimings) {
+	if l == nil {
+		return
+	}
+	slow := l.SlowThreshold > 0 && timings.total() > l.SlowThreshold
+	sampled := l.SampleRate > 0 && rand.Float64() < l.SampleRate //nolint:gosec
+	if !slow && !sampled {
+		return
+	}
+	ctxs := []interface{}{"method", msg.Method, "reqid", idForLog{msg.ID}, "params", truncateParams(msg.Params),
+		"transport", h.peer.Transport, "ip", h.peer.IP, "origin", h.peer.Origin}
+	if ua, ok := ctx.Value("User-Agent").(string); ok {
+		ctxs = append(ctxs, "agent", ua)
+	}
+	ctxs = append(ctxs, "t", timings.total(), "wait", timings.wait, "parse", timings.parse, "exec", timings.exec)
+	if answer != nil && answer.Error != nil {
+		ctxs = append(ctxs, "err", answer.Error.Message)
+	}
+	if slow {
+		h.log.Warn("Slow RPC call", ctxs...)
+	}
+	if sampled {
+		l.Samples.Info("RPC call", ctxs...)
+	}
+}
+
+// truncateParams returns params for the logs, truncated to maxLoggedParamsLength bytes
+func truncateParams(params []byte) string {
+	if len(params) <= maxLoggedParamsLength {
+		return string(params)
+	}
+	return fmt.Sprintf("%s... (%d bytes)", params[:maxLoggedParamsLength], len(params))
+}
@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+)
+
+// recordsHandler keeps the records logged to it
+type recordsHandler struct {
+	mu      sync.Mutex
+	records []*log.Record
+}
+
+func (h *recordsHandler) Log(r *log.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordsHandler) find(msg, method string) map[string]interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.records {
+		fields := make(map[string]interface{})
+		for i := 0; i+1 < len(r.Ctx); i += 2 {
+			fields[r.Ctx[i].(string)] = r.Ctx[i+1]
+		}
+		if r.Msg == msg && fields["method"] == method {
+			return fields
+		}
+	}
+	return nil
+}
+
+func TestRequestLog(t *testing.T) {
+	root := log.Root().GetHandler()
+	defer log.Root().SetHandler(root)
+	slow := &recordsHandler{}
+	log.Root().SetHandler(slow)
+	samples := &recordsHandler{}
+	samplesLog := log.New()
+	samplesLog.SetHandler(samples)
+
+	server := newTestServer()
+	defer server.Stop()
+	if err := server.SetRequestLog(RequestLogConfig{SampleRate: 2}); err == nil {
+		t.Fatal("expected an error for a sample rate over 1")
+	}
+	if err := server.SetRequestLog(RequestLogConfig{SlowThreshold: 50 * time.Millisecond, SampleRate: 1, Samples: samplesLog}); err != nil {
+		t.Fatal(err)
+	}
+	client := DialInProc(server)
+	defer client.Close()
+
+	long := strings.Repeat("x", 2*maxLoggedParamsLength)
+	var result echoResult
+	if err := client.Call(&result, "test_echo", long, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call(nil, "test_sleep", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	echo := samples.find("RPC call", "test_echo")
+	if echo == nil {
+		t.Fatal("the call of test_echo isn't sampled")
+	}
+	if params := echo["params"].(string); len(params) > maxLoggedParamsLength+32 || !strings.HasSuffix(params, "bytes)") {
+		t.Errorf("the params aren't truncated: %s", params)
+	}
+	if echo["transport"] != "inproc" {
+		t.Errorf("wrong transport %v", echo["transport"])
+	}
+	if samples.find("RPC call", "test_sleep") == nil {
+		t.Fatal("the call of test_sleep isn't sampled")
+	}
+
+	if slow.find("Slow RPC call", "test_echo") != nil {
+		t.Error("the call of test_echo is logged as slow")
+	}
+	sleep := slow.find("Slow RPC call", "test_sleep")
+	if sleep == nil {
+		t.Fatal("the call of test_sleep isn't logged as slow")
+	}
+	if exec := sleep["exec"].(time.Duration); exec < 100*time.Millisecond {
+		t.Errorf("wrong execution time %v", exec)
+	}
+}
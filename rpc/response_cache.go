@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// ResponseCachePolicy decides which results the response cache of a server keeps
+type ResponseCachePolicy interface {
+	// Cacheable returns true if the results of method may be immutable, only its calls are looked up in the cache
+	Cacheable(method string) bool
+	// Immutable returns true if result, the serialized result of the call of method with params, will never change
+	Immutable(ctx context.Context, method string, params, result json.RawMessage) bool
+}
+
+// responseCache keeps the serialized results of the calls with immutable results, the least recently used ones are
+// evicted to keep the size of the results and their keys under maxBytes
+type responseCache struct {
+	policy   ResponseCachePolicy
+	maxBytes int
+
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *responseCacheEntry, the most recently used first
+	entries map[string]*list.Element
+}
+
+type responseCacheEntry struct {
+	key    string
+	result json.RawMessage
+}
+
+func newResponseCache(maxBytes int, policy ResponseCachePolicy) *responseCache {
+	return &responseCache{policy: policy, maxBytes: maxBytes, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// responseCacheKey identifies a call by its method and its params, without the insignificant whitespace
+func responseCacheKey(method string, params json.RawMessage) string {
+	var buf bytes.Buffer
+	buf.WriteString(method)
+	buf.WriteByte(0)
+	if err := json.Compact(&buf, params); err != nil {
+		buf.Write(params)
+	}
+	return buf.String()
+}
+
+// get returns the cached result of the call of method with params, if any
+func (c *responseCache) get(method string, params json.RawMessage) (json.RawMessage, bool) {
+	if c == nil || !c.policy.Cacheable(method) {
+		return nil, false
+	}
+	key := responseCacheKey(method, params)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		responseCacheMisses.Inc()
+		return nil, false
+	}
+	responseCacheHits.Inc()
+	c.order.MoveToFront(elem)
+	return elem.Value.(*responseCacheEntry).result, true
+}
+
+// put keeps the result of the call of method with params if the policy finds it immutable
+func (c *responseCache) put(ctx context.Context, method string, params, result json.RawMessage) {
+	if c == nil || len(result) == 0 || bytes.Equal(result, null) || !c.policy.Cacheable(method) {
+		return
+	}
+	key := responseCacheKey(method, params)
+	size := len(key) + len(result)
+	if size > c.maxBytes || !c.policy.Immutable(ctx, method, params, result) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	c.entries[key] = c.order.PushFront(&responseCacheEntry{key: key, result: result})
+	c.size += size
+	for c.size > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*responseCacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.key) + len(entry.result)
+	}
+}
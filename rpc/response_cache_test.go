@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+type cacheTestService struct {
+	calls int32
+}
+
+// Value returns key with the number of calls of the service
+func (s *cacheTestService) Value(key string) string {
+	return fmt.Sprintf("%s-%d", key, atomic.AddInt32(&s.calls, 1))
+}
+
+type cacheTestPolicy struct{}
+
+func (cacheTestPolicy) Cacheable(method string) bool { return method == "cache_value" }
+
+func (cacheTestPolicy) Immutable(ctx context.Context, method string, params, result json.RawMessage) bool {
+	return !bytes.Contains(params, []byte("mutable"))
+}
+
+func TestServerResponseCache(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	if err := server.RegisterName("cache", new(cacheTestService)); err != nil {
+		t.Fatal(err)
+	}
+	// room for a single result
+	server.SetResponseCache(len(responseCacheKey("cache_value", json.RawMessage(`["a"]`)))+len(`"a-1"`), cacheTestPolicy{})
+	client := DialInProc(server)
+	defer client.Close()
+
+	value := func(key string) string {
+		var result string
+		if err := client.Call(&result, "cache_value", key); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	if v := value("a"); v != "a-1" {
+		t.Fatalf("wrong value %s", v)
+	}
+	if v := value("a"); v != "a-1" {
+		t.Fatalf("the result wasn't cached: %s", v)
+	}
+	// the mutable results aren't cached
+	if v := value("mutable"); v != "mutable-2" {
+		t.Fatalf("wrong value %s", v)
+	}
+	if v := value("mutable"); v != "mutable-3" {
+		t.Fatalf("a mutable result was cached: %s", v)
+	}
+	// the least recently used result is evicted
+	if v := value("b"); v != "b-4" {
+		t.Fatalf("wrong value %s", v)
+	}
+	if v := value("b"); v != "b-4" {
+		t.Fatalf("the result wasn't cached: %s", v)
+	}
+	if v := value("a"); v != "a-5" {
+		t.Fatalf("the result wasn't evicted: %s", v)
+	}
+}
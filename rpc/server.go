@@ -50,13 +50,18 @@ type Server struct {
 	codecs          mapset.Set
 
 	batchConcurrency uint
-	disableStreaming bool
 	traceRequests    bool // Whether to print requests at INFO level
+	handlerConfig    handlerConfig
+
+	wsCompressionLevel int   // compression level of the websocket messages written
+	wsMessageSizeLimit int64 // maximum size of the websocket messages read, once decompressed
+	wsConns            *wsConnLimiter
 }
 
 // NewServer creates a new server instance with no registered handlers.
 func NewServer(batchConcurrency uint, traceRequests, disableStreaming bool) *Server {
-	server := &Server{idgen: randomIDGenerator(), codecs: mapset.NewSet(), run: 1, batchConcurrency: batchConcurrency, disableStreaming: disableStreaming, traceRequests: traceRequests}
+	server := &Server{idgen: randomIDGenerator(), codecs: mapset.NewSet(), run: 1, batchConcurrency: batchConcurrency, traceRequests: traceRequests,
+		wsCompressionLevel: DefaultWsCompressionLevel, wsMessageSizeLimit: wsMessageSizeLimit, wsConns: newWsConnLimiter(WebsocketConnLimits{}), handlerConfig: handlerConfig{acl: new(aclRef), disableStreaming: disableStreaming}}
 	// Register the default service providing meta information about the RPC service such
 	// as the services and methods it offers.
 	rpcService := &RPCService{server: server}
@@ -69,6 +74,60 @@ func (s *Server) SetAllowList(allowList AllowList) {
 	s.methodAllowList = allowList
 }
 
+// SetBatchLimits limits the number of messages of the batches, and the size in bytes of their results. The calls
+// whose results would exceed maxResponseSize are answered with an error. Zero means no limit.
+func (s *Server) SetBatchLimits(maxItems, maxResponseSize int) {
+	s.handlerConfig.batchLimits = batchLimits{maxItems: maxItems, maxResponseSize: maxResponseSize}
+}
+
+// SetMethodLimits limits the rate and the concurrency of the calls of some methods, across all connections. The
+// calls exceeding the limits are answered with an error suggesting when to retry.
+func (s *Server) SetMethodLimits(limits MethodLimits) error {
+	limiter, err := newMethodLimiter(limits)
+	if err != nil {
+		return err
+	}
+	s.handlerConfig.methodLimiter = limiter
+	return nil
+}
+
+// SetMethodTimeouts limits the time the calls of some methods may take: their context is cancelled once it's elapsed.
+func (s *Server) SetMethodTimeouts(timeouts MethodTimeouts) error {
+	t, err := newMethodTimeouts(timeouts)
+	if err != nil {
+		return err
+	}
+	s.handlerConfig.timeouts = t
+	return nil
+}
+
+// SetResponseCache keeps up to maxBytes of the serialized results the policy finds immutable, answering the
+// following calls with the same params from memory. Zero disables the cache.
+func (s *Server) SetResponseCache(maxBytes int, policy ResponseCachePolicy) {
+	if maxBytes <= 0 || policy == nil {
+		s.handlerConfig.responseCache = nil
+		return
+	}
+	s.handlerConfig.responseCache = newResponseCache(maxBytes, policy)
+}
+
+// SetRequestLog logs the calls slower than the threshold of cfg, and a sample of all the calls, with their params and
+// the time spent on each step.
+func (s *Server) SetRequestLog(cfg RequestLogConfig) error {
+	requestLog, err := newRequestLogger(cfg)
+	if err != nil {
+		return err
+	}
+	s.handlerConfig.requestLog = requestLog
+	return nil
+}
+
+// SetACL restricts the methods each peer may call, nil removing the restrictions. It can be called while the server
+// runs, the new ACL applies to the calls made afterwards.
+func (s *Server) SetACL(acl *ACL) {
+	s.handlerConfig.acl.store(acl)
+}
+
 // RegisterName creates a service for the given receiver type under the given name. When no
 // methods on the given receiver match the criteria to be either a RPC method or a
 // subscription an error is returned. Otherwise a new service is created and added to the
@@ -83,6 +142,11 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 //
 // Note that codec options are no longer supported.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(codec, s.handlerConfig)
+}
+
+// serveCodec is ServeCodec with the settings of the connection
+func (s *Server) serveCodec(codec ServerCodec, handlerConfig handlerConfig) {
 	defer codec.close()
 
 	// Don't serve if server is stopped.
@@ -94,7 +158,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	c := initClient(codec, s.idgen, &s.services, handlerConfig)
 	<-codec.closed()
 	c.Close()
 }
@@ -102,14 +166,16 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 // serveSingleRequest reads and processes a single RPC request from the given codec. This
 // is used to serve HTTP connections. Subscriptions and reverse calls are not allowed in
 // this mode.
-func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec, stream *jsoniter.Stream) {
+func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec, stream *jsoniter.Stream, ndjson bool) {
 	// Don't serve if server is stopped.
 	if atomic.LoadInt32(&s.run) == 0 {
 		return
 	}
 
 	h := newHandler(ctx, codec, s.idgen, &s.services, s.methodAllowList, s.batchConcurrency, s.traceRequests)
+	h.handlerConfig = s.handlerConfig
 	h.allowSubscribe = false
+	h.ndjson = ndjson
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.readBatch()
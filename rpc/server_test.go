@@ -47,7 +47,7 @@ func TestServerRegisterName(t *testing.T) {
 		t.Fatalf("Expected service calc to be registered")
 	}
 
-	wantCallbacks := 9
+	wantCallbacks := 11
 	if len(svc.callbacks) != wantCallbacks {
 		t.Errorf("Expected %d callbacks for service 'service', got %d", wantCallbacks, len(svc.callbacks))
 	}
@@ -71,17 +71,19 @@ func TestServer(t *testing.T) {
 }
 
 func runTestScript(t *testing.T, file string) {
-	server := newTestServer()
 	content, err := os.ReadFile(file)
 	if err != nil {
 		t.Fatal(err)
 	}
+	runScript(t, newTestServer(), string(content))
+}
 
+func runScript(t *testing.T, server *Server, content string) {
 	clientConn, serverConn := net.Pipe()
 	defer clientConn.Close()
 	go server.ServeCodec(NewCodec(serverConn), 0)
 	readbuf := bufio.NewReader(clientConn)
-	for _, line := range strings.Split(string(content), "\n") {
+	for _, line := range strings.Split(content, "\n") {
 		line = strings.TrimSpace(line)
 		switch {
 		case len(line) == 0 || strings.HasPrefix(line, "//"):
@@ -127,6 +129,24 @@ func runTestScript(t *testing.T, file string) {
 	}
 }
 
+// This test checks that the batches over the limits are rejected.
+func TestServerBatchLimits(t *testing.T) {
+	server := NewServer(1, false /* traceRequests */, true)
+	defer server.Stop()
+	if err := server.RegisterName("test", new(testService)); err != nil {
+		t.Fatal(err)
+	}
+	// The result of test_echo is 32 bytes long.
+	server.SetBatchLimits(3, 40)
+
+	runScript(t, server, `
+--> [{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["x",1]},{"jsonrpc":"2.0","id":2,"method":"test_echo","params":["x",1]},{"jsonrpc":"2.0","id":3,"method":"test_echo","params":["x",1]},{"jsonrpc":"2.0","id":4,"method":"test_echo","params":["x",1]}]
+<-- [{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"batch too large"}}]
+--> [{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["x",1]},{"jsonrpc":"2.0","id":2,"method":"test_echo","params":["x",1]},{"jsonrpc":"2.0","id":3,"method":"test_echo","params":["x",1]}]
+<-- [{"jsonrpc":"2.0","id":1,"result":{"String":"x","Int":1,"Args":null}},{"jsonrpc":"2.0","id":2,"error":{"code":-32003,"message":"response too large"}},{"jsonrpc":"2.0","id":3,"error":{"code":-32003,"message":"response too large"}}]
+`)
+}
+
 // This test checks that responses are delivered for very short-lived connections that
 // only carry a single request.
 func TestServerShortLivedConn(t *testing.T) {
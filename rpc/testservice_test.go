@@ -23,6 +23,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	jsoniter "github.com/json-iterator/go"
 )
 
 func newTestServer() *Server {
@@ -110,6 +112,33 @@ func (s *testService) ReturnError() error {
 	return testError{}
 }
 
+func (s *testService) Stream(ctx context.Context, n int, stream *jsoniter.Stream) error {
+	stream.WriteArrayStart()
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			stream.WriteMore()
+		}
+		stream.WriteInt(i)
+		stream.Flush()
+	}
+	stream.WriteArrayEnd()
+	return nil
+}
+
+func (s *testService) SlowStream(ctx context.Context, n int, delay time.Duration, stream *jsoniter.Stream) error {
+	stream.WriteArrayStart()
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			stream.WriteMore()
+		}
+		stream.WriteInt(i)
+		stream.Flush()
+		time.Sleep(delay)
+	}
+	stream.WriteArrayEnd()
+	return nil
+}
+
 func (s *testService) CallMeBack(ctx context.Context, method string, args []interface{}) (interface{}, error) {
 	c, ok := ClientFromContext(ctx)
 	if !ok {
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	"strconv"
 	"strings"
@@ -67,6 +68,14 @@ type jsonWriter interface {
 	remoteAddr() string
 }
 
+// streamWriter is implemented by the codecs which can write a message to their connection while
+// it's produced, the results of streamable methods are then not held in memory.
+type streamWriter interface {
+	// writeStream writes the message produced by write. Once the message is larger than a buffered size, the other
+	// writes wait until it's written.
+	writeStream(ctx context.Context, write func(w io.Writer) error) error
+}
+
 type BlockNumber int64
 type Timestamp uint64
 
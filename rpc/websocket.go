@@ -17,9 +17,13 @@
 package rpc
 
 import (
+	"bytes"
+	"compress/flate"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
@@ -38,10 +42,93 @@ const (
 	wsPingInterval     = 60 * time.Second
 	wsPingWriteTimeout = 5 * time.Second
 	wsMessageSizeLimit = 32 * 1024 * 1024
+
+	// wsStreamBufferSize is the size of the result of a streamable method buffered before its message is started.
+	// The smaller results are written at once like the other messages, the larger ones hold the connection until
+	// they're written, as the frames of a websocket message can't be interleaved with other messages.
+	wsStreamBufferSize = 1024 * 1024
+
+	// DefaultWsCompressionLevel is the compression level of the websocket messages, the fastest one which also
+	// uses the least memory
+	DefaultWsCompressionLevel = flate.BestSpeed
 )
 
 var wsBufferPool = new(sync.Pool)
 
+// SetWebsocketLimits caps the memory used by each websocket connection. The messages written to the connections
+// negotiating compression are compressed at compressionLevel, from flate.HuffmanOnly to flate.BestCompression, the
+// higher levels using more memory. The messages read are limited to messageSizeLimit bytes once decompressed.
+func (s *Server) SetWebsocketLimits(compressionLevel int, messageSizeLimit int64) error {
+	if compressionLevel < flate.HuffmanOnly || compressionLevel > flate.BestCompression {
+		return fmt.Errorf("invalid websocket compression level %d", compressionLevel)
+	}
+	if messageSizeLimit <= 0 {
+		return fmt.Errorf("invalid websocket message size limit %d", messageSizeLimit)
+	}
+	s.wsCompressionLevel = compressionLevel
+	s.wsMessageSizeLimit = messageSizeLimit
+	return nil
+}
+
+// WebsocketConnLimits bound the websocket connections of a server and their subscriptions, so that a client can't
+// exhaust its goroutines and memory. Zero means no limit.
+type WebsocketConnLimits struct {
+	MaxConns         int           // concurrent connections
+	MaxConnsPerIP    int           // concurrent connections from the same IP
+	MaxSubscriptions int           // active subscriptions of a connection
+	IdleTimeout      time.Duration // the connections without calls nor subscriptions for this long are closed
+}
+
+// SetWebsocketConnLimits bounds the websocket connections of the server. The connections over the limits are refused
+// before the websocket upgrade, and the subscriptions over the limit of their connection are answered with an error.
+func (s *Server) SetWebsocketConnLimits(limits WebsocketConnLimits) error {
+	if limits.MaxConns < 0 || limits.MaxConnsPerIP < 0 || limits.MaxSubscriptions < 0 || limits.IdleTimeout < 0 {
+		return fmt.Errorf("invalid websocket connection limits %+v", limits)
+	}
+	s.wsConns = newWsConnLimiter(limits)
+	return nil
+}
+
+// wsConnLimiter counts the websocket connections, in total and by IP
+type wsConnLimiter struct {
+	limits WebsocketConnLimits
+
+	lock  sync.Mutex
+	conns int
+	byIP  map[string]int
+}
+
+func newWsConnLimiter(limits WebsocketConnLimits) *wsConnLimiter {
+	return &wsConnLimiter{limits: limits, byIP: make(map[string]int)}
+}
+
+// acquire counts a connection from ip, it returns the HTTP status refusing it if it's over the limits, 0 otherwise
+func (l *wsConnLimiter) acquire(ip string) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.limits.MaxConns > 0 && l.conns >= l.limits.MaxConns {
+		return http.StatusServiceUnavailable, fmt.Errorf("too many websocket connections, the limit is %d", l.limits.MaxConns)
+	}
+	if l.limits.MaxConnsPerIP > 0 && l.byIP[ip] >= l.limits.MaxConnsPerIP {
+		return http.StatusTooManyRequests, fmt.Errorf("too many websocket connections from %s, the limit is %d", ip, l.limits.MaxConnsPerIP)
+	}
+	l.conns++
+	l.byIP[ip]++
+	return 0, nil
+}
+
+// release uncounts a connection from ip, once it's closed
+func (l *wsConnLimiter) release(ip string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.conns--
+	if l.byIP[ip]--; l.byIP[ip] <= 0 {
+		delete(l.byIP, ip)
+	}
+}
+
 // WebsocketHandler returns a handler that serves JSON-RPC to WebSocket connections.
 //
 // allowedOrigins should be a comma-separated list of allowed origin URLs.
@@ -58,13 +145,33 @@ func (s *Server) WebsocketHandler(allowedOrigins []string, jwtSecret []byte, com
 		if jwtSecret != nil && !CheckJwtSecret(w, r, jwtSecret) {
 			return
 		}
+		connLimiter := s.wsConns
+		peer := peerFromRequest(r)
+		ip := peer.IP.String()
+		if status, err := connLimiter.acquire(ip); err != nil {
+			log.Warn("Rejected WebSocket connection", "err", err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+		defer connLimiter.release(ip)
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			log.Warn("WebSocket upgrade failed", "err", err)
 			return
 		}
-		codec := newWebsocketCodec(conn)
-		s.ServeCodec(codec, 0)
+		if compression {
+			// only used if the client negotiated compression
+			if err := conn.SetCompressionLevel(s.wsCompressionLevel); err != nil {
+				log.Warn("WebSocket compression level rejected", "err", err)
+			}
+		}
+		codec := newWebsocketCodec(conn, s.wsMessageSizeLimit)
+		codec.peerInfo = peer
+		codec.peerInfo.Transport = "ws"
+		handlerConfig := s.handlerConfig
+		handlerConfig.maxSubscriptions = connLimiter.limits.MaxSubscriptions
+		handlerConfig.idleTimeout = connLimiter.limits.IdleTimeout
+		s.serveCodec(codec, handlerConfig)
 	})
 }
 
@@ -187,10 +294,19 @@ func parseOriginURL(origin string) (string, string, string, error) {
 // DialWebsocketWithDialer creates a new RPC client that communicates with a JSON-RPC server
 // that is listening on the given endpoint using the provided dialer.
 func DialWebsocketWithDialer(ctx context.Context, endpoint, origin string, dialer websocket.Dialer) (*Client, error) {
+	return DialWebsocketWithHeader(ctx, endpoint, origin, dialer, nil)
+}
+
+// DialWebsocketWithHeader is like DialWebsocketWithDialer, sending the given headers with the handshake, for
+// example to authenticate.
+func DialWebsocketWithHeader(ctx context.Context, endpoint, origin string, dialer websocket.Dialer, extraHeader http.Header) (*Client, error) {
 	endpoint, header, err := wsClientHeaders(endpoint, origin)
 	if err != nil {
 		return nil, err
 	}
+	for key, values := range extraHeader {
+		header[key] = values
+	}
 	return newClient(ctx, func(ctx context.Context) (ServerCodec, error) {
 		//nolint
 		conn, resp, err := dialer.DialContext(ctx, endpoint, header)
@@ -201,7 +317,7 @@ func DialWebsocketWithDialer(ctx context.Context, endpoint, origin string, diale
 			}
 			return nil, hErr
 		}
-		return newWebsocketCodec(conn), nil
+		return newWebsocketCodec(conn, wsMessageSizeLimit), nil
 	})
 }
 
@@ -242,12 +358,16 @@ type websocketCodec struct {
 
 	wg        sync.WaitGroup
 	pingReset chan struct{}
+	peerInfo  Peer
 }
 
-func newWebsocketCodec(conn *websocket.Conn) ServerCodec {
-	conn.SetReadLimit(wsMessageSizeLimit)
+func newWebsocketCodec(conn *websocket.Conn, messageSizeLimit int64) *websocketCodec {
+	conn.SetReadLimit(messageSizeLimit)
+	readJSON := func(v interface{}) error {
+		return readLimitedJSON(conn, messageSizeLimit, v)
+	}
 	wc := &websocketCodec{
-		jsonCodec: NewFuncCodec(conn, conn.WriteJSON, conn.ReadJSON).(*jsonCodec),
+		jsonCodec: NewFuncCodec(conn, conn.WriteJSON, readJSON).(*jsonCodec),
 		conn:      conn,
 		pingReset: make(chan struct{}, 1),
 	}
@@ -256,6 +376,43 @@ func newWebsocketCodec(conn *websocket.Conn) ServerCodec {
 	return wc
 }
 
+// readLimitedJSON decodes the next message of conn, failing if it's larger than limit. The read limit of the
+// connection applies to the size of the messages on the wire, a compressed message can be much larger.
+func readLimitedJSON(conn *websocket.Conn, limit int64, v interface{}) error {
+	_, r, err := conn.NextReader()
+	if err != nil {
+		return err
+	}
+	err = json.NewDecoder(&limitedReader{r: r, n: limit}).Decode(v)
+	if err == io.EOF {
+		// One value is expected in the message.
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// limitedReader reads up to n bytes from r, then fails with websocket.ErrReadLimit
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, websocket.ErrReadLimit
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+func (wc *websocketCodec) peer() Peer {
+	return wc.peerInfo
+}
+
 func (wc *websocketCodec) close() {
 	wc.jsonCodec.close()
 	wc.wg.Wait()
@@ -273,6 +430,83 @@ func (wc *websocketCodec) writeJSON(ctx context.Context, v interface{}) error {
 	return err
 }
 
+func (wc *websocketCodec) writeStream(ctx context.Context, write func(w io.Writer) error) error {
+	sw := &wsStreamWriter{ctx: ctx, wc: wc}
+	err := write(sw)
+	if sw.w == nil {
+		if err != nil {
+			return err
+		}
+		return wc.writeJSON(ctx, json.RawMessage(sw.buf.Bytes()))
+	}
+	defer wc.jsonCodec.encMu.Unlock()
+
+	sw.extendDeadline()
+	if closeErr := sw.w.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// Notify pingLoop to delay the next idle ping.
+		select {
+		case wc.pingReset <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
+
+// wsStreamWriter buffers a streamed message up to wsStreamBufferSize, then holds the connection and writes the message
+// while it's produced, extending the write deadline before each write as producing it may take longer than the write
+// timeout.
+type wsStreamWriter struct {
+	ctx context.Context
+	wc  *websocketCodec
+	buf bytes.Buffer
+	w   io.WriteCloser // the writer of the message once started, encMu being held
+	err error
+}
+
+func (sw *wsStreamWriter) Write(p []byte) (int, error) {
+	if sw.err != nil {
+		return 0, sw.err
+	}
+	if sw.w == nil {
+		if sw.buf.Len()+len(p) <= wsStreamBufferSize {
+			return sw.buf.Write(p)
+		}
+		if sw.err = sw.start(); sw.err != nil {
+			return 0, sw.err
+		}
+	}
+	sw.extendDeadline()
+	n, err := sw.w.Write(p)
+	sw.err = err
+	return n, err
+}
+
+// start locks the connection and starts the message with the buffered part
+func (sw *wsStreamWriter) start() error {
+	sw.wc.jsonCodec.encMu.Lock()
+	w, err := sw.wc.conn.NextWriter(websocket.TextMessage)
+	if err != nil {
+		sw.wc.jsonCodec.encMu.Unlock()
+		return err
+	}
+	sw.w = w
+	sw.extendDeadline()
+	_, err = w.Write(sw.buf.Bytes())
+	sw.buf = bytes.Buffer{}
+	return err
+}
+
+func (sw *wsStreamWriter) extendDeadline() {
+	deadline, ok := sw.ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultWriteTimeout)
+	}
+	sw.wc.conn.SetWriteDeadline(deadline) //nolint:errcheck
+}
+
 // pingLoop sends periodic ping frames when the connection is idle.
 func (wc *websocketCodec) pingLoop() {
 	timer := time.NewTimer(wsPingInterval)
@@ -289,10 +523,8 @@ func (wc *websocketCodec) pingLoop() {
 			}
 			timer.Reset(wsPingInterval)
 		case <-timer.C:
-			wc.jsonCodec.encMu.Lock()
-			wc.conn.SetWriteDeadline(time.Now().Add(wsPingWriteTimeout)) //nolint:errcheck
-			wc.conn.WriteMessage(websocket.PingMessage, nil)             //nolint:errcheck
-			wc.jsonCodec.encMu.Unlock()
+			// Control frames may be written while a message is, the pings aren't delayed by streamed results.
+			wc.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsPingWriteTimeout)) //nolint:errcheck
 			timer.Reset(wsPingInterval)
 		}
 	}
@@ -17,6 +17,7 @@
 package rpc
 
 import (
+	"compress/flate"
 	"context"
 	"net"
 	"net/http"
@@ -112,6 +113,129 @@ func TestWebsocketLargeCall(t *testing.T) {
 	}
 }
 
+// This test checks that the size of the compressed messages is limited once decompressed.
+func TestWebsocketCompressedLargeCall(t *testing.T) {
+	t.Parallel()
+
+	var (
+		srv     = newTestServer()
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, true))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	defer srv.Stop()
+	defer httpsrv.Close()
+	const limit = 64 * 1024
+	if err := srv.SetWebsocketLimits(flate.BestCompression+1, limit); err == nil {
+		t.Fatal("no error for invalid compression level")
+	}
+	if err := srv.SetWebsocketLimits(flate.BestCompression, limit); err != nil {
+		t.Fatal(err)
+	}
+
+	client, clientErr := DialWebsocketWithDialer(context.Background(), wsURL, "", websocket.Dialer{EnableCompression: true})
+	if clientErr != nil {
+		t.Fatalf("can't dial: %v", clientErr)
+	}
+	defer client.Close()
+
+	var result echoResult
+	arg := strings.Repeat("x", limit-200)
+	if err := client.Call(&result, "test_echo", arg, 1); err != nil {
+		t.Fatalf("valid call didn't work: %v", err)
+	}
+	if result.String != arg {
+		t.Fatal("wrong string echoed")
+	}
+
+	// The message is well below the limit once compressed, but not once decompressed.
+	arg = strings.Repeat("x", limit*2)
+	if err := client.Call(&result, "test_echo", arg); err == nil {
+		t.Fatal("no error for too large call")
+	}
+}
+
+// This test checks that the results of streamable methods are written to the connection while they're produced.
+func TestWebsocketStreamingCall(t *testing.T) {
+	t.Parallel()
+
+	var (
+		srv     = newTestServer()
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, false))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	srv.handlerConfig.disableStreaming = false
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	client, clientErr := DialWebsocket(context.Background(), wsURL, "")
+	if clientErr != nil {
+		t.Fatalf("can't dial: %v", clientErr)
+	}
+	defer client.Close()
+
+	// The result is larger than the buffered size, it's sent in several frames.
+	const n = 300000
+	var result []int
+	if err := client.Call(&result, "test_stream", n); err != nil {
+		t.Fatalf("streaming call didn't work: %v", err)
+	}
+	if len(result) != n || result[n-1] != n-1 {
+		t.Fatalf("wrong result: got %d items", len(result))
+	}
+	// Other calls are answered once the stream is done.
+	var echo echoResult
+	if err := client.Call(&echo, "test_echo", "x", 1); err != nil {
+		t.Fatalf("call after streaming didn't work: %v", err)
+	}
+}
+
+// This test checks that the calls made while a streamable method runs aren't blocked by it.
+func TestWebsocketConcurrentStreamingCall(t *testing.T) {
+	t.Parallel()
+
+	var (
+		srv     = newTestServer()
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, false))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	srv.handlerConfig.disableStreaming = false
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	client, clientErr := DialWebsocket(context.Background(), wsURL, "")
+	if clientErr != nil {
+		t.Fatalf("can't dial: %v", clientErr)
+	}
+	defer client.Close()
+
+	var result []int
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- client.Call(&result, "test_slowStream", 10, 100*time.Millisecond)
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	// The echo is answered while the stream is produced.
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	var echo echoResult
+	if err := client.CallContext(ctx, &echo, "test_echo", "x", 1); err != nil {
+		t.Fatalf("call during streaming didn't work: %v", err)
+	}
+	select {
+	case err := <-streamErr:
+		t.Fatalf("streaming call done before the echo: %v", err)
+	default:
+	}
+
+	if err := <-streamErr; err != nil {
+		t.Fatalf("streaming call didn't work: %v", err)
+	}
+	if len(result) != 10 || result[9] != 9 {
+		t.Fatalf("wrong result: %v", result)
+	}
+}
+
 // This test checks that client handles WebSocket ping frames correctly.
 func TestClientWebsocketPing(t *testing.T) {
 	if runtime.GOOS == "windows" {
@@ -291,3 +415,148 @@ func wsPingTestHandler(t *testing.T, conn *websocket.Conn, shutdown, sendPing <-
 		}
 	}
 }
+
+// This test checks that the connections over the limits are refused before the upgrade.
+func TestWebsocketConnLimits(t *testing.T) {
+	t.Parallel()
+
+	var (
+		srv     = newTestServer()
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, false))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	defer srv.Stop()
+	defer httpsrv.Close()
+	if err := srv.SetWebsocketConnLimits(WebsocketConnLimits{MaxConns: -1}); err == nil {
+		t.Fatal("no error for invalid limits")
+	}
+
+	dial := func() (*websocket.Conn, int) {
+		conn, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			if resp == nil {
+				t.Fatal(err)
+			}
+			return nil, resp.StatusCode
+		}
+		return conn, http.StatusSwitchingProtocols
+	}
+	for _, tt := range []struct {
+		limits WebsocketConnLimits
+		status int
+	}{
+		{WebsocketConnLimits{MaxConns: 1}, http.StatusServiceUnavailable},
+		{WebsocketConnLimits{MaxConns: 2, MaxConnsPerIP: 1}, http.StatusTooManyRequests},
+	} {
+		if err := srv.SetWebsocketConnLimits(tt.limits); err != nil {
+			t.Fatal(err)
+		}
+		conn, status := dial()
+		if conn == nil {
+			t.Fatalf("%+v: the first connection was refused with status %d", tt.limits, status)
+		}
+		if _, status := dial(); status != tt.status {
+			t.Fatalf("%+v: wrong status of the connection over the limit: got %d, want %d", tt.limits, status, tt.status)
+		}
+		// the connection is uncounted once it's closed
+		conn.Close()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			conn, _ := dial()
+			if conn != nil {
+				conn.Close()
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%+v: the closed connection is still counted", tt.limits)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+// This test checks that the subscriptions of a connection over the limit are refused.
+func TestWebsocketSubscriptionLimit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		srv     = newTestServer()
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, false))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	defer srv.Stop()
+	defer httpsrv.Close()
+	if err := srv.SetWebsocketConnLimits(WebsocketConnLimits{MaxSubscriptions: 2}); err != nil {
+		t.Fatal(err)
+	}
+	client, err := DialWebsocket(context.Background(), wsURL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	subscribe := func() (*ClientSubscription, error) {
+		return client.Subscribe(context.Background(), "nftest", make(chan int), "someSubscription", 0, 0)
+	}
+	first, err := subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := subscribe(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := subscribe(); err == nil {
+		t.Fatal("no error for a subscription over the limit")
+	}
+	first.Unsubscribe()
+	if _, err := subscribe(); err != nil {
+		t.Fatalf("the unsubscribed subscription is still counted: %v", err)
+	}
+}
+
+// This test checks that the connections without calls nor subscriptions are closed after the idle timeout.
+func TestWebsocketIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	var (
+		srv     = newTestServer()
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, false))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	defer srv.Stop()
+	defer httpsrv.Close()
+	if err := srv.SetWebsocketConnLimits(WebsocketConnLimits{IdleTimeout: 100 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+
+	idle, err := DialWebsocket(context.Background(), wsURL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idle.Close()
+	if err := idle.Call(nil, "test_echo", "x", 1); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.codecs.Cardinality() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the idle connection wasn't closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	subscribed, err := DialWebsocket(context.Background(), wsURL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer subscribed.Close()
+	sub, err := subscribed.Subscribe(context.Background(), "nftest", make(chan int), "someSubscription", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-sub.Err():
+		t.Fatalf("the connection with a subscription was closed: %v", err)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
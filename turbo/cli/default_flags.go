@@ -53,20 +53,63 @@ var DefaultFlags = []cli.Flag{
 	utils.AuthRpcPort,
 	utils.JWTSecretPath,
 	utils.HttpCompressionFlag,
+	utils.HTTPJWTSecretFlag,
+	utils.HTTPTLSCertFlag,
+	utils.HTTPTLSKeyFlag,
 	utils.HTTPCORSDomainFlag,
 	utils.HTTPVirtualHostsFlag,
+	utils.HTTPVirtualHostRulesFlag,
 	utils.AuthRpcVirtualHostsFlag,
 	utils.HTTPApiFlag,
 	utils.WSEnabledFlag,
 	utils.WsCompressionFlag,
+	utils.WsCompressionLevelFlag,
+	utils.WsMessageSizeLimitFlag,
+	utils.WsConnsLimitFlag,
+	utils.WsConnsPerIPLimitFlag,
+	utils.WsSubscriptionsLimitFlag,
+	utils.WsIdleTimeoutFlag,
 	utils.HTTPTraceFlag,
 	utils.StateCacheFlag,
 	utils.RpcBatchConcurrencyFlag,
+	utils.RpcBatchLimitFlag,
+	utils.RpcBatchResponseMaxSizeFlag,
+	utils.RpcRequestMaxSizeFlag,
+	utils.RpcRequestMaxDepthFlag,
+	utils.RpcRequestMaxStringFlag,
+	utils.RpcRequestMaxArrayFlag,
+	utils.RpcResponseCacheSizeFlag,
+	utils.RpcSlowThresholdFlag,
+	utils.RpcSampleRateFlag,
+	utils.RpcSampleFileFlag,
 	utils.RpcStreamingDisableFlag,
+	utils.HealthPathFlag,
+	utils.HealthReadinessFlag,
+	utils.HealthLivenessFlag,
+	utils.HealthDefaultFlag,
+	utils.HealthDBMaxLatencyFlag,
+	utils.HealthCallToFlag,
+	utils.HealthCallDataFlag,
+	utils.HealthCallResultFlag,
+	utils.HealthHeadStallFlag,
+	utils.HealthUpstreamsFlag,
+	utils.HealthUpstreamsQuorumFlag,
+	utils.HealthCacheTTLFlag,
+	utils.HealthAuthSecretFlag,
+	utils.HealthAuthJWTSecretFlag,
 	utils.DBReadConcurrencyFlag,
 	utils.RpcAccessListFlag,
+	utils.RpcACLFlag,
+	utils.RpcMethodLimitsFlag,
+	utils.RpcMethodTimeoutsFlag,
+	utils.RpcEvmTimeoutFlag,
+	utils.RpcSignerFlag,
 	utils.RpcTraceCompatFlag,
 	utils.RpcGasCapFlag,
+	utils.RpcMaxGetProofRewindBlockCountFlag,
+	utils.RpcGetLogsMaxBlockRangeFlag,
+	utils.RpcGetLogsMaxResultsFlag,
+	utils.RpcGetLogsTimeoutFlag,
 	utils.MemoryOverlayFlag,
 	utils.TxpoolApiAddrFlag,
 	utils.TraceMaxtracesFlag,
@@ -107,6 +150,8 @@ var DefaultFlags = []cli.Flag{
 	utils.FakePoWFlag,
 	utils.GpoBlocksFlag,
 	utils.GpoPercentileFlag,
+	utils.GpoMaxGasPriceFlag,
+	utils.GpoIgnoreGasPriceFlag,
 	utils.InsecureUnlockAllowedFlag,
 	utils.MetricsEnabledFlag,
 	utils.MetricsEnabledExpensiveFlag,
@@ -119,6 +164,7 @@ var DefaultFlags = []cli.Flag{
 	utils.CliqueSnapshotInmemorySignaturesFlag,
 	utils.CliqueDataDirFlag,
 	utils.EnabledIssuance,
+	utils.EnabledChainStats,
 	utils.MiningEnabledFlag,
 	utils.ProposingDisableFlag,
 	utils.MinerNotifyFlag,
@@ -12,12 +12,14 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/health"
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/ethdb/prune"
 	"github.com/ledgerwatch/erigon/node/nodecfg"
+	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/pflag"
 	"github.com/urfave/cli"
@@ -335,9 +337,13 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config) {
 		AuthRpcHTTPListenAddress: ctx.GlobalString(utils.AuthRpcAddr.Name),
 		AuthRpcPort:              ctx.GlobalInt(utils.AuthRpcPort.Name),
 		JWTSecretPath:            jwtSecretPath,
+		HttpJWTSecretPath:        ctx.GlobalString(utils.HTTPJWTSecretFlag.Name),
+		HttpTLSCertFile:          ctx.GlobalString(utils.HTTPTLSCertFlag.Name),
+		HttpTLSKeyFile:           ctx.GlobalString(utils.HTTPTLSKeyFlag.Name),
 		TraceRequests:            ctx.GlobalBool(utils.HTTPTraceFlag.Name),
 		HttpCORSDomain:           strings.Split(ctx.GlobalString(utils.HTTPCORSDomainFlag.Name), ","),
 		HttpVirtualHost:          strings.Split(ctx.GlobalString(utils.HTTPVirtualHostsFlag.Name), ","),
+		HttpVirtualHostRulesPath: ctx.GlobalString(utils.HTTPVirtualHostRulesFlag.Name),
 		AuthRpcVirtualHost:       strings.Split(ctx.GlobalString(utils.AuthRpcVirtualHostsFlag.Name), ","),
 		API:                      strings.Split(apis, ","),
 		HTTPTimeouts: rpccfg.HTTPTimeouts{
@@ -351,17 +357,60 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config) {
 			IdleTimeout:  ctx.GlobalDuration(HTTPIdleTimeoutFlag.Name),
 		},
 
-		WebsocketEnabled:     ctx.GlobalIsSet(utils.WSEnabledFlag.Name),
-		RpcBatchConcurrency:  ctx.GlobalUint(utils.RpcBatchConcurrencyFlag.Name),
-		RpcStreamingDisable:  ctx.GlobalBool(utils.RpcStreamingDisableFlag.Name),
-		DBReadConcurrency:    ctx.GlobalInt(utils.DBReadConcurrencyFlag.Name),
-		RpcAllowListFilePath: ctx.GlobalString(utils.RpcAccessListFlag.Name),
-		Gascap:               ctx.GlobalUint64(utils.RpcGasCapFlag.Name),
-		MaxTraces:            ctx.GlobalUint64(utils.TraceMaxtracesFlag.Name),
-		TraceCompatibility:   ctx.GlobalBool(utils.RpcTraceCompatFlag.Name),
+		WebsocketEnabled:          ctx.GlobalIsSet(utils.WSEnabledFlag.Name),
+		WebsocketCompressionLevel: ctx.GlobalInt(utils.WsCompressionLevelFlag.Name),
+		WebsocketMessageSizeLimit: ctx.GlobalInt64(utils.WsMessageSizeLimitFlag.Name),
+		RpcBatchConcurrency:       ctx.GlobalUint(utils.RpcBatchConcurrencyFlag.Name),
+		RpcBatchLimit:             ctx.GlobalInt(utils.RpcBatchLimitFlag.Name),
+		RpcBatchResponseMaxSize:   ctx.GlobalInt(utils.RpcBatchResponseMaxSizeFlag.Name),
+		RpcResponseCacheSize:      ctx.GlobalInt(utils.RpcResponseCacheSizeFlag.Name),
+		RpcSlowThreshold:          ctx.GlobalDuration(utils.RpcSlowThresholdFlag.Name),
+		RpcSampleRate:             ctx.GlobalFloat64(utils.RpcSampleRateFlag.Name),
+		RpcSampleFile:             ctx.GlobalString(utils.RpcSampleFileFlag.Name),
+		RpcStreamingDisable:       ctx.GlobalBool(utils.RpcStreamingDisableFlag.Name),
+		DBReadConcurrency:         ctx.GlobalInt(utils.DBReadConcurrencyFlag.Name),
+		RpcAllowListFilePath:      ctx.GlobalString(utils.RpcAccessListFlag.Name),
+		RpcACLFilePath:            ctx.GlobalString(utils.RpcACLFlag.Name),
+		RpcMethodLimitsFilePath:   ctx.GlobalString(utils.RpcMethodLimitsFlag.Name),
+		RpcMethodTimeouts:         ctx.GlobalString(utils.RpcMethodTimeoutsFlag.Name),
+		RpcEvmTimeout:             ctx.GlobalDuration(utils.RpcEvmTimeoutFlag.Name),
+		RpcSigner:                 ctx.GlobalString(utils.RpcSignerFlag.Name),
+		Gascap:                    ctx.GlobalUint64(utils.RpcGasCapFlag.Name),
+		MaxTraces:                 ctx.GlobalUint64(utils.TraceMaxtracesFlag.Name),
+		MaxGetProofRewindBlocks:   ctx.GlobalInt(utils.RpcMaxGetProofRewindBlockCountFlag.Name),
+		GetLogsMaxBlockRange:      ctx.GlobalUint64(utils.RpcGetLogsMaxBlockRangeFlag.Name),
+		GetLogsMaxResults:         ctx.GlobalInt(utils.RpcGetLogsMaxResultsFlag.Name),
+		GetLogsTimeout:            ctx.GlobalDuration(utils.RpcGetLogsTimeoutFlag.Name),
+		TraceCompatibility:        ctx.GlobalBool(utils.RpcTraceCompatFlag.Name),
+		Gpo:                       ethconfig.Defaults.GPO,
 
 		TxPoolApiAddr: ctx.GlobalString(utils.TxpoolApiAddrFlag.Name),
 
+		Health: health.Config{
+			Path:            ctx.GlobalString(utils.HealthPathFlag.Name),
+			ReadinessChecks: splitCommaSeparated(ctx.GlobalString(utils.HealthReadinessFlag.Name)),
+			LivenessChecks:  splitCommaSeparated(ctx.GlobalString(utils.HealthLivenessFlag.Name)),
+			DefaultChecks:   splitCommaSeparated(ctx.GlobalString(utils.HealthDefaultFlag.Name)),
+			DBMaxLatency:    ctx.GlobalDuration(utils.HealthDBMaxLatencyFlag.Name),
+			MaxHeadStall:    ctx.GlobalDuration(utils.HealthHeadStallFlag.Name),
+			Upstreams:       splitCommaSeparated(ctx.GlobalString(utils.HealthUpstreamsFlag.Name)),
+			UpstreamQuorum:  ctx.GlobalInt(utils.HealthUpstreamsQuorumFlag.Name),
+			CacheTTL:        ctx.GlobalDuration(utils.HealthCacheTTLFlag.Name),
+			AuthSecret:      ctx.GlobalString(utils.HealthAuthSecretFlag.Name),
+		},
+		WebsocketConnLimits: rpc.WebsocketConnLimits{
+			MaxConns:         ctx.GlobalInt(utils.WsConnsLimitFlag.Name),
+			MaxConnsPerIP:    ctx.GlobalInt(utils.WsConnsPerIPLimitFlag.Name),
+			MaxSubscriptions: ctx.GlobalInt(utils.WsSubscriptionsLimitFlag.Name),
+			IdleTimeout:      ctx.GlobalDuration(utils.WsIdleTimeoutFlag.Name),
+		},
+		ParseLimits: rpc.ParseLimits{
+			MaxBodySize:     ctx.GlobalInt64(utils.RpcRequestMaxSizeFlag.Name),
+			MaxDepth:        ctx.GlobalInt(utils.RpcRequestMaxDepthFlag.Name),
+			MaxStringLength: ctx.GlobalInt(utils.RpcRequestMaxStringFlag.Name),
+			MaxArrayLength:  ctx.GlobalInt(utils.RpcRequestMaxArrayFlag.Name),
+		},
+
 		StateCache: kvcache.DefaultCoherentConfig,
 	}
 	if ctx.GlobalIsSet(utils.HttpCompressionFlag.Name) {
@@ -376,6 +425,16 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config) {
 	}
 
 	c.StateCache.CodeKeysLimit = ctx.GlobalInt(utils.StateCacheFlag.Name)
+	utils.SetGPO(ctx, &c.Gpo)
+
+	callCheck, err := health.ParseCallCheck(ctx.GlobalString(utils.HealthCallToFlag.Name), ctx.GlobalString(utils.HealthCallDataFlag.Name), ctx.GlobalString(utils.HealthCallResultFlag.Name))
+	if err != nil {
+		utils.Fatalf("Invalid health check call: %v", err)
+	}
+	c.Health.Call = callCheck
+	if c.Health.AuthJWTSecret, err = health.LoadJWTSecret(ctx.GlobalString(utils.HealthAuthJWTSecretFlag.Name)); err != nil {
+		utils.Fatalf("Invalid health check JWT secret: %v", err)
+	}
 
 	/*
 		rootCmd.PersistentFlags().BoolVar(&cfg.GRPCServerEnabled, "grpc", false, "Enable GRPC server")
@@ -386,6 +445,16 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config) {
 	cfg.Http = *c
 }
 
+func splitCommaSeparated(s string) []string {
+	var checks []string
+	for _, check := range strings.Split(s, ",") {
+		if check = strings.TrimSpace(check); check != "" {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
 // setPrivateApi populates configuration fields related to the remote
 // read-only interface to the database
 func setPrivateApi(ctx *cli.Context, cfg *nodecfg.Config) {
@@ -6,6 +6,8 @@ import (
 
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	libstate "github.com/ledgerwatch/erigon-lib/state"
+	"github.com/ledgerwatch/erigon/cmd/state/exec22"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/state"
@@ -118,3 +120,25 @@ func CreateStateReader(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.BlockNum
 	}
 	return stateReader, nil
 }
+
+// CreateHistoryStateReader returns a reader of the state at the beginning of block blockNumber. With historyV2, it's
+// read from the history of state at the first transaction of the block, the changesets read by CreateStateReader not
+// being written in this mode. Otherwise it's the same PlainState reader as CreateStateReader's, which looks each read
+// up in the history indices and the changesets.
+func CreateHistoryStateReader(tx kv.Tx, blockNumber uint64, historyV2 bool, agg *libstate.Aggregator22, txNums *exec22.TxNums) (state.StateReader, error) {
+	if !historyV2 {
+		return state.NewPlainState(tx, blockNumber), nil
+	}
+	if agg == nil || txNums == nil {
+		return nil, fmt.Errorf("the history of state isn't available")
+	}
+	if blockNumber > txNums.LastBlockNum()+1 {
+		return nil, fmt.Errorf("block %d is ahead of the history of state, at block %d", blockNumber, txNums.LastBlockNum())
+	}
+	ac := agg.MakeContext()
+	ac.SetTx(tx)
+	r := state.NewHistoryReader22(ac)
+	r.SetTx(tx)
+	r.SetTxNum(txNums.MinOf(blockNumber))
+	return r, nil
+}
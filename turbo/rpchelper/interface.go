@@ -2,6 +2,7 @@ package rpchelper
 
 import (
 	"context"
+	"errors"
 	"sync/atomic"
 
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
@@ -12,6 +13,9 @@ import (
 	"github.com/ledgerwatch/erigon/p2p"
 )
 
+// ErrPeerManagementUnavailable is returned by the admin peer methods when the backend can't manage the peers of the sentries
+var ErrPeerManagementUnavailable = errors.New("the peers of the sentries can't be managed by this backend")
+
 // ApiBackend - interface which must be used by API layer
 // implementation can work with local Ethereum object or with Remote (grpc-based) one
 // this is reason why all methods are accepting context and returning error
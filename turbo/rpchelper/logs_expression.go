@@ -0,0 +1,324 @@
+package rpchelper
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/holiman/uint256"
+
+	types2 "github.com/ledgerwatch/erigon/core/types"
+)
+
+// MaxLogsExpressionLength caps the size of user-supplied log expressions, they are evaluated for every log
+// distributed to the subscriber
+const MaxLogsExpressionLength = 1024
+
+// LogsExpression is a small boolean expression evaluated server-side against every log delivered to a
+// subscription, on top of the usual address/topics filter. It lets subscribers drop logs by their decoded
+// content (e.g. `data[0] > 1000000 && topic2 == 0xabc...`) instead of receiving and discarding them.
+//
+// Grammar:
+//
+//	expr    := or
+//	or      := and ( "||" and )*
+//	and     := unary ( "&&" unary )*
+//	unary   := "!" unary | "(" expr ")" | compare
+//	compare := operand ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) operand
+//	operand := "topic0".."topic3" | "data[" N "]" | "datalen" | "address" | number
+//
+// All operands are 256-bit unsigned integers: topics and 32-byte data words are read big-endian, numbers
+// may be decimal or 0x-prefixed hex. A comparison referring to a topic or data word the log does not have
+// evaluates to false.
+type LogsExpression struct {
+	root logsExprNode
+}
+
+type logsExprNode interface {
+	eval(lg *types2.Log) bool
+}
+
+type logsExprOperand interface {
+	value(lg *types2.Log) (*uint256.Int, bool)
+}
+
+// ParseLogsExpression compiles expression, an empty expression matches every log
+func ParseLogsExpression(expression string) (*LogsExpression, error) {
+	if len(expression) > MaxLogsExpressionLength {
+		return nil, fmt.Errorf("logs expression too long: %d > %d", len(expression), MaxLogsExpressionLength)
+	}
+	if strings.TrimSpace(expression) == "" {
+		return &LogsExpression{}, nil
+	}
+	tokens, err := tokenizeLogsExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &logsExprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("logs expression: unexpected %q", p.tokens[p.pos])
+	}
+	return &LogsExpression{root: root}, nil
+}
+
+// Match reports whether lg satisfies the expression
+func (e *LogsExpression) Match(lg *types2.Log) bool {
+	if e == nil || e.root == nil {
+		return true
+	}
+	return e.root.eval(lg)
+}
+
+type logsExprOr struct{ left, right logsExprNode }
+
+func (n logsExprOr) eval(lg *types2.Log) bool { return n.left.eval(lg) || n.right.eval(lg) }
+
+type logsExprAnd struct{ left, right logsExprNode }
+
+func (n logsExprAnd) eval(lg *types2.Log) bool { return n.left.eval(lg) && n.right.eval(lg) }
+
+type logsExprNot struct{ inner logsExprNode }
+
+func (n logsExprNot) eval(lg *types2.Log) bool { return !n.inner.eval(lg) }
+
+type logsExprCompare struct {
+	op          string
+	left, right logsExprOperand
+}
+
+func (n logsExprCompare) eval(lg *types2.Log) bool {
+	l, ok := n.left.value(lg)
+	if !ok {
+		return false
+	}
+	r, ok := n.right.value(lg)
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case "==":
+		return l.Eq(r)
+	case "!=":
+		return !l.Eq(r)
+	case "<":
+		return l.Lt(r)
+	case "<=":
+		return !l.Gt(r)
+	case ">":
+		return l.Gt(r)
+	case ">=":
+		return !l.Lt(r)
+	}
+	return false
+}
+
+type logsExprConst struct{ v *uint256.Int }
+
+func (o logsExprConst) value(*types2.Log) (*uint256.Int, bool) { return o.v, true }
+
+type logsExprTopic int
+
+func (o logsExprTopic) value(lg *types2.Log) (*uint256.Int, bool) {
+	if int(o) >= len(lg.Topics) {
+		return nil, false
+	}
+	return new(uint256.Int).SetBytes(lg.Topics[o][:]), true
+}
+
+type logsExprDataWord int
+
+func (o logsExprDataWord) value(lg *types2.Log) (*uint256.Int, bool) {
+	start := int(o) * 32
+	if start+32 > len(lg.Data) {
+		return nil, false
+	}
+	return new(uint256.Int).SetBytes(lg.Data[start : start+32]), true
+}
+
+type logsExprDataLen struct{}
+
+func (logsExprDataLen) value(lg *types2.Log) (*uint256.Int, bool) {
+	return uint256.NewInt(uint64(len(lg.Data))), true
+}
+
+type logsExprAddress struct{}
+
+func (logsExprAddress) value(lg *types2.Log) (*uint256.Int, bool) {
+	return new(uint256.Int).SetBytes(lg.Address[:]), true
+}
+
+func tokenizeLogsExpression(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.ContainsRune("()[]", rune(c)):
+			tokens = append(tokens, s[i:i+1])
+			i++
+		case strings.HasPrefix(s[i:], "&&"), strings.HasPrefix(s[i:], "||"),
+			strings.HasPrefix(s[i:], "=="), strings.HasPrefix(s[i:], "!="),
+			strings.HasPrefix(s[i:], "<="), strings.HasPrefix(s[i:], ">="):
+			tokens = append(tokens, s[i:i+2])
+			i += 2
+		case c == '<' || c == '>' || c == '!':
+			tokens = append(tokens, s[i:i+1])
+			i++
+		case c < unicode.MaxASCII && (unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))):
+			j := i
+			for j < len(s) && s[j] < unicode.MaxASCII && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("logs expression: unexpected character %q at %d", c, i)
+		}
+	}
+	return tokens, nil
+}
+
+type logsExprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *logsExprParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *logsExprParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *logsExprParser) expect(t string) error {
+	if got := p.next(); got != t {
+		return fmt.Errorf("logs expression: expected %q, got %q", t, got)
+	}
+	return nil
+}
+
+func (p *logsExprParser) parseOr() (logsExprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logsExprOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *logsExprParser) parseAnd() (logsExprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = logsExprAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *logsExprParser) parseUnary() (logsExprNode, error) {
+	switch p.peek() {
+	case "!":
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return logsExprNot{inner: inner}, nil
+	case "(":
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *logsExprParser) parseCompare() (logsExprNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	op := p.next()
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return nil, fmt.Errorf("logs expression: expected comparison operator, got %q", op)
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return logsExprCompare{op: op, left: left, right: right}, nil
+}
+
+func (p *logsExprParser) parseOperand() (logsExprOperand, error) {
+	t := p.next()
+	switch t {
+	case "topic0", "topic1", "topic2", "topic3":
+		return logsExprTopic(t[5] - '0'), nil
+	case "datalen":
+		return logsExprDataLen{}, nil
+	case "address":
+		return logsExprAddress{}, nil
+	case "data":
+		if err := p.expect("["); err != nil {
+			return nil, err
+		}
+		idx, err := strconv.ParseUint(p.next(), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("logs expression: invalid data index: %w", err)
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return logsExprDataWord(idx), nil
+	case "":
+		return nil, fmt.Errorf("logs expression: unexpected end of expression")
+	}
+	b, ok := new(big.Int), false
+	if strings.HasPrefix(t, "0x") {
+		b, ok = b.SetString(t[2:], 16)
+	} else {
+		b, ok = b.SetString(t, 10)
+	}
+	if !ok {
+		return nil, fmt.Errorf("logs expression: invalid operand %q", t)
+	}
+	v, overflow := uint256.FromBig(b)
+	if overflow {
+		return nil, fmt.Errorf("logs expression: operand %q overflows 256 bits", t)
+	}
+	return logsExprConst{v: v}, nil
+}
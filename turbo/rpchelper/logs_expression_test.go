@@ -0,0 +1,45 @@
+package rpchelper
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common"
+	types2 "github.com/ledgerwatch/erigon/core/types"
+)
+
+func TestLogsExpression(t *testing.T) {
+	amount := uint256.NewInt(5000).Bytes32()
+	lg := &types2.Log{
+		Address: common.HexToAddress("0x1234"),
+		Topics:  []common.Hash{common.HexToHash("0xdd"), common.HexToHash("0x01")},
+		Data:    amount[:],
+	}
+
+	for _, tt := range []struct {
+		expr  string
+		match bool
+	}{
+		{"", true},
+		{"data[0] > 1000", true},
+		{"data[0] >= 5000 && data[0] <= 5000", true},
+		{"data[0] < 1000", false},
+		{"data[1] > 0", false},
+		{"!(data[1] > 0)", true},
+		{"topic0 == 0xdd && topic1 == 1", true},
+		{"topic2 == 0 || address == 0x1234", true},
+		{"datalen == 32 && (topic0 != 0xdd || data[0] == 5000)", true},
+		{"topic3 != 0", false},
+	} {
+		e, err := ParseLogsExpression(tt.expr)
+		require.NoError(t, err, tt.expr)
+		require.Equal(t, tt.match, e.Match(lg), tt.expr)
+	}
+
+	for _, bad := range []string{"data[0]", "data[x] > 1", "topic0 = 1", "(topic0 == 1", "topic4 > 1", "1 > 0x1g", "a ~ b"} {
+		_, err := ParseLogsExpression(bad)
+		require.Error(t, err, bad)
+	}
+}
@@ -1839,9 +1839,18 @@ RETRY:
 }
 
 func ForEachHeader(ctx context.Context, s *RoSnapshots, walker func(header *types.Header) error) error {
+	return ForEachHeaderFrom(ctx, s, 0, walker)
+}
+
+// ForEachHeaderFrom walks the headers of the segments containing the blocks from block from, skipping the previous
+// segments
+func ForEachHeaderFrom(ctx context.Context, s *RoSnapshots, from uint64, walker func(header *types.Header) error) error {
 	r := bytes.NewReader(nil)
 	err := s.Headers.View(func(snapshots []*HeaderSegment) error {
 		for _, sn := range snapshots {
+			if sn.ranges.to <= from {
+				continue
+			}
 			ch := forEachAsync(ctx, sn.seg)
 			for it := range ch {
 				if it.err != nil {
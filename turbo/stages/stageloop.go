@@ -406,6 +406,7 @@ func NewStagedSync(ctx context.Context,
 				txNums,
 			),
 			stagedsync.StageIssuanceCfg(db, controlServer.ChainConfig, blockReader, cfg.EnabledIssuance),
+			stagedsync.StageChainStatsCfg(db, blockReader, cfg.EnabledChainStats),
 			stagedsync.StageSendersCfg(db, controlServer.ChainConfig, false, dirs.Tmp, cfg.Prune, blockRetire, controlServer.Hd),
 			stagedsync.StageExecuteBlocksCfg(
 				db,
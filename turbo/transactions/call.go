@@ -8,7 +8,6 @@ import (
 
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/kv"
-	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/state"
@@ -17,23 +16,51 @@ import (
 	"github.com/ledgerwatch/erigon/internal/ethapi"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
-	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/services"
 	"github.com/ledgerwatch/log/v3"
 )
 
-const callTimeout = 5 * time.Minute
+// DefaultEVMCallTimeout is the default time limit of the EVM execution of a call
+const DefaultEVMCallTimeout = 5 * time.Minute
+
+// WithEVMTimeout returns the context of an EVM execution limited to timeout, 0 being no limit, and the effective
+// limit. The deadline of ctx, set for the rpc method, takes precedence.
+func WithEVMTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc, time.Duration) {
+	if deadline, ok := ctx.Deadline(); ok {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, time.Until(deadline).Round(time.Millisecond)
+	}
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		return ctx, cancel, timeout
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return ctx, cancel, 0
+}
+
+// CancelOnDone aborts the execution of evm once ctx is done, until stop is called
+func CancelOnDone(ctx context.Context, evm *vm.EVM) (stop func()) {
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			evm.Cancel()
+		case <-stopped:
+		}
+	}()
+	return func() { close(stopped) }
+}
 
 func DoCall(
 	ctx context.Context,
 	args ethapi.CallArgs,
 	tx kv.Tx, blockNrOrHash rpc.BlockNumberOrHash,
-	block *types.Block, overrides *ethapi.StateOverrides,
+	block *types.Block, stateReader state.StateReader,
+	overrides *ethapi.StateOverrides, blockOverrides *ethapi.BlockOverrides,
 	gasCap uint64,
 	chainConfig *params.ChainConfig,
-	filters *rpchelper.Filters,
-	stateCache kvcache.Cache,
 	headerReader services.HeaderReader,
+	evmCallTimeout time.Duration,
 ) (*core.ExecutionResult, error) {
 	// todo: Pending state is only known by the miner
 	/*
@@ -42,10 +69,6 @@ func DoCall(
 			return state, block.Header(), nil
 		}
 	*/
-	stateReader, err := rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, filters, stateCache)
-	if err != nil {
-		return nil, err
-	}
 	state := state.New(stateReader)
 
 	header := block.Header()
@@ -60,12 +83,7 @@ func DoCall(
 
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
-	var cancel context.CancelFunc
-	if callTimeout > 0 {
-		ctx, cancel = context.WithTimeout(ctx, callTimeout)
-	} else {
-		ctx, cancel = context.WithCancel(ctx)
-	}
+	ctx, cancel, timeout := WithEVMTimeout(ctx, evmCallTimeout)
 
 	// Make sure the context is cancelled when the call has completed
 	// this makes sure resources are cleaned up.
@@ -80,13 +98,23 @@ func DoCall(
 			return nil, fmt.Errorf("header.BaseFee uint256 overflow")
 		}
 	}
+	if blockOverrides != nil && blockOverrides.BaseFee != nil {
+		baseFee = blockOverrides.BaseFee
+	}
 	msg, err := args.ToMessage(gasCap, baseFee)
 	if err != nil {
 		return nil, err
 	}
 	blockCtx, txCtx := GetEvmContext(msg, header, blockNrOrHash.RequireCanonical, tx, headerReader)
+	if blockOverrides != nil {
+		blockOverrides.Override(&blockCtx)
+	}
 
-	evm := vm.NewEVM(blockCtx, txCtx, state, chainConfig, vm.Config{NoBaseFee: true})
+	vmConfig := vm.Config{NoBaseFee: true}
+	if vmConfig.Precompiles, err = overrides.Precompiles(chainConfig.Rules(blockCtx.BlockNumber), nil); err != nil {
+		return nil, err
+	}
+	evm := vm.NewEVM(blockCtx, txCtx, state, chainConfig, vmConfig)
 
 	// Wait for the context to be done and cancel the evm. Even if the
 	// EVM has finished, cancelling may be done (repeatedly)
@@ -103,7 +131,7 @@ func DoCall(
 
 	// If the timer caused an abort, return an appropriate error message
 	if evm.Cancelled() {
-		return nil, fmt.Errorf("execution aborted (timeout = %v)", callTimeout)
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
 	}
 	return result, nil
 }
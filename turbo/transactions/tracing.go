@@ -79,7 +79,8 @@ func ComputeTxEnv(ctx context.Context, block *types.Block, cfg *params.ChainConf
 
 // TraceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
-// be tracer dependent.
+// be tracer dependent. The precompiles replace the ones of the chain rules if not
+// nil, see vm.Config.Precompiles.
 func TraceTx(
 	ctx context.Context,
 	message core.Message,
@@ -88,37 +89,48 @@ func TraceTx(
 	ibs vm.IntraBlockState,
 	config *tracers.TraceConfig,
 	chainConfig *params.ChainConfig,
+	precompiles map[common.Address]vm.PrecompiledContract,
 	stream *jsoniter.Stream,
 ) error {
 	// Assemble the structured logger or the JavaScript tracer
 	var (
-		tracer vm.Tracer
-		err    error
+		tracer       vm.Tracer
+		resultTracer tracers.ResultTracer
+		err          error
 	)
 	var streaming bool
+	execCtx := ctx
 	switch {
 	case config != nil && config.Tracer != nil:
 		// Define a meaningful timeout of a single transaction trace
-		timeout := callTimeout
+		var timeout *time.Duration
 		if config.Timeout != nil {
-			if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
+			t, err := time.ParseDuration(*config.Timeout)
+			if err != nil {
 				stream.WriteNil()
 				return err
 			}
+			timeout = &t
 		}
 		// Construct the JavaScript tracer to execute with
-		if tracer, err = tracers.New(*config.Tracer, &tracers.Context{
+		if resultTracer, err = tracers.NewTracer(*config.Tracer, &tracers.Context{
 			TxHash: txCtx.TxHash,
-		}); err != nil {
+		}, config.TracerConfig); err != nil {
 			stream.WriteNil()
 			return err
 		}
+		tracer = resultTracer
 		// Handle timeouts and RPC cancellations
-		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
-		go func() {
+		var cancel context.CancelFunc
+		if timeout != nil {
+			execCtx, cancel = context.WithTimeout(ctx, *timeout)
+		} else {
+			execCtx, cancel, _ = WithEVMTimeout(ctx, DefaultEVMCallTimeout)
+		}
+		go func(deadlineCtx context.Context) {
 			<-deadlineCtx.Done()
-			tracer.(*tracers.Tracer).Stop(errors.New("execution timeout"))
-		}()
+			resultTracer.Stop(errors.New("execution timeout"))
+		}(execCtx)
 		defer cancel()
 		streaming = false
 
@@ -131,7 +143,8 @@ func TraceTx(
 		streaming = true
 	}
 	// Run the transaction with tracing enabled.
-	vmenv := vm.NewEVM(blockCtx, txCtx, ibs, chainConfig, vm.Config{Debug: true, Tracer: tracer})
+	vmenv := vm.NewEVM(blockCtx, txCtx, ibs, chainConfig, vm.Config{Debug: true, Tracer: tracer, Precompiles: precompiles})
+	defer CancelOnDone(execCtx, vmenv)()
 	var refunds = true
 	if config != nil && config.NoRefunds != nil && *config.NoRefunds {
 		refunds = false
@@ -141,7 +154,17 @@ func TraceTx(
 		stream.WriteObjectField("structLogs")
 		stream.WriteArrayStart()
 	}
+	txTracer, isTxTracer := tracer.(tracers.TxTracer)
+	if isTxTracer {
+		txTracer.CaptureTxStart(ibs, message)
+	}
 	result, err := core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.Gas()), refunds, false /* gasBailout */)
+	if err == nil && isTxTracer {
+		txTracer.CaptureTxEnd(result.UsedGas)
+	}
+	if err == nil && streaming && vmenv.Cancelled() {
+		err = execCtx.Err()
+	}
 	if err != nil {
 		if streaming {
 			stream.WriteArrayEnd()
@@ -168,7 +191,7 @@ func TraceTx(
 		stream.WriteString(returnVal)
 		stream.WriteObjectEnd()
 	} else {
-		if r, err1 := tracer.(*tracers.Tracer).GetResult(); err1 == nil {
+		if r, err1 := resultTracer.GetResult(); err1 == nil {
 			stream.Write(r)
 		} else {
 			return err1
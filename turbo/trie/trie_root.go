@@ -122,6 +122,10 @@ type RootHashAggregator struct {
 	a              accounts.Account
 	leafData       GenStructStepLeafData
 	accData        GenStructStepAccountData
+
+	retain    RetainDecider // keys whose paths are kept as trie nodes, nil to only compute the root hash
+	retainKey []byte
+	rootNode  node
 }
 
 type StreamReceiver interface {
@@ -173,6 +177,19 @@ func (l *FlatDBTrieLoader) SetStreamReceiver(receiver StreamReceiver) {
 	l.receiver = receiver
 }
 
+// RetainNodes makes the loader keep the trie nodes on the paths of the keys of rd while computing the root hash, so
+// that merkle proofs of these keys can be built from the trie given by Result. Storage keys are given with the
+// incarnation of their account, like in kv.HashedStorage. The keys must also be retained by the RetainDecider of
+// Reset, for the intermediate hashes on their paths not to be used.
+func (l *FlatDBTrieLoader) RetainNodes(rd RetainDecider) {
+	l.defaultReceiver.retain = rd
+}
+
+// Result returns the trie built by the last CalcTrieRoot, see RetainNodes.
+func (l *FlatDBTrieLoader) Result() SubTries {
+	return l.receiver.Result()
+}
+
 // CalcTrieRoot algo:
 //
 //		for iterateIHOfAccounts {
@@ -327,6 +344,19 @@ func (r *RootHashAggregator) RetainNothing(_ []byte) bool {
 	return false
 }
 
+func (r *RootHashAggregator) retainAccount(prefix []byte) bool {
+	return r.retain != nil && r.retain.Retain(prefix)
+}
+
+func (r *RootHashAggregator) retainStorage(prefix []byte) bool {
+	if r.retain == nil {
+		return false
+	}
+	hexutil.DecompressNibbles(r.currAccK, &r.retainKey)
+	r.retainKey = append(r.retainKey, prefix...)
+	return r.retain.Retain(r.retainKey)
+}
+
 func (r *RootHashAggregator) Reset(hc HashCollector2, shc StorageHashCollector2, trace bool) {
 	r.hc = hc
 	r.shc = shc
@@ -344,6 +374,7 @@ func (r *RootHashAggregator) Reset(hc HashCollector2, shc StorageHashCollector2,
 	r.valueStorage = nil
 	r.wasIHStorage = false
 	r.root = common.Hash{}
+	r.rootNode = nil
 	r.trace = trace
 	r.hb.trace = trace
 }
@@ -493,6 +524,9 @@ func (r *RootHashAggregator) Receive(itemType StreamItem,
 		}
 		if r.hb.hasRoot() {
 			r.root = r.hb.rootHash()
+			if r.retain != nil {
+				r.rootNode = r.hb.root()
+			}
 		} else {
 			r.root = EmptyRoot
 		}
@@ -523,7 +557,10 @@ func (r *RootHashAggregator) Receive(itemType StreamItem,
 // }
 
 func (r *RootHashAggregator) Result() SubTries {
-	panic("don't call me")
+	if r.retain == nil {
+		panic("don't call me")
+	}
+	return SubTries{Hashes: []common.Hash{r.root}, roots: []node{r.rootNode}}
 }
 
 func (r *RootHashAggregator) Root() common.Hash {
@@ -563,7 +600,7 @@ func (r *RootHashAggregator) genStructStorage() error {
 		r.leafData.Value = rlphacks.RlpSerializableBytes(r.valueStorage)
 		data = &r.leafData
 	}
-	r.groupsStorage, r.hasTreeStorage, r.hasHashStorage, err = GenStructStep(r.RetainNothing, r.currStorage.Bytes(), r.succStorage.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
+	r.groupsStorage, r.hasTreeStorage, r.hasHashStorage, err = GenStructStep(r.retainStorage, r.currStorage.Bytes(), r.succStorage.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
 		if r.shc == nil {
 			return nil
 		}
@@ -631,7 +668,7 @@ func (r *RootHashAggregator) genStructAccount() error {
 	r.currStorage.Reset()
 	r.succStorage.Reset()
 	var err error
-	if r.groups, r.hasTree, r.hasHash, err = GenStructStep(r.RetainNothing, r.curr.Bytes(), r.succ.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
+	if r.groups, r.hasTree, r.hasHash, err = GenStructStep(r.retainAccount, r.curr.Bytes(), r.succ.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
 		if r.hc == nil {
 			return nil
 		}